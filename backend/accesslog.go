@@ -0,0 +1,71 @@
+package main
+
+import (
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const redacted = "REDACTED"
+
+// [접근 로그] 요청마다 method/route/status/bytes/duration을 구조화 로그로 남김
+// - log.access.sample_rate 비율만 기록 (5xx는 항상 기록)
+// - 쿼리와 경로 변수 중 log.access.redact 이름은 값을 가림 (닉네임/토큰이 로그에 남지 않게)
+// - log.access.routes로 라우트 패턴(또는 경로)별로 켜고 끔
+// 먹스 바로 바깥에 둬야 r.Pattern을 읽을 수 있음
+func accessLogMiddleware(next http.Handler) http.Handler {
+	if !cfg.Log.Access.Enabled { return next }
+	redact := map[string]bool{}
+	for _, name := range cfg.Log.Access.Redact {
+		redact[strings.ToLower(name)] = true
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		start := time.Now()
+		next.ServeHTTP(rec, r)
+
+		route := r.Pattern
+		if route == "" { route = "unmatched" }
+		if !accessLogEnabled(route, r.URL.Path) { return }
+		if rec.status < 500 && rand.Float64() >= cfg.Log.Access.SampleRate { return }
+
+		slog.InfoContext(r.Context(), "http request",
+			"method", r.Method,
+			"route", route,
+			"path", redactPath(r, redact),
+			"query", redactQuery(r.URL.Query(), redact),
+			"status", rec.status,
+			"bytes", rec.bytes,
+			"duration_ms", time.Since(start).Milliseconds(),
+		)
+	})
+}
+
+// 라우트 패턴 설정이 우선, 없으면 경로, 둘 다 없으면 켜짐
+func accessLogEnabled(route, path string) bool {
+	if on, ok := cfg.Log.Access.Routes[route]; ok { return on }
+	if on, ok := cfg.Log.Access.Routes[path]; ok { return on }
+	return true
+}
+
+func redactQuery(q url.Values, redact map[string]bool) string {
+	for name := range q {
+		if redact[strings.ToLower(name)] { q[name] = []string{redacted} }
+	}
+	return q.Encode()
+}
+
+// /users/{nick}/activity 같은 경로 변수도 같은 목록으로 가림
+func redactPath(r *http.Request, redact map[string]bool) string {
+	path := r.URL.Path
+	for name := range redact {
+		v := r.PathValue(name)
+		if v == "" { continue }
+		path = strings.Replace(path, "/"+v, "/"+redacted, 1)
+	}
+	return path
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// 영구 삭제된 사용자의 메시지에 남길 익명 표시명
+const deletedNick = "[deleted]"
+
+// 탈퇴 후 재활성화가 가능한 유예 기간 (accounts.grace_period)
+func accountGracePeriod() time.Duration {
+	return time.Duration(cfg.Accounts.GracePeriod)
+}
+
+func hashRecoveryCode(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
+// 탈퇴(유예 중) 상태인지 확인
+func isUserDeleted(nick string) bool {
+	var deleted bool
+	db.QueryRow("SELECT deleted_at IS NOT NULL FROM users WHERE nickname = $1", nick).Scan(&deleted)
+	return deleted
+}
+
+// [탈퇴] POST /account/delete (nick)
+// 바로 지우지 않고 톰스톤으로 남긴 뒤 재활성화용 복구 코드를 한 번만 돌려줌
+func deleteAccountHandler(w http.ResponseWriter, r *http.Request) {
+	nick := r.FormValue("nick")
+	if nick == "" { respondError(w, r, http.StatusBadRequest, errors.New("nick required")); return }
+
+	b := make([]byte, 16)
+	rand.Read(b)
+	code := hex.EncodeToString(b)
+
+	res, err := db.Exec(`
+		UPDATE users SET deleted_at = CURRENT_TIMESTAMP, recovery_code_hash = $2
+		WHERE nickname = $1 AND deleted_at IS NULL`, nick, hashRecoveryCode(code))
+	if err != nil { respondError(w, r, 500, err); return }
+	if n, _ := res.RowsAffected(); n == 0 { respondError(w, r, http.StatusNotFound, errors.New("user not found")); return }
+
+	slog.InfoContext(r.Context(), "account scheduled for deletion", "nick", nick, "grace", accountGracePeriod())
+	reassignOwnedRooms(nick)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"nickname":      nick,
+		"recovery_code": code,
+		"purge_after":   time.Now().Add(accountGracePeriod()).UTC().Format(time.RFC3339),
+	})
+}
+
+// [재활성화] POST /account/reactivate (nick, recovery_code)
+func reactivateAccountHandler(w http.ResponseWriter, r *http.Request) {
+	nick := r.FormValue("nick")
+	code := r.FormValue("recovery_code")
+	if nick == "" || code == "" { respondError(w, r, http.StatusBadRequest, errors.New("nick and recovery_code required")); return }
+
+	res, err := db.Exec(`
+		UPDATE users SET deleted_at = NULL, recovery_code_hash = NULL
+		WHERE nickname = $1 AND recovery_code_hash = $2
+		  AND deleted_at > CURRENT_TIMESTAMP - make_interval(secs => $3)`,
+		nick, hashRecoveryCode(code), accountGracePeriod().Seconds())
+	if err != nil { respondError(w, r, 500, err); return }
+	if n, _ := res.RowsAffected(); n == 0 { respondError(w, r, http.StatusForbidden, errors.New("invalid or expired recovery code")); return }
+
+	slog.InfoContext(r.Context(), "account reactivated", "nick", nick)
+	w.WriteHeader(http.StatusOK)
+}
+
+// [보존 작업] 유예 기간이 지난 톰스톤을 영구 삭제하고 메시지 작성자를 익명화
+func purgeDeletedUsers() {
+	tx, err := db.Begin()
+	if err != nil { slog.Warn("account purge failed", "err", err); return }
+	defer tx.Rollback()
+
+	cutoff := accountGracePeriod().Seconds()
+	queries := []string{
+		`DELETE FROM mentions WHERE nickname IN (
+			SELECT nickname FROM users WHERE deleted_at < CURRENT_TIMESTAMP - make_interval(secs => $1))`,
+		`DELETE FROM user_identities WHERE nickname IN (
+			SELECT nickname FROM users WHERE deleted_at < CURRENT_TIMESTAMP - make_interval(secs => $1))`,
+		`DELETE FROM sessions WHERE nickname IN (
+			SELECT nickname FROM users WHERE deleted_at < CURRENT_TIMESTAMP - make_interval(secs => $1))`,
+		`DELETE FROM devices WHERE nickname IN (
+			SELECT nickname FROM users WHERE deleted_at < CURRENT_TIMESTAMP - make_interval(secs => $1))`,
+		`DELETE FROM password_resets WHERE nickname IN (
+			SELECT nickname FROM users WHERE deleted_at < CURRENT_TIMESTAMP - make_interval(secs => $1))`,
+		`DELETE FROM email_verifications WHERE nickname IN (
+			SELECT nickname FROM users WHERE deleted_at < CURRENT_TIMESTAMP - make_interval(secs => $1))`,
+		// 닉네임을 다시 쓰는 사람의 멘션/DM 알림이 예전 주인의 기기로 가지 않게
+		`DELETE FROM push_subscriptions WHERE nickname IN (
+			SELECT nickname FROM users WHERE deleted_at < CURRENT_TIMESTAMP - make_interval(secs => $1))`,
+		`DELETE FROM stars WHERE nickname IN (
+			SELECT nickname FROM users WHERE deleted_at < CURRENT_TIMESTAMP - make_interval(secs => $1))`,
+		`DELETE FROM pending_messages WHERE sender_nick IN (
+			SELECT nickname FROM users WHERE deleted_at < CURRENT_TIMESTAMP - make_interval(secs => $1))`,
+		`DELETE FROM user_notes WHERE author IN (
+			SELECT nickname FROM users WHERE deleted_at < CURRENT_TIMESTAMP - make_interval(secs => $1))`,
+		`DELETE FROM user_blocks WHERE blocker IN (
+			SELECT nickname FROM users WHERE deleted_at < CURRENT_TIMESTAMP - make_interval(secs => $1))`,
+		`UPDATE messages SET sender_nick = '` + deletedNick + `' WHERE sender_nick IN (
+			SELECT nickname FROM users WHERE deleted_at < CURRENT_TIMESTAMP - make_interval(secs => $1))`,
+		// 받은 DM도 보낸 글처럼 익명화 (같은 닉네임을 새로 쓰는 사람의 DM 목록에 섞이지 않게)
+		`UPDATE messages SET recipient_nick = '` + deletedNick + `' WHERE recipient_nick IN (
+			SELECT nickname FROM users WHERE deleted_at < CURRENT_TIMESTAMP - make_interval(secs => $1))`,
+	}
+	for _, q := range queries {
+		if _, err := tx.Exec(q, cutoff); err != nil { slog.Warn("account purge failed", "err", err); return }
+	}
+	// 영구 삭제 대상의 방 멤버십 정리 (소유권은 탈퇴 시점에 이미 넘어감)
+	if _, err := tx.Exec(`DELETE FROM room_members WHERE nickname IN (
+			SELECT nickname FROM users WHERE deleted_at < CURRENT_TIMESTAMP - make_interval(secs => $1))`, cutoff); err != nil {
+		slog.Warn("account purge failed", "err", err)
+		return
+	}
+	res, err := tx.Exec(`DELETE FROM users WHERE deleted_at < CURRENT_TIMESTAMP - make_interval(secs => $1)`, cutoff)
+	if err != nil { slog.Warn("account purge failed", "err", err); return }
+	if err := tx.Commit(); err != nil { slog.Warn("account purge failed", "err", err); return }
+
+	if n, _ := res.RowsAffected(); n > 0 {
+		slog.Info("purged deleted accounts", "count", n)
+	}
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+)
+
+// [관리자 API] /admin/ 아래 모든 경로는 Authorization: Bearer <admin.token> 필요
+// 토큰이 설정되지 않으면 관리자 API 전체가 꺼짐
+var adminMux = http.NewServeMux()
+
+func requireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if cfg.Admin.Token == "" { respondError(w, r, http.StatusNotFound, errors.New("admin API disabled")); return }
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(cfg.Admin.Token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="gotalk-admin"`)
+			respondError(w, r, http.StatusUnauthorized, errors.New("unauthorized"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// 파드별 상태 수집용 브로커 주제: 모든 파드가 자기 상태로 응답
+const subjectAdminStats = "chat.admin.stats"
+
+// 다른 파드 응답을 모으는 시간
+const adminStatsTimeout = time.Second
+
+var startedAt = time.Now()
+
+type PodStats struct {
+	Pod             string     `json:"pod"`
+	Clients         int        `json:"clients"`
+	Nicks           []string   `json:"nicks"`
+	Goroutines      int        `json:"goroutines"`
+	BroadcastQueue  int        `json:"broadcast_queue"`
+	HeapBytes       uint64     `json:"heap_bytes"`
+	Uptime          string     `json:"uptime"`
+	BrokerConnected bool       `json:"broker_connected"`
+	Connections     []ConnMeta `json:"connections"`
+	Protocol        string     `json:"protocol"` // 브로커 프로토콜 버전 (protocol.go)
+}
+
+func localPodStats() PodStats {
+	mutex.Lock()
+	nicks := make([]string, 0, len(clients))
+	for _, c := range clients {
+		if c.nick == "" { continue } // 임베드 위젯 방문자
+		nicks = append(nicks, c.nick)
+	}
+	mutex.Unlock()
+	sort.Strings(nicks)
+
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	return PodStats{
+		Pod: nodeID, Clients: len(nicks), Nicks: nicks, Goroutines: runtime.NumGoroutine(),
+		BroadcastQueue: len(broadcast), HeapBytes: mem.HeapAlloc,
+		Uptime: time.Since(startedAt).Round(time.Second).String(), BrokerConnected: broker.Connected(),
+		Connections: localConns(), Protocol: protocolVersion,
+	}
+}
+
+func handleAdminStats(m BrokerMsg) {
+	data, _ := json.Marshal(localPodStats())
+	m.Respond(data)
+}
+
+// 모든 파드에 상태를 물어보고 제한 시간 동안 응답을 모음 (응답 없는 파드는 빠짐)
+func clusterPodStats() []PodStats {
+	var stats []PodStats
+	for _, data := range broker.Gather(context.Background(), subjectAdminStats, nil, adminStatsTimeout) {
+		var s PodStats
+		if json.Unmarshal(data, &s) == nil { stats = append(stats, s) }
+	}
+	if len(stats) == 0 { return []PodStats{localPodStats()} }
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Pod < stats[j].Pod })
+	return stats
+}
+
+// [파드 상태] GET /admin/stats
+func adminStatsHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, clusterPodStats())
+}
+
+// [접속자] GET /admin/clients - 파드별 SSE 연결과 측정된 RTT
+func adminClientsHandler(w http.ResponseWriter, r *http.Request) {
+	type client struct {
+		ConnMeta
+		Pod string `json:"pod"`
+	}
+	list := []client{}
+	for _, s := range clusterPodStats() {
+		for _, c := range s.Connections {
+			list = append(list, client{ConnMeta: c, Pod: s.Pod})
+		}
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+// [메시지 삭제] DELETE /admin/messages/{id} - 답글/멘션/고정도 함께 삭제되고 클라이언트에 삭제 알림
+func adminDeleteMessageHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil { respondError(w, r, http.StatusBadRequest, errors.New("invalid message id")); return }
+	err = deleteMessage(r.Context(), id)
+	if err == sql.ErrNoRows { respondError(w, r, http.StatusNotFound, errors.New("message not found")); return }
+	if err != nil { respondError(w, r, 500, err); return }
+	slog.InfoContext(r.Context(), "admin deleted message", "message_id", id)
+	w.WriteHeader(http.StatusOK)
+}
+
+// 메시지를 지우고 클라이언트에 삭제 알림 (없으면 sql.ErrNoRows)
+func deleteMessage(ctx context.Context, id int) error {
+	msg := Message{ID: id, Type: messageTypeDeleted}
+	var roomID sql.NullInt64
+	if err := db.QueryRowContext(ctx, "DELETE FROM messages WHERE id = $1 RETURNING room_id", id).Scan(&roomID); err != nil { return err }
+	msg.RoomID = nullableRoom(roomID)
+
+	data, _ := json.Marshal(msg)
+	if err := publishChat(ctx, data); err != nil { slog.WarnContext(ctx, "delete notice failed", "message_id", id, "err", err) }
+	return nil
+}
+
+// 차단 중인지 (기한이 지난 차단은 무시)
+func isUserBanned(nick string) bool {
+	var banned bool
+	db.QueryRow(`SELECT banned_at IS NOT NULL AND (banned_until IS NULL OR banned_until > CURRENT_TIMESTAMP)
+		FROM users WHERE nickname = $1`, nick).Scan(&banned)
+	return banned
+}
+
+// [차단] POST /admin/users/{nick}/ban (reason?, duration?) - 기간이 없으면 영구, 접속 중인 연결은 끊음
+func adminBanHandler(w http.ResponseWriter, r *http.Request) {
+	nick := r.PathValue("nick")
+	until, ok := banUntil(w, r)
+	if !ok { return }
+	if !banUser(w, r, nick, until) { return }
+	slog.InfoContext(r.Context(), "admin banned user", "nick", nick, "until", until)
+	w.WriteHeader(http.StatusOK)
+}
+
+// duration 폼 값 → 차단 만료 시각 (없으면 영구 nil), 잘못되면 400을 쓰고 false
+func banUntil(w http.ResponseWriter, r *http.Request) (*time.Time, bool) {
+	v := r.FormValue("duration")
+	if v == "" { return nil, true }
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 { respondError(w, r, http.StatusBadRequest, errors.New("invalid duration")); return nil, false }
+	t := time.Now().Add(d)
+	return &t, true
+}
+
+// 차단하고 접속 중인 연결을 끊음, 실패하면 응답을 쓰고 false
+func banUser(w http.ResponseWriter, r *http.Request, nick string, until *time.Time) bool {
+	res, err := db.Exec("UPDATE users SET banned_at = CURRENT_TIMESTAMP, ban_reason = $2, banned_until = $3 WHERE nickname = $1",
+		nick, r.FormValue("reason"), until)
+	if err != nil { respondError(w, r, 500, err); return false }
+	if n, _ := res.RowsAffected(); n == 0 { respondError(w, r, http.StatusNotFound, errors.New("user not found")); return false }
+	publishDirect(DirectEvent{Type: "kick", Target: nick})
+	return true
+}
+
+// [차단 해제] DELETE /admin/users/{nick}/ban
+func adminUnbanHandler(w http.ResponseWriter, r *http.Request) {
+	nick := r.PathValue("nick")
+	if !unbanUser(w, r, nick) { return }
+	slog.InfoContext(r.Context(), "admin unbanned user", "nick", nick)
+	w.WriteHeader(http.StatusOK)
+}
+
+func unbanUser(w http.ResponseWriter, r *http.Request, nick string) bool {
+	res, err := db.Exec("UPDATE users SET banned_at = NULL, ban_reason = NULL, banned_until = NULL WHERE nickname = $1 AND banned_at IS NOT NULL", nick)
+	if err != nil { respondError(w, r, 500, err); return false }
+	if n, _ := res.RowsAffected(); n == 0 { respondError(w, r, http.StatusNotFound, errors.New("user is not banned")); return false }
+	return true
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// 한 번에 읽는 행 수 (id 키셋으로 다음 배치를 이어 읽어 메모리에 전체를 올리지 않음)
+const exportBatchSize = 1000
+
+type exportRow struct {
+	ID            int       `json:"id"`
+	CreatedAt     time.Time `json:"created_at"`
+	Type          string    `json:"type"`
+	RoomID        *int      `json:"room_id"`
+	SenderNick    string    `json:"sender_nick"`
+	SenderColor   string    `json:"sender_color"`
+	RecipientNick *string   `json:"recipient_nick"`
+	ParentID      *int      `json:"parent_id"`
+	Content       string    `json:"content"`
+}
+
+var exportCSVHeader = []string{"id", "created_at", "type", "room_id", "sender_nick", "sender_color", "recipient_nick", "parent_id", "content"}
+
+func (e exportRow) csvRecord() []string {
+	optInt := func(p *int) string {
+		if p == nil { return "" }
+		return strconv.Itoa(*p)
+	}
+	recipient := ""
+	if e.RecipientNick != nil { recipient = *e.RecipientNick }
+	return []string{strconv.Itoa(e.ID), e.CreatedAt.Format(time.RFC3339), e.Type, optInt(e.RoomID),
+		e.SenderNick, e.SenderColor, recipient, optInt(e.ParentID), e.Content}
+}
+
+// from/to: RFC3339 또는 YYYY-MM-DD (to가 날짜면 그날 끝까지 포함)
+func parseExportTime(v string, endOfDay bool) (*time.Time, error) {
+	if v == "" { return nil, nil }
+	if t, err := time.Parse(time.RFC3339, v); err == nil { return &t, nil }
+	t, err := time.ParseInLocation("2006-01-02", v, time.Local)
+	if err != nil { return nil, fmt.Errorf("%q must be RFC3339 or YYYY-MM-DD", v) }
+	if endOfDay { t = t.AddDate(0, 0, 1) }
+	return &t, nil
+}
+
+// 전체 메시지를 id 순으로 배치마다 읽어 fn에 넘김 (flush는 배치가 끝날 때마다, nil이면 생략)
+// /admin/export와 백업 작업(backup.go)이 같은 행과 순서를 쓰도록 공유
+func exportMessages(ctx context.Context, from, to *time.Time, fn func(exportRow) error, flush func()) error {
+	lastID := 0
+	for {
+		rows, err := db.QueryContext(ctx, `
+			SELECT m.id, m.created_at, m.type, m.room_id, m.sender_nick, COALESCE(u.color_code, '#ffffff'),
+				m.recipient_nick, m.parent_id, m.content
+			FROM messages m LEFT JOIN users u ON u.nickname = m.sender_nick
+			WHERE m.id > $1 AND ($2::timestamp IS NULL OR m.created_at >= $2) AND ($3::timestamp IS NULL OR m.created_at < $3)
+			ORDER BY m.id LIMIT $4`, lastID, from, to, exportBatchSize)
+		if err != nil { return fmt.Errorf("after id %d: %w", lastID, err) }
+
+		n := 0
+		for rows.Next() {
+			var e exportRow
+			var content sql.NullString
+			if err := rows.Scan(&e.ID, &e.CreatedAt, &e.Type, &e.RoomID, &e.SenderNick, &e.SenderColor, &e.RecipientNick, &e.ParentID, &content); err != nil {
+				rows.Close()
+				return err
+			}
+			e.Content = content.String
+			if err := fn(e); err != nil { rows.Close(); return err }
+			lastID = e.ID
+			n++
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil { return err }
+		if flush != nil { flush() }
+		if n < exportBatchSize { return nil }
+	}
+}
+
+// [기록 내보내기] GET /admin/export?format=json|csv&from=&to=
+// 보관/컴플라이언스용 전체 메시지(DM·이벤트 포함)를 id 순으로 스트리밍
+func adminExportHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	format := q.Get("format")
+	if format == "" { format = "json" }
+	if format != "json" && format != "csv" { respondError(w, r, http.StatusBadRequest, errors.New("format must be json or csv")); return }
+	from, err := parseExportTime(q.Get("from"), false)
+	if err != nil { respondError(w, r, http.StatusBadRequest, errors.New("invalid from: "+err.Error())); return }
+	to, err := parseExportTime(q.Get("to"), true)
+	if err != nil { respondError(w, r, http.StatusBadRequest, errors.New("invalid to: "+err.Error())); return }
+
+	filename := "messages-" + time.Now().Format("20060102-150405") + "." + format
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+	var csvw *csv.Writer
+	if format == "csv" {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		csvw = csv.NewWriter(w)
+		csvw.Write(exportCSVHeader)
+	} else {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte("[\n"))
+	}
+	flusher, _ := w.(http.Flusher)
+
+	ctx := r.Context()
+	total := 0
+	err = exportMessages(ctx, from, to, func(e exportRow) error {
+		if csvw != nil {
+			csvw.Write(e.csvRecord())
+		} else {
+			if total > 0 { w.Write([]byte(",\n")) }
+			b, _ := json.Marshal(e)
+			w.Write(b)
+		}
+		total++
+		return nil
+	}, func() {
+		if csvw != nil { csvw.Flush() }
+		if flusher != nil { flusher.Flush() }
+	})
+	// 헤더를 이미 보냈으므로 중간 오류는 로그만 남기고 끊음
+	if err != nil { slog.WarnContext(ctx, "admin export failed", "rows", total, "err", err); return }
+	if csvw == nil { w.Write([]byte("\n]\n")) }
+	slog.InfoContext(ctx, "admin export finished", "format", format, "rows", total)
+}
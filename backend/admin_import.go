@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 가져오기 결과 (원본 ID는 새 ID로 다시 매겨짐)
+type ImportResult struct {
+	Imported int `json:"imported"`
+	Skipped  int `json:"skipped"` // 대상 환경에 없는 방의 메시지 등
+	Users    int `json:"users"`   // 새로 만든 사용자 수
+}
+
+// /admin/export 형식(json 배열 또는 csv)을 한 행씩 읽어 넘겨줌
+func decodeExport(r io.Reader, format string, fn func(exportRow) error) error {
+	if format == "csv" {
+		cr := csv.NewReader(r)
+		header, err := cr.Read()
+		if err != nil { return fmt.Errorf("csv header: %w", err) }
+		if strings.Join(header, ",") != strings.Join(exportCSVHeader, ",") { return fmt.Errorf("unexpected csv header %v", header) }
+		for {
+			rec, err := cr.Read()
+			if err == io.EOF { return nil }
+			if err != nil { return err }
+			if len(rec) != len(exportCSVHeader) { return fmt.Errorf("csv row %q: expected %d columns", rec[0], len(exportCSVHeader)) }
+			e, err := parseExportRecord(rec)
+			if err != nil { return fmt.Errorf("csv row %q: %w", rec[0], err) }
+			if err := fn(e); err != nil { return err }
+		}
+	}
+
+	dec := json.NewDecoder(r)
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('[') { return fmt.Errorf("expected a JSON array") }
+	for dec.More() {
+		var e exportRow
+		if err := dec.Decode(&e); err != nil { return err }
+		if err := fn(e); err != nil { return err }
+	}
+	return nil
+}
+
+func parseExportRecord(rec []string) (exportRow, error) {
+	var e exportRow
+	var err error
+	optInt := func(s string) (*int, error) {
+		if s == "" { return nil, nil }
+		n, err := strconv.Atoi(s)
+		return &n, err
+	}
+	if e.ID, err = strconv.Atoi(rec[0]); err != nil { return e, err }
+	if e.CreatedAt, err = time.Parse(time.RFC3339, rec[1]); err != nil { return e, err }
+	e.Type, e.SenderNick, e.SenderColor, e.Content = rec[2], rec[4], rec[5], rec[8]
+	if e.RoomID, err = optInt(rec[3]); err != nil { return e, err }
+	if rec[6] != "" { e.RecipientNick = &rec[6] }
+	if e.ParentID, err = optInt(rec[7]); err != nil { return e, err }
+	return e, nil
+}
+
+// 덤프를 한 트랜잭션으로 가져옴 (중간에 실패하면 아무것도 남지 않음)
+// 답글의 parent_id는 새 ID로 바꾸고, 원글이 덤프에 없으면 일반 메시지로 들여옴
+func importMessages(ctx context.Context, r io.Reader, format string) (ImportResult, error) {
+	var res ImportResult
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil { return res, err }
+	defer tx.Rollback()
+
+	idMap := map[int]int{}
+	rooms := map[int]bool{}
+	err = decodeExport(r, format, func(e exportRow) error {
+		if e.Type == "" { e.Type = messageTypeText }
+		if e.RoomID != nil {
+			exists, seen := rooms[*e.RoomID]
+			if !seen {
+				tx.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM rooms WHERE id = $1)", *e.RoomID).Scan(&exists)
+				rooms[*e.RoomID] = exists
+			}
+			if !exists { res.Skipped++; return nil }
+		}
+		var parentID *int
+		if e.ParentID != nil {
+			if id, ok := idMap[*e.ParentID]; ok { parentID = &id }
+		}
+
+		if e.SenderNick != deletedNick {
+			ur, err := tx.ExecContext(ctx, "INSERT INTO users (nickname, color_code) VALUES ($1, $2) ON CONFLICT (nickname) DO NOTHING",
+				e.SenderNick, e.SenderColor)
+			if err != nil { return err }
+			if n, _ := ur.RowsAffected(); n > 0 { res.Users++ }
+		}
+
+		var newID int
+		err := tx.QueryRowContext(ctx, `
+			INSERT INTO messages (content, sender_pod, sender_nick, created_at, type, room_id, recipient_nick, parent_id)
+			VALUES ($1, 'import', $2, $3, $4, $5, $6, $7) RETURNING id`,
+			e.Content, e.SenderNick, e.CreatedAt, e.Type, e.RoomID, e.RecipientNick, parentID).Scan(&newID)
+		if err != nil { return fmt.Errorf("message %d: %w", e.ID, err) }
+		idMap[e.ID] = newID
+		res.Imported++
+		return nil
+	})
+	if err != nil { return res, err }
+	return res, tx.Commit()
+}
+
+// [기록 가져오기] POST /admin/import?format=json|csv - 본문은 /admin/export 결과 그대로
+func adminImportHandler(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" { format = "json" }
+	if format != "json" && format != "csv" { respondError(w, r, http.StatusBadRequest, errors.New("format must be json or csv")); return }
+
+	res, err := importMessages(r.Context(), r.Body, format)
+	if err != nil { respondError(w, r, http.StatusBadRequest, errors.New("import failed: "+err.Error())); return }
+	slog.InfoContext(r.Context(), "admin import finished", "imported", res.Imported, "skipped", res.Skipped)
+	writeJSON(w, http.StatusOK, res)
+}
+
+// [가져오기 명령] gotalk import <파일.json|파일.csv> [설정 플래그...]
+// 스테이징 시드나 환경 복구용으로 서버 없이 실행
+func runImportCommand(args []string) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") { fmt.Fprintln(os.Stderr, "usage: gotalk import <dump.json|dump.csv> [flags]"); os.Exit(2) }
+	path := args[0]
+	format := strings.TrimPrefix(filepath.Ext(path), ".")
+	if format != "json" && format != "csv" { fmt.Fprintln(os.Stderr, "dump must be .json or .csv"); os.Exit(2) }
+
+	var err error
+	if cfg, err = loadConfig(args[1:]); err != nil { fatal("invalid config", err) }
+	initLogger()
+	initDB()
+
+	f, err := os.Open(path)
+	if err != nil { fatal("open dump failed", err) }
+	defer f.Close()
+	res, err := importMessages(context.Background(), f, format)
+	if err != nil { fatal("import failed", err) }
+	slog.Info("import finished", "imported", res.Imported, "skipped", res.Skipped, "users", res.Users)
+}
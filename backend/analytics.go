@@ -0,0 +1,62 @@
+package main
+
+import (
+	"log/slog"
+	"time"
+)
+
+// [분석 롤업] 일 단위 집계 테이블 (analytics_daily: day, metric, dimension, value)
+// 대시보드/통계 API는 원본 테이블 대신 이 롤업을 조회함
+var rollupQueries = map[string]string{
+	// 가입 경로: 초대한 사람 닉네임, 초대 없이 가입하면 organic
+	"signups": `
+		SELECT u.created_at::date, COALESCE(i.created_by, 'organic'), COUNT(*)
+		FROM users u LEFT JOIN invites i ON i.code = u.invite_code
+		WHERE u.created_at >= $1
+		GROUP BY 1, 2`,
+	"messages": `
+		SELECT created_at::date, 'all', COUNT(*)
+		FROM messages WHERE created_at >= $1 AND type = 'message'
+		GROUP BY 1, 2`,
+}
+
+// 시간 단위 집계 (analytics_hourly: hour, metric, dimension, value) - 요일×시간 히트맵용
+// 공개 활동만 집계 (DM 제외)
+var hourlyRollupQueries = map[string]string{
+	"messages_by_user": `
+		SELECT date_trunc('hour', created_at), sender_nick, COUNT(*)
+		FROM messages WHERE created_at >= $1 AND type = 'message' AND recipient_nick IS NULL
+		GROUP BY 1, 2`,
+	"messages_by_room": `
+		SELECT date_trunc('hour', created_at), room_id::text, COUNT(*)
+		FROM messages WHERE created_at >= $1 AND type = 'message' AND room_id IS NOT NULL
+		GROUP BY 1, 2`,
+}
+
+func rollupAnalytics(lookback time.Duration) {
+	rollupDailyAnalytics(lookback)
+	rollupHourlyAnalytics(lookback)
+}
+
+// 최근 lookback 기간의 일별 집계를 다시 계산해 덮어씀 (재실행해도 안전)
+func rollupDailyAnalytics(lookback time.Duration) {
+	since := time.Now().Add(-lookback).Truncate(24 * time.Hour)
+	for metric, query := range rollupQueries {
+		_, err := db.Exec(`
+			INSERT INTO analytics_daily (day, metric, dimension, value)
+			SELECT d, $2, dim, cnt FROM (`+query+`) AS t(d, dim, cnt)
+			ON CONFLICT (day, metric, dimension) DO UPDATE SET value = EXCLUDED.value`, since, metric)
+		if err != nil { slog.Warn("analytics rollup failed", "metric", metric, "err", err) }
+	}
+}
+
+func rollupHourlyAnalytics(lookback time.Duration) {
+	since := time.Now().Add(-lookback).Truncate(time.Hour)
+	for metric, query := range hourlyRollupQueries {
+		_, err := db.Exec(`
+			INSERT INTO analytics_hourly (hour, metric, dimension, value)
+			SELECT h, $2, dim, cnt FROM (`+query+`) AS t(h, dim, cnt)
+			ON CONFLICT (hour, metric, dimension) DO UPDATE SET value = EXCLUDED.value`, since, metric)
+		if err != nil { slog.Warn("analytics rollup failed", "metric", metric, "err", err) }
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net"
+	"net/http"
+)
+
+// [오류 분류] 모든 핸들러의 오류를 여섯 가지 code로 나눠 JSON 오류 본문({error, field, code, retryable})에 실음
+// 핸들러는 상태 코드와 함께 respondError만 부르면 되고, 분류는 상태 코드(또는 kindError로 직접 지정)와 원인 오류로 정함
+// DB/브로커 연결 끊김·시간 초과는 500 대신 503 dependency_unavailable로 바꿔 클라이언트가 다시 시도할 수 있게 함
+// 목록은 GET /capabilities로도 공개
+type errorKind string
+
+const (
+	kindValidation            errorKind = "validation"
+	kindAuth                  errorKind = "auth"
+	kindConflict              errorKind = "conflict"
+	kindRateLimited           errorKind = "rate_limited"
+	kindDependencyUnavailable errorKind = "dependency_unavailable"
+	kindInternal              errorKind = "internal"
+)
+
+type ErrorKindInfo struct {
+	Code        errorKind `json:"code"`
+	Status      int       `json:"status"` // 대표 상태 코드 (validation은 404/413/415 등 다른 4xx도 씀)
+	Retryable   bool      `json:"retryable"`
+	Description string    `json:"description"`
+}
+
+var errorKinds = []ErrorKindInfo{
+	{kindValidation, http.StatusBadRequest, false, "The request is malformed, refers to something that does not exist, or fails validation."},
+	{kindAuth, http.StatusForbidden, false, "The caller is not signed in or is not allowed to do this."},
+	{kindConflict, http.StatusConflict, false, "The request conflicts with the current state, e.g. a duplicate or a full quota."},
+	{kindRateLimited, http.StatusTooManyRequests, true, "Too many requests; retry after the Retry-After header."},
+	{kindDependencyUnavailable, http.StatusServiceUnavailable, true, "The database, broker or an upstream service is unavailable; retry with backoff."},
+	{kindInternal, http.StatusInternalServerError, false, "An unexpected server error."},
+}
+
+// 상태 코드와 무관하게 분류를 정하고 싶을 때 감싸는 오류
+type kindError struct {
+	kind errorKind
+	err  error
+}
+
+func (e kindError) Error() string { return e.err.Error() }
+func (e kindError) Unwrap() error { return e.err }
+
+func (k errorKind) retryable() bool { return k == kindRateLimited || k == kindDependencyUnavailable }
+
+// 오류의 분류와 (바뀌었으면) 응답 상태 코드
+func classifyError(status int, err error) (errorKind, int) {
+	var ke kindError
+	if errors.As(err, &ke) { return ke.kind, status }
+	switch {
+	case status == http.StatusTooManyRequests:
+		return kindRateLimited, status
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return kindAuth, status
+	case status == http.StatusConflict || status == http.StatusPreconditionFailed:
+		return kindConflict, status
+	case status < 500:
+		return kindValidation, status
+	case status == http.StatusBadGateway || status == http.StatusServiceUnavailable || status == http.StatusGatewayTimeout:
+		return kindDependencyUnavailable, status
+	case isDependencyError(err):
+		return kindDependencyUnavailable, http.StatusServiceUnavailable
+	}
+	return kindInternal, status
+}
+
+// 연결 끊김/시간 초과처럼 다시 시도하면 될 수 있는 오류
+func isDependencyError(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone) { return true }
+	var ne net.Error
+	return errors.As(err, &ne)
+}
+
+type Capabilities struct {
+	AuthProvider string          `json:"auth_provider"`
+	FullFeatures bool            `json:"full_features"` // 방/초대/웹훅 등 Postgres 전용 기능
+	Errors       []ErrorKindInfo `json:"errors"`
+}
+
+// [기능 목록] GET /capabilities - 클라이언트/연동 작성자용 (오류 분류 포함)
+func capabilitiesHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, Capabilities{AuthProvider: cfg.Auth.Provider, FullFeatures: fullFeatureStore(), Errors: errorKinds})
+}
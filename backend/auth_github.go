@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// [GitHub 인증] OAuth2 인가 코드 흐름: /auth/github/login → GitHub → /auth/github/callback → 세션 쿠키
+// GitHub은 ID 토큰이 없어 액세스 토큰으로 /user를 읽고, 바뀌지 않는 숫자 id를 subject로 묶음 (linkIdentity)
+// 처음 보는 사용자는 GitHub login 이름으로 자동 생성
+type githubAuth struct {
+	client *http.Client
+}
+
+const (
+	githubStateCookie  = "gotalk_github_state"
+	githubAuthorizeURL = "https://github.com/login/oauth/authorize"
+	githubTokenURL     = "https://github.com/login/oauth/access_token"
+	githubUserURL      = "https://api.github.com/user"
+)
+
+func newGitHubAuth() *githubAuth {
+	return &githubAuth{client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (p *githubAuth) Name() string                             { return authProviderGitHub }
+func (p *githubAuth) Identify(r *http.Request) (string, error) { return sessionNick(r) }
+func (p *githubAuth) LoginURL() string                         { return "/auth/github/login" }
+
+func (p *githubAuth) RegisterRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("GET /auth/github/login", p.loginHandler)
+	mux.HandleFunc("GET /auth/github/callback", p.callbackHandler)
+}
+
+// [GitHub 로그인] GET /auth/github/login - state를 쿠키에 두고 GitHub으로 보냄
+func (p *githubAuth) loginHandler(w http.ResponseWriter, r *http.Request) {
+	b := make([]byte, 16)
+	rand.Read(b)
+	state := hex.EncodeToString(b)
+	http.SetCookie(w, &http.Cookie{
+		Name: githubStateCookie, Value: state, Path: "/auth/github/", MaxAge: 600,
+		HttpOnly: true, SameSite: http.SameSiteLaxMode, Secure: strings.HasPrefix(baseURL(r), "https"),
+	})
+	q := url.Values{
+		"client_id":    {cfg.Auth.GitHub.ClientID},
+		"redirect_uri": {cfg.Auth.GitHub.RedirectURL},
+		"scope":        {"read:user"},
+		"state":        {state},
+	}
+	http.Redirect(w, r, githubAuthorizeURL+"?"+q.Encode(), http.StatusFound)
+}
+
+// [GitHub 콜백] GET /auth/github/callback?code=&state= - 코드 교환, 사용자 조회, 세션 발급 후 첫 화면으로
+func (p *githubAuth) callbackHandler(w http.ResponseWriter, r *http.Request) {
+	c, err := r.Cookie(githubStateCookie)
+	state := r.URL.Query().Get("state")
+	if err != nil || state == "" || c.Value != state { respondError(w, r, http.StatusBadRequest, errors.New("invalid login state")); return }
+	http.SetCookie(w, &http.Cookie{Name: githubStateCookie, Value: "", Path: "/auth/github/", MaxAge: -1})
+	if e := r.URL.Query().Get("error"); e != "" { respondError(w, r, http.StatusUnauthorized, errors.New("login failed: "+e)); return }
+
+	id, login, err := p.exchange(r.Context(), r.URL.Query().Get("code"))
+	if err != nil {
+		slog.WarnContext(r.Context(), "github login failed", "err", err)
+		respondError(w, r, http.StatusUnauthorized, errors.New("login failed"))
+		return
+	}
+	nick, err := linkIdentity(r.Context(), authProviderGitHub, strconv.FormatInt(id, 10), login)
+	if err != nil { respondError(w, r, http.StatusForbidden, err); return }
+	if _, err := issueSession(w, r, nick); err != nil { respondError(w, r, 500, err); return }
+	slog.InfoContext(r.Context(), "github login", "nick", nick, "github_id", id)
+	http.Redirect(w, r, "/", http.StatusFound)
+}
+
+// 인가 코드를 액세스 토큰으로 바꾸고 GitHub 사용자 id와 login 반환
+func (p *githubAuth) exchange(ctx context.Context, code string) (int64, string, error) {
+	if code == "" { return 0, "", errors.New("missing code") }
+	form := url.Values{
+		"code":          {code},
+		"redirect_uri":  {cfg.Auth.GitHub.RedirectURL},
+		"client_id":     {cfg.Auth.GitHub.ClientID},
+		"client_secret": {cfg.Auth.GitHub.ClientSecret},
+	}
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, githubTokenURL, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	var tok struct {
+		AccessToken string `json:"access_token"`
+		Error       string `json:"error"`
+	}
+	if err := p.doJSON(req, &tok); err != nil { return 0, "", err }
+	// GitHub은 잘못된 코드에도 200과 error 필드로 답함
+	if tok.AccessToken == "" { return 0, "", fmt.Errorf("token endpoint: %s", tok.Error) }
+
+	req, _ = http.NewRequestWithContext(ctx, http.MethodGet, githubUserURL, nil)
+	req.Header.Set("Authorization", "Bearer "+tok.AccessToken)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	var user struct {
+		ID    int64  `json:"id"`
+		Login string `json:"login"`
+	}
+	if err := p.doJSON(req, &user); err != nil { return 0, "", err }
+	if user.ID == 0 || user.Login == "" { return 0, "", errors.New("incomplete github user") }
+	return user.ID, user.Login, nil
+}
+
+func (p *githubAuth) doJSON(req *http.Request, v any) error {
+	resp, err := p.client.Do(req)
+	if err != nil { return err }
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK { return fmt.Errorf("%s %s: %s", req.Method, req.URL.Path, resp.Status) }
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/netip"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// [헤더 신뢰 인증] 앞단 SSO 프록시가 넣는 헤더(auth.header.name)를 신원으로 씀
+// 프록시를 거치지 않고 파드에 직접 온 요청이 헤더를 위조하지 못하게 둘 중 설정된 것을 모두 확인
+// - trusted_proxies: 연결 주소(RemoteAddr, X-Forwarded-For 아님)가 이 범위 안
+// - shared_secret: 프록시가 secret_header에 넣는 공유 비밀이 일치 (상수 시간 비교)
+// 처음 보는 사용자는 users에 자동 생성하고, 차단/탈퇴/예약 닉네임은 거부
+type headerAuth struct {
+	header       string
+	trusted      []netip.Prefix
+	secretHeader string
+	secret       string
+}
+
+const maxHeaderNickLen = 64
+
+var (
+	errUntrustedProxy  = errors.New("identity header from untrusted address")
+	errBadProxySecret  = errors.New("identity header without valid proxy secret")
+	errInvalidIdentity = errors.New("invalid identity header")
+
+	// 이미 users에 있다고 확인한 닉네임 (요청마다 INSERT하지 않게)
+	provisionedNicks sync.Map
+)
+
+func newHeaderAuth() headerAuth {
+	p := headerAuth{header: cfg.Auth.Header.Name, secretHeader: cfg.Auth.Header.SecretHeader, secret: cfg.Auth.Header.SharedSecret}
+	for _, cidr := range cfg.Auth.Header.TrustedProxies {
+		prefix, err := parsePrefix(cidr)
+		if err != nil { fatal("invalid auth.header.trusted_proxies", err) }
+		p.trusted = append(p.trusted, prefix)
+	}
+	return p
+}
+
+// "10.0.0.0/8" 또는 단일 주소 "10.0.0.5"
+func parsePrefix(s string) (netip.Prefix, error) {
+	if prefix, err := netip.ParsePrefix(s); err == nil { return prefix, nil }
+	addr, err := netip.ParseAddr(s)
+	if err != nil { return netip.Prefix{}, fmt.Errorf("%q is not an IP or CIDR", s) }
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+func (p headerAuth) Name() string                  { return authProviderHeader }
+func (p headerAuth) LoginURL() string              { return "" }
+func (p headerAuth) RegisterRoutes(*http.ServeMux) {}
+
+func (p headerAuth) Identify(r *http.Request) (string, error) {
+	nick := strings.TrimSpace(r.Header.Get(p.header))
+	if nick == "" { return "", nil }
+	if len(p.trusted) > 0 && !p.fromTrustedProxy(r) { return "", errUntrustedProxy }
+	if p.secret != "" && subtle.ConstantTimeCompare([]byte(r.Header.Get(p.secretHeader)), []byte(p.secret)) != 1 { return "", errBadProxySecret }
+	if !validHeaderNick(nick) { return "", errInvalidIdentity }
+	if err := provisionHeaderUser(r.Context(), nick); err != nil { return "", err }
+	return nick, nil
+}
+
+func validHeaderNick(nick string) bool {
+	if !utf8.ValidString(nick) || utf8.RuneCountInString(nick) > maxHeaderNickLen { return false }
+	for _, c := range nick {
+		if unicode.IsControl(c) { return false }
+	}
+	return true
+}
+
+// 처음 보는 사용자 자동 생성 (상태 확인은 매번, 생성은 파드마다 한 번)
+func provisionHeaderUser(ctx context.Context, nick string) error {
+	if err := checkCanSignIn(nick); err != nil { return err }
+	if _, ok := provisionedNicks.Load(nick); ok { return nil }
+	res, err := db.ExecContext(ctx, "INSERT INTO users (nickname, color_code) VALUES ($1, $2) ON CONFLICT (nickname) DO NOTHING", nick, assignedColor(nick))
+	if err != nil { return err }
+	if n, _ := res.RowsAffected(); n > 0 { slog.InfoContext(ctx, "user provisioned from proxy header", "nick", nick) }
+	provisionedNicks.Store(nick, true)
+	return nil
+}
+
+func (p headerAuth) fromTrustedProxy(r *http.Request) bool {
+	addr, ok := remoteAddr(r)
+	return ok && prefixesContain(p.trusted, addr)
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// [OIDC 인증] 인가 코드 흐름: /auth/oidc/login → IdP → /auth/oidc/callback → 세션 쿠키
+// IdP 주소는 issuer의 /.well-known/openid-configuration에서 찾고, ID 토큰은 JWKS(RS256)로 검증
+// 처음 보는 사용자는 auth.oidc.nick_claim(기본 preferred_username) 값으로 만들어 (iss, sub)에 묶음 (linkIdentity)
+// (IdP가 이미 사용자를 걸렀으므로 registration.mode 초대 제한은 적용하지 않음)
+type oidcAuth struct {
+	mu        sync.Mutex
+	discovery *oidcDiscovery
+	keys      map[string]*rsa.PublicKey // kid → 공개키
+	client    *http.Client
+}
+
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+const oidcStateCookie = "gotalk_oidc_state"
+
+func newOIDCAuth() *oidcAuth {
+	return &oidcAuth{keys: map[string]*rsa.PublicKey{}, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (p *oidcAuth) Name() string                             { return authProviderOIDC }
+func (p *oidcAuth) Identify(r *http.Request) (string, error) { return sessionNick(r) }
+func (p *oidcAuth) LoginURL() string                         { return "/auth/oidc/login" }
+
+func (p *oidcAuth) RegisterRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("GET /auth/oidc/login", p.loginHandler)
+	mux.HandleFunc("GET /auth/oidc/callback", p.callbackHandler)
+}
+
+// IdP 설정은 처음 쓸 때 한 번 가져와 둠 (기동 시 IdP가 죽어 있어도 서버는 뜸)
+func (p *oidcAuth) config(ctx context.Context) (*oidcDiscovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.discovery != nil { return p.discovery, nil }
+	var d oidcDiscovery
+	if err := p.getJSON(ctx, strings.TrimSuffix(cfg.Auth.OIDC.Issuer, "/")+"/.well-known/openid-configuration", &d); err != nil { return nil, err }
+	if d.AuthorizationEndpoint == "" || d.TokenEndpoint == "" || d.JWKSURI == "" { return nil, errors.New("incomplete oidc discovery document") }
+	p.discovery = &d
+	return &d, nil
+}
+
+func (p *oidcAuth) getJSON(ctx context.Context, u string, v any) error {
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	resp, err := p.client.Do(req)
+	if err != nil { return err }
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK { return fmt.Errorf("GET %s: %s", u, resp.Status) }
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// [OIDC 로그인] GET /auth/oidc/login - state(=nonce)를 쿠키에 두고 IdP로 보냄
+func (p *oidcAuth) loginHandler(w http.ResponseWriter, r *http.Request) {
+	d, err := p.config(r.Context())
+	if err != nil { respondError(w, r, http.StatusBadGateway, errors.New("identity provider unavailable: "+err.Error())); return }
+	b := make([]byte, 16)
+	rand.Read(b)
+	state := hex.EncodeToString(b)
+	http.SetCookie(w, &http.Cookie{
+		Name: oidcStateCookie, Value: state, Path: "/auth/oidc/", MaxAge: 600,
+		HttpOnly: true, SameSite: http.SameSiteLaxMode, Secure: strings.HasPrefix(baseURL(r), "https"),
+	})
+	q := url.Values{
+		"response_type": {"code"},
+		"client_id":     {cfg.Auth.OIDC.ClientID},
+		"redirect_uri":  {cfg.Auth.OIDC.RedirectURL},
+		"scope":         {strings.Join(cfg.Auth.OIDC.Scopes, " ")},
+		"state":         {state},
+		"nonce":         {state},
+	}
+	http.Redirect(w, r, d.AuthorizationEndpoint+"?"+q.Encode(), http.StatusFound)
+}
+
+// [OIDC 콜백] GET /auth/oidc/callback?code=&state= - 코드 교환, ID 토큰 검증, 세션 발급 후 첫 화면으로
+func (p *oidcAuth) callbackHandler(w http.ResponseWriter, r *http.Request) {
+	c, err := r.Cookie(oidcStateCookie)
+	state := r.URL.Query().Get("state")
+	if err != nil || state == "" || c.Value != state { respondError(w, r, http.StatusBadRequest, errors.New("invalid login state")); return }
+	http.SetCookie(w, &http.Cookie{Name: oidcStateCookie, Value: "", Path: "/auth/oidc/", MaxAge: -1})
+	if e := r.URL.Query().Get("error"); e != "" { respondError(w, r, http.StatusUnauthorized, errors.New("login failed: "+e)); return }
+
+	claims, err := p.exchange(r.Context(), r.URL.Query().Get("code"))
+	if err != nil {
+		slog.WarnContext(r.Context(), "oidc login failed", "err", err)
+		respondError(w, r, http.StatusUnauthorized, errors.New("login failed"))
+		return
+	}
+	if claims["nonce"] != state { respondError(w, r, http.StatusUnauthorized, errors.New("login failed: nonce mismatch")); return }
+	// subject는 발급자 안에서만 유일하므로 issuer를 공급자 이름으로 씀
+	iss, _ := claims["iss"].(string)
+	sub, _ := claims["sub"].(string)
+	claimed, _ := claims[cfg.Auth.OIDC.NickClaim].(string)
+	nick, err := linkIdentity(r.Context(), iss, sub, claimed)
+	if err != nil { respondError(w, r, http.StatusForbidden, err); return }
+	if _, err := issueSession(w, r, nick); err != nil { respondError(w, r, 500, err); return }
+	slog.InfoContext(r.Context(), "oidc login", "nick", nick, "sub", claims["sub"])
+	http.Redirect(w, r, "/", http.StatusFound)
+}
+
+// 인가 코드를 토큰으로 바꾸고 검증된 ID 토큰 클레임 반환
+func (p *oidcAuth) exchange(ctx context.Context, code string) (map[string]any, error) {
+	if code == "" { return nil, errors.New("missing code") }
+	d, err := p.config(ctx)
+	if err != nil { return nil, err }
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {cfg.Auth.OIDC.RedirectURL},
+		"client_id":     {cfg.Auth.OIDC.ClientID},
+		"client_secret": {cfg.Auth.OIDC.ClientSecret},
+	}
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, d.TokenEndpoint, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := p.client.Do(req)
+	if err != nil { return nil, err }
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK { return nil, fmt.Errorf("token endpoint: %s", resp.Status) }
+	var tok struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tok); err != nil || tok.IDToken == "" { return nil, errors.New("token response without id_token") }
+	return p.verify(ctx, d, tok.IDToken)
+}
+
+// RS256 서명과 iss/aud/exp 확인
+func (p *oidcAuth) verify(ctx context.Context, d *oidcDiscovery, idToken string) (map[string]any, error) {
+	parts := strings.Split(idToken, ".")
+	if len(parts) != 3 { return nil, errors.New("malformed id_token") }
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeJWTPart(parts[0], &header); err != nil { return nil, err }
+	if header.Alg != "RS256" { return nil, fmt.Errorf("unsupported id_token alg %q", header.Alg) }
+	key, err := p.key(ctx, d, header.Kid)
+	if err != nil { return nil, err }
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil { return nil, err }
+	sum := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, sum[:], sig); err != nil { return nil, errors.New("bad id_token signature") }
+
+	var claims map[string]any
+	if err := decodeJWTPart(parts[1], &claims); err != nil { return nil, err }
+	if iss, _ := claims["iss"].(string); iss != d.Issuer && iss != cfg.Auth.OIDC.Issuer { return nil, fmt.Errorf("unexpected issuer %q", iss) }
+	if !audienceContains(claims["aud"], cfg.Auth.OIDC.ClientID) { return nil, errors.New("id_token audience mismatch") }
+	if exp, _ := claims["exp"].(float64); time.Now().Unix() > int64(exp) { return nil, errors.New("id_token expired") }
+	return claims, nil
+}
+
+func decodeJWTPart(s string, v any) error {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil { return err }
+	return json.Unmarshal(b, v)
+}
+
+func audienceContains(aud any, clientID string) bool {
+	switch a := aud.(type) {
+	case string:
+		return a == clientID
+	case []any:
+		return slices.Contains(a, any(clientID))
+	}
+	return false
+}
+
+// kid에 맞는 공개키 (모르는 kid면 키 교체로 보고 JWKS를 다시 읽음)
+func (p *oidcAuth) key(ctx context.Context, d *oidcDiscovery, kid string) (*rsa.PublicKey, error) {
+	p.mu.Lock()
+	key := p.keys[kid]
+	p.mu.Unlock()
+	if key != nil { return key, nil }
+
+	var jwks struct {
+		Keys []struct {
+			Kid string `json:"kid"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := p.getJSON(ctx, d.JWKSURI, &jwks); err != nil { return nil, err }
+	keys := map[string]*rsa.PublicKey{}
+	for _, k := range jwks.Keys {
+		if k.Kty != "RSA" { continue }
+		n, err1 := base64.RawURLEncoding.DecodeString(k.N)
+		e, err2 := base64.RawURLEncoding.DecodeString(k.E)
+		if err1 != nil || err2 != nil { continue }
+		keys[k.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	}
+	p.mu.Lock()
+	p.keys = keys
+	p.mu.Unlock()
+	if key = keys[kid]; key == nil { return nil, fmt.Errorf("unknown id_token key %q", kid) }
+	return key, nil
+}
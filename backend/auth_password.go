@@ -0,0 +1,99 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"unicode/utf8"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// [비밀번호 인증] POST /auth/login (nick, password) → 세션 쿠키 + 토큰
+// 없는 닉네임이면 가입 정책(checkCanPost)에 따라 그 비밀번호로 만들고,
+// 비밀번호 없이 쓰던 기존 닉네임은 관리자가 비밀번호를 정해 줘야 로그인 가능 (선점 방지)
+// auth.provider=none이어도 /register나 관리자가 비밀번호를 건 닉네임은 보호됨 (guestMiddleware, loginHandler)
+type passwordAuth struct{}
+
+const minPasswordLen = 8
+
+var errBadCredentials = errors.New("invalid nickname or password")
+
+func (passwordAuth) Name() string                             { return authProviderPassword }
+func (passwordAuth) Identify(r *http.Request) (string, error) { return sessionNick(r) }
+func (passwordAuth) LoginURL() string                         { return "" }
+
+func (passwordAuth) RegisterRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("POST /auth/login", passwordLoginHandler)
+}
+
+func passwordLoginHandler(w http.ResponseWriter, r *http.Request) {
+	nick, password := r.FormValue("nick"), r.FormValue("password")
+	if nick == "" || password == "" { respondError(w, r, http.StatusBadRequest, errors.New("nick and password required")); return }
+
+	var hash sql.NullString
+	err := db.QueryRow("SELECT password_hash FROM users WHERE nickname = $1", nick).Scan(&hash)
+	switch {
+	case err == sql.ErrNoRows:
+		if err := checkCanPost(nick); err != nil { respondError(w, r, http.StatusForbidden, err); return }
+		h, err := hashPassword(password)
+		var fe fieldError
+		if errors.As(err, &fe) { respondError(w, r, http.StatusBadRequest, err); return }
+		if err != nil { respondError(w, r, 500, err); return }
+		_, err = db.Exec("INSERT INTO users (nickname, color_code, password_hash) VALUES ($1, $2, $3)", nick, assignedColor(nick), h)
+		if err != nil { respondError(w, r, 500, err); return }
+		slog.InfoContext(r.Context(), "user registered with password", "nick", nick)
+	case err != nil:
+		respondError(w, r, 500, err)
+		return
+	case !hash.Valid:
+		respondError(w, r, http.StatusForbidden, errors.New("no password set for this nickname; ask an admin"))
+		return
+	default:
+		if bcrypt.CompareHashAndPassword([]byte(hash.String), []byte(password)) != nil { respondError(w, r, http.StatusUnauthorized, errBadCredentials); return }
+		if err := checkCanSignIn(nick); err != nil { respondError(w, r, http.StatusForbidden, err); return }
+	}
+
+	token, err := issueSession(w, r, nick)
+	if err != nil { respondError(w, r, 500, err); return }
+	writeJSON(w, http.StatusOK, map[string]string{"nickname": nick, "token": token})
+}
+
+// [비밀번호 설정] POST /admin/users/{nick}/password (password) - 기존 계정 이관/재설정
+func adminSetPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	nick, password := r.PathValue("nick"), r.FormValue("password")
+	if utf8.RuneCountInString(password) < minPasswordLen { respondError(w, r, http.StatusBadRequest, errors.New("password too short")); return }
+	h, err := hashPassword(password)
+	if err != nil { respondError(w, r, 500, err); return }
+	res, err := db.Exec("UPDATE users SET password_hash = $2 WHERE nickname = $1", nick, h)
+	if err != nil { respondError(w, r, 500, err); return }
+	if n, _ := res.RowsAffected(); n == 0 { respondError(w, r, http.StatusNotFound, errors.New("user not found")); return }
+	// 재설정은 보통 도용 대응이라 기존 세션은 모두 끊음
+	if _, err := revokeSessions(r.Context(), nick, ""); err != nil { respondError(w, r, 500, err); return }
+	w.WriteHeader(http.StatusNoContent)
+}
+
+var errPasswordRequired = errors.New("this nickname is password-protected; log in with its password")
+
+func hashPassword(password string) (string, error) {
+	if utf8.RuneCountInString(password) < minPasswordLen { return "", fieldError{"password", "too short"} }
+	h, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	return string(h), err
+}
+
+// 비밀번호가 걸린 닉네임인지 (users.password_hash는 Postgres 전용이라 다른 저장소에서는 항상 false)
+func passwordProtected(nick string) bool {
+	if nick == "" || !fullFeatureStore() { return false }
+	var protected bool
+	db.QueryRow("SELECT password_hash IS NOT NULL FROM users WHERE nickname = $1", nick).Scan(&protected)
+	return protected
+}
+
+func checkPassword(nick, password string) error {
+	if password == "" { return errPasswordRequired }
+	var hash sql.NullString
+	db.QueryRow("SELECT password_hash FROM users WHERE nickname = $1", nick).Scan(&hash)
+	if !hash.Valid || bcrypt.CompareHashAndPassword([]byte(hash.String), []byte(password)) != nil { return errBadCredentials }
+	return nil
+}
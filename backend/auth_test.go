@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/base64"
+	"io"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseToken(t *testing.T) {
+	cfg.Auth.SessionSecret = "test-secret"
+	valid := mintToken("alice", time.Now().Add(time.Hour), signSession)
+	parts := strings.Split(valid, ".")
+
+	tests := []struct {
+		name    string
+		token   string
+		sign    func(string) string
+		want    string
+		wantErr bool
+	}{
+		{"valid session", valid, signSession, "alice", false},
+		{"expired", mintToken("alice", time.Now().Add(-time.Minute), signSession), signSession, "", true},
+		{"tampered payload", base64.RawURLEncoding.EncodeToString([]byte("mallory")) + "." + parts[1] + "." + parts[2], signSession, "", true},
+		{"tampered expiry", parts[0] + ".9999999999." + parts[2], signSession, "", true},
+		{"tampered signature", parts[0] + "." + parts[1] + "." + strings.Repeat("A", len(parts[2])), signSession, "", true},
+		{"missing part", parts[0] + "." + parts[1], signSession, "", true},
+		{"empty", "", signSession, "", true},
+		{"session token as guest", valid, signGuest, "", true},
+		{"session token as room invite", valid, signRoomInvite, "", true},
+		{"guest token as session", mintToken("alice", time.Now().Add(time.Hour), signGuest), signSession, "", true},
+		{"room invite as session", mintToken("42", time.Now().Add(time.Hour), signRoomInvite), signSession, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseToken(tt.token, tt.sign)
+			if (err != nil) != tt.wantErr { t.Fatalf("parseToken() err = %v, wantErr %v", err, tt.wantErr) }
+			if got != tt.want { t.Errorf("parseToken() = %q, want %q", got, tt.want) }
+		})
+	}
+}
+
+func TestParseTokenOtherSecret(t *testing.T) {
+	cfg.Auth.SessionSecret = "old-secret"
+	token := mintToken("alice", time.Now().Add(time.Hour), signSession)
+	cfg.Auth.SessionSecret = "new-secret"
+	if _, err := parseToken(token, signSession); err == nil { t.Fatal("token signed with a rotated secret was accepted") }
+}
+
+func TestPinIdentity(t *testing.T) {
+	tests := []struct {
+		name        string
+		method      string
+		target      string
+		contentType string
+		body        string
+		nick        string
+		wantQuery   string // 고친 뒤 쿼리의 nick ("" = 없음)
+		wantForm    string // FormValue("nick")
+		wantBody    string // JSON 본문일 때 고친 뒤 본문
+	}{
+		{"query replaced", "GET", "/history?nick=mallory", "", "", "alice", "alice", "alice", ""},
+		{"query removed without identity", "GET", "/history?nick=mallory", "", "", "", "", "", ""},
+		{"query added", "GET", "/history", "", "", "alice", "alice", "alice", ""},
+		{"form replaced", "POST", "/send", "application/x-www-form-urlencoded", "nick=mallory&content=hi", "alice", "alice", "alice", ""},
+		{"form and query both replaced", "POST", "/send?nick=eve", "application/x-www-form-urlencoded", "nick=mallory", "alice", "alice", "alice", ""},
+		{"form removed without identity", "POST", "/send", "application/x-www-form-urlencoded", "nick=mallory", "", "", "", ""},
+		{"json replaced", "POST", "/send", "application/json", `{"nick":"mallory","content":"hi"}`, "alice", "alice", "", `{"content":"hi","nick":"alice"}`},
+		{"json with charset", "POST", "/send", "application/json; charset=utf-8", `{"nick":"mallory"}`, "alice", "alice", "", `{"nick":"alice"}`},
+		{"json removed without identity", "POST", "/send", "application/json", `{"nick":"mallory","content":"hi"}`, "", "", "", `{"content":"hi"}`},
+		{"json without nick unchanged", "POST", "/send", "application/json", `{"content":"hi"}`, "alice", "alice", "", `{"content":"hi"}`},
+		{"json nested nick untouched", "POST", "/send", "application/json", `{"meta":{"nick":"mallory"}}`, "alice", "alice", "", `{"meta":{"nick":"mallory"}}`},
+		{"json array left for handler", "POST", "/send", "application/json", `[{"nick":"mallory"}]`, "alice", "alice", "", `[{"nick":"mallory"}]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			if tt.contentType != "" { r.Header.Set("Content-Type", tt.contentType) }
+			if err := pinIdentity(r, tt.nick); err != nil { t.Fatalf("pinIdentity() err = %v", err) }
+
+			if got := r.URL.Query().Get("nick"); got != tt.wantQuery { t.Errorf("query nick = %q, want %q", got, tt.wantQuery) }
+			if !isJSONRequest(r) {
+				if got := r.FormValue("nick"); got != tt.wantForm { t.Errorf("FormValue(nick) = %q, want %q", got, tt.wantForm) }
+				return
+			}
+			b, _ := io.ReadAll(r.Body)
+			if string(b) != tt.wantBody { t.Errorf("body = %s, want %s", b, tt.wantBody) }
+			if r.ContentLength != int64(len(b)) { t.Errorf("ContentLength = %d, want %d", r.ContentLength, len(b)) }
+		})
+	}
+}
+
+func TestPinIdentityMultipart(t *testing.T) {
+	body := "--b\r\nContent-Disposition: form-data; name=\"nick\"\r\n\r\nmallory\r\n--b--\r\n"
+	r := httptest.NewRequest("POST", "/upload", strings.NewReader(body))
+	r.Header.Set("Content-Type", "multipart/form-data; boundary=b")
+	if err := pinIdentity(r, "alice"); err != nil { t.Fatalf("pinIdentity() err = %v", err) }
+	if got := r.FormValue("nick"); got != "alice" { t.Errorf("FormValue(nick) = %q, want alice", got) }
+	if got := r.MultipartForm.Value["nick"]; len(got) != 0 { t.Errorf("multipart nick = %q, want removed", got) }
+}
+
+func TestPinJSONNickTooLarge(t *testing.T) {
+	r := httptest.NewRequest("POST", "/send", strings.NewReader(`{"content":"`+strings.Repeat("a", maxJSONBody)+`"}`))
+	r.Header.Set("Content-Type", "application/json")
+	if err := pinIdentity(r, "alice"); err == nil { t.Fatal("oversized JSON body was accepted") }
+}
+
+func TestPinIdentityQueryEncoding(t *testing.T) {
+	r := httptest.NewRequest("GET", "/history?room_id=3&nick=a%26nick%3Dmallory", nil)
+	if err := pinIdentity(r, "alice"); err != nil { t.Fatal(err) }
+	q, _ := url.ParseQuery(r.URL.RawQuery)
+	if got := q["nick"]; len(got) != 1 || got[0] != "alice" { t.Errorf("nick = %q, want [alice]", got) }
+	if got := q.Get("room_id"); got != "3" { t.Errorf("room_id = %q, want 3", got) }
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// [아바타] POST /update를 multipart/form-data로 보내면 avatar 파일을 받아 오브젝트 저장소(fs/s3)에 둠
+// 객체 이름이 내용 해시라 같은 주소의 내용은 바뀌지 않으므로 GET /avatars/{name}은 오래 캐시해도 됨
+// 메시지와 /login 응답의 avatar_url로 내려가며, 탈퇴한 사용자는 비움 (Postgres 전용)
+const (
+	avatarMaxBytes           = 1 << 20
+	avatarUnverifiedMaxBytes = 256 << 10 // email.require_verified일 때 이메일 인증 전 한도
+	avatarPrefix             = "/avatars/"
+)
+
+// 받는 이미지 형식 (내용으로 판별) → 확장자
+var avatarTypes = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+var avatarNamePattern = regexp.MustCompile(`^[0-9a-f]{64}\.(png|jpg|gif|webp)$`)
+
+var errAvatarType = errors.New("avatar must be a png, jpeg, gif or webp image")
+
+// 업로드 파일을 저장하고 객체 이름 반환
+func saveAvatar(r *http.Request, nick string) (string, error) {
+	limit := avatarMaxBytes
+	if requireVerifiedEmail(nick) != nil { limit = avatarUnverifiedMaxBytes }
+	f, _, err := r.FormFile("avatar")
+	if err != nil { return "", err }
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, int64(limit)+1))
+	if err != nil { return "", err }
+	if len(data) > limit && limit < avatarMaxBytes { return "", fieldError{"avatar", "must be 256KB or smaller until you verify your email"} }
+	if len(data) > limit { return "", fieldError{"avatar", "must be 1MB or smaller"} }
+	ext, ok := avatarTypes[http.DetectContentType(data)]
+	if !ok { return "", fieldError{"avatar", errAvatarType.Error()} }
+
+	sum := sha256.Sum256(data)
+	name := hex.EncodeToString(sum[:]) + ext
+	if err := objectStore.Put(r.Context(), "avatars/"+name, bytes.NewReader(data)); err != nil { return "", err }
+	return name, nil
+}
+
+// 사용자의 아바타 주소 (없으면 "")
+func avatarURLFor(nick string) string {
+	if !fullFeatureStore() { return "" }
+	var name *string
+	db.QueryRow("SELECT avatar FROM users WHERE nickname = $1 AND deleted_at IS NULL", nick).Scan(&name)
+	if name == nil { return "" }
+	return avatarPrefix + *name
+}
+
+// [아바타] GET /avatars/{name}
+func avatarHandler(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if !avatarNamePattern.MatchString(name) { http.NotFound(w, r); return }
+	obj, err := objectStore.Get(r.Context(), "avatars/"+name)
+	if errors.Is(err, os.ErrNotExist) { http.NotFound(w, r); return }
+	if err != nil { respondError(w, r, 500, err); return }
+	defer obj.Close()
+	for ct, ext := range avatarTypes {
+		if strings.HasSuffix(name, ext) { w.Header().Set("Content-Type", ct) }
+	}
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	io.Copy(w, obj)
+}
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// [백업] backup.interval마다 전체 메시지를 /admin/export JSON 형식 그대로 오브젝트 저장소 backups/ 아래에 씀
+// 쓰는 동안 원본 행 수와 체크섬을 세어 매니페스트(backups/latest.json)에 남기고, 복구는 /admin/import나 gotalk import로
+// [백업 검증] backup.verify_interval마다 최신 백업을 임시 SQLite 파일에 복원해 다시 센 행 수/체크섬을 매니페스트와 비교
+// 결과는 메트릭과 backups/verification.json에 남기고, 실패하면 에러 리포터로 알림 (probe.go와 같은 관리자 알림 경로)
+// 저장소에 목록/삭제가 없으므로 오래된 백업 정리는 버킷 수명 주기 규칙 등에 맡김
+const (
+	backupManifestKey     = "backups/latest.json"
+	backupVerificationKey = "backups/verification.json"
+)
+
+var (
+	backupRuns = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "gotalk_backup_runs_total",
+		Help: "Message backups written, by result (ok, error).",
+	}, []string{"result"})
+	backupVerifications = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "gotalk_backup_verifications_total",
+		Help: "Backup verification runs, by result (ok, mismatch, error).",
+	}, []string{"result"})
+	backupLastVerified = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "gotalk_backup_last_verified_timestamp_seconds",
+		Help: "Unix time of the last backup that restored with matching row count and checksum.",
+	})
+	backupVerifiedRows = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "gotalk_backup_verified_rows",
+		Help: "Rows restored from the last successfully verified backup.",
+	})
+)
+
+type BackupManifest struct {
+	Key       string    `json:"key"`
+	CreatedAt time.Time `json:"created_at"`
+	Rows      int       `json:"rows"`
+	MaxID     int       `json:"max_id"`
+	Checksum  string    `json:"checksum"` // 행마다 /admin/export CSV 형식으로 이어 붙인 sha256
+}
+
+type BackupVerification struct {
+	Key              string    `json:"key"`
+	BackupAt         time.Time `json:"backup_at"`
+	VerifiedAt       time.Time `json:"verified_at"`
+	OK               bool      `json:"ok"`
+	ExpectedRows     int       `json:"expected_rows"`
+	RestoredRows     int       `json:"restored_rows"`
+	ExpectedChecksum string    `json:"expected_checksum"`
+	RestoredChecksum string    `json:"restored_checksum"`
+	Error            string    `json:"error,omitempty"`
+}
+
+// 행 수와 체크섬을 함께 셈 (백업할 때는 원본 행, 검증할 때는 복원한 행을 같은 순서로 넣음)
+type rowDigest struct {
+	h     hash.Hash
+	w     *csv.Writer
+	rows  int
+	maxID int
+}
+
+func newRowDigest() *rowDigest {
+	h := sha256.New()
+	return &rowDigest{h: h, w: csv.NewWriter(h)}
+}
+
+func (d *rowDigest) add(e exportRow) {
+	d.w.Write(e.csvRecord())
+	d.rows++
+	d.maxID = max(d.maxID, e.ID)
+}
+
+func (d *rowDigest) sum() string {
+	d.w.Flush()
+	return hex.EncodeToString(d.h.Sum(nil))
+}
+
+func putJSON(ctx context.Context, key string, v any) error {
+	b, err := json.Marshal(v)
+	if err != nil { return err }
+	return objectStore.Put(ctx, key, bytes.NewReader(b))
+}
+
+func getJSON(ctx context.Context, key string, v any) error {
+	rc, err := objectStore.Get(ctx, key)
+	if err != nil { return err }
+	defer rc.Close()
+	return json.NewDecoder(rc).Decode(v)
+}
+
+// 백업 하나를 쓰고 매니페스트를 갱신 (덤프가 끝까지 써진 뒤에만 latest.json이 바뀜)
+func writeBackup(ctx context.Context) (BackupManifest, error) {
+	m := BackupManifest{CreatedAt: time.Now().UTC()}
+	m.Key = "backups/messages-" + m.CreatedAt.Format("20060102-150405") + ".json"
+	d := newRowDigest()
+
+	pr, pw := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		pw.Write([]byte("[\n"))
+		err := exportMessages(ctx, nil, nil, func(e exportRow) error {
+			if d.rows > 0 { pw.Write([]byte(",\n")) }
+			b, _ := json.Marshal(e)
+			d.add(e)
+			_, err := pw.Write(b)
+			return err
+		}, nil)
+		if err == nil { _, err = pw.Write([]byte("\n]\n")) }
+		pw.CloseWithError(err)
+		done <- err
+	}()
+	err := objectStore.Put(ctx, m.Key, pr)
+	pr.CloseWithError(err) // 저장이 먼저 실패하면 쓰는 쪽을 풀어 줌
+	if dumpErr := <-done; err == nil { err = dumpErr }
+	if err != nil { return m, err }
+
+	m.Rows, m.MaxID, m.Checksum = d.rows, d.maxID, d.sum()
+	return m, putJSON(ctx, backupManifestKey, m)
+}
+
+func runBackup() {
+	ctx := context.Background()
+	m, err := writeBackup(ctx)
+	if err != nil {
+		backupRuns.WithLabelValues("error").Inc()
+		slog.Error("backup failed", "key", m.Key, "err", err)
+		errorReporter.CaptureException(fmt.Errorf("backup failed: %w", err), map[string]string{"node": nodeID, "key": m.Key})
+		return
+	}
+	backupRuns.WithLabelValues("ok").Inc()
+	slog.Info("backup written", "key", m.Key, "rows", m.Rows, "max_id", m.MaxID)
+}
+
+// 덤프를 임시 SQLite 파일에 복원한 뒤 id 순으로 다시 읽어 셈 (JSON만 다시 읽는 것보다 실제 복원에 가까움)
+func restoreIntoScratch(ctx context.Context, r io.Reader) (*rowDigest, error) {
+	f, err := os.CreateTemp(cfg.Backup.ScratchDir, "gotalk-verify-*.db")
+	if err != nil { return nil, err }
+	path := f.Name()
+	f.Close()
+	defer os.Remove(path)
+
+	scratch, err := sql.Open("sqlite", path)
+	if err != nil { return nil, err }
+	defer scratch.Close()
+	if _, err := scratch.ExecContext(ctx, `CREATE TABLE messages (
+		id INTEGER PRIMARY KEY, created_at TEXT NOT NULL, type TEXT NOT NULL, room_id INTEGER, sender_nick TEXT NOT NULL,
+		sender_color TEXT NOT NULL, recipient_nick TEXT, parent_id INTEGER, content TEXT NOT NULL)`); err != nil {
+		return nil, err
+	}
+
+	tx, err := scratch.BeginTx(ctx, nil)
+	if err != nil { return nil, err }
+	defer tx.Rollback()
+	err = decodeExport(r, "json", func(e exportRow) error {
+		_, err := tx.ExecContext(ctx, "INSERT INTO messages VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+			e.ID, e.CreatedAt.Format(time.RFC3339Nano), e.Type, e.RoomID, e.SenderNick, e.SenderColor, e.RecipientNick, e.ParentID, e.Content)
+		return err
+	})
+	if err != nil { return nil, fmt.Errorf("restore: %w", err) }
+	if err := tx.Commit(); err != nil { return nil, err }
+
+	rows, err := scratch.QueryContext(ctx, "SELECT id, created_at, type, room_id, sender_nick, sender_color, recipient_nick, parent_id, content FROM messages ORDER BY id")
+	if err != nil { return nil, err }
+	defer rows.Close()
+	d := newRowDigest()
+	for rows.Next() {
+		var e exportRow
+		var created string
+		if err := rows.Scan(&e.ID, &created, &e.Type, &e.RoomID, &e.SenderNick, &e.SenderColor, &e.RecipientNick, &e.ParentID, &e.Content); err != nil { return nil, err }
+		if e.CreatedAt, err = time.Parse(time.RFC3339Nano, created); err != nil { return nil, err }
+		d.add(e)
+	}
+	return d, rows.Err()
+}
+
+// 최신 백업을 검증 (아직 백업이 없으면 ok=false, found=false)
+func verifyLatestBackup(ctx context.Context) (v BackupVerification, found bool) {
+	v.VerifiedAt = time.Now().UTC()
+	var m BackupManifest
+	if err := getJSON(ctx, backupManifestKey, &m); err != nil {
+		if errors.Is(err, fs.ErrNotExist) { return v, false }
+		v.Error = "manifest: " + err.Error()
+		return v, true
+	}
+	v.Key, v.BackupAt, v.ExpectedRows, v.ExpectedChecksum = m.Key, m.CreatedAt, m.Rows, m.Checksum
+
+	rc, err := objectStore.Get(ctx, m.Key)
+	if err != nil { v.Error = "open backup: " + err.Error(); return v, true }
+	defer rc.Close()
+	d, err := restoreIntoScratch(ctx, rc)
+	if err != nil { v.Error = err.Error(); return v, true }
+	v.RestoredRows, v.RestoredChecksum = d.rows, d.sum()
+	v.OK = v.RestoredRows == v.ExpectedRows && v.RestoredChecksum == v.ExpectedChecksum
+	if !v.OK { v.Error = "row count or checksum mismatch" }
+	return v, true
+}
+
+// 검증 결과를 메트릭/저장소/알림으로 남김
+func recordVerification(ctx context.Context, v BackupVerification) {
+	result := "ok"
+	switch {
+	case v.OK:
+		backupLastVerified.Set(float64(v.VerifiedAt.Unix()))
+		backupVerifiedRows.Set(float64(v.RestoredRows))
+	case v.RestoredChecksum != "":
+		result = "mismatch"
+	default:
+		result = "error"
+	}
+	backupVerifications.WithLabelValues(result).Inc()
+	if err := putJSON(ctx, backupVerificationKey, v); err != nil { slog.Warn("backup verification result not saved", "err", err) }
+	if v.OK {
+		slog.Info("backup verified", "key", v.Key, "rows", v.RestoredRows)
+		return
+	}
+	slog.Error("backup verification failed", "key", v.Key, "result", result, "expected_rows", v.ExpectedRows, "restored_rows", v.RestoredRows, "err", v.Error)
+	errorReporter.CaptureException(fmt.Errorf("backup verification failed (%s): %s", result, v.Error), map[string]string{"node": nodeID, "key": v.Key})
+}
+
+func runBackupVerification() {
+	ctx := context.Background()
+	v, found := verifyLatestBackup(ctx)
+	if !found { slog.Info("backup verification skipped: no backup yet"); return }
+	recordVerification(ctx, v)
+}
+
+// [백업 상태] GET /admin/backups - 최신 매니페스트와 마지막 검증 결과
+func adminBackupsHandler(w http.ResponseWriter, r *http.Request) {
+	resp := map[string]any{"enabled": cfg.Backup.Enabled, "latest": nil, "verification": nil}
+	var m BackupManifest
+	if err := getJSON(r.Context(), backupManifestKey, &m); err == nil { resp["latest"] = m }
+	var v BackupVerification
+	if err := getJSON(r.Context(), backupVerificationKey, &v); err == nil { resp["verification"] = v }
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// [백업 검증] POST /admin/backups/verify - 다음 주기를 기다리지 않고 바로 검증 (결과는 같은 경로로 기록)
+func adminVerifyBackupHandler(w http.ResponseWriter, r *http.Request) {
+	v, found := verifyLatestBackup(r.Context())
+	if !found { respondError(w, r, http.StatusNotFound, errors.New("no backup yet")); return }
+	recordVerification(r.Context(), v)
+	writeJSON(w, http.StatusOK, v)
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// [차단] POST /block/{nick}으로 차단한 사람이 보낸 메시지/이벤트는 스트림(연결별 전달)과 /history에서 서버가 걸러 냄
+// 파드마다 접속한 사람의 차단 목록을 메모리에 들고, 바뀌면 브로커로 알려 모든 파드가 다시 읽음
+// 캐시에는 이 파드에 스트림이 열려 있는 닉네임만 두고 마지막 스트림이 닫히면 뺌 (none에서 아무 닉네임으로 접속해도 쌓이지 않게)
+// 기록은 방별 캐시를 공유하므로 페이지를 읽은 뒤 거르고 커서는 그대로 둠 (페이지가 limit보다 짧을 수 있음)
+
+// 차단 목록이 바뀐 닉네임을 알리는 브로커 주제
+const subjectBlocksChanged = "chat.blocks_changed"
+
+var (
+	blocksMu   sync.RWMutex
+	blockCache = map[string]map[string]bool{} // 차단한 사람 → 차단된 닉네임
+	blockConns = map[string]int{}             // 스트림이 열려 있는 닉네임 → 연결 수
+)
+
+type Block struct {
+	Nick      string    `json:"nick"`
+	BlockedAt time.Time `json:"blocked_at"`
+}
+
+// DB에서 다시 읽고 스트림이 열려 있으면 캐시에 넣음 (mutex를 잡은 채로 부르지 말 것)
+func loadBlocks(ctx context.Context, nick string) map[string]bool {
+	set := map[string]bool{}
+	if nick == "" || !fullFeatureStore() { return set }
+	rows, err := db.QueryContext(ctx, "SELECT blocked FROM user_blocks WHERE blocker = $1", nick)
+	if err != nil { slog.WarnContext(ctx, "block list load failed", "nick", nick, "err", err); return set }
+	defer rows.Close()
+	for rows.Next() {
+		var b string
+		rows.Scan(&b)
+		set[b] = true
+	}
+	blocksMu.Lock()
+	if blockConns[nick] > 0 { blockCache[nick] = set }
+	blocksMu.Unlock()
+	return set
+}
+
+// 스트림 연결 시 부름: 연결 수를 세고 차단 목록을 캐시에 채움
+func retainBlocks(ctx context.Context, nick string) {
+	if nick == "" { return }
+	blocksMu.Lock()
+	blockConns[nick]++
+	blocksMu.Unlock()
+	loadBlocks(ctx, nick)
+}
+
+// 스트림 종료 시 부름: 그 닉네임의 마지막 연결이면 캐시에서 뺌
+func releaseBlocks(nick string) {
+	if nick == "" { return }
+	blocksMu.Lock()
+	defer blocksMu.Unlock()
+	if blockConns[nick]--; blockConns[nick] > 0 { return }
+	delete(blockConns, nick)
+	delete(blockCache, nick)
+}
+
+// 캐시에 있으면 그대로, 없으면 읽어 옴
+func blocksFor(ctx context.Context, nick string) map[string]bool {
+	blocksMu.RLock()
+	set, ok := blockCache[nick]
+	blocksMu.RUnlock()
+	if ok { return set }
+	return loadBlocks(ctx, nick)
+}
+
+// 전달 경로용 (캐시만 봄): 연결할 때 loadBlocks로 미리 채워 둠
+func isBlocked(nick, sender string) bool {
+	if nick == "" || sender == "" { return false }
+	blocksMu.RLock()
+	defer blocksMu.RUnlock()
+	return blockCache[nick][sender]
+}
+
+// 차단한 사람이 보낸 항목을 뺀 새 슬라이스 (원본은 캐시와 공유할 수 있어 건드리지 않음)
+func dropBlocked(ctx context.Context, nick string, msgs []Message) []Message {
+	set := blocksFor(ctx, nick)
+	if len(set) == 0 { return msgs }
+	out := make([]Message, 0, len(msgs))
+	for _, m := range msgs {
+		if !set[m.SenderNick] { out = append(out, m) }
+	}
+	return out
+}
+
+// 방송 페이로드의 보낸 사람 (차단 확인용)
+func streamSender(data string) string {
+	var m struct {
+		SenderNick string `json:"sender_nick"`
+	}
+	json.Unmarshal([]byte(data), &m)
+	return m.SenderNick
+}
+
+// 다른 파드가 바꾼 차단 목록: 이 파드가 들고 있던 사람만 다시 읽음
+func handleBlocksChanged(m BrokerMsg) {
+	nick := string(m.Data)
+	blocksMu.RLock()
+	_, cached := blockCache[nick]
+	blocksMu.RUnlock()
+	if cached { loadBlocks(m.Ctx, nick) }
+}
+
+func blocker(w http.ResponseWriter, r *http.Request) (string, bool) {
+	if !fullFeatureStore() { respondError(w, r, http.StatusNotImplemented, errors.New("blocking requires the postgres store")); return "", false }
+	nick, ok := identityFrom(r.Context())
+	if !ok { respondError(w, r, http.StatusUnauthorized, errLoginRequired); return "", false }
+	return nick, true
+}
+
+func blocksChanged(ctx context.Context, nick string) {
+	loadBlocks(ctx, nick)
+	if err := broker.Publish(ctx, subjectBlocksChanged, []byte(nick)); err != nil {
+		slog.WarnContext(ctx, "block change publish failed", "nick", nick, "err", err)
+	}
+}
+
+// [차단] POST /block/{nick}
+func blockUserHandler(w http.ResponseWriter, r *http.Request) {
+	nick, ok := blocker(w, r)
+	if !ok { return }
+	target := r.PathValue("nick")
+	if target == "" || target == nick { respondError(w, r, http.StatusBadRequest, fieldError{"nick", "cannot block yourself"}); return }
+	if target == systemNick() { respondError(w, r, http.StatusBadRequest, fieldError{"nick", "cannot block the system account"}); return }
+	_, err := db.ExecContext(r.Context(), "INSERT INTO user_blocks (blocker, blocked) VALUES ($1, $2) ON CONFLICT DO NOTHING", nick, target)
+	if err != nil { respondError(w, r, 500, err); return }
+	blocksChanged(r.Context(), nick)
+	slog.InfoContext(r.Context(), "user blocked", "nick", nick, "blocked", target)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// [차단 해제] DELETE /block/{nick}
+func unblockUserHandler(w http.ResponseWriter, r *http.Request) {
+	nick, ok := blocker(w, r)
+	if !ok { return }
+	res, err := db.ExecContext(r.Context(), "DELETE FROM user_blocks WHERE blocker = $1 AND blocked = $2", nick, r.PathValue("nick"))
+	if err != nil { respondError(w, r, 500, err); return }
+	if n, _ := res.RowsAffected(); n == 0 { respondError(w, r, http.StatusNotFound, errors.New("user is not blocked")); return }
+	blocksChanged(r.Context(), nick)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// [차단 목록] GET /blocks
+func blocksHandler(w http.ResponseWriter, r *http.Request) {
+	nick, ok := blocker(w, r)
+	if !ok { return }
+	rows, err := db.QueryContext(r.Context(), "SELECT blocked, created_at FROM user_blocks WHERE blocker = $1 ORDER BY created_at DESC", nick)
+	if err != nil { respondError(w, r, 500, err); return }
+	defer rows.Close()
+	list := []Block{}
+	for rows.Next() {
+		var b Block
+		rows.Scan(&b.Nick, &b.BlockedAt)
+		list = append(list, b)
+	}
+	writeJSON(w, http.StatusOK, list)
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+// 캐시 항목은 그 닉네임의 마지막 스트림이 닫힐 때 빠져야 함
+func TestReleaseBlocks(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name     string
+		streams  int
+		releases int
+		cached   bool
+	}{
+		{"one stream closed", 1, 1, false},
+		{"one of two closed", 2, 1, true},
+		{"both closed", 2, 2, false},
+		{"extra release", 1, 2, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for range tt.streams { retainBlocks(ctx, "alice") }
+			blocksMu.Lock()
+			blockCache["alice"] = map[string]bool{"mallory": true} // DB에서 읽어 온 것처럼
+			blocksMu.Unlock()
+			for range tt.releases { releaseBlocks("alice") }
+
+			blocksMu.RLock()
+			_, cached := blockCache["alice"]
+			blocksMu.RUnlock()
+			if cached != tt.cached { t.Errorf("cached = %v, want %v", cached, tt.cached) }
+			if isBlocked("alice", "mallory") != tt.cached { t.Errorf("isBlocked = %v, want %v", !tt.cached, tt.cached) }
+
+			for range tt.streams { releaseBlocks("alice") }
+			if len(blockCache) != 0 || len(blockConns) != 0 { t.Errorf("leftover cache %v, conns %v", blockCache, blockConns) }
+		})
+	}
+}
+
+func TestRetainBlocksIgnoresEmptyNick(t *testing.T) {
+	retainBlocks(context.Background(), "")
+	releaseBlocks("")
+	if len(blockConns) != 0 { t.Errorf("conns = %v, want empty", blockConns) }
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// [메시지 브로커] 파드 간 전달을 NATS(기본), Redis Pub/Sub, Kafka 중 broker.backend로 선택
+// 핸들러는 백엔드와 무관하게 BrokerMsg만 다룸
+type Broker interface {
+	Publish(ctx context.Context, subject string, data []byte) error
+	Subscribe(subject string, handler func(BrokerMsg)) error
+	// 파드별로 이어받는 구독: 재시작/일시 단절 뒤 놓친 메시지를 재생 (지원하지 않으면 일반 구독)
+	SubscribeDurable(subject string, handler func(BrokerMsg)) error
+	// 첫 응답 하나만 기다림 (제한 시간 안에 응답이 없으면 errNoReply)
+	Request(ctx context.Context, subject string, data []byte, timeout time.Duration) ([]byte, error)
+	// 제한 시간 동안 모든 응답을 모음 (scatter-gather)
+	Gather(ctx context.Context, subject string, data []byte, timeout time.Duration) [][]byte
+	Connected() bool
+	Close()
+}
+
+const (
+	brokerBackendNATS  = "nats"
+	brokerBackendRedis = "redis"
+	brokerBackendKafka = "kafka"
+)
+
+// 전체 채팅 주제 (JetStream/Kafka면 순서와 시퀀스가 보존됨)
+const subjectChat = "chat.global"
+
+var (
+	broker     Broker
+	errNoReply = errors.New("broker: no reply")
+)
+
+type BrokerMsg struct {
+	Subject string
+	Data    []byte
+	Ctx     context.Context // 헤더에서 꺼낸 트레이스 문맥
+	Seq     uint64          // 순서가 있는 백엔드(JetStream, Kafka)의 시퀀스, 없으면 0
+	respond func([]byte) error
+}
+
+// 요청/응답 메시지면 요청한 파드에 응답 (일반 메시지면 무시)
+func (m BrokerMsg) Respond(data []byte) error {
+	if m.respond == nil { return nil }
+	return m.respond(data)
+}
+
+func initBroker() {
+	switch cfg.Broker.Backend {
+	case brokerBackendRedis:
+		broker = newRedisBroker()
+	case brokerBackendKafka:
+		broker = newKafkaBroker()
+	default:
+		broker = newNATSBroker()
+	}
+
+	if err := broker.SubscribeDurable(subjectChat, handleChatStreamMsg); err != nil { fatal("chat subscribe failed", err) }
+	broker.Subscribe(subjectDirect, handleDirectEvent)
+	broker.Subscribe(subjectPresenceCheck, handlePresenceCheck)
+	broker.Subscribe(subjectPresenceList, handlePresenceList)
+	broker.Subscribe(subjectUserStatus, handleUserStatus)
+	broker.Subscribe(subjectAdminStats, handleAdminStats)
+	broker.Subscribe(subjectPong, handlePongEvent)
+	broker.Subscribe(subjectSurvey, handleSurveyEvent)
+	broker.Subscribe(subjectUserUpdated, handleUserUpdated)
+	broker.Subscribe(subjectClusterHeartbeat, handleClusterHeartbeat)
+	broker.Subscribe(subjectBlocksChanged, handleBlocksChanged)
+	broker.Subscribe(subjectRoomMembersChanged, handleRoomMembersChanged)
+	slog.Info("message broker ready", "backend", cfg.Broker.Backend)
+}
+
+// 스트림 시퀀스를 페이로드에 실어 클라이언트가 누락을 감지할 수 있게 함
+func handleChatStreamMsg(m BrokerMsg) {
+	data := string(m.Data)
+	if m.Seq > 0 {
+		var msg Message
+		if json.Unmarshal(m.Data, &msg) == nil {
+			msg.Seq = m.Seq
+			b, _ := json.Marshal(msg)
+			data = string(b)
+		}
+	}
+	slog.DebugContext(m.Ctx, "received chat message", "subject", m.Subject)
+	broadcast <- newOutbound(m.Ctx, data)
+}
+
+// 채팅 메시지 발행, 트레이스 문맥은 헤더로 전달
+func publishChat(ctx context.Context, data []byte) error {
+	ctx, span := tracer.Start(ctx, "publish "+subjectChat, trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(attribute.String("messaging.system", cfg.Broker.Backend)))
+	defer span.End()
+	return broker.Publish(ctx, subjectChat, data)
+}
+
+// 헤더를 따로 실을 수 없는 백엔드(Redis, Kafka)용 봉투
+// 요청이면 Reply(응답받을 파드의 수신함 주제)와 ReplyID를 채움
+type envelope struct {
+	Version string            `json:"v,omitempty"` // 프로토콜 버전 (protocol.go), 보낼 때 send가 채움
+	Data    []byte            `json:"data"`
+	Header  map[string]string `json:"header,omitempty"`
+	Reply   string            `json:"reply,omitempty"`
+	ReplyID string            `json:"reply_id,omitempty"`
+}
+
+// 봉투 기반 백엔드가 공유하는 요청/응답 처리
+// 파드마다 수신함 주제 하나를 구독하고, ReplyID로 기다리는 요청을 찾아 넘김
+type replyInbox struct {
+	subject string
+	send    func(ctx context.Context, subject string, env envelope) error
+	seq     atomic.Uint64
+	mu      sync.Mutex
+	pending map[string]chan []byte
+}
+
+func newReplyInbox(subject string, send func(context.Context, string, envelope) error) *replyInbox {
+	return &replyInbox{subject: subject, send: send, pending: map[string]chan []byte{}}
+}
+
+// 수신함에 들어온 응답 전달 (이미 끝난 요청이거나 버퍼가 차면 버림)
+func (in *replyInbox) deliver(env envelope) {
+	in.mu.Lock()
+	ch := in.pending[env.ReplyID]
+	in.mu.Unlock()
+	if ch == nil { return }
+	select {
+	case ch <- env.Data:
+	default:
+	}
+}
+
+// 받은 봉투를 핸들러용 메시지로 (요청이면 응답 함수를 붙임)
+func (in *replyInbox) message(subject string, env envelope, seq uint64) BrokerMsg {
+	m := BrokerMsg{Subject: subject, Data: env.Data, Ctx: traceContext(env.Header), Seq: seq}
+	if env.Reply != "" {
+		m.respond = func(data []byte) error {
+			return in.send(context.Background(), env.Reply, envelope{Data: data, ReplyID: env.ReplyID})
+		}
+	}
+	return m
+}
+
+func (in *replyInbox) request(ctx context.Context, subject string, data []byte, timeout time.Duration, first bool) [][]byte {
+	id := strconv.FormatUint(in.seq.Add(1), 36)
+	ch := make(chan []byte, 64)
+	in.mu.Lock()
+	in.pending[id] = ch
+	in.mu.Unlock()
+	defer func() {
+		in.mu.Lock()
+		delete(in.pending, id)
+		in.mu.Unlock()
+	}()
+
+	env := envelope{Data: data, Header: traceHeaders(ctx), Reply: in.subject, ReplyID: id}
+	if err := in.send(ctx, subject, env); err != nil { return nil }
+
+	var replies [][]byte
+	deadline := time.After(timeout)
+	for {
+		select {
+		case data := <-ch:
+			replies = append(replies, data)
+			if first { return replies }
+		case <-deadline:
+			return replies
+		case <-ctx.Done():
+			return replies
+		}
+	}
+}
+
+func (in *replyInbox) Request(ctx context.Context, subject string, data []byte, timeout time.Duration) ([]byte, error) {
+	replies := in.request(ctx, subject, data, timeout, true)
+	if len(replies) == 0 { return nil, errNoReply }
+	return replies[0], nil
+}
+
+func (in *replyInbox) Gather(ctx context.Context, subject string, data []byte, timeout time.Duration) [][]byte {
+	return in.request(ctx, subject, data, timeout, false)
+}
+
+// 트레이스 문맥을 봉투 헤더로
+func traceHeaders(ctx context.Context) map[string]string {
+	h := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, h)
+	return h
+}
+
+// 봉투 헤더에서 트레이스 문맥 추출
+func traceContext(h map[string]string) context.Context {
+	if h == nil { return context.Background() }
+	return otel.GetTextMapPropagator().Extract(context.Background(), propagation.MapCarrier(h))
+}
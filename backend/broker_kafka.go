@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// [Kafka 브로커] 주제 이름을 그대로 토픽으로 씀 (순서 보장을 위해 파티션 1개로 생성)
+// 일반 구독은 최신 오프셋부터 읽고, durable 구독은 파드별 컨슈머 그룹이 커밋된 오프셋부터 이어 읽음
+type kafkaBroker struct {
+	client *kafka.Client
+	writer *kafka.Writer
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu      sync.Mutex
+	topics  map[string]bool
+	readers []*kafka.Reader
+	*replyInbox
+}
+
+func newKafkaBroker() *kafkaBroker {
+	addrs := cfg.Broker.KafkaBrokers
+	ctx, cancel := context.WithCancel(context.Background())
+	b := &kafkaBroker{
+		client: &kafka.Client{Addr: kafka.TCP(addrs...), Timeout: 10 * time.Second},
+		writer: &kafka.Writer{
+			Addr:         kafka.TCP(addrs...),
+			Balancer:     &kafka.LeastBytes{},
+			BatchTimeout: 5 * time.Millisecond, // 채팅 지연을 줄이려고 배치를 짧게
+			RequiredAcks: kafka.RequireAll,
+		},
+		ctx: ctx, cancel: cancel, topics: map[string]bool{},
+	}
+	if !b.Connected() { fatal("kafka connect failed", errors.New("no reachable broker in "+addrs[0])) }
+
+	b.replyInbox = newReplyInbox(inboxSubject(), b.send)
+	if err := b.listen(b.replyInbox.subject, "", func(env envelope, _ kafka.Message) { b.deliver(env) }); err != nil {
+		fatal("kafka inbox subscribe failed", err)
+	}
+	slog.Info("connected to kafka broker", "brokers", addrs)
+	return b
+}
+
+// 토픽이 없으면 만듦 (이미 있으면 무시)
+func (b *kafkaBroker) ensureTopic(topic string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.topics[topic] { return nil }
+	resp, err := b.client.CreateTopics(b.ctx, &kafka.CreateTopicsRequest{
+		Topics: []kafka.TopicConfig{{Topic: topic, NumPartitions: 1, ReplicationFactor: -1}},
+	})
+	if err != nil { return err }
+	if err := resp.Errors[topic]; err != nil && !errors.Is(err, kafka.TopicAlreadyExists) { return err }
+	b.topics[topic] = true
+	return nil
+}
+
+func (b *kafkaBroker) send(ctx context.Context, subject string, env envelope) error {
+	if err := b.ensureTopic(subject); err != nil { return err }
+	env.Version = protocolVersion
+	data, _ := json.Marshal(env)
+	return b.writer.WriteMessages(ctx, kafka.Message{Topic: subject, Value: data})
+}
+
+// group이 비어 있으면 이 파드 전용 리더로 최신 오프셋부터 읽음
+func (b *kafkaBroker) listen(topic, group string, fn func(envelope, kafka.Message)) error {
+	if err := b.ensureTopic(topic); err != nil { return err }
+	rc := kafka.ReaderConfig{Brokers: cfg.Broker.KafkaBrokers, Topic: topic, MaxWait: 100 * time.Millisecond}
+	if group != "" {
+		rc.GroupID = group
+		rc.StartOffset = kafka.LastOffset // 그룹이 처음 생길 때만 적용
+	}
+	r := kafka.NewReader(rc)
+	if group == "" {
+		if err := r.SetOffset(kafka.LastOffset); err != nil { r.Close(); return err }
+	}
+	b.mu.Lock()
+	b.readers = append(b.readers, r)
+	b.mu.Unlock()
+
+	go func() {
+		for {
+			m, err := r.ReadMessage(b.ctx)
+			if b.ctx.Err() != nil { return }
+			if err != nil {
+				slog.Warn("kafka read failed", "topic", topic, "err", err)
+				time.Sleep(time.Second)
+				continue
+			}
+			var env envelope
+			if err := json.Unmarshal(m.Value, &env); err != nil {
+				slog.Warn("invalid broker message", "subject", topic, "err", err)
+				continue
+			}
+			if !acceptProtocol(topic, env.Version) { continue }
+			fn(env, m)
+		}
+	}()
+	return nil
+}
+
+func (b *kafkaBroker) Publish(ctx context.Context, subject string, data []byte) error {
+	return b.send(ctx, subject, envelope{Data: data, Header: traceHeaders(ctx)})
+}
+
+func (b *kafkaBroker) Subscribe(subject string, handler func(BrokerMsg)) error {
+	return b.listen(subject, "", func(env envelope, _ kafka.Message) { handler(b.message(subject, env, 0)) })
+}
+
+// 오프셋+1을 시퀀스로 (JetStream 시퀀스처럼 1부터)
+func (b *kafkaBroker) SubscribeDurable(subject string, handler func(BrokerMsg)) error {
+	group := "gotalk-node-" + consumerName()
+	err := b.listen(subject, group, func(env envelope, m kafka.Message) {
+		handler(b.message(subject, env, uint64(m.Offset)+1))
+	})
+	if err == nil { slog.Info("kafka consumer group ready", "topic", subject, "group", group) }
+	return err
+}
+
+func (b *kafkaBroker) Connected() bool {
+	for _, addr := range cfg.Broker.KafkaBrokers {
+		conn, err := net.DialTimeout("tcp", addr, time.Second)
+		if err == nil {
+			conn.Close()
+			return true
+		}
+	}
+	return false
+}
+
+func (b *kafkaBroker) Close() {
+	b.cancel()
+	b.mu.Lock()
+	for _, r := range b.readers {
+		r.Close()
+	}
+	b.mu.Unlock()
+	b.writer.Close()
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"regexp"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+const chatStream = "CHAT"
+
+var (
+	// 파드가 사라진 뒤 durable 컨슈머를 정리하기까지의 시간
+	consumerInactiveThreshold = time.Hour
+
+	invalidConsumerChars = regexp.MustCompile(`[^A-Za-z0-9_-]`)
+)
+
+// [NATS 브로커] 요청/응답은 NATS 기본 기능, chat.global은 JetStream 스트림으로 보존
+// JetStream을 쓸 수 없는 서버면 코어 NATS 구독으로 대체
+type natsBroker struct {
+	nc *nats.Conn
+	js nats.JetStreamContext
+}
+
+func newNATSBroker() *natsBroker {
+	natsURL := cfg.NATS.URL
+	if natsURL == "" {
+		natsURL = nats.DefaultURL
+		slog.Warn("NATS URL not configured, using default", "url", natsURL)
+	} else {
+		slog.Info("connecting to NATS", "url", natsURL)
+	}
+
+	nc, err := nats.Connect(natsURL, nats.Name("GoTalk"), nats.MaxReconnects(-1),
+		nats.ReconnectHandler(func(*nats.Conn) { natsReconnects.Inc() }))
+	if err != nil { fatal("NATS connect failed", err) }
+	b := &natsBroker{nc: nc}
+	b.initJetStream()
+	slog.Info("connected to NATS")
+	return b
+}
+
+func (b *natsBroker) initJetStream() {
+	js, err := b.nc.JetStream()
+	if err == nil {
+		cfg := &nats.StreamConfig{
+			Name:     chatStream,
+			Subjects: []string{subjectChat},
+			Storage:  nats.FileStorage,
+			MaxAge:   time.Duration(cfg.NATS.StreamMaxAge),
+		}
+		if _, err = js.StreamInfo(chatStream); err == nats.ErrStreamNotFound {
+			_, err = js.AddStream(cfg)
+		} else if err == nil {
+			_, err = js.UpdateStream(cfg)
+		}
+	}
+	if err != nil {
+		slog.Warn("jetstream unavailable, falling back to core NATS", "err", err)
+		return
+	}
+	b.js = js
+}
+
+// 멤버십 레지스트리 버킷 (항목은 node_ttl 동안 갱신이 없으면 사라짐)
+func (b *natsBroker) membersKV() (nats.KeyValue, error) {
+	kv, err := b.js.KeyValue(membersBucket)
+	if err == nats.ErrBucketNotFound {
+		kv, err = b.js.CreateKeyValue(&nats.KeyValueConfig{
+			Bucket:  membersBucket,
+			TTL:     time.Duration(cfg.Cluster.NodeTTL),
+			Storage: nats.MemoryStorage,
+		})
+	}
+	return kv, err
+}
+
+func (b *natsBroker) message(m *nats.Msg) BrokerMsg {
+	msg := BrokerMsg{Subject: m.Subject, Data: m.Data, Ctx: extractTrace(m)}
+	if m.Reply != "" { msg.respond = m.Respond }
+	return msg
+}
+
+// 채팅 주제는 JetStream이면 저장 확인까지 대기
+func (b *natsBroker) Publish(ctx context.Context, subject string, data []byte) error {
+	msg := &nats.Msg{Subject: subject, Data: data}
+	injectTrace(ctx, msg)
+	if b.js != nil && subject == subjectChat {
+		_, err := b.js.PublishMsg(msg)
+		return err
+	}
+	return b.nc.PublishMsg(msg)
+}
+
+func (b *natsBroker) Subscribe(subject string, handler func(BrokerMsg)) error {
+	_, err := b.nc.Subscribe(subject, func(m *nats.Msg) {
+		if !acceptProtocol(subject, m.Header.Get(protocolHeader)) { return }
+		handler(b.message(m))
+	})
+	return err
+}
+
+// 프로세스별 durable 컨슈머, 처리 후 ack
+func (b *natsBroker) SubscribeDurable(subject string, handler func(BrokerMsg)) error {
+	if b.js == nil { return b.Subscribe(subject, handler) }
+	durable := "node-" + consumerName()
+	_, err := b.js.Subscribe(subject, func(m *nats.Msg) {
+		if !acceptProtocol(subject, m.Header.Get(protocolHeader)) { m.Ack(); return }
+		msg := b.message(m)
+		if meta, err := m.Metadata(); err == nil { msg.Seq = meta.Sequence.Stream }
+		handler(msg)
+		m.Ack()
+	},
+		nats.Durable(durable),
+		nats.DeliverNew(),
+		nats.ManualAck(),
+		nats.InactiveThreshold(consumerInactiveThreshold),
+	)
+	if err == nil { slog.Info("jetstream stream ready", "stream", chatStream, "consumer", durable) }
+	return err
+}
+
+func (b *natsBroker) Request(ctx context.Context, subject string, data []byte, timeout time.Duration) ([]byte, error) {
+	msg := &nats.Msg{Subject: subject, Data: data}
+	injectTrace(ctx, msg)
+	reply, err := b.nc.RequestMsg(msg, timeout)
+	if err != nil { return nil, errNoReply }
+	return reply.Data, nil
+}
+
+func (b *natsBroker) Gather(ctx context.Context, subject string, data []byte, timeout time.Duration) [][]byte {
+	inbox := nats.NewInbox()
+	replies := make(chan *nats.Msg, 64)
+	sub, err := b.nc.ChanSubscribe(inbox, replies)
+	if err != nil { return nil }
+	defer sub.Unsubscribe()
+	msg := &nats.Msg{Subject: subject, Reply: inbox, Data: data}
+	injectTrace(ctx, msg)
+	if err := b.nc.PublishMsg(msg); err != nil { return nil }
+
+	var out [][]byte
+	deadline := time.After(timeout)
+	for {
+		select {
+		case m := <-replies:
+			out = append(out, m.Data)
+		case <-deadline:
+			return out
+		case <-ctx.Done():
+			return out
+		}
+	}
+}
+
+func (b *natsBroker) Connected() bool { return b.nc.IsConnected() }
+func (b *natsBroker) Close()          { b.nc.Drain() }
+
+// NATS 메시지 헤더에 트레이스 문맥과 프로토콜 버전 주입
+func injectTrace(ctx context.Context, m *nats.Msg) {
+	if m.Header == nil { m.Header = nats.Header{} }
+	m.Header.Set(protocolHeader, protocolVersion)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(m.Header))
+}
+
+// NATS 메시지 헤더에서 트레이스 문맥 추출
+func extractTrace(m *nats.Msg) context.Context {
+	if m.Header == nil { return context.Background() }
+	return otel.GetTextMapPropagator().Extract(context.Background(), propagation.HeaderCarrier(m.Header))
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// [Redis 브로커] Pub/Sub은 저장하지 않으므로 durable 구독도 일반 구독과 같음
+// (끊긴 동안의 메시지는 클라이언트가 /history?after_id로 채움)
+type redisBroker struct {
+	rdb *redis.Client
+	*replyInbox
+}
+
+func newRedisBroker() *redisBroker {
+	opts, err := redis.ParseURL(cfg.Broker.RedisURL)
+	if err != nil { fatal("invalid broker.redis_url", err) }
+	rdb := redis.NewClient(opts)
+	if err := rdb.Ping(context.Background()).Err(); err != nil { fatal("redis connect failed", err) }
+
+	b := &redisBroker{rdb: rdb}
+	b.replyInbox = newReplyInbox(inboxSubject(), b.send)
+	b.listen(b.replyInbox.subject, func(env envelope) { b.deliver(env) })
+	slog.Info("connected to redis broker", "addr", opts.Addr)
+	return b
+}
+
+func (b *redisBroker) send(ctx context.Context, subject string, env envelope) error {
+	env.Version = protocolVersion
+	data, _ := json.Marshal(env)
+	return b.rdb.Publish(ctx, subject, data).Err()
+}
+
+func (b *redisBroker) listen(subject string, fn func(envelope)) {
+	ps := b.rdb.Subscribe(context.Background(), subject)
+	go func() {
+		for m := range ps.Channel() {
+			var env envelope
+			if err := json.Unmarshal([]byte(m.Payload), &env); err != nil {
+				slog.Warn("invalid broker message", "subject", subject, "err", err)
+				continue
+			}
+			if !acceptProtocol(subject, env.Version) { continue }
+			fn(env)
+		}
+	}()
+}
+
+func (b *redisBroker) Publish(ctx context.Context, subject string, data []byte) error {
+	return b.send(ctx, subject, envelope{Data: data, Header: traceHeaders(ctx)})
+}
+
+func (b *redisBroker) Subscribe(subject string, handler func(BrokerMsg)) error {
+	b.listen(subject, func(env envelope) { handler(b.message(subject, env, 0)) })
+	return nil
+}
+
+func (b *redisBroker) SubscribeDurable(subject string, handler func(BrokerMsg)) error {
+	return b.Subscribe(subject, handler)
+}
+
+func (b *redisBroker) Connected() bool {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	return b.rdb.Ping(ctx).Err() == nil
+}
+
+func (b *redisBroker) Close() { b.rdb.Close() }
+
+// 이 프로세스의 응답 수신함 주제
+func inboxSubject() string {
+	return "gotalk.inbox." + consumerName()
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// [방 일정] 방 안에서 모임을 잡고(제목/시작 시각/설명) 참석 여부(RSVP)를 모음
+// 만들거나 취소하면 타임라인 이벤트로 남고, 시작 calendar.reminder_before 전에
+// 스케줄러가 방 타임라인과 참석(going/maybe) 응답자에게 한 번 알림
+// GET /rooms/{id}/calendar.ics는 캘린더 앱이 구독하는 iCal 피드 (공개 방은 누구나, 비공개 방은 멤버만)
+const (
+	eventCalendar = "calendar" // 타임라인 이벤트 종류
+
+	rsvpGoing    = "going"
+	rsvpMaybe    = "maybe"
+	rsvpDeclined = "declined"
+
+	calendarMaxTitle       = 200
+	calendarMaxDescription = 2000
+)
+
+type CalendarEvent struct {
+	ID          int            `json:"id"`
+	RoomID      int            `json:"room_id"`
+	Title       string         `json:"title"`
+	Description string         `json:"description,omitempty"`
+	StartsAt    time.Time      `json:"starts_at"`
+	CreatedBy   string         `json:"created_by"`
+	CreatedAt   time.Time      `json:"created_at"`
+	CanceledAt  *time.Time     `json:"canceled_at,omitempty"`
+	RSVPs       map[string]int `json:"rsvps"`             // 응답별 인원
+	MyRSVP      string         `json:"my_rsvp,omitempty"` // 요청자 응답
+}
+
+// [일정 만들기] POST /rooms/{id}/calendar (nick, title, starts_at=RFC3339, description?) - 방 멤버
+func createCalendarEventHandler(w http.ResponseWriter, r *http.Request) {
+	roomID, ok := roomIDFrom(r)
+	nick := r.FormValue("nick")
+	if !ok || nick == "" { respondError(w, r, http.StatusBadRequest, errors.New("room id and nick required")); return }
+	if roomRole(roomID, nick) == "" { respondError(w, r, http.StatusForbidden, errors.New("not a room member")); return }
+
+	ev := CalendarEvent{RoomID: roomID, Title: strings.TrimSpace(r.FormValue("title")), Description: strings.TrimSpace(r.FormValue("description")), CreatedBy: nick, RSVPs: map[string]int{}}
+	if ev.Title == "" || utf8.RuneCountInString(ev.Title) > calendarMaxTitle { respondError(w, r, http.StatusBadRequest, errors.New("title required, up to 200 characters")); return }
+	if utf8.RuneCountInString(ev.Description) > calendarMaxDescription { respondError(w, r, http.StatusBadRequest, errors.New("description too long")); return }
+	startsAt, err := time.Parse(time.RFC3339, r.FormValue("starts_at"))
+	if err != nil { respondError(w, r, http.StatusBadRequest, errors.New("starts_at must be RFC3339")); return }
+	if !startsAt.After(time.Now()) { respondError(w, r, http.StatusBadRequest, errors.New("starts_at must be in the future")); return }
+	ev.StartsAt = startsAt
+
+	err = db.QueryRow(`INSERT INTO calendar_events (room_id, title, description, starts_at, created_by) VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at`, roomID, ev.Title, ev.Description, startsAt, nick).Scan(&ev.ID, &ev.CreatedAt)
+	if err != nil { respondError(w, r, 500, err); return }
+	// 만든 사람은 참석으로
+	db.Exec("INSERT INTO calendar_rsvps (event_id, nickname, status) VALUES ($1, $2, $3)", ev.ID, nick, rsvpGoing)
+	ev.RSVPs[rsvpGoing], ev.MyRSVP = 1, rsvpGoing
+
+	recordEvent(r.Context(), &roomID, RoomEvent{Kind: eventCalendar, Actor: nick, Data: map[string]any{"event_id": ev.ID, "action": "created"}},
+		fmt.Sprintf("📅 %s scheduled \"%s\" for %s.", nick, ev.Title, startsAt.UTC().Format("2006-01-02 15:04 MST")))
+	writeJSON(w, http.StatusCreated, ev)
+}
+
+// [일정 목록] GET /rooms/{id}/calendar?nick=&past=true - 기본은 다가오는 일정 (취소된 일정 제외)
+func listCalendarEventsHandler(w http.ResponseWriter, r *http.Request) {
+	roomID, ok := roomIDFrom(r)
+	nick := r.URL.Query().Get("nick")
+	if !ok || nick == "" { respondError(w, r, http.StatusBadRequest, errors.New("room id and nick required")); return }
+	if !roomAccessOK(w, r, roomID) { return }
+	if roomRole(roomID, nick) == "" { respondError(w, r, http.StatusForbidden, errors.New("not a room member")); return }
+
+	cond := " AND starts_at > CURRENT_TIMESTAMP ORDER BY starts_at"
+	if r.URL.Query().Get("past") == "true" { cond = " AND starts_at <= CURRENT_TIMESTAMP ORDER BY starts_at DESC LIMIT 50" }
+	list, err := loadCalendarEvents(r.Context(), "room_id = $1 AND canceled_at IS NULL"+cond, roomID)
+	if err != nil { respondError(w, r, 500, err); return }
+	if err := fillRSVPs(r.Context(), list, nick); err != nil { respondError(w, r, 500, err); return }
+	writeJSON(w, http.StatusOK, list)
+}
+
+func loadCalendarEvents(ctx context.Context, where string, args ...any) ([]CalendarEvent, error) {
+	rows, err := db.QueryContext(ctx, "SELECT id, room_id, title, description, starts_at, created_by, created_at, canceled_at FROM calendar_events WHERE "+where, args...)
+	if err != nil { return nil, err }
+	defer rows.Close()
+	list := []CalendarEvent{}
+	for rows.Next() {
+		ev := CalendarEvent{RSVPs: map[string]int{}}
+		if err := rows.Scan(&ev.ID, &ev.RoomID, &ev.Title, &ev.Description, &ev.StartsAt, &ev.CreatedBy, &ev.CreatedAt, &ev.CanceledAt); err != nil { return nil, err }
+		list = append(list, ev)
+	}
+	return list, rows.Err()
+}
+
+// 응답별 인원과 요청자 응답 채우기
+func fillRSVPs(ctx context.Context, list []CalendarEvent, nick string) error {
+	for i := range list {
+		rows, err := db.QueryContext(ctx, `SELECT status, COUNT(*), BOOL_OR(nickname = $2) FROM calendar_rsvps
+			WHERE event_id = $1 GROUP BY status`, list[i].ID, nick)
+		if err != nil { return err }
+		for rows.Next() {
+			var status string
+			var n int
+			var mine bool
+			rows.Scan(&status, &n, &mine)
+			list[i].RSVPs[status] = n
+			if mine { list[i].MyRSVP = status }
+		}
+		rows.Close()
+	}
+	return nil
+}
+
+// 일정과 요청자의 방 역할 (취소된 일정도 포함)
+func loadCalendarEvent(r *http.Request, nick string) (CalendarEvent, string, error) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil { return CalendarEvent{}, "", sql.ErrNoRows }
+	list, err := loadCalendarEvents(r.Context(), "id = $1", id)
+	if err != nil { return CalendarEvent{}, "", err }
+	if len(list) == 0 { return CalendarEvent{}, "", sql.ErrNoRows }
+	return list[0], roomRole(list[0].RoomID, nick), nil
+}
+
+// [참석 응답] POST /calendar/{id}/rsvp (nick, status=going|maybe|declined) - 방 멤버, 다시 보내면 덮어씀
+func rsvpCalendarEventHandler(w http.ResponseWriter, r *http.Request) {
+	nick := r.FormValue("nick")
+	status := r.FormValue("status")
+	if nick == "" { respondError(w, r, http.StatusBadRequest, errors.New("nick required")); return }
+	if status != rsvpGoing && status != rsvpMaybe && status != rsvpDeclined { respondError(w, r, http.StatusBadRequest, errors.New("status must be going, maybe or declined")); return }
+
+	ev, role, err := loadCalendarEvent(r, nick)
+	if err == sql.ErrNoRows { respondError(w, r, http.StatusNotFound, errors.New("event not found")); return }
+	if err != nil { respondError(w, r, 500, err); return }
+	if role == "" { respondError(w, r, http.StatusForbidden, errors.New("not a room member")); return }
+	if ev.CanceledAt != nil { respondError(w, r, http.StatusGone, errors.New("event canceled")); return }
+	if !ev.StartsAt.After(time.Now()) { respondError(w, r, http.StatusConflict, errors.New("event already started")); return }
+
+	_, err = db.Exec(`INSERT INTO calendar_rsvps (event_id, nickname, status) VALUES ($1, $2, $3)
+		ON CONFLICT (event_id, nickname) DO UPDATE SET status = $3, responded_at = CURRENT_TIMESTAMP`, ev.ID, nick, status)
+	if err != nil { respondError(w, r, 500, err); return }
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// [일정 취소] DELETE /calendar/{id}?nick= - 만든 사람 또는 방장/운영진
+func cancelCalendarEventHandler(w http.ResponseWriter, r *http.Request) {
+	nick := r.URL.Query().Get("nick")
+	if nick == "" { respondError(w, r, http.StatusBadRequest, errors.New("nick required")); return }
+	ev, role, err := loadCalendarEvent(r, nick)
+	if err == sql.ErrNoRows { respondError(w, r, http.StatusNotFound, errors.New("event not found")); return }
+	if err != nil { respondError(w, r, 500, err); return }
+	if ev.CreatedBy != nick && role != roomRoleOwner && role != roomRoleModerator { respondError(w, r, http.StatusForbidden, errors.New("only the organizer, owners and moderators can cancel")); return }
+
+	res, err := db.Exec("UPDATE calendar_events SET canceled_at = CURRENT_TIMESTAMP WHERE id = $1 AND canceled_at IS NULL", ev.ID)
+	if err != nil { respondError(w, r, 500, err); return }
+	if n, _ := res.RowsAffected(); n == 0 { respondError(w, r, http.StatusGone, errors.New("event already canceled")); return }
+	recordEvent(r.Context(), &ev.RoomID, RoomEvent{Kind: eventCalendar, Actor: nick, Data: map[string]any{"event_id": ev.ID, "action": "canceled"}},
+		fmt.Sprintf("📅 %s canceled \"%s\".", nick, ev.Title))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// [일정 알림] 곧 시작할 일정마다 한 번: 방 타임라인에 남기고 참석/미정 응답자에게 멘션처럼 알림
+// reminded_at을 먼저 찍고 가져오므로 작업이 겹쳐도 두 번 보내지 않음
+func sendCalendarReminders() {
+	ctx := context.Background()
+	before := time.Duration(cfg.Calendar.ReminderBefore)
+	rows, err := db.QueryContext(ctx, `
+		UPDATE calendar_events SET reminded_at = CURRENT_TIMESTAMP
+		WHERE reminded_at IS NULL AND canceled_at IS NULL
+		  AND starts_at > CURRENT_TIMESTAMP AND starts_at <= CURRENT_TIMESTAMP + $1 * INTERVAL '1 second'
+		RETURNING id, room_id, title, starts_at, created_by`, before.Seconds())
+	if err != nil { slog.Warn("calendar reminder query failed", "err", err); return }
+	var due []CalendarEvent
+	for rows.Next() {
+		var ev CalendarEvent
+		rows.Scan(&ev.ID, &ev.RoomID, &ev.Title, &ev.StartsAt, &ev.CreatedBy)
+		due = append(due, ev)
+	}
+	rows.Close()
+
+	for _, ev := range due {
+		mins := max(int(time.Until(ev.StartsAt).Round(time.Minute).Minutes()), 1)
+		content := fmt.Sprintf("⏰ \"%s\" starts in %d min.", ev.Title, mins)
+		msg, err := postEvent(ctx, &ev.RoomID, RoomEvent{Kind: eventCalendar, Actor: ev.CreatedBy, Data: map[string]any{"event_id": ev.ID, "action": "reminder"}}, content)
+		if err != nil { slog.Warn("calendar reminder post failed", "event_id", ev.ID, "err", err); continue }
+
+		attendees, err := db.QueryContext(ctx, "SELECT nickname FROM calendar_rsvps WHERE event_id = $1 AND status IN ($2, $3)", ev.ID, rsvpGoing, rsvpMaybe)
+		if err != nil { continue }
+		var nicks []string
+		for attendees.Next() {
+			var nick string
+			attendees.Scan(&nick)
+			nicks = append(nicks, nick)
+		}
+		attendees.Close()
+		for _, nick := range nicks {
+			publishDirect(DirectEvent{Type: "reminder", Target: nick, Message: msg})
+			go notifyOffline(nick, PushPayload{Title: ev.Title, Body: content, MessageID: msg.ID})
+		}
+		slog.Info("calendar reminder sent", "event_id", ev.ID, "room_id", ev.RoomID, "attendees", len(nicks))
+	}
+}
+
+// [iCal 피드] GET /rooms/{id}/calendar.ics - 최근 30일부터의 일정 (취소된 일정은 STATUS:CANCELLED), 비공개 방은 확인된 신원이 멤버일 때만
+func calendarFeedHandler(w http.ResponseWriter, r *http.Request) {
+	roomID, ok := roomIDFrom(r)
+	if !ok { respondError(w, r, http.StatusBadRequest, errors.New("invalid room id")); return }
+	if !roomAccessOK(w, r, roomID) { return }
+	var name string
+	err := db.QueryRow("SELECT name FROM rooms WHERE id = $1", roomID).Scan(&name)
+	if err == sql.ErrNoRows { respondError(w, r, http.StatusNotFound, errors.New("room not found")); return }
+	if err != nil { respondError(w, r, 500, err); return }
+
+	list, err := loadCalendarEvents(r.Context(), "room_id = $1 AND starts_at > CURRENT_TIMESTAMP - INTERVAL '30 days' ORDER BY starts_at", roomID)
+	if err != nil { respondError(w, r, 500, err); return }
+
+	host := strings.TrimPrefix(strings.TrimPrefix(baseURL(r), "https://"), "http://")
+	var b strings.Builder
+	b.WriteString("BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//gotalk//calendar//EN\r\nCALSCALE:GREGORIAN\r\n")
+	icalLine(&b, "X-WR-CALNAME", name)
+	for _, ev := range list {
+		b.WriteString("BEGIN:VEVENT\r\n")
+		icalLine(&b, "UID", fmt.Sprintf("event-%d@%s", ev.ID, host))
+		b.WriteString("DTSTAMP:" + ev.CreatedAt.UTC().Format(icalTime) + "\r\n")
+		b.WriteString("DTSTART:" + ev.StartsAt.UTC().Format(icalTime) + "\r\n")
+		// 종료 시각은 따로 받지 않으므로 1시간으로 표시
+		b.WriteString("DTEND:" + ev.StartsAt.Add(time.Hour).UTC().Format(icalTime) + "\r\n")
+		icalLine(&b, "SUMMARY", ev.Title)
+		if ev.Description != "" { icalLine(&b, "DESCRIPTION", ev.Description) }
+		status := "CONFIRMED"
+		if ev.CanceledAt != nil { status = "CANCELLED" }
+		b.WriteString("STATUS:" + status + "\r\n")
+		b.WriteString("END:VEVENT\r\n")
+	}
+	b.WriteString("END:VCALENDAR\r\n")
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=\"room-%d.ics\"", roomID))
+	w.Write([]byte(b.String()))
+}
+
+const icalTime = "20060102T150405Z"
+
+// RFC 5545 텍스트 이스케이프
+var icalEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+func icalEscape(s string) string { return icalEscaper.Replace(s) }
+
+// 한 줄은 75바이트에서 접음 (UTF-8 문자 중간은 자르지 않음)
+func icalLine(b *strings.Builder, name, value string) {
+	line := name + ":" + icalEscape(value)
+	limit := 75
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut] + "\r\n ")
+		line, limit = line[cut:], 74 // 이어지는 줄은 앞의 공백 포함 75
+	}
+	b.WriteString(line + "\r\n")
+}
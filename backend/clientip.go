@@ -0,0 +1,53 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// [클라이언트 IP] IP 한도, 폭주 차단, 세션/기기 기록에 쓰는 요청자 주소
+// X-Forwarded-For는 누구나 넣을 수 있으므로 연결 주소(RemoteAddr)가 http.trusted_proxies 안일 때만 봄
+// 그때도 맨 앞 값(클라이언트가 꾸밀 수 있음)이 아니라 오른쪽부터 믿는 프록시를 건너뛴 첫 주소를 씀
+var trustedProxies []netip.Prefix
+
+func initTrustedProxies() {
+	trustedProxies = nil
+	for _, cidr := range cfg.HTTP.TrustedProxies {
+		prefix, err := parsePrefix(cidr)
+		if err != nil { fatal("invalid http.trusted_proxies", err) }
+		trustedProxies = append(trustedProxies, prefix)
+	}
+}
+
+func prefixesContain(prefixes []netip.Prefix, addr netip.Addr) bool {
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) { return true }
+	}
+	return false
+}
+
+// 연결 주소 (IPv4-mapped IPv6는 IPv4로)
+func remoteAddr(r *http.Request) (netip.Addr, bool) {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil { return netip.Addr{}, false }
+	addr, err := netip.ParseAddr(host)
+	if err != nil { return netip.Addr{}, false }
+	return addr.Unmap(), true
+}
+
+func clientIP(r *http.Request) string {
+	addr, ok := remoteAddr(r)
+	if !ok { return r.RemoteAddr }
+	if !prefixesContain(trustedProxies, addr) { return addr.String() }
+	// 여러 X-Forwarded-For 헤더는 순서대로 이어 붙인 것과 같음
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil { break } // 믿는 프록시가 이런 값을 넣지는 않으므로 여기부터는 꾸민 값
+		addr = hop.Unmap()
+		if !prefixesContain(trustedProxies, addr) { return addr.String() }
+	}
+	return addr.String()
+}
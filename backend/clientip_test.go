@@ -0,0 +1,40 @@
+package main
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	cfg.HTTP.TrustedProxies = []string{"10.0.0.0/8", "192.0.2.7"}
+	initTrustedProxies()
+	t.Cleanup(func() { cfg.HTTP.TrustedProxies = nil; initTrustedProxies() })
+
+	tests := []struct {
+		name   string
+		remote string
+		xff    []string
+		want   string
+	}{
+		{"direct client", "203.0.113.5:1234", nil, "203.0.113.5"},
+		{"spoofed xff from untrusted peer", "203.0.113.5:1234", []string{"1.2.3.4"}, "203.0.113.5"},
+		{"trusted proxy", "10.0.0.2:1234", []string{"198.51.100.9"}, "198.51.100.9"},
+		{"client prepends fake hop", "10.0.0.2:1234", []string{"1.2.3.4, 198.51.100.9"}, "198.51.100.9"},
+		{"proxy chain", "10.0.0.2:1234", []string{"198.51.100.9, 192.0.2.7, 10.1.1.1"}, "198.51.100.9"},
+		{"multiple headers joined", "10.0.0.2:1234", []string{"1.2.3.4", "198.51.100.9, 10.1.1.1"}, "198.51.100.9"},
+		{"garbage hop stops walk", "10.0.0.2:1234", []string{"198.51.100.9, not-an-ip, 10.1.1.1"}, "10.1.1.1"},
+		{"all hops trusted", "10.0.0.2:1234", []string{"10.3.3.3"}, "10.3.3.3"},
+		{"trusted proxy without xff", "10.0.0.2:1234", nil, "10.0.0.2"},
+		{"mapped ipv6 remote", "[::ffff:10.0.0.2]:1234", []string{"198.51.100.9"}, "198.51.100.9"},
+		{"ipv6 client", "[2001:db8::1]:1234", []string{"198.51.100.9"}, "2001:db8::1"},
+		{"unparsable remote", "pipe", nil, "pipe"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = tt.remote
+			for _, v := range tt.xff { r.Header.Add("X-Forwarded-For", v) }
+			if got := clientIP(r); got != tt.want { t.Errorf("clientIP() = %q, want %q", got, tt.want) }
+		})
+	}
+}
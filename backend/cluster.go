@@ -0,0 +1,180 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// [노드 ID] 페이로드(sender_pod), 메트릭, 관리 API에 호스트 이름 대신 쓰는 고정 식별자
+// cluster.node_id로 정하거나, 비우면 처음 뜰 때 만들어 cluster.node_id_file에 두고 재사용
+// 호스트 이름은 내부 로그(pod 필드)에만 남김
+
+// 노드 ID 형식 (NATS KV 키로도 쓰므로 제한)
+var validNodeID = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// 멤버십 레지스트리 KV 버킷
+const membersBucket = "gotalk_members"
+
+var (
+	nodeID     string
+	instanceID = randomHex(4) // 프로세스마다 새로 (같은 노드 ID의 재시작 구분, 브로커 컨슈머 이름)
+	members    MemberRegistry
+
+	nodeInfo = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "gotalk_node_info",
+		Help: "Always 1; labels identify this node.",
+	}, []string{"node_id"})
+)
+
+// 레지스트리에 올라가는 노드 정보 (하트비트마다 갱신)
+type NodeInfo struct {
+	NodeID    string    `json:"node_id"`
+	Instance  string    `json:"instance"`
+	StartedAt time.Time `json:"started_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type MemberRegistry interface {
+	Put(n NodeInfo) error
+	Get(id string) (NodeInfo, bool)
+	List() ([]NodeInfo, error)
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// 설정 → 파일 → 새로 만들어 파일에 저장 순으로 노드 ID를 정함
+func initNodeID() {
+	nodeID = cfg.Cluster.NodeID
+	if nodeID == "" { nodeID = loadNodeIDFile(cfg.Cluster.NodeIDFile) }
+	nodeInfo.WithLabelValues(nodeID).Set(1)
+}
+
+func loadNodeIDFile(path string) string {
+	if data, err := os.ReadFile(path); err == nil {
+		if id := strings.TrimSpace(string(data)); validNodeID.MatchString(id) { return id }
+		slog.Warn("ignoring invalid node id file", "path", path)
+	}
+	id := "node-" + randomHex(6)
+	err := os.MkdirAll(filepath.Dir(path), 0o755)
+	if err == nil { err = os.WriteFile(path, []byte(id+"\n"), 0o644) }
+	if err != nil { slog.Warn("node id not persisted, it will change on restart", "path", path, "err", err) }
+	return id
+}
+
+// 브로커 컨슈머/수신함 이름: 같은 노드 ID로 다시 떠도 이전 프로세스의 컨슈머를 이어받지 않도록 인스턴스를 붙임
+func consumerName() string {
+	return invalidConsumerChars.ReplaceAllString(nodeID+"-"+instanceID, "_")
+}
+
+// NATS JetStream이면 KV 버킷, 아니면 이 노드만 아는 로컬 레지스트리
+func initMembership() {
+	members = &localMembers{}
+	if nb, ok := broker.(*natsBroker); ok && nb.js != nil {
+		kv, err := nb.membersKV()
+		if err == nil {
+			members = kvMembers{kv: kv}
+		} else {
+			slog.Warn("membership registry unavailable, using local registry", "err", err)
+		}
+	}
+
+	self := NodeInfo{NodeID: nodeID, Instance: instanceID, StartedAt: startedAt}
+	if prev, ok := members.Get(nodeID); ok && prev.Instance != instanceID && time.Since(prev.UpdatedAt) < time.Duration(cfg.Cluster.NodeTTL) {
+		slog.Warn("node id already registered by another live instance", "node_id", nodeID, "instance", prev.Instance)
+	}
+	heartbeatMember(self)
+	publishClusterHeartbeat()
+	go func() {
+		for range time.Tick(time.Duration(cfg.Cluster.HeartbeatInterval)) {
+			heartbeatMember(self)
+			publishClusterHeartbeat()
+		}
+	}()
+	slog.Info("cluster node registered", "node_id", nodeID, "instance", instanceID)
+}
+
+func heartbeatMember(n NodeInfo) {
+	n.UpdatedAt = time.Now()
+	if err := members.Put(n); err != nil { slog.Warn("membership heartbeat failed", "err", err) }
+}
+
+// TTL이 지난 항목은 버킷이 지우지만 로컬 레지스트리용으로 한 번 더 거름
+func liveMembers() []NodeInfo {
+	list, err := members.List()
+	if err != nil { slog.Warn("membership list failed", "err", err) }
+	live := []NodeInfo{}
+	for _, n := range list {
+		if time.Since(n.UpdatedAt) < time.Duration(cfg.Cluster.NodeTTL) { live = append(live, n) }
+	}
+	sort.Slice(live, func(i, j int) bool { return live[i].NodeID < live[j].NodeID })
+	return live
+}
+
+type kvMembers struct{ kv nats.KeyValue }
+
+func (m kvMembers) Put(n NodeInfo) error {
+	data, _ := json.Marshal(n)
+	_, err := m.kv.Put(n.NodeID, data)
+	return err
+}
+
+func (m kvMembers) Get(id string) (NodeInfo, bool) {
+	var n NodeInfo
+	e, err := m.kv.Get(id)
+	if err != nil { return n, false }
+	return n, json.Unmarshal(e.Value(), &n) == nil
+}
+
+func (m kvMembers) List() ([]NodeInfo, error) {
+	keys, err := m.kv.Keys()
+	if errors.Is(err, nats.ErrNoKeysFound) { return nil, nil }
+	if err != nil { return nil, err }
+	var list []NodeInfo
+	for _, k := range keys {
+		if n, ok := m.Get(k); ok { list = append(list, n) }
+	}
+	return list, nil
+}
+
+type localMembers struct {
+	mu   sync.Mutex
+	self NodeInfo
+}
+
+func (m *localMembers) Put(n NodeInfo) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.self = n
+	return nil
+}
+
+func (m *localMembers) Get(id string) (NodeInfo, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.self, id == m.self.NodeID && m.self.NodeID != ""
+}
+
+func (m *localMembers) List() ([]NodeInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.self.NodeID == "" { return nil, nil }
+	return []NodeInfo{m.self}, nil
+}
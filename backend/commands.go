@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// [슬래시 명령] "/"로 시작하는 글은 저장/방송 전에 등록된 명령으로 보냄
+// 명령은 본문을 바꿔 그대로 올리거나(/me, /shrug), 보낸 사람에게만 답하고 끝낼 수 있음(/help)
+// 새 명령은 Command를 구현해 init에서 registerCommand로 등록
+// "//"로 시작하면 명령이 아니라 "/"로 시작하는 일반 글 (예: "//usr/bin" → "/usr/bin")
+type Command interface {
+	Name() string  // 앞의 "/" 없이 (예: "me")
+	Usage() string // /help에 보일 사용법
+	Run(call CommandCall) (CommandResult, error)
+}
+
+// 명령 호출 정보 (sendHandler가 권한/방/답글 확인을 끝낸 뒤)
+type CommandCall struct {
+	Ctx      context.Context
+	Nick     string
+	Color    string
+	RoomID   *int
+	ParentID *int
+	Args     string // 명령 이름 뒤 나머지 (앞뒤 공백 제거)
+}
+
+// Content가 있으면 그 내용으로 평소처럼 올리고, 없으면 Reply만 보낸 사람에게 돌려줌
+type CommandResult struct {
+	Content string `json:"-"`
+	Reply   string `json:"reply,omitempty"`
+}
+
+var commands = map[string]Command{}
+
+var commandsRun = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "gotalk_commands_total",
+	Help: "Slash commands run by /send, by command name.",
+}, []string{"command"})
+
+func registerCommand(c Command) { commands[c.Name()] = c }
+
+// 함수 하나로 된 간단한 명령
+type simpleCommand struct {
+	name, usage string
+	run         func(call CommandCall) (CommandResult, error)
+}
+
+func (c simpleCommand) Name() string                                { return c.name }
+func (c simpleCommand) Usage() string                               { return c.usage }
+func (c simpleCommand) Run(call CommandCall) (CommandResult, error) { return c.run(call) }
+
+func init() {
+	registerCommand(simpleCommand{"me", "/me <action> - describe what you are doing", func(call CommandCall) (CommandResult, error) {
+		if call.Args == "" { return CommandResult{}, fieldError{"msg", "usage: /me <action>"} }
+		return CommandResult{Content: "* " + call.Nick + " " + call.Args}, nil
+	}})
+	registerCommand(simpleCommand{"shrug", "/shrug [message] - append ¯\\_(ツ)_/¯", func(call CommandCall) (CommandResult, error) {
+		return CommandResult{Content: strings.TrimSpace(call.Args + ` ¯\_(ツ)_/¯`)}, nil
+	}})
+	registerCommand(simpleCommand{"help", "/help - list commands", func(call CommandCall) (CommandResult, error) {
+		names := make([]string, 0, len(commands))
+		for name := range commands {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		var b strings.Builder
+		for _, name := range names {
+			fmt.Fprintln(&b, commands[name].Usage())
+		}
+		return CommandResult{Reply: strings.TrimSpace(b.String())}, nil
+	}})
+}
+
+// 명령이면 실행 결과와 true, 일반 글이면 false ("//" 이스케이프는 앞의 "/" 하나를 떼어 Content로)
+func dispatchCommand(call CommandCall, content string) (CommandResult, bool, error) {
+	if !strings.HasPrefix(content, "/") { return CommandResult{}, false, nil }
+	if strings.HasPrefix(content, "//") { return CommandResult{Content: content[1:]}, true, nil }
+	name, args, _ := strings.Cut(content[1:], " ")
+	c, ok := commands[strings.ToLower(name)]
+	if !ok { return CommandResult{}, true, fieldError{"msg", "unknown command /" + name + " (try /help)"} }
+	call.Args = strings.TrimSpace(args)
+	res, err := c.Run(call)
+	commandsRun.WithLabelValues(c.Name()).Inc()
+	return res, true, err
+}
@@ -0,0 +1,859 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// [설정] 우선순위: 기본값 < 설정 파일(YAML/JSON) < 환경변수 < CLI 플래그
+type Config struct {
+	Port              string   `yaml:"port" json:"port"`
+	KeepaliveInterval Duration `yaml:"keepalive_interval" json:"keepalive_interval"`
+	HistoryLimit      int      `yaml:"history_limit" json:"history_limit"`
+	BroadcastBuffer   int      `yaml:"broadcast_buffer" json:"broadcast_buffer"`
+	ClientBuffer      int      `yaml:"client_buffer" json:"client_buffer"`
+	MaxMessageLength  int      `yaml:"max_message_length" json:"max_message_length"` // 메시지 최대 글자 수
+
+	Log struct {
+		Level  string `yaml:"level" json:"level"`   // debug, info, warn, error
+		Format string `yaml:"format" json:"format"` // text 또는 json
+		Access struct {
+			Enabled    bool            `yaml:"enabled" json:"enabled"`
+			SampleRate float64         `yaml:"sample_rate" json:"sample_rate"` // 0-1, 5xx는 항상 기록
+			Redact     []string        `yaml:"redact" json:"redact"`           // 값을 가릴 쿼리/경로 변수 이름
+			Routes     map[string]bool `yaml:"routes" json:"routes"`           // 라우트 패턴 또는 경로 → 기록 여부
+		} `yaml:"access" json:"access"`
+	} `yaml:"log" json:"log"`
+
+	// /history 첫 페이지 방별 캐시
+	HistoryCache struct {
+		TTL       Duration `yaml:"ttl" json:"ttl"`
+		WarmRooms int      `yaml:"warm_rooms" json:"warm_rooms"` // 기동 시 미리 채울 활발한 방 수
+	} `yaml:"history_cache" json:"history_cache"`
+
+	Grouping struct {
+		Window Duration `yaml:"window" json:"window"` // 같은 사람이 이 간격 안에 보내면 한 묶음
+	} `yaml:"grouping" json:"grouping"`
+
+	// SSE 연결별 전송 예산 (넘으면 묶음 전달로 낮춤)
+	Stream struct {
+		BytesPerSec   int      `yaml:"bytes_per_sec" json:"bytes_per_sec"`
+		BatchInterval Duration `yaml:"batch_interval" json:"batch_interval"`
+		PingInterval  Duration `yaml:"ping_interval" json:"ping_interval"` // RTT 측정용 ping 주기
+		MaxRTT        Duration `yaml:"max_rtt" json:"max_rtt"`             // 넘으면 묶음 전달로 낮춤
+		DeadAfter     int      `yaml:"dead_after" json:"dead_after"`       // 이 횟수의 ping 주기 동안 쓰기가 안 끝나면 연결 회수
+		ReplayMax     int      `yaml:"replay_max" json:"replay_max"`       // 재접속 때 DB에서 다시 보낼 최대 메시지 수 (넘으면 resync)
+
+		SlowClientPolicy string `yaml:"slow_client_policy" json:"slow_client_policy"` // drop(기본), drop_oldest, disconnect 또는 grow
+		MaxClientBuffer  int    `yaml:"max_client_buffer" json:"max_client_buffer"`   // grow: 연결별로 더 쌓을 수 있는 메시지 수
+	} `yaml:"stream" json:"stream"`
+
+	DB struct {
+		Driver   string `yaml:"driver" json:"driver"` // postgres(기본), sqlite 또는 mysql (단일 노드, 로비 채팅만)
+		DSN      string `yaml:"dsn" json:"dsn"`       // sqlite 파일/mysql DSN, 비우면 host/user/password/name으로 구성
+		Host     string `yaml:"host" json:"host"`
+		User     string `yaml:"user" json:"user"`
+		Password string `yaml:"password" json:"password"`
+		Name     string `yaml:"name" json:"name"`
+	} `yaml:"db" json:"db"`
+
+	NATS struct {
+		URL          string   `yaml:"url" json:"url"`
+		StreamMaxAge Duration `yaml:"stream_max_age" json:"stream_max_age"`
+	} `yaml:"nats" json:"nats"`
+
+	// 노드 식별과 멤버십 레지스트리 (cluster.go)
+	Cluster struct {
+		NodeID            string   `yaml:"node_id" json:"node_id"`           // 비우면 node_id_file에 만들어 두고 재사용
+		NodeIDFile        string   `yaml:"node_id_file" json:"node_id_file"`
+		HeartbeatInterval Duration `yaml:"heartbeat_interval" json:"heartbeat_interval"`
+		NodeTTL           Duration `yaml:"node_ttl" json:"node_ttl"` // 이 시간 동안 하트비트가 없으면 레지스트리에서 빠짐
+	} `yaml:"cluster" json:"cluster"`
+
+	Broker struct {
+		Backend      string   `yaml:"backend" json:"backend"` // nats(기본), redis 또는 kafka
+		RedisURL     string   `yaml:"redis_url" json:"redis_url"`
+		KafkaBrokers []string `yaml:"kafka_brokers" json:"kafka_brokers"`
+	} `yaml:"broker" json:"broker"`
+
+	HTTP struct {
+		Timeout          Duration            `yaml:"timeout" json:"timeout"`
+		SLA              Duration            `yaml:"sla" json:"sla"`
+		EndpointTimeouts map[string]Duration `yaml:"endpoint_timeouts" json:"endpoint_timeouts"`
+		EndpointSLAs     map[string]Duration `yaml:"endpoint_slas" json:"endpoint_slas"`
+		// 이 주소(CIDR)에서 온 연결만 X-Forwarded-For를 믿음 (clientip.go), 비우면 항상 연결 주소
+		TrustedProxies []string `yaml:"trusted_proxies" json:"trusted_proxies"`
+		// 프론트엔드를 다른 출처에서 띄울 때 (비우면 같은 출처만)
+		CORS struct {
+			AllowedOrigins   []string `yaml:"allowed_origins" json:"allowed_origins"` // 정확한 출처 또는 "*"
+			AllowedMethods   []string `yaml:"allowed_methods" json:"allowed_methods"`
+			AllowedHeaders   []string `yaml:"allowed_headers" json:"allowed_headers"`
+			AllowCredentials bool     `yaml:"allow_credentials" json:"allow_credentials"`
+			MaxAge           Duration `yaml:"max_age" json:"max_age"` // 프리플라이트 캐시 시간
+		} `yaml:"cors" json:"cors"`
+	} `yaml:"http" json:"http"`
+
+	// 직접 HTTPS 서비스 (인그레스 없이), 비우면 평문 HTTP
+	TLS struct {
+		CertFile         string   `yaml:"cert_file" json:"cert_file"`
+		KeyFile          string   `yaml:"key_file" json:"key_file"`
+		AutocertDomains  []string `yaml:"autocert_domains" json:"autocert_domains"` // Let's Encrypt 자동 발급 도메인
+		AutocertEmail    string   `yaml:"autocert_email" json:"autocert_email"`
+		AutocertCacheDir string   `yaml:"autocert_cache_dir" json:"autocert_cache_dir"`
+		RedirectPort     string   `yaml:"redirect_port" json:"redirect_port"` // HTTP → HTTPS 리다이렉트 리스너 (비우면 끔)
+	} `yaml:"tls" json:"tls"`
+
+	// 비동기 작업(푸시 전송 등) 재시도, 끝내 실패하면 dead_letters로
+	DeadLetter struct {
+		MaxAttempts int      `yaml:"max_attempts" json:"max_attempts"`
+		Backoff     Duration `yaml:"backoff" json:"backoff"` // 첫 재시도 대기, 이후 두 배씩
+	} `yaml:"dead_letter" json:"dead_letter"`
+
+	// 요청 한도 (파드별 고정 창)
+	RateLimit struct {
+		API           RateQuota `yaml:"api" json:"api"`                       // IP별 API 호출
+		Messages      RateQuota `yaml:"messages" json:"messages"`             // 닉네임별 메시지 전송 (로그인/가입한 사용자)
+		GuestMessages RateQuota `yaml:"guest_messages" json:"guest_messages"` // 게스트 닉네임별 메시지 전송
+		Flood         struct {
+			Limit    int      `yaml:"limit" json:"limit"`         // IP별 /send + /stream 요청 수 (0이면 끔)
+			Window   Duration `yaml:"window" json:"window"`
+			Block    Duration `yaml:"block" json:"block"`         // 첫 차단 시간, 다시 넘을 때마다 두 배
+			MaxBlock Duration `yaml:"max_block" json:"max_block"` // 차단 시간 상한
+		} `yaml:"flood" json:"flood"`
+	} `yaml:"rate_limit" json:"rate_limit"`
+
+	// 메시지 보관 기간 (0이면 영구 보관)
+	Retention struct {
+		MessageTTL Duration `yaml:"message_ttl" json:"message_ttl"`
+		BatchSize  int      `yaml:"batch_size" json:"batch_size"`
+		Archive    bool     `yaml:"archive" json:"archive"` // 지우기 전에 messages_archive로 옮김
+	} `yaml:"retention" json:"retention"`
+
+	Accounts struct {
+		GracePeriod Duration `yaml:"grace_period" json:"grace_period"`
+	} `yaml:"accounts" json:"accounts"`
+
+	// 인증/복구 메일 (smtp_addr를 비우면 보내지 않고 로그에 남김)
+	Email struct {
+		SMTPAddr        string   `yaml:"smtp_addr" json:"smtp_addr"` // host:port
+		Username        string   `yaml:"username" json:"username"`
+		Password        string   `yaml:"password" json:"password"`
+		From            string   `yaml:"from" json:"from"`
+		VerifyTTL       Duration `yaml:"verify_ttl" json:"verify_ttl"`             // 인증 링크 유효 기간
+		ResetTTL        Duration `yaml:"reset_ttl" json:"reset_ttl"`               // 비밀번호 재설정 링크 유효 기간
+		ResendInterval  Duration `yaml:"resend_interval" json:"resend_interval"`   // 인증 메일 다시 보내기 최소 간격
+		RequireVerified bool     `yaml:"require_verified" json:"require_verified"` // 방 수신 웹훅/큰 아바타에 인증 필요
+	} `yaml:"email" json:"email"`
+
+	Registration struct {
+		Mode        string   `yaml:"mode" json:"mode"`
+		InviteQuota int      `yaml:"invite_quota" json:"invite_quota"`
+		InviteTTL   Duration `yaml:"invite_ttl" json:"invite_ttl"`
+	} `yaml:"registration" json:"registration"`
+
+	Push struct {
+		VAPIDPublicKey  string `yaml:"vapid_public_key" json:"vapid_public_key"`
+		VAPIDPrivateKey string `yaml:"vapid_private_key" json:"vapid_private_key"`
+		VAPIDSubject    string `yaml:"vapid_subject" json:"vapid_subject"`
+	} `yaml:"push" json:"push"`
+
+	Pins struct {
+		MaxPerRoom       int      `yaml:"max_per_room" json:"max_per_room"`           // 넘으면 가장 오래된 고정이 내려감
+		ExpiredRetention Duration `yaml:"expired_retention" json:"expired_retention"` // 만료 고정 기록 보관 기간
+	} `yaml:"pins" json:"pins"`
+
+	// 방 잠금 (lockdown.go)
+	Lockdown struct {
+		DefaultDuration Duration `yaml:"default_duration" json:"default_duration"` // duration을 안 주면 이만큼 잠금
+		MaxDuration     Duration `yaml:"max_duration" json:"max_duration"`
+	} `yaml:"lockdown" json:"lockdown"`
+
+	// 운영진 호출 당번과 처리 기한 (escalation.go)
+	Escalation struct {
+		Rotation      [][]string `yaml:"rotation" json:"rotation"`             // 교대 순서, 항목마다 그 교대의 운영진 닉네임 (비우면 사이트 운영진 전원)
+		RotationStart time.Time  `yaml:"rotation_start" json:"rotation_start"` // 첫 교대가 시작한 시각
+		ShiftLength   Duration   `yaml:"shift_length" json:"shift_length"`
+		AckSLA        Duration   `yaml:"ack_sla" json:"ack_sla"`         // 이 안에 누군가 맡아야 함
+		ResolveSLA    Duration   `yaml:"resolve_sla" json:"resolve_sla"` // 이 안에 해결해야 함
+	} `yaml:"escalation" json:"escalation"`
+
+	// 신뢰 등급 (trust.go)
+	Trust struct {
+		Enabled         bool     `yaml:"enabled" json:"enabled"`
+		BasicAge        Duration `yaml:"basic_age" json:"basic_age"`               // basic이 되는 최소 계정 나이
+		BasicMessages   int      `yaml:"basic_messages" json:"basic_messages"`     // basic이 되는 최소 보낸 메시지 수
+		RegularAge      Duration `yaml:"regular_age" json:"regular_age"`
+		RegularMessages int      `yaml:"regular_messages" json:"regular_messages"`
+		Links           string   `yaml:"links" json:"links"`             // 본문에 링크를 쓸 수 있는 최소 등급
+		Uploads         string   `yaml:"uploads" json:"uploads"`         // 아바타를 올릴 수 있는 최소 등급
+		MentionAll      string   `yaml:"mention_all" json:"mention_all"` // @all/@here를 쓸 수 있는 최소 등급
+	} `yaml:"trust" json:"trust"`
+
+	// 방 일정 (calendar.go)
+	Calendar struct {
+		ReminderBefore Duration `yaml:"reminder_before" json:"reminder_before"` // 시작 얼마 전에 알릴지 (0이면 알리지 않음)
+	} `yaml:"calendar" json:"calendar"`
+
+	// 방 언어별 자동 번역 중계 (translate.go)
+	Translation struct {
+		Provider   string `yaml:"provider" json:"provider"`       // none(기본) 또는 libretranslate
+		URL        string `yaml:"url" json:"url"`                 // LibreTranslate 호환 서버 (https://translate.example.com)
+		APIKey     string `yaml:"api_key" json:"api_key"`
+		MaxTargets int    `yaml:"max_targets" json:"max_targets"` // 메시지 하나를 번역할 최대 언어 수 (API 비용 상한)
+		MaxLength  int    `yaml:"max_length" json:"max_length"`   // 이보다 긴 메시지는 번역하지 않음 (글자 수)
+	} `yaml:"translation" json:"translation"`
+
+	// 링크 미리보기 (새 글의 주소에서 OpenGraph를 읽어 붙임, Postgres 전용)
+	Unfurl struct {
+		Enabled  bool     `yaml:"enabled" json:"enabled"`
+		Timeout  Duration `yaml:"timeout" json:"timeout"`     // 주소 하나를 가져오는 시간 제한
+		MaxBytes int      `yaml:"max_bytes" json:"max_bytes"` // 페이지에서 읽을 최대 바이트 (<head>만 보면 됨)
+		MaxLinks int      `yaml:"max_links" json:"max_links"` // 메시지 하나에서 미리보기를 만들 최대 주소 수
+	} `yaml:"unfurl" json:"unfurl"`
+
+	// 합성 프로브: 카나리 메시지를 저장→브로커→연결 전달까지 흘려 지연을 잼 (Postgres 전용)
+	Probe struct {
+		Enabled    bool     `yaml:"enabled" json:"enabled"`
+		Interval   Duration `yaml:"interval" json:"interval"`
+		Timeout    Duration `yaml:"timeout" json:"timeout"`         // 이 안에 전달되지 않으면 실패
+		AlertAfter int      `yaml:"alert_after" json:"alert_after"` // 연속 실패가 이 횟수에 이르면 에러 리포터로 알림
+	} `yaml:"probe" json:"probe"`
+
+	// 메시지 백업과 복원 검증 (backup.go, Postgres 전용, 백업은 오브젝트 저장소에 씀)
+	Backup struct {
+		Enabled        bool     `yaml:"enabled" json:"enabled"`
+		Interval       Duration `yaml:"interval" json:"interval"`               // 전체 메시지 백업 주기
+		VerifyInterval Duration `yaml:"verify_interval" json:"verify_interval"` // 최신 백업 복원 검증 주기
+		ScratchDir     string   `yaml:"scratch_dir" json:"scratch_dir"`         // 검증용 임시 SQLite 파일 위치 (비우면 OS 임시 디렉터리)
+	} `yaml:"backup" json:"backup"`
+
+	// 방 내보내기, 아바타 등 오브젝트 저장 위치
+	Storage struct {
+		Backend string `yaml:"backend" json:"backend"` // fs(기본) 또는 s3
+		Dir     string `yaml:"dir" json:"dir"`         // fs: 로컬 디렉터리 (여러 파드면 공유 볼륨)
+		S3      struct {
+			Endpoint  string `yaml:"endpoint" json:"endpoint"` // 예: https://s3.ap-northeast-2.amazonaws.com, http://minio:9000
+			Region    string `yaml:"region" json:"region"`
+			Bucket    string `yaml:"bucket" json:"bucket"`
+			AccessKey string `yaml:"access_key" json:"access_key"`
+			SecretKey string `yaml:"secret_key" json:"secret_key"`
+		} `yaml:"s3" json:"s3"`
+	} `yaml:"storage" json:"storage"`
+
+	// 요청자 신원 확인 방식 (auth.go)
+	Auth struct {
+		Provider       string   `yaml:"provider" json:"provider"`               // none(기본), password, oidc, github 또는 header
+		SessionSecret  string   `yaml:"session_secret" json:"session_secret"`   // 세션 서명 키 (32자 이상, none이면 비워도 되지만 파드마다 임시 키)
+		SessionTTL     Duration `yaml:"session_ttl" json:"session_ttl"`
+		GuestTTL       Duration `yaml:"guest_ttl" json:"guest_ttl"`             // none에서 /login이 주는 게스트 토큰 유효 기간
+		AllowAnonymous bool     `yaml:"allow_anonymous" json:"allow_anonymous"` // false면 로그인한 신원 없이는 API를 못 씀 (닉네임만 쓰는 접속 차단)
+		OIDC           struct {
+			Issuer       string   `yaml:"issuer" json:"issuer"`
+			ClientID     string   `yaml:"client_id" json:"client_id"`
+			ClientSecret string   `yaml:"client_secret" json:"client_secret"`
+			RedirectURL  string   `yaml:"redirect_url" json:"redirect_url"` // https://chat.example.com/auth/oidc/callback
+			Scopes       []string `yaml:"scopes" json:"scopes"`
+			NickClaim    string   `yaml:"nick_claim" json:"nick_claim"` // 닉네임으로 쓸 ID 토큰 클레임
+		} `yaml:"oidc" json:"oidc"`
+		GitHub struct {
+			ClientID     string `yaml:"client_id" json:"client_id"`
+			ClientSecret string `yaml:"client_secret" json:"client_secret"`
+			RedirectURL  string `yaml:"redirect_url" json:"redirect_url"` // https://chat.example.com/auth/github/callback
+		} `yaml:"github" json:"github"`
+		Header struct {
+			Name           string   `yaml:"name" json:"name"`                       // 프록시가 넣는 사용자 헤더
+			TrustedProxies []string `yaml:"trusted_proxies" json:"trusted_proxies"` // 이 주소(CIDR)에서 온 요청의 헤더만 믿음
+			SecretHeader   string   `yaml:"secret_header" json:"secret_header"`
+			SharedSecret   string   `yaml:"shared_secret" json:"shared_secret"` // 프록시가 secret_header에 넣는 값
+		} `yaml:"header" json:"header"`
+	} `yaml:"auth" json:"auth"`
+
+	Admin struct {
+		Token string `yaml:"token" json:"token"` // /admin API Bearer 토큰 (비우면 관리자 API 꺼짐)
+	} `yaml:"admin" json:"admin"`
+
+	Presence struct {
+		Backend  string   `yaml:"backend" json:"backend"` // nats(기본, 브로커 요청/응답) 또는 redis
+		RedisURL string   `yaml:"redis_url" json:"redis_url"`
+		TTL      Duration `yaml:"ttl" json:"ttl"` // 하트비트가 끊긴 연결이 온라인으로 남는 최대 시간
+
+		Announce      bool     `yaml:"announce" json:"announce"`             // 첫 연결/마지막 연결 종료 때 "X joined / X left" 알림 방송
+		AnnounceDelay Duration `yaml:"announce_delay" json:"announce_delay"` // 마지막 연결이 끊긴 뒤 퇴장 알림까지 기다리는 시간 (새로고침은 알리지 않음)
+	} `yaml:"presence" json:"presence"`
+
+	// 방 단위 지표 라벨 (roomtier.go)
+	Metrics struct {
+		RoomTopN        int      `yaml:"room_top_n" json:"room_top_n"`               // 방 ID로 따로 라벨을 달 활발한 방 수 (0이면 모두 other)
+		RoomTierRefresh Duration `yaml:"room_tier_refresh" json:"room_tier_refresh"` // 상위 방 목록을 다시 읽는 주기
+	} `yaml:"metrics" json:"metrics"`
+
+	Tracing struct {
+		OTLPEndpoint string  `yaml:"otlp_endpoint" json:"otlp_endpoint"` // 비우면 트레이싱 끔
+		SampleRatio  float64 `yaml:"sample_ratio" json:"sample_ratio"`
+	} `yaml:"tracing" json:"tracing"`
+
+	I18n struct {
+		DefaultLocale string `yaml:"default_locale" json:"default_locale"`
+	} `yaml:"i18n" json:"i18n"`
+
+	// 첫 로그인 환영 DM (배포/테넌트별로 문구와 링크를 다르게 설정)
+	Welcome struct {
+		Enabled    bool              `yaml:"enabled" json:"enabled"`
+		SystemNick string            `yaml:"system_nick" json:"system_nick"`
+		Links      []WelcomeLink     `yaml:"links" json:"links"`
+		Templates  map[string]string `yaml:"templates" json:"templates"` // 로케일 → text/template
+	} `yaml:"welcome" json:"welcome"`
+}
+
+var cfg Config
+
+// 설정 파일에서 "15s", "720h" 형태로 쓰는 기간 값
+type Duration time.Duration
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+func (d *Duration) UnmarshalText(b []byte) error {
+	v, err := time.ParseDuration(string(b))
+	if err != nil { return err }
+	*d = Duration(v)
+	return nil
+}
+
+func defaultConfig() Config {
+	var c Config
+	c.Port = "8080"
+	c.KeepaliveInterval = Duration(15 * time.Second)
+	c.HistoryLimit = 30
+	c.BroadcastBuffer = 100
+	c.ClientBuffer = 10
+	c.MaxMessageLength = 4000
+	c.HistoryCache.TTL = Duration(30 * time.Second)
+	c.HistoryCache.WarmRooms = 20
+	c.Grouping.Window = Duration(5 * time.Minute)
+	c.Stream.BytesPerSec = 64 * 1024
+	c.Stream.BatchInterval = Duration(time.Second)
+	c.Stream.PingInterval = Duration(15 * time.Second)
+	c.Stream.MaxRTT = Duration(2 * time.Second)
+	c.Stream.DeadAfter = 4
+	c.Stream.ReplayMax = 500
+	c.Stream.SlowClientPolicy = slowClientDrop
+	c.Stream.MaxClientBuffer = 200
+	c.Log.Level = "info"
+	c.Log.Format = "text"
+	c.Log.Access.Enabled = true
+	c.Log.Access.SampleRate = 1
+	c.Log.Access.Redact = []string{"nick", "to", "target", "token", "invite_code", "recovery_code", "conn"}
+	// 프로브/스크레이프/RTT 에코는 양만 많고 쓸모가 없어 기본으로 끔
+	c.Log.Access.Routes = map[string]bool{"GET /metrics": false, "GET /healthz": false, "GET /readyz": false, "POST /pong": false}
+	c.DB.Name = "cotalk"
+	c.DB.Driver = dbDriverPostgres
+	c.NATS.StreamMaxAge = Duration(24 * time.Hour)
+	c.Cluster.NodeIDFile = "./data/node-id"
+	c.Cluster.HeartbeatInterval = Duration(10 * time.Second)
+	c.Cluster.NodeTTL = Duration(30 * time.Second)
+	c.Broker.Backend = brokerBackendNATS
+	c.Broker.RedisURL = "redis://localhost:6379/0"
+	c.Broker.KafkaBrokers = []string{"localhost:9092"}
+	c.HTTP.Timeout = Duration(10 * time.Second)
+	c.HTTP.SLA = Duration(500 * time.Millisecond)
+	c.HTTP.CORS.AllowedMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE"}
+	c.HTTP.CORS.AllowedHeaders = []string{"Content-Type", "Accept", "Authorization", "Last-Event-ID"}
+	c.HTTP.CORS.MaxAge = Duration(10 * time.Minute)
+	c.TLS.AutocertCacheDir = "./data/autocert"
+	c.DeadLetter.MaxAttempts = 3
+	c.DeadLetter.Backoff = Duration(2 * time.Second)
+	c.RateLimit.API = RateQuota{Limit: 600, Window: Duration(time.Minute)}
+	c.RateLimit.Messages = RateQuota{Limit: 30, Window: Duration(time.Minute)}
+	c.RateLimit.GuestMessages = RateQuota{Limit: 10, Window: Duration(time.Minute)}
+	c.RateLimit.Flood.Limit = 120
+	c.RateLimit.Flood.Window = Duration(10 * time.Second)
+	c.RateLimit.Flood.Block = Duration(30 * time.Second)
+	c.RateLimit.Flood.MaxBlock = Duration(time.Hour)
+	c.Retention.BatchSize = 1000
+	c.Accounts.GracePeriod = Duration(30 * 24 * time.Hour)
+	c.Unfurl.Enabled = true
+	c.Unfurl.Timeout = Duration(5 * time.Second)
+	c.Unfurl.MaxBytes = 512 << 10
+	c.Unfurl.MaxLinks = 3
+	c.Probe.Interval = Duration(30 * time.Second)
+	c.Probe.Timeout = Duration(5 * time.Second)
+	c.Probe.AlertAfter = 3
+	c.Backup.Interval = Duration(24 * time.Hour)
+	c.Backup.VerifyInterval = Duration(24 * time.Hour)
+	c.Email.From = "gotalk@localhost"
+	c.Email.VerifyTTL = Duration(24 * time.Hour)
+	c.Email.ResetTTL = Duration(time.Hour)
+	c.Email.ResendInterval = Duration(time.Minute)
+	c.Registration.Mode = registrationOpen
+	c.Registration.InviteQuota = 5
+	c.Registration.InviteTTL = Duration(7 * 24 * time.Hour)
+	c.Push.VAPIDSubject = "mailto:admin@localhost"
+	c.Pins.MaxPerRoom = 10
+	c.Pins.ExpiredRetention = Duration(7 * 24 * time.Hour)
+	c.Lockdown.DefaultDuration = Duration(15 * time.Minute)
+	c.Lockdown.MaxDuration = Duration(24 * time.Hour)
+	c.Escalation.RotationStart = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC) // 월요일
+	c.Escalation.ShiftLength = Duration(7 * 24 * time.Hour)
+	c.Escalation.AckSLA = Duration(15 * time.Minute)
+	c.Escalation.ResolveSLA = Duration(24 * time.Hour)
+	c.Trust.Enabled = true
+	c.Trust.BasicAge = Duration(24 * time.Hour)
+	c.Trust.BasicMessages = 5
+	c.Trust.RegularAge = Duration(30 * 24 * time.Hour)
+	c.Trust.RegularMessages = 100
+	c.Trust.Links = trustBasic
+	c.Trust.Uploads = trustBasic
+	c.Trust.MentionAll = trustRegular
+	c.Calendar.ReminderBefore = Duration(15 * time.Minute)
+	c.Translation.Provider = translationProviderNone
+	c.Translation.MaxTargets = 5
+	c.Translation.MaxLength = 2000
+	c.Storage.Backend = storageBackendFS
+	c.Storage.Dir = "./data/objects"
+	c.Storage.S3.Region = "us-east-1"
+	c.Auth.Provider = authProviderNone
+	c.Auth.SessionTTL = Duration(30 * 24 * time.Hour)
+	c.Auth.GuestTTL = Duration(24 * time.Hour)
+	c.Auth.AllowAnonymous = true
+	c.Auth.OIDC.Scopes = []string{"openid", "profile"}
+	c.Auth.OIDC.NickClaim = "preferred_username"
+	c.Auth.Header.Name = "X-Auth-Request-User"
+	c.Auth.Header.SecretHeader = "X-Auth-Proxy-Secret"
+	c.Presence.Backend = presenceBackendNATS
+	c.Presence.RedisURL = "redis://localhost:6379/0"
+	c.Presence.TTL = Duration(45 * time.Second)
+	c.Presence.Announce = true
+	c.Presence.AnnounceDelay = Duration(10 * time.Second)
+	c.Metrics.RoomTopN = 20
+	c.Metrics.RoomTierRefresh = Duration(10 * time.Minute)
+	c.Tracing.SampleRatio = 0.1
+	c.I18n.DefaultLocale = "en"
+	c.Welcome.Enabled = true
+	c.Welcome.SystemNick = "cotalk"
+	return c
+}
+
+// 설정 로드: 파일 경로는 -config 플래그 또는 CONFIG_FILE 환경변수
+func loadConfig(args []string) (Config, error) {
+	c := defaultConfig()
+
+	path := os.Getenv("CONFIG_FILE")
+	for i, a := range args {
+		if v, ok := strings.CutPrefix(a, "-config="); ok {
+			path = v
+		} else if (a == "-config" || a == "--config") && i+1 < len(args) {
+			path = args[i+1]
+		} else if v, ok := strings.CutPrefix(a, "--config="); ok {
+			path = v
+		}
+	}
+	if path != "" {
+		if err := c.loadFile(path); err != nil { return c, err }
+	}
+	if err := c.loadEnv(); err != nil { return c, err }
+	if err := c.loadFlags(args); err != nil { return c, err }
+	return c, c.validate()
+}
+
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil { return fmt.Errorf("config file: %w", err) }
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.Unmarshal(data, c)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, c)
+	default:
+		return fmt.Errorf("config file: unsupported extension %q", filepath.Ext(path))
+	}
+	if err != nil { return fmt.Errorf("config file %s: %w", path, err) }
+	return nil
+}
+
+// 환경변수 덮어쓰기 (기존 배포에서 쓰던 이름 그대로 유지)
+func (c *Config) loadEnv() error {
+	strs := map[string]*string{
+		"PORT":                 &c.Port,
+		"DB_HOST":              &c.DB.Host,
+		"DB_USER":              &c.DB.User,
+		"DB_PASSWORD":          &c.DB.Password,
+		"DB_NAME":              &c.DB.Name,
+		"DB_DRIVER":            &c.DB.Driver,
+		"DB_DSN":               &c.DB.DSN,
+		"NATS_URL":             &c.NATS.URL,
+		"NODE_ID":              &c.Cluster.NodeID,
+		"NODE_ID_FILE":         &c.Cluster.NodeIDFile,
+		"REGISTRATION_MODE":    &c.Registration.Mode,
+		"VAPID_PUBLIC_KEY":     &c.Push.VAPIDPublicKey,
+		"VAPID_PRIVATE_KEY":    &c.Push.VAPIDPrivateKey,
+		"VAPID_SUBJECT":        &c.Push.VAPIDSubject,
+		"DEFAULT_LOCALE":       &c.I18n.DefaultLocale,
+		"LOG_LEVEL":            &c.Log.Level,
+		"LOG_FORMAT":           &c.Log.Format,
+		"STORAGE_DIR":          &c.Storage.Dir,
+		"STORAGE_BACKEND":      &c.Storage.Backend,
+		"S3_ENDPOINT":          &c.Storage.S3.Endpoint,
+		"S3_REGION":            &c.Storage.S3.Region,
+		"S3_BUCKET":            &c.Storage.S3.Bucket,
+		"S3_ACCESS_KEY":        &c.Storage.S3.AccessKey,
+		"S3_SECRET_KEY":        &c.Storage.S3.SecretKey,
+		"ADMIN_TOKEN":          &c.Admin.Token,
+		"PRESENCE_BACKEND":     &c.Presence.Backend,
+		"REDIS_URL":            &c.Presence.RedisURL,
+		"BROKER_BACKEND":       &c.Broker.Backend,
+		"BROKER_REDIS_URL":     &c.Broker.RedisURL,
+		"TLS_CERT_FILE":        &c.TLS.CertFile,
+		"TLS_KEY_FILE":         &c.TLS.KeyFile,
+		"AUTOCERT_EMAIL":       &c.TLS.AutocertEmail,
+		"AUTOCERT_CACHE":       &c.TLS.AutocertCacheDir,
+		"TLS_REDIRECT_PORT":    &c.TLS.RedirectPort,
+		"TRANSLATION_PROVIDER": &c.Translation.Provider,
+		"TRANSLATION_URL":      &c.Translation.URL,
+		"TRANSLATION_API_KEY":  &c.Translation.APIKey,
+		"AUTH_PROVIDER":        &c.Auth.Provider,
+		"AUTH_SESSION_SECRET":  &c.Auth.SessionSecret,
+		"AUTH_HEADER":          &c.Auth.Header.Name,
+		"AUTH_PROXY_SECRET":    &c.Auth.Header.SharedSecret,
+		"OIDC_ISSUER":          &c.Auth.OIDC.Issuer,
+		"OIDC_CLIENT_ID":       &c.Auth.OIDC.ClientID,
+		"OIDC_CLIENT_SECRET":   &c.Auth.OIDC.ClientSecret,
+		"OIDC_REDIRECT_URL":    &c.Auth.OIDC.RedirectURL,
+		"GITHUB_CLIENT_ID":     &c.Auth.GitHub.ClientID,
+		"GITHUB_CLIENT_SECRET": &c.Auth.GitHub.ClientSecret,
+		"GITHUB_REDIRECT_URL":  &c.Auth.GitHub.RedirectURL,
+		"SMTP_ADDR":            &c.Email.SMTPAddr,
+		"SMTP_PASSWORD":        &c.Email.Password,
+		// OpenTelemetry 표준 변수명
+		"OTEL_EXPORTER_OTLP_TRACES_ENDPOINT": &c.Tracing.OTLPEndpoint,
+	}
+	for name, p := range strs {
+		if v, ok := os.LookupEnv(name); ok && v != "" { *p = v }
+	}
+
+	ints := map[string]*int{
+		"HISTORY_LIMIT":             &c.HistoryLimit,
+		"BROADCAST_BUFFER":          &c.BroadcastBuffer,
+		"CLIENT_BUFFER":             &c.ClientBuffer,
+		"MAX_MESSAGE_LENGTH":        &c.MaxMessageLength,
+		"INVITE_QUOTA":              &c.Registration.InviteQuota,
+		"RATE_LIMIT_API":            &c.RateLimit.API.Limit,
+		"RATE_LIMIT_MESSAGES":       &c.RateLimit.Messages.Limit,
+		"RATE_LIMIT_GUEST_MESSAGES": &c.RateLimit.GuestMessages.Limit,
+		"RATE_LIMIT_FLOOD":          &c.RateLimit.Flood.Limit,
+	}
+	for name, p := range ints {
+		v := os.Getenv(name)
+		if v == "" { continue }
+		n, err := strconv.Atoi(v)
+		if err != nil { return fmt.Errorf("env %s: %w", name, err) }
+		*p = n
+	}
+
+	durations := map[string]*Duration{
+		"KEEPALIVE_INTERVAL":   &c.KeepaliveInterval,
+		"JS_MAX_AGE":           &c.NATS.StreamMaxAge,
+		"HTTP_TIMEOUT":         &c.HTTP.Timeout,
+		"HTTP_SLA":             &c.HTTP.SLA,
+		"ACCOUNT_GRACE_PERIOD": &c.Accounts.GracePeriod,
+		"MESSAGE_RETENTION":    &c.Retention.MessageTTL,
+		"AUTH_SESSION_TTL":     &c.Auth.SessionTTL,
+		"AUTH_GUEST_TTL":       &c.Auth.GuestTTL,
+		"INVITE_TTL":           &c.Registration.InviteTTL,
+		"GROUPING_WINDOW":      &c.Grouping.Window,
+		"CALENDAR_REMINDER":    &c.Calendar.ReminderBefore,
+	}
+	for name, p := range durations {
+		v := os.Getenv(name)
+		if v == "" { continue }
+		if err := p.UnmarshalText([]byte(v)); err != nil { return fmt.Errorf("env %s: %w", name, err) }
+	}
+
+	if v := os.Getenv("ACCESS_LOG_SAMPLE_RATE"); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil { return fmt.Errorf("env ACCESS_LOG_SAMPLE_RATE: %w", err) }
+		c.Log.Access.SampleRate = f
+	}
+	if v := os.Getenv("ACCESS_LOG"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil { return fmt.Errorf("env ACCESS_LOG: %w", err) }
+		c.Log.Access.Enabled = b
+	}
+	if v := os.Getenv("AUTH_ALLOW_ANONYMOUS"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil { return fmt.Errorf("env AUTH_ALLOW_ANONYMOUS: %w", err) }
+		c.Auth.AllowAnonymous = b
+	}
+
+	if v := os.Getenv("OTEL_TRACES_SAMPLER_ARG"); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil { return fmt.Errorf("env OTEL_TRACES_SAMPLER_ARG: %w", err) }
+		c.Tracing.SampleRatio = f
+	}
+
+	if v := os.Getenv("KAFKA_BROKERS"); v != "" {
+		c.Broker.KafkaBrokers = strings.Split(v, ",")
+	}
+
+	// 쉼표 구분 목록
+	lists := map[string]*[]string{
+		"AUTOCERT_DOMAINS":     &c.TLS.AutocertDomains,
+		"AUTH_TRUSTED_PROXIES": &c.Auth.Header.TrustedProxies,
+		"TRUSTED_PROXIES":      &c.HTTP.TrustedProxies,
+		"CORS_ALLOWED_ORIGINS": &c.HTTP.CORS.AllowedOrigins,
+		"CORS_ALLOWED_METHODS": &c.HTTP.CORS.AllowedMethods,
+		"CORS_ALLOWED_HEADERS": &c.HTTP.CORS.AllowedHeaders,
+	}
+	for name, p := range lists {
+		v := os.Getenv(name)
+		if v == "" { continue }
+		*p = nil
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item != "" { *p = append(*p, item) }
+		}
+	}
+	if v := os.Getenv("CORS_ALLOW_CREDENTIALS"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil { return fmt.Errorf("env CORS_ALLOW_CREDENTIALS: %w", err) }
+		c.HTTP.CORS.AllowCredentials = b
+	}
+
+	// "경로=기간,경로=기간" 형식 (예: "/history=5s,/send=2s")
+	maps := map[string]*map[string]Duration{
+		"ENDPOINT_TIMEOUTS": &c.HTTP.EndpointTimeouts,
+		"ENDPOINT_SLAS":     &c.HTTP.EndpointSLAs,
+	}
+	for name, p := range maps {
+		v := os.Getenv(name)
+		if v == "" { continue }
+		m, err := parseDurationMap(v)
+		if err != nil { return fmt.Errorf("env %s: %w", name, err) }
+		*p = m
+	}
+	return nil
+}
+
+func parseDurationMap(s string) (map[string]Duration, error) {
+	out := map[string]Duration{}
+	for _, pair := range strings.Split(s, ",") {
+		path, val, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok { return nil, fmt.Errorf("invalid entry %q", pair) }
+		var d Duration
+		if err := d.UnmarshalText([]byte(val)); err != nil { return nil, fmt.Errorf("%s: %w", path, err) }
+		out[path] = d
+	}
+	return out, nil
+}
+
+func (c *Config) loadFlags(args []string) error {
+	fs := flag.NewFlagSet("gotalk", flag.ContinueOnError)
+	fs.String("config", "", "path to YAML/JSON config file")
+	fs.StringVar(&c.Port, "port", c.Port, "HTTP listen port")
+	fs.DurationVar((*time.Duration)(&c.KeepaliveInterval), "keepalive", time.Duration(c.KeepaliveInterval), "SSE keepalive interval")
+	fs.IntVar(&c.HistoryLimit, "history-limit", c.HistoryLimit, "messages per /history page")
+	fs.IntVar(&c.BroadcastBuffer, "broadcast-buffer", c.BroadcastBuffer, "broadcast channel buffer size")
+	fs.IntVar(&c.ClientBuffer, "client-buffer", c.ClientBuffer, "per-client channel buffer size")
+	fs.StringVar(&c.DB.Driver, "db-driver", c.DB.Driver, "postgres, sqlite or mysql")
+	fs.StringVar(&c.NATS.URL, "nats-url", c.NATS.URL, "NATS server URL")
+	fs.StringVar(&c.Broker.Backend, "broker", c.Broker.Backend, "message broker: nats, redis or kafka")
+	fs.StringVar(&c.Log.Level, "log-level", c.Log.Level, "debug, info, warn or error")
+	fs.StringVar(&c.Log.Format, "log-format", c.Log.Format, "text or json")
+	fs.StringVar(&c.TLS.CertFile, "tls-cert", c.TLS.CertFile, "TLS certificate file (serve HTTPS)")
+	fs.StringVar(&c.TLS.KeyFile, "tls-key", c.TLS.KeyFile, "TLS private key file")
+	fs.StringVar(&c.Auth.Provider, "auth", c.Auth.Provider, "auth provider: none, password, oidc, github or header")
+	fs.StringVar(&c.Registration.Mode, "registration-mode", c.Registration.Mode, "open or invite")
+	return fs.Parse(args)
+}
+
+func (c *Config) validate() error {
+	var errs []string
+	if p, err := strconv.Atoi(c.Port); err != nil || p < 1 || p > 65535 {
+		errs = append(errs, fmt.Sprintf("port %q must be 1-65535", c.Port))
+	}
+	if c.KeepaliveInterval <= 0 { errs = append(errs, "keepalive_interval must be positive") }
+	if c.HistoryLimit < 1 || c.HistoryLimit > 1000 { errs = append(errs, "history_limit must be 1-1000") }
+	if c.BroadcastBuffer < 1 { errs = append(errs, "broadcast_buffer must be positive") }
+	if c.ClientBuffer < 1 { errs = append(errs, "client_buffer must be positive") }
+	// 글자당 4바이트여도 /send 본문 한도(64KB) 안에 들어가야 함
+	if c.MaxMessageLength < 1 || c.MaxMessageLength > 15000 { errs = append(errs, "max_message_length must be 1-15000") }
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 { errs = append(errs, "tracing.sample_ratio must be 0-1") }
+	if !validLogLevel(c.Log.Level) { errs = append(errs, fmt.Sprintf("log.level %q must be debug, info, warn or error", c.Log.Level)) }
+	if c.Log.Format != "text" && c.Log.Format != "json" { errs = append(errs, fmt.Sprintf("log.format %q must be text or json", c.Log.Format)) }
+	if c.Log.Access.SampleRate < 0 || c.Log.Access.SampleRate > 1 { errs = append(errs, "log.access.sample_rate must be 0-1") }
+	if c.HistoryCache.TTL <= 0 { errs = append(errs, "history_cache.ttl must be positive") }
+	if c.HistoryCache.WarmRooms < 0 { errs = append(errs, "history_cache.warm_rooms must not be negative") }
+	if c.Grouping.Window < 0 { errs = append(errs, "grouping.window must not be negative") }
+	if c.Stream.BytesPerSec < 1 { errs = append(errs, "stream.bytes_per_sec must be positive") }
+	if c.Stream.BatchInterval <= 0 { errs = append(errs, "stream.batch_interval must be positive") }
+	if c.Stream.PingInterval <= 0 { errs = append(errs, "stream.ping_interval must be positive") }
+	if c.Stream.MaxRTT <= 0 { errs = append(errs, "stream.max_rtt must be positive") }
+	if c.Stream.DeadAfter < 2 { errs = append(errs, "stream.dead_after must be at least 2") }
+	if c.Stream.ReplayMax < 1 { errs = append(errs, "stream.replay_max must be positive") }
+	switch c.Stream.SlowClientPolicy {
+	case slowClientDrop, slowClientDropOldest, slowClientDisconnect:
+	case slowClientGrow:
+		if c.Stream.MaxClientBuffer < 1 { errs = append(errs, "stream.max_client_buffer must be positive for grow") }
+	default:
+		errs = append(errs, fmt.Sprintf("stream.slow_client_policy %q must be drop, drop_oldest, disconnect or grow", c.Stream.SlowClientPolicy))
+	}
+	if c.DB.Name == "" { errs = append(errs, "db.name is required") }
+	if c.DB.Driver != dbDriverPostgres && c.DB.Driver != dbDriverSQLite && c.DB.Driver != dbDriverMySQL {
+		errs = append(errs, fmt.Sprintf("db.driver %q must be postgres, sqlite or mysql", c.DB.Driver))
+	}
+	if c.HTTP.Timeout <= 0 { errs = append(errs, "http.timeout must be positive") }
+	if len(c.TLS.AutocertDomains) > 0 && c.TLS.CertFile != "" { errs = append(errs, "tls.cert_file and tls.autocert_domains are mutually exclusive") }
+	if (c.TLS.CertFile == "") != (c.TLS.KeyFile == "") { errs = append(errs, "tls.cert_file and tls.key_file must be set together") }
+	if len(c.TLS.AutocertDomains) > 0 && c.TLS.AutocertCacheDir == "" { errs = append(errs, "tls.autocert_cache_dir is required for autocert") }
+	if p := c.TLS.RedirectPort; p != "" {
+		if n, err := strconv.Atoi(p); err != nil || n < 1 || n > 65535 || p == c.Port {
+			errs = append(errs, fmt.Sprintf("tls.redirect_port %q must be 1-65535 and differ from port", p))
+		}
+	}
+	switch c.Auth.Provider {
+	case authProviderNone:
+		if c.Auth.GuestTTL <= 0 { errs = append(errs, "auth.guest_ttl must be positive") }
+	case authProviderPassword, authProviderOIDC, authProviderGitHub:
+		if len(c.Auth.SessionSecret) < 32 { errs = append(errs, "auth.session_secret must be at least 32 characters for password/oidc/github") }
+		if c.Auth.SessionTTL <= 0 { errs = append(errs, "auth.session_ttl must be positive") }
+		if c.DB.Driver != dbDriverPostgres { errs = append(errs, "auth.provider password/oidc/github requires db.driver postgres") }
+		if c.Auth.Provider == authProviderOIDC && (c.Auth.OIDC.Issuer == "" || c.Auth.OIDC.ClientID == "" || c.Auth.OIDC.RedirectURL == "") {
+			errs = append(errs, "auth.oidc.issuer, client_id and redirect_url are required for oidc")
+		}
+		if c.Auth.Provider == authProviderGitHub && (c.Auth.GitHub.ClientID == "" || c.Auth.GitHub.ClientSecret == "" || c.Auth.GitHub.RedirectURL == "") {
+			errs = append(errs, "auth.github.client_id, client_secret and redirect_url are required for github")
+		}
+	case authProviderHeader:
+		if c.Auth.Header.Name == "" { errs = append(errs, "auth.header.name is required for header auth") }
+		if len(c.Auth.Header.TrustedProxies) == 0 && c.Auth.Header.SharedSecret == "" {
+			errs = append(errs, "auth.header.trusted_proxies or shared_secret is required for header auth")
+		}
+		if c.Auth.Header.SharedSecret != "" && (len(c.Auth.Header.SharedSecret) < 16 || c.Auth.Header.SecretHeader == "") {
+			errs = append(errs, "auth.header.shared_secret must be at least 16 characters with a secret_header")
+		}
+		if c.DB.Driver != dbDriverPostgres { errs = append(errs, "auth.provider header requires db.driver postgres") }
+		for _, p := range c.Auth.Header.TrustedProxies {
+			if _, err := parsePrefix(p); err != nil { errs = append(errs, "auth.header.trusted_proxies: "+err.Error()) }
+		}
+	default:
+		errs = append(errs, fmt.Sprintf("auth.provider %q must be none, password, oidc, github or header", c.Auth.Provider))
+	}
+	for _, p := range c.HTTP.TrustedProxies {
+		if _, err := parsePrefix(p); err != nil { errs = append(errs, "http.trusted_proxies: "+err.Error()) }
+	}
+	if c.Retention.MessageTTL < 0 { errs = append(errs, "retention.message_ttl must not be negative") }
+	if c.Probe.Enabled && (c.Probe.Timeout <= 0 || c.Probe.Interval <= c.Probe.Timeout || c.Probe.AlertAfter < 1) {
+		errs = append(errs, "probe.timeout must be positive and less than probe.interval, probe.alert_after at least 1")
+	}
+	if c.Backup.Enabled && (c.Backup.Interval <= 0 || c.Backup.VerifyInterval <= 0) {
+		errs = append(errs, "backup.interval and backup.verify_interval must be positive")
+	}
+	if c.Metrics.RoomTopN < 0 || (c.Metrics.RoomTopN > 0 && c.Metrics.RoomTierRefresh <= 0) {
+		errs = append(errs, "metrics.room_top_n must not be negative, metrics.room_tier_refresh must be positive")
+	}
+	if c.Unfurl.Enabled && (c.Unfurl.Timeout <= 0 || c.Unfurl.MaxBytes < 1 || c.Unfurl.MaxLinks < 1) {
+		errs = append(errs, "unfurl.timeout, max_bytes and max_links must be positive")
+	}
+	if c.Email.VerifyTTL <= 0 { errs = append(errs, "email.verify_ttl must be positive") }
+	if c.Email.ResetTTL <= 0 { errs = append(errs, "email.reset_ttl must be positive") }
+	if c.Email.ResendInterval < 0 { errs = append(errs, "email.resend_interval must not be negative") }
+	if c.Retention.BatchSize < 1 { errs = append(errs, "retention.batch_size must be positive") }
+	if c.DeadLetter.MaxAttempts < 1 { errs = append(errs, "dead_letter.max_attempts must be positive") }
+	if c.DeadLetter.Backoff < 0 { errs = append(errs, "dead_letter.backoff must not be negative") }
+	for name, q := range map[string]RateQuota{"api": c.RateLimit.API, "messages": c.RateLimit.Messages, "guest_messages": c.RateLimit.GuestMessages} {
+		if q.Limit < 0 { errs = append(errs, fmt.Sprintf("rate_limit.%s.limit must not be negative", name)) }
+		if q.Limit > 0 && q.Window <= 0 { errs = append(errs, fmt.Sprintf("rate_limit.%s.window must be positive", name)) }
+	}
+	if f := c.RateLimit.Flood; f.Limit > 0 && (f.Window <= 0 || f.Block <= 0 || f.MaxBlock < f.Block) {
+		errs = append(errs, "rate_limit.flood needs a positive window and block, and max_block >= block")
+	}
+	for _, o := range c.HTTP.CORS.AllowedOrigins {
+		if o == "*" {
+			if c.HTTP.CORS.AllowCredentials { errs = append(errs, `http.cors.allowed_origins "*" cannot be used with allow_credentials`) }
+			continue
+		}
+		if !strings.HasPrefix(o, "http://") && !strings.HasPrefix(o, "https://") || strings.HasSuffix(o, "/") {
+			errs = append(errs, fmt.Sprintf("http.cors.allowed_origins %q must be scheme://host[:port]", o))
+		}
+	}
+	if c.HTTP.CORS.MaxAge < 0 { errs = append(errs, "http.cors.max_age must not be negative") }
+	if c.Registration.Mode != registrationOpen && c.Registration.Mode != registrationInvite {
+		errs = append(errs, fmt.Sprintf("registration.mode %q must be open or invite", c.Registration.Mode))
+	}
+	if _, ok := catalog[c.I18n.DefaultLocale]; !ok {
+		errs = append(errs, fmt.Sprintf("i18n.default_locale %q is not supported", c.I18n.DefaultLocale))
+	}
+	if c.Welcome.SystemNick == "" { errs = append(errs, "welcome.system_nick is required") }
+	switch c.Storage.Backend {
+	case storageBackendFS:
+		if c.Storage.Dir == "" { errs = append(errs, "storage.dir is required") }
+	case storageBackendS3:
+		s3 := c.Storage.S3
+		if s3.Endpoint == "" || s3.Region == "" || s3.Bucket == "" || s3.AccessKey == "" || s3.SecretKey == "" {
+			errs = append(errs, "storage.s3 endpoint, region, bucket, access_key and secret_key are required for s3")
+		}
+	default:
+		errs = append(errs, fmt.Sprintf("storage.backend %q must be fs or s3", c.Storage.Backend))
+	}
+	if c.Cluster.NodeID != "" && !validNodeID.MatchString(c.Cluster.NodeID) {
+		errs = append(errs, fmt.Sprintf("cluster.node_id %q must be 1-64 letters, digits, - or _", c.Cluster.NodeID))
+	}
+	if c.Cluster.NodeID == "" && c.Cluster.NodeIDFile == "" { errs = append(errs, "cluster.node_id_file is required when node_id is empty") }
+	if c.Cluster.HeartbeatInterval <= 0 || c.Cluster.NodeTTL <= c.Cluster.HeartbeatInterval {
+		errs = append(errs, "cluster.heartbeat_interval must be positive and less than cluster.node_ttl")
+	}
+	switch c.Broker.Backend {
+	case brokerBackendNATS, brokerBackendRedis:
+	case brokerBackendKafka:
+		if len(c.Broker.KafkaBrokers) == 0 { errs = append(errs, "broker.kafka_brokers is required for kafka") }
+	default:
+		errs = append(errs, fmt.Sprintf("broker.backend %q must be nats, redis or kafka", c.Broker.Backend))
+	}
+	if c.Presence.Backend != presenceBackendNATS && c.Presence.Backend != presenceBackendRedis {
+		errs = append(errs, fmt.Sprintf("presence.backend %q must be nats or redis", c.Presence.Backend))
+	}
+	if c.Presence.TTL < Duration(3*time.Second) { errs = append(errs, "presence.ttl must be at least 3s") }
+	if c.Presence.AnnounceDelay < 0 { errs = append(errs, "presence.announce_delay must not be negative") }
+	if c.Pins.MaxPerRoom < 1 { errs = append(errs, "pins.max_per_room must be positive") }
+	if c.Escalation.ShiftLength < Duration(time.Hour) { errs = append(errs, "escalation.shift_length must be at least 1h") }
+	if c.Escalation.AckSLA <= 0 || c.Escalation.ResolveSLA < c.Escalation.AckSLA {
+		errs = append(errs, "escalation.ack_sla must be positive and no longer than escalation.resolve_sla")
+	}
+	for _, t := range []struct{ name, level string }{{"links", c.Trust.Links}, {"uploads", c.Trust.Uploads}, {"mention_all", c.Trust.MentionAll}} {
+		if !validTrustLevel(t.level) { errs = append(errs, fmt.Sprintf("trust.%s %q must be new, basic or regular", t.name, t.level)) }
+	}
+	if c.Trust.BasicAge < 0 || c.Trust.BasicMessages < 0 || c.Trust.RegularAge < c.Trust.BasicAge || c.Trust.RegularMessages < c.Trust.BasicMessages {
+		errs = append(errs, "trust thresholds must not be negative and regular must not be lower than basic")
+	}
+	if c.Lockdown.DefaultDuration < Duration(time.Minute) || c.Lockdown.MaxDuration < c.Lockdown.DefaultDuration {
+		errs = append(errs, "lockdown.default_duration must be at least 1m and no longer than lockdown.max_duration")
+	}
+	if c.Calendar.ReminderBefore < 0 { errs = append(errs, "calendar.reminder_before must not be negative") }
+	switch c.Translation.Provider {
+	case translationProviderNone:
+	case translationProviderLibre:
+		if c.Translation.URL == "" { errs = append(errs, "translation.url is required for libretranslate") }
+		if c.DB.Driver != dbDriverPostgres { errs = append(errs, "translation requires db.driver postgres") }
+		if c.Translation.MaxTargets < 1 { errs = append(errs, "translation.max_targets must be positive") }
+		if c.Translation.MaxLength < 1 { errs = append(errs, "translation.max_length must be positive") }
+	default:
+		errs = append(errs, fmt.Sprintf("translation.provider %q must be none or libretranslate", c.Translation.Provider))
+	}
+	for locale, src := range c.Welcome.Templates {
+		if _, err := template.New("welcome").Funcs(template.FuncMap{"join": strings.Join}).Parse(src); err != nil {
+			errs = append(errs, fmt.Sprintf("welcome.templates[%s]: %v", locale, err))
+		}
+	}
+	if c.Registration.InviteQuota < 0 { errs = append(errs, "registration.invite_quota must not be negative") }
+	if len(errs) > 0 { return fmt.Errorf("invalid config: %s", strings.Join(errs, "; ")) }
+	return nil
+}
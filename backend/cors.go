@@ -0,0 +1,55 @@
+package main
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// [CORS] 정적 프론트엔드를 API 파드와 다른 출처(CDN 등)에서 서비스할 때 교차 출처 요청 허용
+// http.cors.allowed_origins가 비어 있으면 꺼짐 (같은 출처만)
+// "*"는 모든 출처 허용, 단 allow_credentials와 함께 쓸 수 없음 (validate에서 막음)
+// 먹스 앞에 둬야 프리플라이트(OPTIONS)가 라우트 405로 떨어지지 않음
+func corsMiddleware(next http.Handler) http.Handler {
+	c := cfg.HTTP.CORS
+	if len(c.AllowedOrigins) == 0 { return next }
+	anyOrigin := slices.Contains(c.AllowedOrigins, "*")
+	methods := strings.Join(c.AllowedMethods, ", ")
+	headers := strings.Join(c.AllowedHeaders, ", ")
+	maxAge := strconv.Itoa(int(time.Duration(c.MaxAge).Seconds()))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" { next.ServeHTTP(w, r); return }
+		h := w.Header()
+		h.Add("Vary", "Origin")
+		if !anyOrigin && !slices.Contains(c.AllowedOrigins, origin) {
+			// 허용 안 된 출처: 헤더 없이 그대로 처리 (브라우저가 응답을 막음)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if anyOrigin && !c.AllowCredentials {
+			h.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			h.Set("Access-Control-Allow-Origin", origin)
+		}
+		if c.AllowCredentials { h.Set("Access-Control-Allow-Credentials", "true") }
+
+		// 프리플라이트는 여기서 끝냄
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			h.Add("Vary", "Access-Control-Request-Method")
+			h.Add("Vary", "Access-Control-Request-Headers")
+			h.Set("Access-Control-Allow-Methods", methods)
+			h.Set("Access-Control-Allow-Headers", headers)
+			if c.MaxAge > 0 { h.Set("Access-Control-Max-Age", maxAge) }
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		// 봇/프론트엔드가 요청 한도 헤더를 읽을 수 있게
+		h.Set("Access-Control-Expose-Headers", "X-RateLimit-Limit, X-RateLimit-Remaining, X-RateLimit-Reset, Retry-After")
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// [DLQ] 비동기 작업이 재시도(dead_letter.max_attempts)까지 실패하면 오류와 함께 dead_letters에 남김
+// 로그 한 줄로 사라지던 실패를 관리자 API에서 확인하고 재시도하거나 폐기할 수 있음
+// 종류마다 재처리 함수를 deadLetterRetriers에 등록 (없는 종류는 조회/폐기만 가능)
+
+const (
+	deadLetterPush       = "push"
+	deadLetterRoomExport = "room_export"
+)
+
+var errDeadLetterPayload = errors.New("invalid dead letter payload")
+
+type DeadLetter struct {
+	ID           int             `json:"id"`
+	Kind         string          `json:"kind"`
+	Payload      json.RawMessage `json:"payload"`
+	Error        string          `json:"error"`
+	Attempts     int             `json:"attempts"`
+	CreatedAt    time.Time       `json:"created_at"`
+	LastFailedAt time.Time       `json:"last_failed_at"`
+}
+
+// 종류별 재처리 (성공하면 DLQ에서 지움)
+var deadLetterRetriers = map[string]func(ctx context.Context, payload json.RawMessage) error{
+	deadLetterPush:       retryPushDeadLetter,
+	deadLetterRoomExport: retryRoomExportDeadLetter,
+}
+
+var deadLettersTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "gotalk_dead_letters_total",
+	Help: "Async jobs moved to the dead-letter queue after exhausting retries, by kind.",
+}, []string{"kind"})
+
+func init() {
+	// 클러스터 공용 테이블이라 모든 파드가 같은 값을 냄 (대시보드에서는 max)
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "gotalk_dead_letters_depth",
+		Help: "Entries waiting in the dead-letter queue.",
+	}, func() float64 {
+		if db == nil || !fullFeatureStore() { return 0 }
+		var n int
+		db.QueryRow("SELECT COUNT(*) FROM dead_letters").Scan(&n)
+		return float64(n)
+	})
+}
+
+// fn을 지수 백오프로 재시도하고, 끝내 실패하면 payload를 DLQ에 남긴 뒤 마지막 오류 반환
+func withRetries(ctx context.Context, kind string, payload any, fn func() error) error {
+	backoff := time.Duration(cfg.DeadLetter.Backoff)
+	var err error
+	for attempt := 1; attempt <= cfg.DeadLetter.MaxAttempts; attempt++ {
+		if err = fn(); err == nil { return nil }
+		if attempt < cfg.DeadLetter.MaxAttempts {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+	}
+	recordDeadLetter(ctx, kind, payload, err, cfg.DeadLetter.MaxAttempts)
+	return err
+}
+
+func recordDeadLetter(ctx context.Context, kind string, payload any, cause error, attempts int) {
+	deadLettersTotal.WithLabelValues(kind).Inc()
+	data, _ := json.Marshal(payload)
+	_, err := db.ExecContext(ctx, "INSERT INTO dead_letters (kind, payload, error, attempts) VALUES ($1, $2, $3, $4)",
+		kind, data, cause.Error(), attempts)
+	if err != nil {
+		slog.ErrorContext(ctx, "dead letter insert failed", "kind", kind, "cause", cause, "err", err)
+		return
+	}
+	slog.WarnContext(ctx, "moved to dead-letter queue", "kind", kind, "attempts", attempts, "err", cause)
+}
+
+// [DLQ 목록] GET /admin/dead-letters?kind=&before_id=&limit=
+func adminDeadLettersHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	limit := queryInt(r, "limit", 50)
+	if limit < 1 || limit > 500 { limit = 50 }
+	beforeID := queryInt(r, "before_id", 0)
+
+	query := "SELECT id, kind, payload, error, attempts, created_at, last_failed_at FROM dead_letters WHERE ($1 = '' OR kind = $1) AND ($2 = 0 OR id < $2) ORDER BY id DESC LIMIT $3"
+	rows, err := db.Query(query, q.Get("kind"), beforeID, limit)
+	if err != nil { respondError(w, r, 500, err); return }
+	defer rows.Close()
+	list := []DeadLetter{}
+	for rows.Next() {
+		var d DeadLetter
+		rows.Scan(&d.ID, &d.Kind, &d.Payload, &d.Error, &d.Attempts, &d.CreatedAt, &d.LastFailedAt)
+		list = append(list, d)
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+// [DLQ 재시도] POST /admin/dead-letters/{id}/retry - 성공하면 삭제, 실패하면 오류/횟수 갱신 후 502
+func adminRetryDeadLetterHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil { respondError(w, r, http.StatusBadRequest, errors.New("invalid id")); return }
+	var kind string
+	var payload json.RawMessage
+	err = db.QueryRow("SELECT kind, payload FROM dead_letters WHERE id = $1", id).Scan(&kind, &payload)
+	if err == sql.ErrNoRows { respondError(w, r, http.StatusNotFound, errors.New("dead letter not found")); return }
+	if err != nil { respondError(w, r, 500, err); return }
+	retry, ok := deadLetterRetriers[kind]
+	if !ok { respondError(w, r, http.StatusBadRequest, errors.New("no retry handler for kind "+kind)); return }
+
+	if err := retry(r.Context(), payload); err != nil {
+		db.Exec("UPDATE dead_letters SET attempts = attempts + 1, error = $2, last_failed_at = CURRENT_TIMESTAMP WHERE id = $1", id, err.Error())
+		respondError(w, r, http.StatusBadGateway, errors.New("retry failed: "+err.Error()))
+		return
+	}
+	db.Exec("DELETE FROM dead_letters WHERE id = $1", id)
+	slog.InfoContext(r.Context(), "dead letter retried", "id", id, "kind", kind)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// [DLQ 폐기] DELETE /admin/dead-letters/{id}
+func adminDiscardDeadLetterHandler(w http.ResponseWriter, r *http.Request) {
+	res, err := db.Exec("DELETE FROM dead_letters WHERE id = $1", r.PathValue("id"))
+	if err != nil { respondError(w, r, 500, err); return }
+	if n, _ := res.RowsAffected(); n == 0 { respondError(w, r, http.StatusNotFound, errors.New("dead letter not found")); return }
+	w.WriteHeader(http.StatusNoContent)
+}
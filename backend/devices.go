@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// [기기 목록] 로그인할 때마다 기기를 devices에 기록하고 세션/푸시 구독을 그 기기에 연결
+// 기기는 오래 사는 gotalk_device 쿠키(앱은 X-Device-ID 헤더)로 구분하고, 쿠키를 지운 브라우저는
+// User-Agent + Accept-Language 해시(fingerprint)로 같은 기기인지 다시 찾음 (추적용이 아니라 새 기기 알림 오탐을 줄이는 정도)
+// 처음 보는 기기로 로그인하면 시스템 DM과 (인증된) 메일로 알림, 기존 기기가 하나도 없으면 첫 로그인이라 알리지 않음
+// GET /auth/devices로 보고 DELETE /auth/devices/{id}로 그 기기의 세션과 푸시 구독을 한꺼번에 끊음
+const (
+	deviceCookie = "gotalk_device"
+	deviceHeader = "X-Device-ID"
+	deviceMaxID  = 64
+	deviceTTL    = 400 * 24 * time.Hour // 기기 쿠키 수명 (브라우저 상한)
+)
+
+type Device struct {
+	ID           string    `json:"id"`
+	Platform     string    `json:"platform"`
+	UserAgent    string    `json:"user_agent"`
+	Push         bool      `json:"push"` // 이 기기로 푸시 구독이 있는지
+	CreatedAt    time.Time `json:"created_at"`
+	LastActiveAt time.Time `json:"last_active_at"`
+	Current      bool      `json:"current"` // 이 요청을 보낸 기기
+}
+
+// 요청에 실린 기기 ID (없거나 형식이 이상하면 "")
+func deviceIDFrom(r *http.Request) string {
+	id := r.Header.Get(deviceHeader)
+	if id == "" {
+		if c, err := r.Cookie(deviceCookie); err == nil { id = c.Value }
+	}
+	if len(id) > deviceMaxID || strings.ContainsAny(id, " ;,\"") { return "" }
+	return id
+}
+
+func deviceFingerprint(r *http.Request) string {
+	sum := sha256.Sum256([]byte(r.UserAgent() + "|" + r.Header.Get("Accept-Language")))
+	return hex.EncodeToString(sum[:8])
+}
+
+// User-Agent에서 대강의 플랫폼 이름
+func devicePlatform(ua string) string {
+	ua = strings.ToLower(ua)
+	switch {
+	case strings.Contains(ua, "iphone"), strings.Contains(ua, "ipad"):
+		return "ios"
+	case strings.Contains(ua, "android"):
+		return "android"
+	case strings.Contains(ua, "windows"):
+		return "windows"
+	case strings.Contains(ua, "mac os"):
+		return "macos"
+	case strings.Contains(ua, "linux"):
+		return "linux"
+	}
+	return "other"
+}
+
+// 로그인한 기기를 기록하고 기기 쿠키를 심음 (처음 보는 기기면 isNew)
+func registerDevice(w http.ResponseWriter, r *http.Request, nick string) (id string, isNew bool, err error) {
+	ctx := r.Context()
+	fp := deviceFingerprint(r)
+	if id = deviceIDFrom(r); id == "" {
+		db.QueryRowContext(ctx, "SELECT id FROM devices WHERE nickname = $1 AND fingerprint = $2 ORDER BY last_active_at DESC LIMIT 1", nick, fp).Scan(&id)
+	}
+	if id == "" { id = newEmbedToken() }
+	ua := r.UserAgent()
+	if len(ua) > sessionMaxAgent { ua = ua[:sessionMaxAgent] }
+	err = db.QueryRowContext(ctx, `
+		INSERT INTO devices (id, nickname, fingerprint, platform, user_agent) VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (nickname, id) DO UPDATE SET fingerprint = $3, platform = $4, user_agent = $5, last_active_at = CURRENT_TIMESTAMP
+		RETURNING xmax = 0`, id, nick, fp, devicePlatform(ua), ua).Scan(&isNew)
+	if err != nil { return "", false, err }
+	http.SetCookie(w, &http.Cookie{
+		Name: deviceCookie, Value: id, Path: "/", Expires: time.Now().Add(deviceTTL),
+		HttpOnly: true, SameSite: http.SameSiteLaxMode, Secure: strings.HasPrefix(baseURL(r), "https"),
+	})
+	return id, isNew, nil
+}
+
+// 세션을 쓸 때마다 그 기기의 마지막 사용 시각도 갱신 (lookupSession의 갱신 주기를 따름)
+func touchSessionDevice(ctx context.Context, sessionID string) {
+	db.ExecContext(ctx, `UPDATE devices d SET last_active_at = CURRENT_TIMESTAMP FROM sessions s
+		WHERE s.id = $1 AND d.nickname = s.nickname AND d.id = s.device_id`, sessionID)
+}
+
+// 새 기기 로그인 알림 (다른 기기가 이미 있는 계정만)
+func notifyNewDevice(nick, deviceID, platform, ip string) {
+	var others int
+	db.QueryRow("SELECT COUNT(*) FROM devices WHERE nickname = $1 AND id <> $2", nick, deviceID).Scan(&others)
+	if others == 0 { return }
+	when := time.Now().UTC().Format("2006-01-02 15:04 UTC")
+	content := fmt.Sprintf("🔐 New sign-in to your account from a %s device (IP %s) at %s. If this wasn't you, remove the device under Settings → Devices and change your password.", platform, ip, when)
+	if _, err := sendDirectMessage(systemNick(), nick, content); err != nil { slog.Warn("new device notice failed", "nick", nick, "err", err) }
+
+	var to string
+	if db.QueryRow("SELECT email FROM users WHERE nickname = $1 AND email_verified_at IS NOT NULL", nick).Scan(&to) != nil { return }
+	body := fmt.Sprintf("Hi %s,\n\nYour GoTalk account was just signed in from a new %s device (IP %s) at %s.\n\nIf this was you, there is nothing to do. If not, remove the device from your device list and reset your password.\n",
+		nick, platform, ip, when)
+	if err := sendMail(to, "New sign-in to your GoTalk account", body); err != nil { slog.Warn("new device email failed", "nick", nick, "err", err) }
+}
+
+// [내 기기 목록] GET /auth/devices - 최근 사용 순
+func listDevicesHandler(w http.ResponseWriter, r *http.Request) {
+	nick, _, ok := requireSession(w, r)
+	if !ok { return }
+	current := deviceIDFrom(r)
+	rows, err := db.QueryContext(r.Context(), `
+		SELECT d.id, d.platform, d.user_agent, d.created_at, d.last_active_at,
+		       EXISTS(SELECT 1 FROM push_subscriptions p WHERE p.nickname = d.nickname AND p.device_id = d.id)
+		FROM devices d WHERE d.nickname = $1 ORDER BY d.last_active_at DESC`, nick)
+	if err != nil { respondError(w, r, 500, err); return }
+	defer rows.Close()
+	list := []Device{}
+	for rows.Next() {
+		var d Device
+		rows.Scan(&d.ID, &d.Platform, &d.UserAgent, &d.CreatedAt, &d.LastActiveAt, &d.Push)
+		d.Current = d.ID == current
+		list = append(list, d)
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+// [기기 삭제] DELETE /auth/devices/{id} - 그 기기의 세션을 폐기하고 푸시 구독을 지움
+func removeDeviceHandler(w http.ResponseWriter, r *http.Request) {
+	nick, _, ok := requireSession(w, r)
+	if !ok { return }
+	id := r.PathValue("id")
+	ctx := r.Context()
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil { respondError(w, r, 500, err); return }
+	defer tx.Rollback()
+	res, err := tx.ExecContext(ctx, "DELETE FROM devices WHERE nickname = $1 AND id = $2", nick, id)
+	if err != nil { respondError(w, r, 500, err); return }
+	if n, _ := res.RowsAffected(); n == 0 { respondError(w, r, http.StatusNotFound, errors.New("device not found")); return }
+	if _, err := tx.ExecContext(ctx, "UPDATE sessions SET revoked_at = CURRENT_TIMESTAMP WHERE nickname = $1 AND device_id = $2 AND revoked_at IS NULL", nick, id); err != nil {
+		respondError(w, r, 500, err)
+		return
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM push_subscriptions WHERE nickname = $1 AND device_id = $2", nick, id); err != nil { respondError(w, r, 500, err); return }
+	if err := tx.Commit(); err != nil { respondError(w, r, 500, err); return }
+	if id == deviceIDFrom(r) { clearAuthCookies(w) }
+	slog.InfoContext(ctx, "device removed", "nick", nick)
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"time"
+)
+
+// 특정 사용자 대상 이벤트(멘션, DM) 전용 브로커 주제
+// 모든 파드가 구독하고, 대상 닉네임의 연결을 가진 파드만 전달함
+const subjectDirect = "chat.direct"
+
+type DirectEvent struct {
+	Type    string          `json:"type"` // "mention" | "dm" | "kick" | "reminder" | "pending" | "pending_resolved"
+	Target  string          `json:"target"`
+	Message Message         `json:"message"`
+	Pending *PendingMessage `json:"pending,omitempty"` // 승인 대기 이벤트 (moderation.go)
+}
+
+func publishDirect(ev DirectEvent) {
+	data, _ := json.Marshal(ev)
+	broker.Publish(context.Background(), subjectDirect, data)
+}
+
+// [개별 이벤트 수신] 이 파드에 붙어 있는 대상 사용자 스트림에만 전달
+func handleDirectEvent(m BrokerMsg) {
+	var ev DirectEvent
+	if err := json.Unmarshal(m.Data, &ev); err != nil { return }
+	out := newOutbound(m.Ctx, string(m.Data))
+	out.Disconnect = ev.Type == "kick"
+	out.Sender = ev.Message.SenderNick
+	sendToNick(ev.Target, out)
+}
+
+// 특정 닉네임의 모든 연결에 전달 (가득 찬 채널은 느린 클라이언트 정책대로)
+func sendToNick(nick string, data outbound) int {
+	mutex.Lock()
+	defer mutex.Unlock()
+	count := 0
+	for clientChan, c := range clients {
+		if c.nick != nick { continue }
+		if c.offer(clientChan, data) { count++ }
+	}
+	return count
+}
+
+// [DM 전송] 받는 사람 전용 메시지로 저장하고 대상 스트림/푸시로 알림
+// DM은 recipient_nick이 채워진 messages 행이며 /history에는 나오지 않음
+func sendDirectMessage(from, to, content string) (Message, error) {
+	msg := Message{Type: messageTypeText, Content: content, SenderPod: nodeID, SenderNick: from, Time: time.Now().Format("15:04:05")}
+	err := db.QueryRow(`
+		INSERT INTO messages (content, sender_pod, sender_nick, recipient_nick) VALUES ($1, $2, $3, $4)
+		RETURNING id`, content, nodeID, from, to).Scan(&msg.ID)
+	if err != nil { return msg, err }
+	db.QueryRow("SELECT COALESCE(color_code, '#ffffff') FROM users WHERE nickname = $1", from).Scan(&msg.SenderColor)
+	msg.RecipientNick = to
+
+	publishDirect(DirectEvent{Type: "dm", Target: to, Message: msg})
+	go notifyOffline(to, PushPayload{Title: "Message from " + from, Body: content, MessageID: msg.ID})
+	return msg, nil
+}
+
+// [DM 목록] GET /dms?nick=&before_id=&limit= - 내가 받거나 보낸 DM (최신순)
+func dmsHandler(w http.ResponseWriter, r *http.Request) {
+	nick := r.URL.Query().Get("nick")
+	if nick == "" { respondError(w, r, http.StatusBadRequest, errors.New("nick required")); return }
+	limit := queryInt(r, "limit", 30)
+	if limit < 1 || limit > 100 { limit = 30 }
+	beforeID := queryInt(r, "before_id", 0)
+	if beforeID <= 0 { beforeID = math.MaxInt32 }
+
+	rows, err := db.Query(`
+		SELECT m.id, m.content, m.sender_pod, m.sender_nick, m.recipient_nick,
+			COALESCE(u.color_code, '#ffffff'), to_char(m.created_at, 'HH24:MI:SS')
+		FROM messages m
+		LEFT JOIN users u ON m.sender_nick = u.nickname
+		WHERE (m.recipient_nick = $1 OR (m.sender_nick = $1 AND m.recipient_nick IS NOT NULL)) AND m.id < $2
+		ORDER BY m.id DESC LIMIT $3`, nick, beforeID, limit)
+	if err != nil { respondError(w, r, 500, err); return }
+	defer rows.Close()
+
+	dms := []Message{}
+	for rows.Next() {
+		m := Message{Type: messageTypeText}
+		rows.Scan(&m.ID, &m.Content, &m.SenderPod, &m.SenderNick, &m.RecipientNick, &m.SenderColor, &m.Time)
+		dms = append(dms, m)
+	}
+	writeJSON(w, http.StatusOK, dms)
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/mail"
+	"strings"
+	"time"
+)
+
+// [이메일 인증] 가입 계정(게스트 제외)이 POST /account/email로 주소를 등록하면 인증 링크(GET /verify?token=)를 메일로 보냄
+// 토큰은 해시만 저장하고 email.verify_ttl 뒤 만료, 다시 보내기는 email.resend_interval 간격과 하루 emailMaxPerDay번으로 제한
+// email.require_verified를 켜면 인증해야 방 수신 웹훅을 만들고 큰 아바타(avatarUnverifiedMaxBytes 초과)를 올릴 수 있음
+// 만료된 토큰은 스케줄러가 정리 (Postgres 전용)
+const (
+	emailMaxLen    = 254
+	emailMaxPerDay = 5
+)
+
+var errEmailUnverified = errors.New("verify your email address first")
+
+type EmailStatus struct {
+	Email    string `json:"email,omitempty"`
+	Verified bool   `json:"verified"`
+}
+
+// 가입 계정 본인 확인 (게스트/익명이면 401)
+func requireAccount(w http.ResponseWriter, r *http.Request) (string, bool) {
+	if !fullFeatureStore() { respondError(w, r, http.StatusNotImplemented, errors.New("accounts require the postgres store")); return "", false }
+	nick, ok := identityFrom(r.Context())
+	if !ok || isGuest(r.Context()) { respondError(w, r, http.StatusUnauthorized, errLoginRequired); return "", false }
+	return nick, true
+}
+
+func emailVerified(nick string) bool {
+	if !fullFeatureStore() { return false }
+	var verified bool
+	db.QueryRow("SELECT email_verified_at IS NOT NULL FROM users WHERE nickname = $1", nick).Scan(&verified)
+	return verified
+}
+
+// email.require_verified일 때 인증 안 된 계정이면 errEmailUnverified
+func requireVerifiedEmail(nick string) error {
+	if !cfg.Email.RequireVerified || emailVerified(nick) { return nil }
+	return errEmailUnverified
+}
+
+// [내 이메일] GET /account/email
+func emailStatusHandler(w http.ResponseWriter, r *http.Request) {
+	nick, ok := requireAccount(w, r)
+	if !ok { return }
+	var st EmailStatus
+	var email sql.NullString
+	if err := db.QueryRowContext(r.Context(), "SELECT email, email_verified_at IS NOT NULL FROM users WHERE nickname = $1", nick).Scan(&email, &st.Verified); err != nil && err != sql.ErrNoRows {
+		respondError(w, r, 500, err)
+		return
+	}
+	st.Email = email.String
+	writeJSON(w, http.StatusOK, st)
+}
+
+// [이메일 등록] POST /account/email (email) - 바꾸면 인증이 풀리고 새 주소로 링크를 보냄
+func setEmailHandler(w http.ResponseWriter, r *http.Request) {
+	nick, ok := requireAccount(w, r)
+	if !ok { return }
+	email := strings.TrimSpace(r.FormValue("email"))
+	if a, err := mail.ParseAddress(email); err != nil || a.Address != email || len(email) > emailMaxLen {
+		respondError(w, r, http.StatusBadRequest, fieldError{"email", "must be a plain address like name@example.com"})
+		return
+	}
+	if err := checkEmailResend(r.Context(), nick); err != nil { respondError(w, r, http.StatusTooManyRequests, err); return }
+	_, err := db.ExecContext(r.Context(), `UPDATE users SET email = $2,
+		email_verified_at = CASE WHEN LOWER(email) = LOWER($2) THEN email_verified_at END WHERE nickname = $1`, nick, email)
+	if err != nil { respondError(w, r, 500, err); return }
+	if emailVerified(nick) { writeJSON(w, http.StatusOK, EmailStatus{Email: email, Verified: true}); return }
+	if err := sendVerification(r, nick, email); err != nil { respondError(w, r, http.StatusBadGateway, err); return }
+	writeJSON(w, http.StatusAccepted, EmailStatus{Email: email})
+}
+
+// [인증 메일 다시 보내기] POST /account/email/resend
+func resendVerificationHandler(w http.ResponseWriter, r *http.Request) {
+	nick, ok := requireAccount(w, r)
+	if !ok { return }
+	var email sql.NullString
+	var verified bool
+	db.QueryRowContext(r.Context(), "SELECT email, email_verified_at IS NOT NULL FROM users WHERE nickname = $1", nick).Scan(&email, &verified)
+	if !email.Valid { respondError(w, r, http.StatusBadRequest, fieldError{"email", "set an email address first"}); return }
+	if verified { respondError(w, r, http.StatusConflict, errors.New("email already verified")); return }
+	if err := checkEmailResend(r.Context(), nick); err != nil { respondError(w, r, http.StatusTooManyRequests, err); return }
+	if err := sendVerification(r, nick, email.String); err != nil { respondError(w, r, http.StatusBadGateway, err); return }
+	writeJSON(w, http.StatusAccepted, EmailStatus{Email: email.String})
+}
+
+// 다시 보내기 간격과 하루 횟수 확인
+func checkEmailResend(ctx context.Context, nick string) error {
+	var last sql.NullTime
+	var today int
+	db.QueryRowContext(ctx, `SELECT MAX(created_at), COUNT(*) FILTER (WHERE created_at > CURRENT_TIMESTAMP - INTERVAL '1 day')
+		FROM email_verifications WHERE nickname = $1`, nick).Scan(&last, &today)
+	if last.Valid && time.Since(last.Time) < time.Duration(cfg.Email.ResendInterval) { return errors.New("verification email sent recently; try again later") }
+	if today >= emailMaxPerDay { return errors.New("too many verification emails today") }
+	return nil
+}
+
+func sendVerification(r *http.Request, nick, email string) error {
+	token := newEmbedToken()
+	_, err := db.ExecContext(r.Context(), "INSERT INTO email_verifications (token_hash, nickname, email, expires_at) VALUES ($1, $2, $3, $4)",
+		hashRecoveryCode(token), nick, email, time.Now().Add(time.Duration(cfg.Email.VerifyTTL)))
+	if err != nil { return err }
+	link := baseURL(r) + "/verify?token=" + token
+	body := fmt.Sprintf("Hi %s,\n\nConfirm your email address for GoTalk by opening this link:\n\n%s\n\nThe link expires in %s. If you did not ask for this, ignore this email.\n",
+		nick, link, time.Duration(cfg.Email.VerifyTTL))
+	if err := sendMail(email, "Confirm your GoTalk email", body); err != nil { return fmt.Errorf("send verification email: %w", err) }
+	slog.InfoContext(r.Context(), "verification email sent", "nick", nick)
+	return nil
+}
+
+// [이메일 인증] GET /verify?token= - 메일의 링크, 브라우저면 인증 후 첫 화면으로
+func verifyEmailHandler(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if token == "" { respondError(w, r, http.StatusBadRequest, fieldError{"token", "required"}); return }
+	var nick, email string
+	err := db.QueryRowContext(r.Context(), `SELECT nickname, email FROM email_verifications
+		WHERE token_hash = $1 AND expires_at > CURRENT_TIMESTAMP`, hashRecoveryCode(token)).Scan(&nick, &email)
+	if err == sql.ErrNoRows { respondError(w, r, http.StatusGone, errors.New("invalid or expired verification link")); return }
+	if err != nil { respondError(w, r, 500, err); return }
+
+	var taken bool
+	db.QueryRowContext(r.Context(), "SELECT EXISTS (SELECT 1 FROM users WHERE LOWER(email) = LOWER($1) AND email_verified_at IS NOT NULL AND nickname <> $2)", email, nick).Scan(&taken)
+	if taken { respondError(w, r, http.StatusConflict, errors.New("this email is already verified by another account")); return }
+	// 그 사이 주소를 바꿨으면 옛 링크는 무효
+	res, err := db.ExecContext(r.Context(), `UPDATE users SET email_verified_at = CURRENT_TIMESTAMP
+		WHERE nickname = $1 AND email = $2 AND email_verified_at IS NULL`, nick, email)
+	if err != nil { respondError(w, r, 500, err); return }
+	if n, _ := res.RowsAffected(); n == 0 { respondError(w, r, http.StatusGone, errors.New("invalid or expired verification link")); return }
+	// 쓴 토큰과 같은 주소로 보낸 나머지 링크는 지움 (횟수 제한용 기록은 다른 주소 것만 남음)
+	db.ExecContext(r.Context(), "DELETE FROM email_verifications WHERE nickname = $1 AND email = $2", nick, email)
+	slog.InfoContext(r.Context(), "email verified", "nick", nick)
+
+	if wantsJSONError(r) { writeJSON(w, http.StatusOK, EmailStatus{Email: email, Verified: true}); return }
+	http.Redirect(w, r, "/?email_verified=1", http.StatusSeeOther)
+}
+
+// 만료되고 하루가 지난 토큰 정리 (하루 횟수 계산에 쓰므로 바로 지우지 않음)
+func pruneEmailVerifications() {
+	res, err := db.Exec("DELETE FROM email_verifications WHERE expires_at < CURRENT_TIMESTAMP AND created_at < CURRENT_TIMESTAMP - INTERVAL '1 day'")
+	if err != nil { slog.Warn("email verification cleanup failed", "err", err); return }
+	if n, _ := res.RowsAffected(); n > 0 { slog.Info("expired email verifications removed", "count", n) }
+}
@@ -0,0 +1,370 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	texttemplate "text/template"
+	"time"
+	"unicode/utf8"
+
+	"github.com/lib/pq"
+)
+
+// [임베드 위젯] 외부 사이트에 방을 iframe으로 붙이는 기능
+// 위젯 페이지와 그 API(/embed/{room}/...)는 같은 출처라 CORS 없이 동작하고,
+// 방 토큰 하나로 그 방의 기록/스트림(read) 또는 게스트 글쓰기(guest)까지만 허용함
+// 허용 출처(allowed_origins)가 있으면 CSP frame-ancestors로 그 사이트에서만 iframe을 띄울 수 있음
+const (
+	embedModeRead  = "read"
+	embedModeGuest = "guest"
+
+	guestNickPrefix   = "~" // 게스트 이름 앞에 붙여 일반 계정과 구분
+	guestMaxName      = 24
+	guestMaxMessage   = 500
+	guestPostInterval = 3 * time.Second
+)
+
+type EmbedToken struct {
+	Token          string     `json:"token"`
+	RoomID         int        `json:"room_id"`
+	Mode           string     `json:"mode"`
+	AllowedOrigins []string   `json:"allowed_origins"`
+	CreatedBy      string     `json:"created_by"`
+	CreatedAt      time.Time  `json:"created_at"`
+	RevokedAt      *time.Time `json:"revoked_at,omitempty"`
+	Snippet        string     `json:"snippet,omitempty"`
+}
+
+var (
+	guestPostsMu sync.Mutex
+	guestPosts   = map[string]time.Time{} // 토큰+IP → 마지막 게스트 글 시각
+)
+
+func newEmbedToken() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// 외부 사이트에 붙여 넣을 것이라 절대 URL로
+func embedSnippet(r *http.Request, t EmbedToken) string {
+	return fmt.Sprintf(`<script src="%s/embed/%d/widget.js?token=%s" async></script>`, baseURL(r), t.RoomID, t.Token)
+}
+
+// 프록시(X-Forwarded-Proto) 뒤에서도 맞는 scheme://host
+func baseURL(r *http.Request) string {
+	scheme := "https"
+	if r.TLS == nil && r.Header.Get("X-Forwarded-Proto") != "https" { scheme = "http" }
+	return scheme + "://" + r.Host
+}
+
+func canManageEmbeds(roomID int, nick string) bool {
+	role := roomRole(roomID, nick)
+	return role == roomRoleOwner || role == roomRoleModerator
+}
+
+// [임베드 토큰 발급] POST /rooms/{id}/embeds (nick, mode=read|guest, origins=https://a.com,https://b.com)
+func createEmbedHandler(w http.ResponseWriter, r *http.Request) {
+	roomID, ok := roomIDFrom(r)
+	nick := r.FormValue("nick")
+	if !ok || nick == "" { respondError(w, r, http.StatusBadRequest, errors.New("room id and nick required")); return }
+	if !canManageEmbeds(roomID, nick) { respondError(w, r, http.StatusForbidden, errors.New("only owners and moderators can manage embeds")); return }
+	mode := r.FormValue("mode")
+	if mode == "" { mode = embedModeRead }
+	if mode != embedModeRead && mode != embedModeGuest { respondError(w, r, http.StatusBadRequest, errors.New("mode must be read or guest")); return }
+	origins := []string{}
+	for _, o := range strings.Split(r.FormValue("origins"), ",") {
+		o = strings.TrimRight(strings.TrimSpace(o), "/")
+		if o == "" { continue }
+		if !strings.HasPrefix(o, "https://") && !strings.HasPrefix(o, "http://") { respondError(w, r, http.StatusBadRequest, errors.New("invalid origin "+o)); return }
+		origins = append(origins, o)
+	}
+
+	t := EmbedToken{Token: newEmbedToken(), RoomID: roomID, Mode: mode, AllowedOrigins: origins, CreatedBy: nick}
+	err := db.QueryRow(`INSERT INTO embed_tokens (token, room_id, mode, allowed_origins, created_by) VALUES ($1, $2, $3, $4, $5)
+		RETURNING created_at`, t.Token, roomID, mode, pq.Array(origins), nick).Scan(&t.CreatedAt)
+	if err != nil { respondError(w, r, 500, err); return }
+	t.Snippet = embedSnippet(r, t)
+	slog.InfoContext(r.Context(), "embed token created", "room_id", roomID, "nick", nick, "mode", mode)
+	writeJSON(w, http.StatusCreated, t)
+}
+
+// [임베드 토큰 목록] GET /rooms/{id}/embeds?nick=
+func listEmbedsHandler(w http.ResponseWriter, r *http.Request) {
+	roomID, ok := roomIDFrom(r)
+	nick := r.URL.Query().Get("nick")
+	if !ok || nick == "" { respondError(w, r, http.StatusBadRequest, errors.New("room id and nick required")); return }
+	if !canManageEmbeds(roomID, nick) { respondError(w, r, http.StatusForbidden, errors.New("only owners and moderators can manage embeds")); return }
+
+	rows, err := db.Query(`SELECT token, room_id, mode, allowed_origins, created_by, created_at, revoked_at
+		FROM embed_tokens WHERE room_id = $1 ORDER BY created_at DESC`, roomID)
+	if err != nil { respondError(w, r, 500, err); return }
+	defer rows.Close()
+	list := []EmbedToken{}
+	for rows.Next() {
+		var t EmbedToken
+		rows.Scan(&t.Token, &t.RoomID, &t.Mode, pq.Array(&t.AllowedOrigins), &t.CreatedBy, &t.CreatedAt, &t.RevokedAt)
+		if t.RevokedAt == nil { t.Snippet = embedSnippet(r, t) }
+		list = append(list, t)
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+// [임베드 토큰 폐기] DELETE /rooms/{id}/embeds/{token}?nick=
+func revokeEmbedHandler(w http.ResponseWriter, r *http.Request) {
+	roomID, ok := roomIDFrom(r)
+	nick := r.URL.Query().Get("nick")
+	if !ok || nick == "" { respondError(w, r, http.StatusBadRequest, errors.New("room id and nick required")); return }
+	if !canManageEmbeds(roomID, nick) { respondError(w, r, http.StatusForbidden, errors.New("only owners and moderators can manage embeds")); return }
+	res, err := db.Exec("UPDATE embed_tokens SET revoked_at = CURRENT_TIMESTAMP WHERE token = $1 AND room_id = $2 AND revoked_at IS NULL",
+		r.PathValue("token"), roomID)
+	if err != nil { respondError(w, r, 500, err); return }
+	if n, _ := res.RowsAffected(); n == 0 { respondError(w, r, http.StatusNotFound, errors.New("embed token not found")); return }
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// 경로의 방과 ?token=이 맞는 유효한 토큰인지 (아니면 404로 존재 여부도 숨김)
+func embedTokenFrom(w http.ResponseWriter, r *http.Request) (EmbedToken, bool) {
+	var t EmbedToken
+	roomID, err := strconv.Atoi(r.PathValue("room"))
+	token := r.URL.Query().Get("token")
+	if err != nil || token == "" { http.NotFound(w, r); return t, false }
+	err = db.QueryRow(`SELECT token, room_id, mode, allowed_origins FROM embed_tokens
+		WHERE token = $1 AND room_id = $2 AND revoked_at IS NULL`, token, roomID).
+		Scan(&t.Token, &t.RoomID, &t.Mode, pq.Array(&t.AllowedOrigins))
+	if err == sql.ErrNoRows { http.NotFound(w, r); return t, false }
+	if err != nil { respondError(w, r, 500, err); return t, false }
+	return t, true
+}
+
+// 허용 출처가 없으면 어디서든 iframe 가능
+func frameAncestors(t EmbedToken) string {
+	if len(t.AllowedOrigins) == 0 { return "*" }
+	return strings.Join(t.AllowedOrigins, " ")
+}
+
+// [위젯 페이지] GET /embed/{room}?token= - iframe 안에서 뜨는 최소 채팅 화면
+func embedPageHandler(w http.ResponseWriter, r *http.Request) {
+	t, ok := embedTokenFrom(w, r)
+	if !ok { return }
+	var name, topic string
+	db.QueryRow("SELECT name, COALESCE(topic, '') FROM rooms WHERE id = $1", t.RoomID).Scan(&name, &topic)
+
+	w.Header().Set("Content-Security-Policy", "default-src 'self'; style-src 'unsafe-inline'; script-src 'unsafe-inline'; frame-ancestors "+frameAncestors(t))
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	embedPageTemplate.Execute(w, map[string]any{
+		"Room": t.RoomID, "Token": t.Token, "Name": name, "Topic": topic, "Guest": t.Mode == embedModeGuest,
+	})
+}
+
+// [위젯 스크립트] GET /embed/{room}/widget.js?token= - 스크립트 태그 자리에 iframe을 넣음
+func embedScriptHandler(w http.ResponseWriter, r *http.Request) {
+	t, ok := embedTokenFrom(w, r)
+	if !ok { return }
+	src := fmt.Sprintf("%s/embed/%d?token=%s", baseURL(r), t.RoomID, t.Token)
+	w.Header().Set("Content-Type", "text/javascript; charset=utf-8")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	embedScriptTemplate.Execute(w, map[string]string{"Src": src})
+}
+
+// [위젯 기록] GET /embed/{room}/history?token=&before_id=&after_id= - 경량 필드만
+func embedHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	t, ok := embedTokenFrom(w, r)
+	if !ok { return }
+	beforeID := queryInt(r, "before_id", 0)
+	afterID := queryInt(r, "after_id", 0)
+	var page HistoryPage
+	var err error
+	if beforeID == 0 && afterID == 0 {
+		page, err = recentHistory.get(r.Context(), &t.RoomID)
+	} else {
+		page, err = loadHistory(r.Context(), &t.RoomID, beforeID, afterID)
+	}
+	if err != nil { respondError(w, r, 500, err); return }
+	writeJSON(w, http.StatusOK, litePage(page))
+}
+
+// 방송 중 이 방의 공개 메시지만 통과
+func embedVisible(data string, roomID int) bool {
+	var m Message
+	if json.Unmarshal([]byte(data), &m) != nil || m.ID == 0 { return false }
+	return m.RoomID != nil && *m.RoomID == roomID && m.RecipientNick == ""
+}
+
+// [위젯 스트림] GET /embed/{room}/stream?token= - 이 방 메시지만 경량 페이로드로
+// 접속 상태/관리자 연결 목록에는 잡히지 않음 (익명 방문자)
+func embedStreamHandler(w http.ResponseWriter, r *http.Request) {
+	t, ok := embedTokenFrom(w, r)
+	if !ok { return }
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	flusher := w.(http.Flusher)
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	myChan := make(chan outbound, cfg.ClientBuffer)
+	mutex.Lock()
+	clients[myChan] = &streamClient{kick: cancel, room: t.RoomID} // 닉네임이 없으니 DM/멘션 대상이 되지 않음
+	mutex.Unlock()
+	defer func() {
+		mutex.Lock()
+		delete(clients, myChan)
+		close(myChan)
+		mutex.Unlock()
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg := <-myChan:
+			refill(myChan)
+			if msg.Disconnect || !embedVisible(msg.Data, t.RoomID) { continue }
+			fmt.Fprintf(w, "data: %s\n\n", msg.LiteData)
+			flusher.Flush()
+		case <-time.After(time.Duration(cfg.KeepaliveInterval)):
+			fmt.Fprint(w, ":keepalive\n\n")
+			flusher.Flush()
+		}
+	}
+}
+
+// [게스트 글쓰기] POST /embed/{room}/send?token= (name, msg) - guest 모드 토큰만
+// 게스트 이름에는 "~"를 붙여 일반 계정을 사칭하지 못하게 하고, 토큰+IP마다 글 간격을 제한
+func embedSendHandler(w http.ResponseWriter, r *http.Request) {
+	t, ok := embedTokenFrom(w, r)
+	if !ok { return }
+	if t.Mode != embedModeGuest { respondError(w, r, http.StatusForbidden, errors.New("this embed is read-only")); return }
+	name := strings.TrimSpace(r.FormValue("name"))
+	content := strings.TrimSpace(r.FormValue("msg"))
+	if name == "" || content == "" { respondError(w, r, http.StatusBadRequest, errors.New("name and msg required")); return }
+	if utf8.RuneCountInString(name) > guestMaxName { respondError(w, r, http.StatusBadRequest, errors.New("name too long")); return }
+	if err := validateText("msg", content, min(guestMaxMessage, cfg.MaxMessageLength)); err != nil { respondError(w, r, http.StatusBadRequest, err); return }
+
+	key := t.Token + "|" + clientIP(r)
+	guestPostsMu.Lock()
+	last := guestPosts[key]
+	allowed := time.Since(last) >= guestPostInterval
+	if allowed { guestPosts[key] = time.Now() }
+	guestPostsMu.Unlock()
+	if !allowed { countSendError(&t.RoomID, "rate_limited"); respondError(w, r, http.StatusTooManyRequests, errors.New("slow down")); return }
+
+	ctx := r.Context()
+	nick := guestNickPrefix + name
+	if err := checkRoomLock(ctx, &t.RoomID, nick); err != nil { countSendError(&t.RoomID, "locked"); respondError(w, r, http.StatusForbidden, err); return }
+	groupKey, dayDivider := groupingHints(ctx, &t.RoomID, nick, false)
+	id, err := store.InsertMessage(ctx, NewMessage{
+		Content: content, SenderPod: nodeID, SenderNick: nick, RoomID: &t.RoomID, GroupKey: groupKey, DayDivider: dayDivider,
+	})
+	if err != nil { countSendError(&t.RoomID, "store"); respondError(w, r, 500, err); return }
+
+	msg := Message{
+		ID: id, Type: messageTypeText, Content: content, SenderPod: nodeID, SenderNick: nick, SenderColor: "#9ca3af",
+		Time: time.Now().Format("15:04:05"), RoomID: &t.RoomID, GroupKey: id,
+	}
+	if groupKey != nil { msg.GroupKey = *groupKey }
+	if dayDivider != nil { msg.DayDivider = *dayDivider }
+	data, _ := json.Marshal(msg)
+	if err := publishChat(ctx, data); err != nil { countSendError(&t.RoomID, "publish"); respondError(w, r, http.StatusServiceUnavailable, err); return }
+	messagesSent.WithLabelValues(roomLabel(&t.RoomID, false)).Inc()
+	slog.InfoContext(ctx, "guest message posted", "room_id", t.RoomID, "message_id", id)
+	dispatchWebhooks(msg)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// 오래된 게스트 글 간격 기록 정리 (스케줄러 없이 가볍게)
+func pruneGuestPosts() {
+	for range time.Tick(time.Minute) {
+		guestPostsMu.Lock()
+		for k, t := range guestPosts {
+			if time.Since(t) > guestPostInterval { delete(guestPosts, k) }
+		}
+		guestPostsMu.Unlock()
+	}
+}
+
+var embedScriptTemplate = texttemplate.Must(texttemplate.New("widget").Parse(`(function () {
+  var s = document.currentScript;
+  var f = document.createElement('iframe');
+  f.src = {{printf "%q" .Src}};
+  f.title = 'gotalk';
+  f.style.cssText = 'width:100%;max-width:420px;height:480px;border:1px solid #e5e7eb;border-radius:8px';
+  f.setAttribute('loading', 'lazy');
+  s.parentNode.insertBefore(f, s.nextSibling);
+})();
+`))
+
+var embedPageTemplate = template.Must(template.New("embed").Parse(`<!DOCTYPE html>
+<html lang="ko">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Name}}</title>
+<style>
+  body { margin: 0; font: 14px/1.4 system-ui, sans-serif; display: flex; flex-direction: column; height: 100vh; }
+  header { padding: 8px 12px; background: #111827; color: #fff; }
+  header small { color: #9ca3af; margin-left: 6px; }
+  #log { flex: 1; overflow-y: auto; padding: 8px 12px; }
+  .m { margin: 2px 0; word-wrap: break-word; }
+  .m b { margin-right: 6px; }
+  .m time { color: #9ca3af; font-size: 11px; margin-left: 6px; }
+  .ev { color: #6b7280; font-style: italic; }
+  form { display: flex; gap: 4px; padding: 8px; border-top: 1px solid #e5e7eb; }
+  input { padding: 6px; border: 1px solid #d1d5db; border-radius: 4px; }
+  #msg { flex: 1; }
+</style>
+</head>
+<body>
+<header><b>#{{.Name}}</b>{{if .Topic}}<small>{{.Topic}}</small>{{end}}</header>
+<div id="log"></div>
+{{if .Guest}}<form id="f">
+  <input id="name" placeholder="이름" maxlength="24" size="8" required>
+  <input id="msg" placeholder="메시지" maxlength="500" required autocomplete="off">
+  <button>보내기</button>
+</form>{{end}}
+<script>
+  var base = '/embed/{{.Room}}', q = '?token={{.Token}}', log = document.getElementById('log'), lastID = 0;
+  function add(m) {
+    if (m.id <= lastID) return;
+    lastID = m.id;
+    var d = document.createElement('div');
+    d.className = m.type === 'event' ? 'm ev' : 'm';
+    if (m.type !== 'event') { var b = document.createElement('b'); b.textContent = m.sender_nick; d.appendChild(b); }
+    d.appendChild(document.createTextNode(m.content));
+    var t = document.createElement('time'); t.textContent = m.time; d.appendChild(t);
+    log.appendChild(d);
+    log.scrollTop = log.scrollHeight;
+  }
+  function fill() {
+    var u = base + '/history' + q + (lastID ? '&after_id=' + lastID : '');
+    return fetch(u).then(function (r) { return r.json(); }).then(function (p) { p.messages.forEach(add); });
+  }
+  fill().then(function () {
+    var es = new EventSource(base + '/stream' + q);
+    es.onopen = fill;
+    es.onmessage = function (e) { var m = JSON.parse(e.data); if (m.type === 'deleted') return; add(m); };
+  });
+  var f = document.getElementById('f');
+  if (f) f.onsubmit = function (e) {
+    e.preventDefault();
+    var msg = document.getElementById('msg');
+    var body = new URLSearchParams({ name: document.getElementById('name').value, msg: msg.value });
+    fetch(base + '/send' + q, { method: 'POST', body: body }).then(function (r) { if (r.ok) msg.value = ''; });
+  };
+</script>
+</body>
+</html>
+`))
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// [운영진 호출] POST /escalate로 신고하면 신고자와 당번 운영진만 들어가는 비공개 분류 방(triage-<id>)을 만들어 거기서 대화
+// 당번은 escalation.rotation의 교대 순서를 rotation_start부터 shift_length마다 돌려 정하고, 비어 있으면 사이트 운영진/관리자 전원
+// 상태는 open → ack(운영진이 맡음) → resolved, 맡기/해결 기한(ack_sla, resolve_sla)을 넘긴 건은 관리자 API에서 overdue로 표시
+// 방 소유자는 시스템 계정이라 당번이 바뀌어도 방이 주인 없이 남지 않음
+const (
+	escalationOpen     = "open"
+	escalationAck      = "ack"
+	escalationResolved = "resolved"
+
+	eventEscalation = "escalation"
+
+	escalationMaxReason  = 1000
+	escalationMaxOpen    = 3 // 신고자 한 명이 동시에 열어 둘 수 있는 건수
+	escalationExcerptLen = 200
+)
+
+var errEscalationNotFound = errors.New("escalation not found")
+
+type Escalation struct {
+	ID           int64      `json:"id"`
+	Reporter     string     `json:"reporter"`
+	Reason       string     `json:"reason"`
+	MessageID    *int       `json:"message_id,omitempty"` // 신고한 메시지
+	RoomID       *int       `json:"room_id,omitempty"`    // 분류 방
+	Status       string     `json:"status"`
+	Assignee     string     `json:"assignee,omitempty"`
+	Resolution   string     `json:"resolution,omitempty"`
+	Moderators   []string   `json:"moderators,omitempty"` // 만들 때 부른 당번
+	CreatedAt    time.Time  `json:"created_at"`
+	AckedAt      *time.Time `json:"acked_at,omitempty"`
+	ResolvedAt   *time.Time `json:"resolved_at,omitempty"`
+	AckDueAt     time.Time  `json:"ack_due_at"`
+	ResolveDueAt time.Time  `json:"resolve_due_at"`
+	Overdue      bool       `json:"overdue"` // 지금 단계의 기한을 넘김
+}
+
+// SLA 기한과 초과 여부 채우기
+func (e *Escalation) applySLA(now time.Time) {
+	e.AckDueAt = e.CreatedAt.Add(time.Duration(cfg.Escalation.AckSLA))
+	e.ResolveDueAt = e.CreatedAt.Add(time.Duration(cfg.Escalation.ResolveSLA))
+	switch e.Status {
+	case escalationOpen:
+		e.Overdue = now.After(e.AckDueAt) || now.After(e.ResolveDueAt)
+	case escalationAck:
+		e.Overdue = now.After(e.ResolveDueAt)
+	}
+}
+
+// 지금 당번인 운영진 (교대표가 없거나 비어 있으면 사이트 운영진/관리자)
+func onDutyModerators(ctx context.Context, now time.Time) []string {
+	if rot := cfg.Escalation.Rotation; len(rot) > 0 {
+		shift := int(now.Sub(cfg.Escalation.RotationStart) / time.Duration(cfg.Escalation.ShiftLength))
+		if shift < 0 { shift = 0 }
+		if mods := rot[shift%len(rot)]; len(mods) > 0 { return mods }
+	}
+	rows, err := db.QueryContext(ctx, "SELECT nickname FROM users WHERE role IN ($1, $2) AND deleted_at IS NULL ORDER BY nickname", userRoleAdmin, userRoleModerator)
+	if err != nil { slog.WarnContext(ctx, "moderator lookup failed", "err", err); return nil }
+	defer rows.Close()
+	var mods []string
+	for rows.Next() {
+		var nick string
+		rows.Scan(&nick)
+		mods = append(mods, nick)
+	}
+	return mods
+}
+
+// [운영진 호출] POST /escalate (reason, message_id?) - 로그인한 계정만, 분류 방과 함께 만든 건을 돌려줌
+func escalateHandler(w http.ResponseWriter, r *http.Request) {
+	nick, ok := requireAccount(w, r)
+	if !ok { return }
+	reason := r.FormValue("reason")
+	if reason == "" { respondError(w, r, http.StatusBadRequest, fieldError{"reason", "required"}); return }
+	if err := validateText("reason", reason, escalationMaxReason); err != nil { respondError(w, r, http.StatusBadRequest, err); return }
+	messageID, err := formInt(r, "message_id")
+	if err != nil { respondError(w, r, http.StatusBadRequest, fieldError{"message_id", "must be an integer"}); return }
+	ctx := r.Context()
+
+	var excerpt, sender string
+	if messageID != nil {
+		err := db.QueryRowContext(ctx, "SELECT sender_nick, COALESCE(content, '') FROM messages WHERE id = $1", *messageID).Scan(&sender, &excerpt)
+		if err == sql.ErrNoRows { respondError(w, r, http.StatusNotFound, fieldError{"message_id", "message not found"}); return }
+		if err != nil { respondError(w, r, 500, err); return }
+	}
+	var open int
+	db.QueryRowContext(ctx, "SELECT COUNT(*) FROM escalations WHERE reporter = $1 AND status <> $2", nick, escalationResolved).Scan(&open)
+	if open >= escalationMaxOpen { respondError(w, r, http.StatusTooManyRequests, errors.New("too many open escalations; wait for a moderator to respond")); return }
+	mods := onDutyModerators(ctx, time.Now())
+	if len(mods) == 0 { respondError(w, r, http.StatusServiceUnavailable, errors.New("no moderators on duty")); return }
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil { respondError(w, r, 500, err); return }
+	defer tx.Rollback()
+	e := Escalation{Reporter: nick, Reason: reason, MessageID: messageID, Status: escalationOpen, Moderators: mods}
+	err = tx.QueryRowContext(ctx, "INSERT INTO escalations (reporter, reason, message_id) VALUES ($1, $2, $3) RETURNING id, created_at",
+		nick, reason, messageID).Scan(&e.ID, &e.CreatedAt)
+	if err != nil { respondError(w, r, 500, err); return }
+	var roomID int
+	err = tx.QueryRowContext(ctx, `INSERT INTO rooms (name, topic, is_public, owner_nick) VALUES ($1, $2, FALSE, $3) RETURNING id`,
+		fmt.Sprintf("triage-%d", e.ID), "Escalation #"+strconv.FormatInt(e.ID, 10)+" from "+nick, systemNick()).Scan(&roomID)
+	if err != nil { respondError(w, r, 500, err); return }
+	members := map[string]string{systemNick(): roomRoleOwner, nick: roomRoleMember}
+	for _, m := range mods {
+		if m != nick { members[m] = roomRoleModerator }
+	}
+	for m, role := range members {
+		if _, err := tx.ExecContext(ctx, "INSERT INTO room_members (room_id, nickname, role) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING", roomID, m, role); err != nil {
+			respondError(w, r, 500, err)
+			return
+		}
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE escalations SET room_id = $2 WHERE id = $1", e.ID, roomID); err != nil { respondError(w, r, 500, err); return }
+	if err := tx.Commit(); err != nil { respondError(w, r, 500, err); return }
+	e.RoomID = &roomID
+	e.applySLA(time.Now())
+	roomMembersChanged(ctx, roomID)
+
+	content := "🚨 " + nick + " escalated: " + reason
+	if messageID != nil { content += fmt.Sprintf("\nReported message #%d by %s: %s", *messageID, sender, clipRunes(excerpt, escalationExcerptLen)) }
+	recordEvent(ctx, &roomID, RoomEvent{Kind: eventEscalation, Actor: nick, Data: map[string]any{"id": e.ID, "status": escalationOpen}}, content)
+	notice := fmt.Sprintf("🚨 New escalation #%d from %s: %s — please acknowledge in #triage-%d within %s.",
+		e.ID, nick, clipRunes(reason, escalationExcerptLen), e.ID, time.Duration(cfg.Escalation.AckSLA))
+	for _, m := range mods {
+		if m == nick { continue }
+		if _, err := sendDirectMessage(systemNick(), m, notice); err != nil { slog.WarnContext(ctx, "escalation notice failed", "nick", m, "err", err) }
+	}
+	slog.InfoContext(ctx, "escalation opened", "id", e.ID, "reporter", nick, "moderators", len(mods))
+	writeJSON(w, http.StatusCreated, e)
+}
+
+// 분류 방의 운영진이거나 사이트 운영진/관리자만 상태를 바꿈
+func canHandleEscalation(ctx context.Context, e Escalation, nick string) bool {
+	if e.RoomID != nil && roomRole(*e.RoomID, nick) == roomRoleModerator { return true }
+	role := userRole(ctx, nick)
+	return role == userRoleAdmin || role == userRoleModerator
+}
+
+func loadEscalation(ctx context.Context, id int64) (Escalation, error) {
+	var e Escalation
+	var assignee, resolution sql.NullString
+	err := db.QueryRowContext(ctx, `SELECT id, reporter, reason, message_id, room_id, status, assignee, resolution, created_at, acked_at, resolved_at
+		FROM escalations WHERE id = $1`, id).Scan(&e.ID, &e.Reporter, &e.Reason, &e.MessageID, &e.RoomID, &e.Status, &assignee, &resolution, &e.CreatedAt, &e.AckedAt, &e.ResolvedAt)
+	if err == sql.ErrNoRows { return e, errEscalationNotFound }
+	e.Assignee, e.Resolution = assignee.String, resolution.String
+	e.applySLA(time.Now())
+	return e, err
+}
+
+// 상태 변경 요청 공통: 로그인, 건 조회, 권한 확인
+func escalationRequest(w http.ResponseWriter, r *http.Request) (Escalation, string, bool) {
+	nick, ok := requireAccount(w, r)
+	if !ok { return Escalation{}, "", false }
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil { respondError(w, r, http.StatusBadRequest, errors.New("invalid escalation id")); return Escalation{}, "", false }
+	e, err := loadEscalation(r.Context(), id)
+	if err == errEscalationNotFound { respondError(w, r, http.StatusNotFound, err); return Escalation{}, "", false }
+	if err != nil { respondError(w, r, 500, err); return Escalation{}, "", false }
+	if !canHandleEscalation(r.Context(), e, nick) { respondError(w, r, http.StatusForbidden, errPermissionDenied); return Escalation{}, "", false }
+	return e, nick, true
+}
+
+// [호출 맡기] POST /escalations/{id}/ack - 열린 건만, 맡은 운영진을 기록
+func ackEscalationHandler(w http.ResponseWriter, r *http.Request) {
+	e, nick, ok := escalationRequest(w, r)
+	if !ok { return }
+	ctx := r.Context()
+	res, err := db.ExecContext(ctx, "UPDATE escalations SET status = $2, assignee = $3, acked_at = CURRENT_TIMESTAMP WHERE id = $1 AND status = $4",
+		e.ID, escalationAck, nick, escalationOpen)
+	if err != nil { respondError(w, r, 500, err); return }
+	if n, _ := res.RowsAffected(); n == 0 { respondError(w, r, http.StatusConflict, errors.New("escalation is not open")); return }
+	recordEvent(ctx, e.RoomID, RoomEvent{Kind: eventEscalation, Actor: nick, Data: map[string]any{"id": e.ID, "status": escalationAck}},
+		"👀 "+nick+" is handling this escalation")
+	slog.InfoContext(ctx, "escalation acknowledged", "id", e.ID, "nick", nick, "after", time.Since(e.CreatedAt).Round(time.Second))
+	e, _ = loadEscalation(ctx, e.ID)
+	writeJSON(w, http.StatusOK, e)
+}
+
+// [호출 해결] POST /escalations/{id}/resolve (resolution?) - 맡지 않은 건도 바로 해결할 수 있음
+func resolveEscalationHandler(w http.ResponseWriter, r *http.Request) {
+	e, nick, ok := escalationRequest(w, r)
+	if !ok { return }
+	resolution := r.FormValue("resolution")
+	if err := validateText("resolution", resolution, escalationMaxReason); err != nil { respondError(w, r, http.StatusBadRequest, err); return }
+	ctx := r.Context()
+	res, err := db.ExecContext(ctx, `UPDATE escalations SET status = $2, assignee = COALESCE(assignee, $3), resolution = NULLIF($4, ''), resolved_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status <> $2`, e.ID, escalationResolved, nick, resolution)
+	if err != nil { respondError(w, r, 500, err); return }
+	if n, _ := res.RowsAffected(); n == 0 { respondError(w, r, http.StatusConflict, errors.New("escalation is already resolved")); return }
+	content := "✅ " + nick + " resolved this escalation"
+	if resolution != "" { content += ": " + resolution }
+	recordEvent(ctx, e.RoomID, RoomEvent{Kind: eventEscalation, Actor: nick, Data: map[string]any{"id": e.ID, "status": escalationResolved}}, content)
+	slog.InfoContext(ctx, "escalation resolved", "id", e.ID, "nick", nick, "after", time.Since(e.CreatedAt).Round(time.Second))
+	e, _ = loadEscalation(ctx, e.ID)
+	writeJSON(w, http.StatusOK, e)
+}
+
+type EscalationDashboard struct {
+	OnDuty      []string     `json:"on_duty"`
+	Open        int          `json:"open"`
+	Acked       int          `json:"acked"`
+	Overdue     int          `json:"overdue"`
+	Escalations []Escalation `json:"escalations"` // 오래된 순
+}
+
+// [호출 현황] GET /admin/escalations?status=open|ack|resolved - 기본은 해결되지 않은 건, 건별 SLA 기한과 초과 여부
+func adminEscalationsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	status := r.URL.Query().Get("status")
+	if status != "" && status != escalationOpen && status != escalationAck && status != escalationResolved {
+		respondError(w, r, http.StatusBadRequest, fieldError{"status", "must be open, ack or resolved"})
+		return
+	}
+	query, args := `SELECT id FROM escalations WHERE status <> $1 ORDER BY created_at LIMIT 200`, []any{escalationResolved}
+	if status != "" {
+		query, args = `SELECT id FROM escalations WHERE status = $1 ORDER BY created_at DESC LIMIT 200`, []any{status}
+	}
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil { respondError(w, r, 500, err); return }
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		rows.Scan(&id)
+		ids = append(ids, id)
+	}
+	rows.Close()
+
+	d := EscalationDashboard{OnDuty: onDutyModerators(ctx, time.Now()), Escalations: []Escalation{}}
+	for _, id := range ids {
+		e, err := loadEscalation(ctx, id)
+		if err != nil { continue }
+		d.Escalations = append(d.Escalations, e)
+	}
+	// 요약은 목록 필터와 무관하게 해결되지 않은 건 전체 기준
+	rows, err = db.QueryContext(ctx, "SELECT status, created_at FROM escalations WHERE status <> $1", escalationResolved)
+	if err != nil { respondError(w, r, 500, err); return }
+	defer rows.Close()
+	now := time.Now()
+	for rows.Next() {
+		var e Escalation
+		rows.Scan(&e.Status, &e.CreatedAt)
+		e.applySLA(now)
+		if e.Status == escalationOpen { d.Open++ } else { d.Acked++ }
+		if e.Overdue { d.Overdue++ }
+	}
+	writeJSON(w, http.StatusOK, d)
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+)
+
+// 타임라인 항목 종류 (messages.type)
+const (
+	messageTypeText       = "message"
+	messageTypeEvent      = "event"
+	messageTypeDeleted    = "deleted"    // 스트림 전용: 관리자가 삭제한 메시지 (id만 채워 방송)
+	messageTypeEdited     = "edited"     // 스트림 전용: 본문이 바뀐 메시지 (id와 새 content/html만 채워 방송)
+	messageTypeTranslated = "translated" // 스트림 전용: 번역본 (id, room_id, translations만 채워 방송, translate.go)
+	messageTypeUnfurled   = "unfurled"   // 스트림 전용: 링크 미리보기 (id, room_id, previews만 채워 방송, unfurl.go)
+	messageTypePins       = "pins"       // 스트림 전용: 바뀐 고정 목록 (room_id, pins만 채워 방송, pins.go)
+	messageTypeProbe      = "probe"      // 스트림 전용: 합성 프로브 카나리 (프로브 연결에만 전달, probe.go)
+	messageTypeSystem     = "system"     // 스트림 전용: 접속/퇴장 알림 (event.kind가 online/offline, 저장하지 않음, presence.go)
+)
+
+// 시스템 이벤트 종류
+const (
+	eventJoin        = "join"
+	eventLeave       = "leave"
+	eventTopic       = "topic"
+	eventRename      = "rename"
+	eventDescription = "description"
+	eventPin         = "pin"
+	eventUnpin       = "unpin"
+	eventNote        = "note"
+	eventOnline      = "online"
+	eventOffline     = "offline"
+)
+
+// [시스템 이벤트] 입장/퇴장/주제 변경/고정 같은 타임라인 항목
+// messages 행(type='event')으로 저장해 /history 커서와 같은 순서로 섞여 나옴
+type RoomEvent struct {
+	Kind  string         `json:"kind"`
+	Actor string         `json:"actor"`
+	Data  map[string]any `json:"data,omitempty"`
+}
+
+// 이벤트를 방(nil이면 로비) 타임라인에 기록하고 방송
+// content는 이벤트를 모르는 클라이언트가 그대로 보여줄 대체 문구
+func postEvent(ctx context.Context, roomID *int, ev RoomEvent, content string) (Message, error) {
+	msg := Message{
+		Type: messageTypeEvent, Event: &ev, Content: content, SenderPod: nodeID, SenderNick: ev.Actor,
+		Time: time.Now().Format("15:04:05"), RoomID: roomID,
+	}
+	payload, _ := json.Marshal(ev)
+	_, dayDivider := groupingHints(ctx, roomID, ev.Actor, true)
+	err := db.QueryRowContext(ctx, `
+		INSERT INTO messages (type, event, content, sender_pod, sender_nick, room_id, day_divider) VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id`, messageTypeEvent, payload, content, nodeID, ev.Actor, roomID, dayDivider).Scan(&msg.ID)
+	if err != nil { return msg, err }
+	msg.GroupKey = msg.ID
+	if dayDivider != nil { msg.DayDivider = *dayDivider }
+	db.QueryRowContext(ctx, "SELECT COALESCE(color_code, '#ffffff') FROM users WHERE nickname = $1", ev.Actor).Scan(&msg.SenderColor)
+	data, _ := json.Marshal(msg)
+	return msg, publishChat(ctx, data)
+}
+
+// 이벤트 기록 실패는 본 요청을 실패시키지 않고 로그만 남김
+func recordEvent(ctx context.Context, roomID *int, ev RoomEvent, content string) {
+	if _, err := postEvent(ctx, roomID, ev, content); err != nil {
+		slog.WarnContext(ctx, "event record failed", "kind", ev.Kind, "nick", ev.Actor, "err", err)
+	}
+}
+
+// 저장된 글의 본문을 바꾸고 클라이언트에 수정 알림 (클라이언트는 같은 id의 본문만 교체)
+func editMessage(ctx context.Context, id int, roomID *int, content string) error {
+	if _, err := db.ExecContext(ctx, "UPDATE messages SET content = $1 WHERE id = $2", content, id); err != nil { return err }
+	data, _ := json.Marshal(Message{ID: id, Type: messageTypeEdited, Content: content, HTML: renderContent(content), RoomID: roomID})
+	return publishChat(ctx, data)
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	exportPending = "pending"
+	exportRunning = "running"
+	exportDone    = "done"
+	exportFailed  = "failed"
+)
+
+type RoomExport struct {
+	ID          int        `json:"id"`
+	RoomID      int        `json:"room_id"`
+	RequestedBy string     `json:"requested_by"`
+	Status      string     `json:"status"`
+	Error       string     `json:"error,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	DownloadURL string     `json:"download_url,omitempty"`
+}
+
+func exportDownloadURL(id int) string {
+	return fmt.Sprintf("/exports/%d/download", id)
+}
+
+// [방 보관 내보내기] POST /rooms/{id}/export (nick) - 소유자/모더레이터만
+// 실제 렌더링은 스케줄러 작업(room-exports)이 처리하고 끝나면 요청자에게 DM으로 링크를 보냄
+func requestRoomExportHandler(w http.ResponseWriter, r *http.Request) {
+	roomID, ok := roomIDFrom(r)
+	nick := r.FormValue("nick")
+	if !ok || nick == "" { respondError(w, r, http.StatusBadRequest, errors.New("room id and nick required")); return }
+	if role := roomRole(roomID, nick); role != roomRoleOwner && role != roomRoleModerator {
+		respondError(w, r, http.StatusForbidden, errors.New("only owners and moderators can export a room"))
+		return
+	}
+
+	exp := RoomExport{RoomID: roomID, RequestedBy: nick, Status: exportPending}
+	err := db.QueryRow("INSERT INTO room_exports (room_id, requested_by) VALUES ($1, $2) RETURNING id, created_at",
+		roomID, nick).Scan(&exp.ID, &exp.CreatedAt)
+	if err != nil { respondError(w, r, 500, err); return }
+	writeJSON(w, http.StatusAccepted, exp)
+}
+
+func loadRoomExport(id int) (RoomExport, error) {
+	var exp RoomExport
+	var errMsg sql.NullString
+	err := db.QueryRow(`SELECT id, room_id, requested_by, status, error, created_at, completed_at
+		FROM room_exports WHERE id = $1`, id).
+		Scan(&exp.ID, &exp.RoomID, &exp.RequestedBy, &exp.Status, &errMsg, &exp.CreatedAt, &exp.CompletedAt)
+	exp.Error = errMsg.String
+	if exp.Status == exportDone { exp.DownloadURL = exportDownloadURL(exp.ID) }
+	return exp, err
+}
+
+// 내보내기 조회/다운로드는 현재 방 멤버만
+func authorizedExport(w http.ResponseWriter, r *http.Request) (RoomExport, bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil { respondError(w, r, http.StatusBadRequest, errors.New("invalid export id")); return RoomExport{}, false }
+	exp, err := loadRoomExport(id)
+	if err == sql.ErrNoRows { respondError(w, r, http.StatusNotFound, errors.New("export not found")); return exp, false }
+	if err != nil { respondError(w, r, 500, err); return exp, false }
+	if roomRole(exp.RoomID, r.URL.Query().Get("nick")) == "" { respondError(w, r, http.StatusForbidden, errors.New("not a room member")); return exp, false }
+	return exp, true
+}
+
+// [내보내기 상태] GET /exports/{id}?nick=
+func roomExportHandler(w http.ResponseWriter, r *http.Request) {
+	exp, ok := authorizedExport(w, r)
+	if !ok { return }
+	writeJSON(w, http.StatusOK, exp)
+}
+
+// [내보내기 다운로드] GET /exports/{id}/download?nick= - zip 번들
+func downloadRoomExportHandler(w http.ResponseWriter, r *http.Request) {
+	exp, ok := authorizedExport(w, r)
+	if !ok { return }
+	if exp.Status != exportDone { respondError(w, r, http.StatusConflict, errors.New("export not ready")); return }
+
+	obj, err := objectStore.Get(r.Context(), exportObjectKey(exp))
+	if err != nil { respondError(w, r, 500, err); return }
+	defer obj.Close()
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="room-%d-export-%d.zip"`, exp.RoomID, exp.ID))
+	io.Copy(w, obj)
+}
+
+func exportObjectKey(exp RoomExport) string {
+	return fmt.Sprintf("exports/room-%d/%d.zip", exp.RoomID, exp.ID)
+}
+
+// [스케줄러 작업] 대기 중인 내보내기를 하나씩 처리
+func processRoomExports() {
+	for {
+		var exp RoomExport
+		err := db.QueryRow(`
+			UPDATE room_exports SET status = $1
+			WHERE id = (SELECT id FROM room_exports WHERE status = $2 ORDER BY id LIMIT 1 FOR UPDATE SKIP LOCKED)
+			RETURNING id, room_id, requested_by`, exportRunning, exportPending).
+			Scan(&exp.ID, &exp.RoomID, &exp.RequestedBy)
+		if err == sql.ErrNoRows { return }
+		if err != nil { slog.Warn("room export claim failed", "err", err); return }
+
+		ctx := context.Background()
+		if err := renderRoomExport(ctx, exp); err != nil {
+			slog.Warn("room export failed", "room_id", exp.RoomID, "export_id", exp.ID, "err", err)
+			db.Exec("UPDATE room_exports SET status = $2, error = $3, completed_at = CURRENT_TIMESTAMP WHERE id = $1",
+				exp.ID, exportFailed, err.Error())
+			recordDeadLetter(ctx, deadLetterRoomExport, roomExportDeadLetter{ExportID: exp.ID, RoomID: exp.RoomID}, err, 1)
+			continue
+		}
+		db.Exec("UPDATE room_exports SET status = $2, completed_at = CURRENT_TIMESTAMP WHERE id = $1", exp.ID, exportDone)
+		slog.Info("room export finished", "room_id", exp.RoomID, "export_id", exp.ID)
+
+		content := fmt.Sprintf("📦 Room export #%d is ready: %s", exp.ID, exportDownloadURL(exp.ID))
+		if _, err := sendDirectMessage(systemNick(), exp.RequestedBy, content); err != nil {
+			slog.Warn("room export notice failed", "nick", exp.RequestedBy, "err", err)
+		}
+	}
+}
+
+type roomExportDeadLetter struct {
+	ExportID int `json:"export_id"`
+	RoomID   int `json:"room_id"`
+}
+
+// 실패한 내보내기를 다시 대기열에 넣음 (실제 렌더링은 다음 스케줄러 실행에서)
+func retryRoomExportDeadLetter(ctx context.Context, payload json.RawMessage) error {
+	var dl roomExportDeadLetter
+	if err := json.Unmarshal(payload, &dl); err != nil || dl.ExportID == 0 { return errDeadLetterPayload }
+	res, err := db.ExecContext(ctx, "UPDATE room_exports SET status = $2, error = NULL, completed_at = NULL WHERE id = $1 AND status = $3",
+		dl.ExportID, exportPending, exportFailed)
+	if err != nil { return err }
+	if n, _ := res.RowsAffected(); n == 0 { return errors.New("export is not in failed state") }
+	return nil
+}
+
+type exportMessage struct {
+	ID       int
+	Nick     string
+	Content  string
+	Time     time.Time
+	ParentID *int
+	IsEvent  bool
+}
+
+type exportPage struct {
+	Room       Room
+	Messages   []exportMessage
+	ExportedAt time.Time
+}
+
+// 방 전체 기록을 index.html + style.css 한 묶음(zip)으로 렌더링해 오브젝트 스토리지에 저장
+// 첨부파일 기능이 생기면 같은 번들의 files/ 아래에 함께 담음
+func renderRoomExport(ctx context.Context, exp RoomExport) error {
+	var page exportPage
+	var topic sql.NullString
+	err := db.QueryRowContext(ctx, "SELECT id, name, topic, created_at FROM rooms WHERE id = $1", exp.RoomID).
+		Scan(&page.Room.ID, &page.Room.Name, &topic, &page.Room.CreatedAt)
+	if err != nil { return err }
+	page.Room.Topic = topic.String
+	page.ExportedAt = time.Now().UTC()
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT m.id, CASE WHEN u.deleted_at IS NOT NULL THEN $2 ELSE m.sender_nick END, m.content, m.created_at, m.parent_id, m.type = $3
+		FROM messages m LEFT JOIN users u ON u.nickname = m.sender_nick
+		WHERE m.room_id = $1 ORDER BY m.id`, exp.RoomID, deletedNick, messageTypeEvent)
+	if err != nil { return err }
+	for rows.Next() {
+		var m exportMessage
+		rows.Scan(&m.ID, &m.Nick, &m.Content, &m.Time, &m.ParentID, &m.IsEvent)
+		page.Messages = append(page.Messages, m)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil { return err }
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	f, err := zw.Create("index.html")
+	if err != nil { return err }
+	if err := exportTemplate.Execute(f, page); err != nil { return err }
+	f, err = zw.Create("style.css")
+	if err != nil { return err }
+	if _, err := f.Write([]byte(exportCSS)); err != nil { return err }
+	if err := zw.Close(); err != nil { return err }
+
+	return objectStore.Put(ctx, exportObjectKey(exp), &buf)
+}
+
+var exportTemplate = template.Must(template.New("export").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>#{{.Room.Name}} archive</title>
+<link rel="stylesheet" href="style.css">
+</head>
+<body>
+<header>
+<h1>#{{.Room.Name}}</h1>
+{{if .Room.Topic}}<p class="topic">{{.Room.Topic}}</p>{{end}}
+<p class="meta">{{len .Messages}} messages · exported {{.ExportedAt.Format "2006-01-02 15:04 UTC"}}</p>
+</header>
+<main>
+{{range .Messages}}{{if .IsEvent}}<p class="event" id="m{{.ID}}">{{.Content}} <time>{{.Time.Format "2006-01-02 15:04"}}</time></p>
+{{else}}<article id="m{{.ID}}"{{if .ParentID}} class="reply"{{end}}>
+<div class="head"><span class="nick">{{.Nick}}</span> <time>{{.Time.Format "2006-01-02 15:04"}}</time>{{if .ParentID}} <a href="#m{{.ParentID}}">↪ #{{.ParentID}}</a>{{end}}</div>
+<div class="body">{{.Content}}</div>
+</article>
+{{end}}{{end}}</main>
+</body>
+</html>
+`))
+
+const exportCSS = `body { font-family: system-ui, sans-serif; max-width: 860px; margin: 2rem auto; color: #1f2937; }
+header { border-bottom: 1px solid #e5e7eb; margin-bottom: 1rem; }
+.topic, .meta { color: #6b7280; }
+article { padding: .5rem 0; border-bottom: 1px solid #f3f4f6; }
+article.reply { margin-left: 2rem; }
+.nick { font-weight: 600; }
+time, .head a { color: #9ca3af; font-size: .85em; }
+.body { white-space: pre-wrap; }
+.event { color: #6b7280; font-style: italic; text-align: center; font-size: .9em; }
+`
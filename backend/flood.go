@@ -0,0 +1,89 @@
+package main
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// [IP 폭주 차단] /send, /stream을 IP별로 세어(clientIP: 믿는 프록시 뒤에서만 X-Forwarded-For) rate_limit.flood.limit을 넘으면
+// 그 IP를 잠시 막음: 처음 block, 다시 넘을 때마다 두 배씩 max_block까지 (닉네임 한도와 별개라 닉네임을 바꿔 가며 보내도 막힘)
+// 막힌 동안의 요청은 세지 않고 429 + Retry-After, 마지막 차단이 끝나고 max_block 동안 조용하면 단계를 처음으로 되돌림
+var floodPaths = map[string]bool{"/send": true, "/stream": true}
+
+var floodBlocks = promauto.NewCounter(prometheus.CounterOpts{
+	Name: "gotalk_flood_blocks_total",
+	Help: "Client IPs temporarily blocked for flooding /send or /stream.",
+})
+
+type floodEntry struct {
+	strikes int
+	until   time.Time
+}
+
+type floodGuard struct {
+	counter *rateLimiter
+	mu      sync.Mutex
+	blocked map[string]*floodEntry
+}
+
+var flood *floodGuard
+
+func initFloodGuard() {
+	q := cfg.RateLimit.Flood
+	flood = &floodGuard{counter: newRateLimiter(RateQuota{Limit: q.Limit, Window: q.Window}), blocked: map[string]*floodEntry{}}
+	if q.Limit > 0 { go flood.prune() }
+}
+
+// 막혀 있으면 풀리는 시각과 false
+func (g *floodGuard) allow(ip string) (time.Time, bool) {
+	now := time.Now()
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	e := g.blocked[ip]
+	if e != nil && now.Before(e.until) { return e.until, false }
+	if g.counter.take(ip).Allowed { return time.Time{}, true }
+
+	if e == nil || now.Sub(e.until) > time.Duration(cfg.RateLimit.Flood.MaxBlock) { e = &floodEntry{} }
+	e.strikes++
+	block := time.Duration(cfg.RateLimit.Flood.Block) << min(e.strikes-1, 20)
+	block = min(block, time.Duration(cfg.RateLimit.Flood.MaxBlock))
+	e.until = now.Add(block)
+	g.blocked[ip] = e
+	floodBlocks.Inc()
+	slog.Warn("blocking flooding client", "ip", ip, "strikes", e.strikes, "for", block)
+	return e.until, false
+}
+
+// 단계를 되돌릴 때가 지난 항목 정리
+func (g *floodGuard) prune() {
+	for range time.Tick(time.Duration(cfg.RateLimit.Flood.MaxBlock)) {
+		now := time.Now()
+		g.mu.Lock()
+		for ip, e := range g.blocked {
+			if now.Sub(e.until) > time.Duration(cfg.RateLimit.Flood.MaxBlock) { delete(g.blocked, ip) }
+		}
+		g.mu.Unlock()
+	}
+}
+
+func floodMiddleware(next http.Handler) http.Handler {
+	if cfg.RateLimit.Flood.Limit <= 0 { return next }
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !floodPaths[r.URL.Path] {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if until, ok := flood.allow(clientIP(r)); !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(max(int(time.Until(until).Seconds()+0.999), 1)))
+			respondError(w, r, http.StatusTooManyRequests, errRateLimited)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// [묶음 힌트] 클라이언트마다 말풍선 묶기가 달라지지 않도록 서버에서 한 번 계산해 저장
+// group_key: 같은 사람이 grouping.window 안에 이어서 보낸 메시지 묶음의 첫 메시지 ID (NULL이면 자기 자신)
+// day_divider: 타임라인에서 날짜가 바뀐 첫 항목이면 그 날짜(YYYY-MM-DD)
+func groupingHints(ctx context.Context, roomID *int, sender string, isEvent bool) (groupKey *int, dayDivider *string) {
+	var key int
+	var sameGroup, newDay bool
+	var today string
+	err := db.QueryRowContext(ctx, `
+		SELECT COALESCE(group_key, id),
+			sender_nick = $2 AND type = 'message' AND created_at > LOCALTIMESTAMP - make_interval(secs => $3),
+			created_at::date <> CURRENT_DATE,
+			to_char(CURRENT_DATE, 'YYYY-MM-DD')
+		FROM messages
+		WHERE recipient_nick IS NULL AND parent_id IS NULL AND room_id IS NOT DISTINCT FROM $1
+		ORDER BY id DESC LIMIT 1`, roomID, sender, time.Duration(cfg.Grouping.Window).Seconds()).
+		Scan(&key, &sameGroup, &newDay, &today)
+	if err == sql.ErrNoRows {
+		today = time.Now().Format("2006-01-02")
+		return nil, &today
+	}
+	if err != nil { return nil, nil }
+	if newDay { return nil, &today }
+	if sameGroup && !isEvent { return &key, nil }
+	return nil, nil
+}
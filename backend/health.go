@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// [라이브니스] GET /healthz - 프로세스가 요청을 처리할 수 있으면 200
+func healthzHandler(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte("ok"))
+}
+
+// [레디니스] GET /readyz - DB와 메시지 브로커가 살아 있고 기록 캐시 예열이 끝나야 200
+// 하나라도 끊기면 503을 돌려 쿠버네티스가 이 파드로 트래픽을 보내지 않게 함
+func readyzHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
+	defer cancel()
+
+	checks := map[string]string{"db": "ok", "broker": "ok"}
+	ready := true
+	if err := db.PingContext(ctx); err != nil {
+		checks["db"] = err.Error()
+		ready = false
+	}
+	if broker == nil || !broker.Connected() {
+		checks["broker"] = "disconnected"
+		ready = false
+	}
+	checks["history_cache"] = "ok"
+	if !historyWarm.Load() {
+		checks["history_cache"] = "warming"
+		ready = false
+	}
+
+	status := http.StatusOK
+	if !ready { status = http.StatusServiceUnavailable }
+	writeJSON(w, status, map[string]any{"ready": ready, "checks": checks})
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// [하트비트] 스트림에 `event: ping`(conn, id)을 주기적으로 보내고 클라이언트가 POST /pong으로 되돌려 줌
+// 보낸 시각과 되돌아온 시각의 차이로 연결별 왕복 시간(RTT)을 재서 연결 메타데이터와 저하 판단에 씀
+// (WebSocket 전송은 아직 없어서 SSE + 에코 엔드포인트 방식만 있음)
+
+// 다른 파드로 들어온 pong을 연결을 가진 파드로 넘기는 브로커 주제
+const subjectPong = "chat.pong"
+
+// 측정값 평활 계수 (한 번 튄 값에 바로 저하되지 않도록)
+const rttSmoothing = 0.3
+
+var (
+	connsMu sync.Mutex
+	conns   = map[string]*connInfo{} // 이 파드의 스트림 연결 (연결 ID → 정보)
+
+	streamRTT = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "gotalk_stream_rtt_seconds",
+		Help:    "Round-trip time measured by stream ping/pong.",
+		Buckets: []float64{.01, .025, .05, .1, .25, .5, 1, 2, 5},
+	})
+)
+
+type connInfo struct {
+	ID          string
+	Nick        string
+	ConnectedAt time.Time
+	Lite        bool
+
+	reap      func()       // 연결을 강제로 끊음 (reapDeadConns)
+	lastWrite atomic.Int64 // 마지막으로 flush까지 끝난 시각 (unix nano)
+	pingID    atomic.Uint64
+	pingSent  atomic.Int64 // 마지막 ping을 보낸 시각 (unix nano)
+	rtt       atomic.Int64 // 평활된 RTT (ns), 아직 측정 전이면 0
+	degraded  atomic.Bool
+	dropped   atomic.Int64 // 채널이 밀려 버린 메시지 수 (slowclient.go)
+}
+
+// 연결 메타데이터 (GET /admin/clients, 파드 상태)
+type ConnMeta struct {
+	ID          string    `json:"id"`
+	Nick        string    `json:"nick"`
+	ConnectedAt time.Time `json:"connected_at"`
+	RTTMillis   *float64  `json:"rtt_ms,omitempty"`
+	Lite        bool      `json:"lite"`
+	Degraded    bool      `json:"degraded"`
+	Dropped     int64     `json:"dropped"`
+}
+
+func registerConn(id, nick string, lite bool, reap func()) *connInfo {
+	c := &connInfo{ID: id, Nick: nick, ConnectedAt: time.Now(), Lite: lite, reap: reap}
+	c.lastWrite.Store(c.ConnectedAt.UnixNano())
+	connsMu.Lock()
+	conns[id] = c
+	connsMu.Unlock()
+	return c
+}
+
+func unregisterConn(id string) {
+	connsMu.Lock()
+	delete(conns, id)
+	connsMu.Unlock()
+}
+
+func (c *connInfo) RTT() time.Duration { return time.Duration(c.rtt.Load()) }
+
+// 다음 ping 번호를 발급하고 보낸 시각 기록
+func (c *connInfo) nextPing() uint64 {
+	c.pingSent.Store(time.Now().UnixNano())
+	return c.pingID.Add(1)
+}
+
+// 가장 최근 ping에 대한 pong만 반영 (늦게 온 이전 pong은 무시)
+func (c *connInfo) recordPong(id uint64) bool {
+	if id != c.pingID.Load() { return false }
+	sample := time.Since(time.Unix(0, c.pingSent.Load()))
+	streamRTT.Observe(sample.Seconds())
+	prev := c.rtt.Load()
+	if prev == 0 {
+		c.rtt.Store(int64(sample))
+	} else {
+		c.rtt.Store(int64(rttSmoothing*float64(sample) + (1-rttSmoothing)*float64(prev)))
+	}
+	return true
+}
+
+func (c *connInfo) meta() ConnMeta {
+	m := ConnMeta{ID: c.ID, Nick: c.Nick, ConnectedAt: c.ConnectedAt, Lite: c.Lite, Degraded: c.degraded.Load(), Dropped: c.dropped.Load()}
+	if rtt := c.RTT(); rtt > 0 {
+		ms := float64(rtt.Microseconds()) / 1000
+		m.RTTMillis = &ms
+	}
+	return m
+}
+
+func localConns() []ConnMeta {
+	connsMu.Lock()
+	list := make([]ConnMeta, 0, len(conns))
+	for _, c := range conns {
+		list = append(list, c.meta())
+	}
+	connsMu.Unlock()
+	sort.Slice(list, func(i, j int) bool { return list[i].ConnectedAt.Before(list[j].ConnectedAt) })
+	return list
+}
+
+type pongEvent struct {
+	Conn string `json:"conn"`
+	ID   uint64 `json:"id"`
+}
+
+func applyPong(ev pongEvent) bool {
+	connsMu.Lock()
+	c := conns[ev.Conn]
+	connsMu.Unlock()
+	return c != nil && c.recordPong(ev.ID)
+}
+
+// [pong] POST /pong (conn, id) - 연결이 다른 파드에 있으면 브로커로 넘김
+func pongHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(r.FormValue("id"), 10, 64)
+	ev := pongEvent{Conn: r.FormValue("conn"), ID: id}
+	if err != nil || ev.Conn == "" { respondError(w, r, http.StatusBadRequest, errors.New("conn and id required")); return }
+	if !applyPong(ev) {
+		data, _ := json.Marshal(ev)
+		broker.Publish(r.Context(), subjectPong, data)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func handlePongEvent(m BrokerMsg) {
+	var ev pongEvent
+	if json.Unmarshal(m.Data, &ev) == nil { applyPong(ev) }
+}
+
+// 스트림 루프에서 ping 주기마다 호출
+func (s *streamWriter) ping() {
+	id := s.conn.nextPing()
+	s.write("event: ping\ndata: {\"conn\":%q,\"id\":%d}\n\n", s.conn.ID, id)
+	s.flush()
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+const hoursPerWeek = 7 * 24
+
+// 요일×시간 활동 분포 (UTC, 월요일 00시가 0번 칸)
+type Heatmap struct {
+	Days     int     `json:"days"`
+	Timezone string  `json:"timezone"`
+	Total    int64   `json:"total"`
+	Buckets  []int64 `json:"buckets"` // 길이 168, (요일-1)*24 + 시
+}
+
+// analytics_hourly 롤업에서 최근 days일치를 hour-of-week 칸으로 합산
+func loadHeatmap(metric, dimension string, days int) (Heatmap, error) {
+	hm := Heatmap{Days: days, Timezone: "UTC", Buckets: make([]int64, hoursPerWeek)}
+	rows, err := db.Query(`
+		SELECT ((EXTRACT(ISODOW FROM hour)::int - 1) * 24 + EXTRACT(HOUR FROM hour)::int), SUM(value)
+		FROM analytics_hourly
+		WHERE metric = $1 AND dimension = $2 AND hour >= CURRENT_TIMESTAMP - make_interval(days => $3)
+		GROUP BY 1`, metric, dimension, days)
+	if err != nil { return hm, err }
+	defer rows.Close()
+	for rows.Next() {
+		var bucket int
+		var n int64
+		rows.Scan(&bucket, &n)
+		if bucket >= 0 && bucket < hoursPerWeek {
+			hm.Buckets[bucket] = n
+			hm.Total += n
+		}
+	}
+	return hm, rows.Err()
+}
+
+func heatmapDays(r *http.Request) int {
+	if d := queryInt(r, "days", 0); d > 0 && d <= 365 { return d }
+	return 28
+}
+
+// [활동 히트맵] GET /users/{nick}/activity?days=28 - 프로필 페이지용 (DM 제외)
+func userActivityHandler(w http.ResponseWriter, r *http.Request) {
+	nick := r.PathValue("nick")
+	if !userExists(nick) || isUserDeleted(nick) { respondError(w, r, http.StatusNotFound, errors.New("user not found")); return }
+
+	hm, err := loadHeatmap("messages_by_user", nick, heatmapDays(r))
+	if err != nil { respondError(w, r, 500, err); return }
+	writeJSON(w, http.StatusOK, hm)
+}
+
+// [방 히트맵] GET /rooms/{id}/heatmap?days=28 - 비공개 방은 확인된 신원이 멤버일 때만
+func roomHeatmapHandler(w http.ResponseWriter, r *http.Request) {
+	roomID, ok := roomIDFrom(r)
+	if !ok { respondError(w, r, http.StatusBadRequest, errors.New("invalid room id")); return }
+	if !roomAccessOK(w, r, roomID) { return }
+
+	hm, err := loadHeatmap("messages_by_room", strconv.Itoa(roomID), heatmapDays(r))
+	if err != nil { respondError(w, r, 500, err); return }
+	writeJSON(w, http.StatusOK, hm)
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// [최근 기록 캐시] 방별 /history 첫 페이지를 파드 메모리에 보관
+// 새 메시지/이벤트/삭제가 방송되면 그 방 항목을 비우고, TTL은 놓친 무효화에 대한 안전장치
+type historyCache struct {
+	mu      sync.Mutex
+	entries map[string]*historyEntry
+}
+
+type historyEntry struct {
+	mu       sync.Mutex // 같은 방을 동시에 여러 요청이 채우지 않도록 (나머지는 기다렸다 결과 사용)
+	page     HistoryPage
+	loadedAt time.Time
+	valid    bool
+}
+
+var recentHistory = &historyCache{entries: map[string]*historyEntry{}}
+
+// 캐시 예열이 끝나야 /readyz가 200
+var historyWarm atomic.Bool
+
+func historyCacheKey(roomID *int) string {
+	if roomID == nil { return "lobby" }
+	return strconv.Itoa(*roomID)
+}
+
+func (c *historyCache) entry(key string) *historyEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e := c.entries[key]
+	if e == nil {
+		e = &historyEntry{}
+		c.entries[key] = e
+	}
+	return e
+}
+
+func (c *historyCache) get(ctx context.Context, roomID *int) (HistoryPage, error) {
+	e := c.entry(historyCacheKey(roomID))
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.valid && time.Since(e.loadedAt) < time.Duration(cfg.HistoryCache.TTL) {
+		historyCacheHits.Inc()
+		return e.page, nil
+	}
+	historyCacheMisses.Inc()
+	page, err := loadHistory(ctx, roomID, 0, 0)
+	if err != nil { return page, err }
+	e.page, e.loadedAt, e.valid = page, time.Now(), true
+	return page, nil
+}
+
+func (c *historyCache) invalidate(roomID *int) {
+	c.mu.Lock()
+	e := c.entries[historyCacheKey(roomID)]
+	c.mu.Unlock()
+	if e == nil { return }
+	e.mu.Lock()
+	e.valid = false
+	e.mu.Unlock()
+}
+
+// 모든 방 항목 무효화 (보낸 사람 정보가 바뀐 경우)
+func (c *historyCache) invalidateAll() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, e := range c.entries {
+		e.mu.Lock()
+		e.valid = false
+		e.mu.Unlock()
+	}
+}
+
+// 방송되는 타임라인 항목의 방 캐시를 비움 (DM은 /history에 없으므로 무시)
+func invalidateHistoryFor(data string) {
+	var m struct {
+		RoomID        *int   `json:"room_id"`
+		RecipientNick string `json:"recipient_nick"`
+	}
+	if json.Unmarshal([]byte(data), &m) != nil || m.RecipientNick != "" { return }
+	recentHistory.invalidate(m.RoomID)
+}
+
+// [캐시 예열] 배포 직후 첫 접속 폭주가 Postgres로 몰리지 않도록
+// 롤업 기준 최근 7일 가장 활발한 방들과 로비의 첫 페이지를 미리 채운 뒤 준비 완료로 표시
+func warmHistoryCache() {
+	defer historyWarm.Store(true)
+	start := time.Now()
+	ctx := context.Background()
+
+	rooms := []*int{nil}
+	rows, err := db.QueryContext(ctx, `
+		SELECT dimension::int FROM analytics_hourly
+		WHERE metric = 'messages_by_room' AND hour >= CURRENT_TIMESTAMP - INTERVAL '7 days'
+		GROUP BY dimension ORDER BY SUM(value) DESC LIMIT $1`, cfg.HistoryCache.WarmRooms)
+	if err != nil { slog.Warn("history cache warm query failed", "err", err) }
+	if err == nil {
+		for rows.Next() {
+			var id int
+			rows.Scan(&id)
+			rooms = append(rooms, &id)
+		}
+		rows.Close()
+	}
+
+	for _, roomID := range rooms {
+		if _, err := recentHistory.get(ctx, roomID); err != nil {
+			slog.Warn("history cache warm failed", "room", historyCacheKey(roomID), "err", err)
+		}
+	}
+	slog.Info("history cache warmed", "rooms", len(rooms), "duration", time.Since(start))
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// [수신 웹훅] POST /hooks/{token} {"text": "..."} 로 CI/모니터링 도구가 클라이언트 없이 글을 올림
+// 토큰 하나가 방 하나(또는 로비)와 봇 이름 하나에 묶이고, 메시지는 sender_type=bot으로 저장/방송
+// 방 토큰은 방장/운영진이 /rooms/{id}/hooks로, 로비 토큰은 관리자가 /admin/hooks로 만듦
+// 토큰이 곧 자격 증명이라 인증 미들웨어를 거치지 않고, 토큰마다 메시지 한도(rate_limit.messages)를 적용
+const (
+	senderTypeBot = "bot"
+
+	hookMaxName    = 32
+	hookMaxMessage = 4000
+)
+
+type IncomingHook struct {
+	Token      string     `json:"token"`
+	Name       string     `json:"name"`
+	RoomID     *int       `json:"room_id,omitempty"`
+	CreatedBy  string     `json:"created_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	URL        string     `json:"url,omitempty"` // 만들 때만 (외부 도구에 넣을 전체 주소)
+}
+
+// 수신 본문 (Slack 호환 text, content도 허용)
+type hookPayload struct {
+	Text    string `json:"text"`
+	Content string `json:"content"`
+}
+
+// [수신 웹훅 글쓰기] POST /hooks/{token}
+func incomingHookHandler(w http.ResponseWriter, r *http.Request) {
+	token := r.PathValue("token")
+	var name string
+	var roomID *int
+	err := db.QueryRow("SELECT name, room_id FROM incoming_hooks WHERE token = $1 AND revoked_at IS NULL", token).Scan(&name, &roomID)
+	if err == sql.ErrNoRows { respondError(w, r, http.StatusNotFound, errors.New("unknown hook")); return }
+	if err != nil { respondError(w, r, 500, err); return }
+
+	if messageLimiter.enabled() {
+		st := messageLimiter.take("hook:" + token)
+		setRateLimitHeaders(w, st)
+		if !st.Allowed { respondError(w, r, http.StatusTooManyRequests, errRateLimited); return }
+	}
+
+	content, status, err := readHookPayload(r)
+	if err != nil { respondError(w, r, status, err); return }
+
+	ctx := r.Context()
+	msg, err := postBotMessage(ctx, roomID, name, content)
+	if err != nil { respondError(w, r, http.StatusServiceUnavailable, err); return }
+	db.Exec("UPDATE incoming_hooks SET last_used_at = CURRENT_TIMESTAMP WHERE token = $1", token)
+	// 봇 알림의 @멘션은 살리되, 나가는 웹훅으로는 보내지 않음 (수신↔발신 웹훅 루프 방지)
+	notifyMentions(msg)
+	slog.InfoContext(ctx, "hook message posted", "hook", name, "room_id", roomID, "message_id", msg.ID)
+	writeJSON(w, http.StatusCreated, map[string]int{"id": msg.ID})
+}
+
+// 봇 이름으로 글을 저장하고 방송 (수신 웹훅, 타이머 등 서버가 올리는 글)
+func postBotMessage(ctx context.Context, roomID *int, name, content string) (Message, error) {
+	groupKey, dayDivider := groupingHints(ctx, roomID, name, false)
+	msg := Message{
+		Type: messageTypeText, Content: content, HTML: renderContent(content), SenderPod: nodeID, SenderNick: name, SenderColor: "#ffffff",
+		SenderType: senderTypeBot, Time: time.Now().Format("15:04:05"), RoomID: roomID,
+	}
+	err := db.QueryRowContext(ctx, `
+		INSERT INTO messages (content, sender_pod, sender_nick, room_id, group_key, day_divider, sender_type)
+		VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`,
+		content, nodeID, name, roomID, groupKey, dayDivider, senderTypeBot).Scan(&msg.ID)
+	if err != nil { countSendError(roomID, "store"); return msg, err }
+	msg.GroupKey = msg.ID
+	if groupKey != nil { msg.GroupKey = *groupKey }
+	if dayDivider != nil { msg.DayDivider = *dayDivider }
+	data, _ := json.Marshal(msg)
+	if err := publishChat(ctx, data); err != nil { countSendError(roomID, "publish"); return msg, err }
+	messagesSent.WithLabelValues(roomLabel(roomID, false)).Inc()
+	return msg, nil
+}
+
+// 봇 이름은 기존 사용자/시스템 계정과 겹치지 않게
+func validHookName(name string) error {
+	if name == "" || utf8.RuneCountInString(name) > hookMaxName { return fieldError{"name", "required, up to 32 characters"} }
+	if strings.HasPrefix(name, guestNickPrefix) || isReservedNick(name) || userExists(name) { return fieldError{"name", "already used by a user"} }
+	return nil
+}
+
+func createHook(r *http.Request, roomID *int, createdBy string) (IncomingHook, error) {
+	h := IncomingHook{Token: newEmbedToken(), Name: strings.TrimSpace(r.FormValue("name")), RoomID: roomID, CreatedBy: createdBy}
+	if err := validHookName(h.Name); err != nil { return h, err }
+	err := db.QueryRow("INSERT INTO incoming_hooks (token, name, room_id, created_by) VALUES ($1, $2, $3, $4) RETURNING created_at",
+		h.Token, h.Name, roomID, createdBy).Scan(&h.CreatedAt)
+	h.URL = baseURL(r) + "/hooks/" + h.Token
+	return h, err
+}
+
+func listHooks(w http.ResponseWriter, r *http.Request, query string, args ...any) {
+	rows, err := db.Query("SELECT token, name, room_id, created_by, created_at, last_used_at FROM incoming_hooks WHERE revoked_at IS NULL AND "+query+" ORDER BY created_at", args...)
+	if err != nil { respondError(w, r, 500, err); return }
+	defer rows.Close()
+	list := []IncomingHook{}
+	for rows.Next() {
+		var h IncomingHook
+		rows.Scan(&h.Token, &h.Name, &h.RoomID, &h.CreatedBy, &h.CreatedAt, &h.LastUsedAt)
+		list = append(list, h)
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+func revokeHook(w http.ResponseWriter, r *http.Request, query string, args ...any) {
+	res, err := db.Exec("UPDATE incoming_hooks SET revoked_at = CURRENT_TIMESTAMP WHERE revoked_at IS NULL AND "+query, args...)
+	if err != nil { respondError(w, r, 500, err); return }
+	if n, _ := res.RowsAffected(); n == 0 { respondError(w, r, http.StatusNotFound, errors.New("hook not found")); return }
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// [방 수신 웹훅 생성] POST /rooms/{id}/hooks (nick, name) - 방장/운영진
+func createRoomHookHandler(w http.ResponseWriter, r *http.Request) {
+	roomID, ok := roomIDFrom(r)
+	nick := r.FormValue("nick")
+	if !ok || nick == "" { respondError(w, r, http.StatusBadRequest, errors.New("room id and nick required")); return }
+	if !canManageEmbeds(roomID, nick) { respondError(w, r, http.StatusForbidden, errors.New("only owners and moderators can manage hooks")); return }
+	if err := requireVerifiedEmail(nick); err != nil { respondError(w, r, http.StatusForbidden, err); return }
+	h, err := createHook(r, &roomID, nick)
+	if err != nil { respondError(w, r, http.StatusBadRequest, err); return }
+	slog.InfoContext(r.Context(), "incoming hook created", "room_id", roomID, "nick", nick, "name", h.Name)
+	writeJSON(w, http.StatusCreated, h)
+}
+
+// [방 수신 웹훅 목록] GET /rooms/{id}/hooks?nick=
+func listRoomHooksHandler(w http.ResponseWriter, r *http.Request) {
+	roomID, ok := roomIDFrom(r)
+	nick := r.URL.Query().Get("nick")
+	if !ok || nick == "" { respondError(w, r, http.StatusBadRequest, errors.New("room id and nick required")); return }
+	if !canManageEmbeds(roomID, nick) { respondError(w, r, http.StatusForbidden, errors.New("only owners and moderators can manage hooks")); return }
+	listHooks(w, r, "room_id = $1", roomID)
+}
+
+// [방 수신 웹훅 폐기] DELETE /rooms/{id}/hooks/{token}?nick=
+func revokeRoomHookHandler(w http.ResponseWriter, r *http.Request) {
+	roomID, ok := roomIDFrom(r)
+	nick := r.URL.Query().Get("nick")
+	if !ok || nick == "" { respondError(w, r, http.StatusBadRequest, errors.New("room id and nick required")); return }
+	if !canManageEmbeds(roomID, nick) { respondError(w, r, http.StatusForbidden, errors.New("only owners and moderators can manage hooks")); return }
+	revokeHook(w, r, "token = $1 AND room_id = $2", r.PathValue("token"), roomID)
+}
+
+// [로비 수신 웹훅] POST /admin/hooks (name), GET /admin/hooks, DELETE /admin/hooks/{token}
+func adminCreateHookHandler(w http.ResponseWriter, r *http.Request) {
+	h, err := createHook(r, nil, "admin")
+	if err != nil { respondError(w, r, http.StatusBadRequest, err); return }
+	writeJSON(w, http.StatusCreated, h)
+}
+
+func adminHooksHandler(w http.ResponseWriter, r *http.Request) { listHooks(w, r, "room_id IS NULL") }
+
+func adminRevokeHookHandler(w http.ResponseWriter, r *http.Request) {
+	revokeHook(w, r, "token = $1 AND room_id IS NULL", r.PathValue("token"))
+}
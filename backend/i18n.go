@@ -0,0 +1,41 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+)
+
+// [다국어] 서버가 보내는 문구의 로케일별 카탈로그
+// 설정(i18n.default_locale)에 없는 로케일은 기본 로케일로 대체
+var catalog = map[string]map[string]string{
+	"en": {
+		"welcome.dm": "Welcome to GoTalk, {{.Nick}}! 👋" +
+			"{{if .Links}}\nGetting started:{{range .Links}}\n• {{.Title}}: {{.URL}}{{end}}{{end}}" +
+			"{{if .Rooms}}\nRooms you might like: {{join .Rooms \", \"}}{{end}}",
+	},
+	"ko": {
+		"welcome.dm": "{{.Nick}}님, GoTalk에 오신 것을 환영합니다! 👋" +
+			"{{if .Links}}\n시작하기:{{range .Links}}\n• {{.Title}}: {{.URL}}{{end}}{{end}}" +
+			"{{if .Rooms}}\n추천 방: {{join .Rooms \", \"}}{{end}}",
+	},
+}
+
+// 요청 로케일 결정: ?locale= → Accept-Language → 기본 로케일
+func resolveLocale(r *http.Request) string {
+	if l := r.URL.Query().Get("locale"); l != "" {
+		if _, ok := catalog[l]; ok { return l }
+	}
+	for _, part := range strings.Split(r.Header.Get("Accept-Language"), ",") {
+		tag, _, _ := strings.Cut(strings.TrimSpace(part), ";")
+		lang, _, _ := strings.Cut(strings.ToLower(tag), "-")
+		if _, ok := catalog[lang]; ok { return lang }
+	}
+	return cfg.I18n.DefaultLocale
+}
+
+// 카탈로그 조회 (로케일에 없으면 기본 로케일, 그래도 없으면 키 그대로)
+func translate(locale, key string) string {
+	if s, ok := catalog[locale][key]; ok { return s }
+	if s, ok := catalog[cfg.I18n.DefaultLocale][key]; ok { return s }
+	return key
+}
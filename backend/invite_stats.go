@@ -0,0 +1,69 @@
+package main
+
+import (
+	"net/http"
+)
+
+type InviterStats struct {
+	Inviter        string `json:"inviter"`
+	InvitesCreated int    `json:"invites_created"`
+	Signups        int    `json:"signups"`
+	ActiveUsers    int    `json:"active_users"` // 가입 후 메시지를 한 번이라도 보낸 사용자
+}
+
+type InviteStatsResponse struct {
+	TotalSignups   int            `json:"total_signups"`
+	OrganicSignups int            `json:"organic_signups"`
+	ByInviter      []InviterStats `json:"by_inviter"`
+	Daily          []DailyCount   `json:"daily"`
+}
+
+type DailyCount struct {
+	Day       string `json:"day"`
+	Dimension string `json:"dimension"`
+	Value     int    `json:"value"`
+}
+
+// [추천 통계] GET /admin/invites/stats?days=30
+// 초대자별 가입/활성 전환과 롤업 기반 일별 가입 경로를 반환
+func inviteStatsHandler(w http.ResponseWriter, r *http.Request) {
+	days := 30
+	if d := queryInt(r, "days", 0); d > 0 && d <= 365 { days = d }
+
+	var resp InviteStatsResponse
+	db.QueryRow(`SELECT COUNT(*), COUNT(*) FILTER (WHERE invite_code IS NULL) FROM users WHERE deleted_at IS NULL`).
+		Scan(&resp.TotalSignups, &resp.OrganicSignups)
+
+	rows, err := db.Query(`
+		SELECT i.created_by,
+			COUNT(DISTINCT i.code),
+			COUNT(DISTINCT u.nickname),
+			COUNT(DISTINCT u.nickname) FILTER (WHERE EXISTS (SELECT 1 FROM messages m WHERE m.sender_nick = u.nickname AND m.type = 'message'))
+		FROM invites i
+		LEFT JOIN users u ON u.invite_code = i.code
+		GROUP BY i.created_by
+		ORDER BY 3 DESC, 1`)
+	if err != nil { respondError(w, r, 500, err); return }
+	defer rows.Close()
+	resp.ByInviter = []InviterStats{}
+	for rows.Next() {
+		var s InviterStats
+		rows.Scan(&s.Inviter, &s.InvitesCreated, &s.Signups, &s.ActiveUsers)
+		resp.ByInviter = append(resp.ByInviter, s)
+	}
+
+	daily, err := db.Query(`
+		SELECT to_char(day, 'YYYY-MM-DD'), dimension, value FROM analytics_daily
+		WHERE metric = 'signups' AND day >= CURRENT_DATE - $1::int
+		ORDER BY day, dimension`, days)
+	if err != nil { respondError(w, r, 500, err); return }
+	defer daily.Close()
+	resp.Daily = []DailyCount{}
+	for daily.Next() {
+		var c DailyCount
+		daily.Scan(&c.Day, &c.Dimension, &c.Value)
+		resp.Daily = append(resp.Daily, c)
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// 가입 방식 (REGISTRATION_MODE): open = 닉네임만으로 자동 가입, invite = 초대 코드 필수
+const (
+	registrationOpen   = "open"
+	registrationInvite = "invite"
+)
+
+type Invite struct {
+	Code      string     `json:"code"`
+	CreatedBy string     `json:"created_by"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	MaxUses   int        `json:"max_uses"`
+	Uses      int        `json:"uses"`
+}
+
+func userExists(nick string) bool {
+	var exists bool
+	db.QueryRow("SELECT EXISTS(SELECT 1 FROM users WHERE nickname = $1)", nick).Scan(&exists)
+	return exists
+}
+
+// 처음 보는 닉네임을 암묵적으로 가입시켜도 되는지 (/send, /update의 UPSERT 경로)
+func canAutoRegister(nick string) bool {
+	return cfg.Registration.Mode == registrationOpen || userExists(nick)
+}
+
+func newInviteCode() string {
+	b := make([]byte, 6)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func insertInvite(createdBy string, maxUses int, ttl time.Duration) (Invite, error) {
+	inv := Invite{Code: newInviteCode(), CreatedBy: createdBy, MaxUses: maxUses}
+	var expiresAt *time.Time
+	if ttl > 0 {
+		t := time.Now().Add(ttl)
+		expiresAt = &t
+	}
+	err := db.QueryRow(`
+		INSERT INTO invites (code, created_by, expires_at, max_uses) VALUES ($1, $2, $3, $4)
+		RETURNING created_at, expires_at`, inv.Code, createdBy, expiresAt, maxUses).Scan(&inv.CreatedAt, &inv.ExpiresAt)
+	return inv, err
+}
+
+// 폼의 max_uses/ttl 파싱 (없으면 1회용, 기본 유효 기간)
+func parseInviteOptions(r *http.Request) (int, time.Duration, bool) {
+	maxUses, ttl := 1, time.Duration(cfg.Registration.InviteTTL)
+	if v := r.FormValue("max_uses"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 { return 0, 0, false }
+		maxUses = n
+	}
+	if v := r.FormValue("ttl"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 { return 0, 0, false }
+		ttl = d
+	}
+	return maxUses, ttl, true
+}
+
+// [초대 생성] POST /invites (nick, max_uses?, ttl?) - 기존 사용자만, 할당량 내에서
+func createInviteHandler(w http.ResponseWriter, r *http.Request) {
+	nick := r.FormValue("nick")
+	if nick == "" { respondError(w, r, http.StatusBadRequest, errors.New("nick required")); return }
+	if !userExists(nick) || isUserDeleted(nick) { respondError(w, r, http.StatusForbidden, errors.New("only registered users can invite")); return }
+	maxUses, ttl, ok := parseInviteOptions(r)
+	if !ok { respondError(w, r, http.StatusBadRequest, errors.New("invalid max_uses or ttl")); return }
+	// 일반 사용자는 유효 기간 없는 초대를 만들 수 없음
+	if maxTTL := time.Duration(cfg.Registration.InviteTTL); ttl == 0 || ttl > maxTTL { ttl = maxTTL }
+
+	if activeInviteCount(nick) >= cfg.Registration.InviteQuota { respondError(w, r, http.StatusTooManyRequests, errors.New("invite quota exceeded")); return }
+
+	inv, err := insertInvite(nick, maxUses, ttl)
+	if err != nil { respondError(w, r, 500, err); return }
+	writeJSON(w, http.StatusCreated, inv)
+}
+
+// 아직 쓸 수 있는(남은 횟수, 유효 기간) 초대 수
+func activeInviteCount(nick string) int {
+	var active int
+	db.QueryRow(`
+		SELECT COUNT(*) FROM invites
+		WHERE created_by = $1 AND uses < max_uses AND (expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP)`,
+		nick).Scan(&active)
+	return active
+}
+
+// [내 초대 목록] GET /invites?nick=
+func listInvitesHandler(w http.ResponseWriter, r *http.Request) {
+	nick := r.URL.Query().Get("nick")
+	if nick == "" { respondError(w, r, http.StatusBadRequest, errors.New("nick required")); return }
+	rows, err := db.Query(`
+		SELECT code, created_by, created_at, expires_at, max_uses, uses FROM invites
+		WHERE created_by = $1 ORDER BY created_at DESC`, nick)
+	if err != nil { respondError(w, r, 500, err); return }
+	defer rows.Close()
+
+	invites := []Invite{}
+	for rows.Next() {
+		var inv Invite
+		rows.Scan(&inv.Code, &inv.CreatedBy, &inv.CreatedAt, &inv.ExpiresAt, &inv.MaxUses, &inv.Uses)
+		invites = append(invites, inv)
+	}
+	writeJSON(w, http.StatusOK, invites)
+}
+
+// [관리자 초대 생성] POST /admin/invites (max_uses?, ttl?) - 할당량 없음, ttl=0이면 무기한
+func adminCreateInviteHandler(w http.ResponseWriter, r *http.Request) {
+	maxUses, ttl, ok := parseInviteOptions(r)
+	if !ok { respondError(w, r, http.StatusBadRequest, errors.New("invalid max_uses or ttl")); return }
+	inv, err := insertInvite("admin", maxUses, ttl)
+	if err != nil { respondError(w, r, 500, err); return }
+	writeJSON(w, http.StatusCreated, inv)
+}
+
+// 초대 코드 사용 처리 (만료/소진 코드면 sql.ErrNoRows)
+func redeemInvite(tx *sql.Tx, code string) error {
+	var redeemed string
+	return tx.QueryRow(`
+		UPDATE invites SET uses = uses + 1
+		WHERE code = $1 AND uses < max_uses AND (expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP)
+		RETURNING code`, code).Scan(&redeemed)
+}
+
+// [가입] POST /register (nick, color?, invite_code?, password?) - invite 모드면 초대 코드 필수
+// 비밀번호를 주면(auth.provider=password거나 익명 접속을 끈 none이면 필수) 그 닉네임은 보호되고 바로 로그인 세션을 받음
+func registerHandler(w http.ResponseWriter, r *http.Request) {
+	nick := r.FormValue("nick")
+	color := r.FormValue("color")
+	code := r.FormValue("invite_code")
+	password := r.FormValue("password")
+	if nick == "" { respondError(w, r, http.StatusBadRequest, errors.New("nick required")); return }
+	if color == "" { color = assignedColor(nick) }
+	if isReservedNick(nick) { respondError(w, r, http.StatusForbidden, errors.New("nickname is reserved")); return }
+	if cfg.Registration.Mode == registrationInvite && code == "" { respondError(w, r, http.StatusForbidden, errors.New("invite code required")); return }
+	// password 방식이거나 none에서 익명 접속을 끄면 비밀번호 없이 만든 닉네임으로는 로그인할 수 없음
+	if (authProvider.Name() == authProviderPassword || (authProvider.Name() == authProviderNone && !cfg.Auth.AllowAnonymous)) && password == "" { respondError(w, r, http.StatusBadRequest, errors.New("password required")); return }
+	var passwordHash *string
+	if password != "" {
+		h, err := hashPassword(password)
+		if err != nil { respondError(w, r, http.StatusBadRequest, err); return }
+		passwordHash = &h
+	}
+
+	tx, err := db.Begin()
+	if err != nil { respondError(w, r, 500, err); return }
+	defer tx.Rollback()
+
+	// 초대 코드를 먼저 소진해야 users.invite_code 참조가 유효함
+	var inviteCode *string
+	if code != "" {
+		err = redeemInvite(tx, code)
+		if err == sql.ErrNoRows { respondError(w, r, http.StatusForbidden, errors.New("invalid or expired invite code")); return }
+		if err != nil { respondError(w, r, 500, err); return }
+		inviteCode = &code
+	}
+
+	res, err := tx.Exec(`INSERT INTO users (nickname, color_code, invite_code, password_hash) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (nickname) DO NOTHING`, nick, color, inviteCode, passwordHash)
+	if err != nil { respondError(w, r, 500, err); return }
+	if n, _ := res.RowsAffected(); n == 0 { respondError(w, r, http.StatusConflict, errors.New("nickname already taken")); return }
+	if err := tx.Commit(); err != nil { respondError(w, r, 500, err); return }
+	// 가입은 끝났으니 세션 발급에 실패하면 로그인부터 다시 하게 둠
+	if passwordHash != nil {
+		if _, err := issueSession(w, r, nick); err != nil { slog.WarnContext(r.Context(), "session after registration failed", "nick", nick, "err", err) }
+	}
+
+	slog.InfoContext(r.Context(), "user registered", "nick", nick, "invite", code)
+	writeJSON(w, http.StatusCreated, User{Nickname: nick, ColorCode: color})
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// [경량 페이로드] 모바일 등 대역폭이 좁은 클라이언트용 최소 필드
+// 표시용 부가 정보(색상, 파드, 이벤트 상세, 앞으로 붙을 렌더링 HTML·리액션·아바타 등)는 빼고
+// 타임라인을 그리는 데 꼭 필요한 필드만 허용 목록으로 남김 (필드 이름은 Message와 같음)
+type LiteMessage struct {
+	ID            int    `json:"id"`
+	Type          string `json:"type"`
+	SenderNick    string `json:"sender_nick"`
+	Content       string `json:"content"`
+	Time          string `json:"time"`
+	ParentID      *int   `json:"parent_id,omitempty"`
+	RoomID        *int   `json:"room_id,omitempty"`
+	RecipientNick string `json:"recipient_nick,omitempty"`
+	Seq           uint64 `json:"seq,omitempty"`
+	GroupKey      int    `json:"group_key,omitempty"`
+	DayDivider    string `json:"day_divider,omitempty"`
+}
+
+func liteMessage(m Message) LiteMessage {
+	return LiteMessage{
+		ID: m.ID, Type: m.Type, SenderNick: m.SenderNick, Content: m.Content, Time: m.Time,
+		ParentID: m.ParentID, RoomID: m.RoomID, RecipientNick: m.RecipientNick, Seq: m.Seq,
+		GroupKey: m.GroupKey, DayDivider: m.DayDivider,
+	}
+}
+
+// 방송 페이로드의 경량 버전 (Message가 아니면 원본 그대로)
+func liteData(data string) string {
+	var m Message
+	if json.Unmarshal([]byte(data), &m) != nil || m.ID == 0 { return data }
+	b, _ := json.Marshal(liteMessage(m))
+	return string(b)
+}
+
+// ?fields=minimal 또는 ?lite=1
+func wantsLite(r *http.Request) bool {
+	q := r.URL.Query()
+	return q.Get("fields") == "minimal" || q.Get("lite") == "1" || q.Get("lite") == "true"
+}
+
+type LiteHistoryPage struct {
+	Messages   []LiteMessage `json:"messages"`
+	HasMore    bool          `json:"has_more"`
+	NextCursor *int          `json:"next_cursor"`
+}
+
+func litePage(p HistoryPage) LiteHistoryPage {
+	out := LiteHistoryPage{Messages: make([]LiteMessage, len(p.Messages)), HasMore: p.HasMore, NextCursor: p.NextCursor}
+	for i, m := range p.Messages {
+		out.Messages[i] = liteMessage(m)
+	}
+	return out
+}
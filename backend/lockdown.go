@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// [방 잠금] 습격(raid) 대응용: 운영진이 POST /rooms/{id}/lockdown 한 번으로 방을 잠그면 소유자/운영진만 글을 쓸 수 있음
+// 잠금/해제는 타임라인 이벤트로 남고 브로커를 거쳐 모든 파드의 연결에 바로 방송됨
+// 글쓰기 확인은 매번 rooms.locked_until을 읽으므로 어느 파드로 들어온 요청이든 즉시 막힘 (파드별 캐시 없음)
+// 기간(기본 lockdown.default_duration, 최대 lockdown.max_duration)이 지나면 스케줄러가 풀고 해제 알림을 올림
+const (
+	eventLockdown = "lockdown"
+	eventUnlock   = "unlock"
+)
+
+var (
+	errRoomLocked       = errors.New("room is locked; only moderators can post")
+	errRoomAnnouncement = errors.New("announcement room; only moderators can post")
+)
+
+// 잠긴 방이나 공지 방(permissions.go)에 일반 멤버가 쓰려 하면 errRoomLocked/errRoomAnnouncement
+func checkRoomLock(ctx context.Context, roomID *int, nick string) error {
+	if roomID == nil || !fullFeatureStore() { return nil }
+	var locked, announcement bool
+	db.QueryRowContext(ctx, "SELECT COALESCE(locked_until > CURRENT_TIMESTAMP, FALSE), announcement FROM rooms WHERE id = $1", *roomID).Scan(&locked, &announcement)
+	if !locked && !announcement { return nil }
+	if role := roomRole(*roomID, nick); role == roomRoleOwner || role == roomRoleModerator { return nil }
+	if locked { return errRoomLocked }
+	return errRoomAnnouncement
+}
+
+// [방 잠금] POST /rooms/{id}/lockdown (nick, duration=15m) - 소유자/운영진, 이미 잠겨 있으면 기간을 새로 정함
+func lockRoomHandler(w http.ResponseWriter, r *http.Request) {
+	roomID, ok := roomIDFrom(r)
+	nick := r.FormValue("nick")
+	if !ok || nick == "" { respondError(w, r, http.StatusBadRequest, errors.New("room id and nick required")); return }
+	if role := roomRole(roomID, nick); role != roomRoleOwner && role != roomRoleModerator {
+		respondError(w, r, http.StatusForbidden, errors.New("only owners and moderators can lock the room"))
+		return
+	}
+	d := time.Duration(cfg.Lockdown.DefaultDuration)
+	if v := r.FormValue("duration"); v != "" {
+		var err error
+		if d, err = time.ParseDuration(v); err != nil || d < time.Minute || d > time.Duration(cfg.Lockdown.MaxDuration) {
+			respondError(w, r, http.StatusBadRequest, fieldError{"duration", "must be between 1m and " + time.Duration(cfg.Lockdown.MaxDuration).String()})
+			return
+		}
+	}
+
+	until := time.Now().Add(d)
+	res, err := db.ExecContext(r.Context(), "UPDATE rooms SET locked_until = $2, locked_by = $3 WHERE id = $1", roomID, until, nick)
+	if err != nil { respondError(w, r, 500, err); return }
+	if n, _ := res.RowsAffected(); n == 0 { respondError(w, r, http.StatusNotFound, errors.New("room not found")); return }
+	ev := RoomEvent{Kind: eventLockdown, Actor: nick, Data: map[string]any{"until": until.UTC().Format(time.RFC3339), "duration": d.String()}}
+	recordEvent(r.Context(), &roomID, ev, "🔒 "+nick+" locked the room for "+d.String()+"; only moderators can post")
+	slog.InfoContext(r.Context(), "room locked", "room_id", roomID, "nick", nick, "duration", d)
+	writeJSON(w, http.StatusOK, map[string]any{"room_id": roomID, "locked_until": until.UTC()})
+}
+
+// [방 잠금 해제] DELETE /rooms/{id}/lockdown?nick= - 소유자/운영진
+func unlockRoomHandler(w http.ResponseWriter, r *http.Request) {
+	roomID, ok := roomIDFrom(r)
+	nick := r.URL.Query().Get("nick")
+	if !ok || nick == "" { respondError(w, r, http.StatusBadRequest, errors.New("room id and nick required")); return }
+	if role := roomRole(roomID, nick); role != roomRoleOwner && role != roomRoleModerator {
+		respondError(w, r, http.StatusForbidden, errors.New("only owners and moderators can unlock the room"))
+		return
+	}
+	res, err := db.ExecContext(r.Context(), "UPDATE rooms SET locked_until = NULL, locked_by = NULL WHERE id = $1 AND locked_until > CURRENT_TIMESTAMP", roomID)
+	if err != nil { respondError(w, r, 500, err); return }
+	if n, _ := res.RowsAffected(); n == 0 { respondError(w, r, http.StatusConflict, errors.New("room is not locked")); return }
+	recordEvent(r.Context(), &roomID, RoomEvent{Kind: eventUnlock, Actor: nick}, "🔓 "+nick+" unlocked the room")
+	slog.InfoContext(r.Context(), "room unlocked", "room_id", roomID, "nick", nick)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// 기간이 지난 잠금을 풀고 알림 (먼저 비우고 가져가므로 파드가 여럿이어도 한 번만 알림)
+func expireLockdowns() {
+	ctx := context.Background()
+	rows, err := db.QueryContext(ctx, `UPDATE rooms SET locked_until = NULL, locked_by = NULL
+		WHERE locked_until <= CURRENT_TIMESTAMP RETURNING id`)
+	if err != nil { slog.Warn("lockdown expiry failed", "err", err); return }
+	var ids []int
+	for rows.Next() {
+		var id int
+		rows.Scan(&id)
+		ids = append(ids, id)
+	}
+	rows.Close()
+	for _, id := range ids {
+		roomID := id
+		recordEvent(ctx, &roomID, RoomEvent{Kind: eventUnlock, Actor: systemNick()}, "🔓 The room lockdown ended")
+		slog.Info("room lockdown expired", "room_id", id)
+	}
+}
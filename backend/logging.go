@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// [구조화 로그] Loki/ELK에서 바로 파싱할 수 있도록 log/slog 사용
+// 공통 필드: pod/node (전역, 호스트 이름은 로그에만), request_id/trace_id (요청 컨텍스트), nick/message_id (호출부)
+func initLogger() {
+	var level slog.Level
+	level.UnmarshalText([]byte(cfg.Log.Level)) // validate에서 이미 검증
+
+	opts := &slog.HandlerOptions{Level: level}
+	var h slog.Handler
+	if cfg.Log.Format == "json" {
+		h = slog.NewJSONHandler(os.Stdout, opts)
+	} else {
+		h = slog.NewTextHandler(os.Stdout, opts)
+	}
+	slog.SetDefault(slog.New(contextHandler{h.WithAttrs([]slog.Attr{slog.String("pod", hostname), slog.String("node", nodeID)})}))
+}
+
+func validLogLevel(s string) bool {
+	var l slog.Level
+	return l.UnmarshalText([]byte(s)) == nil
+}
+
+// 컨텍스트에 실린 request_id와 trace_id를 레코드에 자동으로 붙이는 핸들러
+type contextHandler struct{ slog.Handler }
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := requestIDFrom(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(slog.String("trace_id", sc.TraceID().String()))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+// 기동 실패 등 복구 불가능한 오류: 에러 로그를 남기고 종료
+func fatal(msg string, err error) {
+	slog.Error(msg, "err", err)
+	os.Exit(1)
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// [메일 발송] 인증/복구 메일을 email.smtp_addr로 보냄 (STARTTLS는 서버가 지원하면 net/smtp가 알아서 씀)
+// smtp_addr를 비우면 보내지 않고 본문을 로그에 남김 (개발용, 링크를 로그에서 복사해 씀)
+func sendMail(to, subject, body string) error {
+	if cfg.Email.SMTPAddr == "" {
+		slog.Info("mail not sent (email.smtp_addr unset)", "to", to, "subject", subject, "body", body)
+		return nil
+	}
+	var auth smtp.Auth
+	if cfg.Email.Username != "" {
+		host, _, _ := net.SplitHostPort(cfg.Email.SMTPAddr)
+		auth = smtp.PlainAuth("", cfg.Email.Username, cfg.Email.Password, host)
+	}
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\nTo: %s\r\nSubject: %s\r\nDate: %s\r\n", cfg.Email.From, to, subject, time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	if err := smtp.SendMail(cfg.Email.SMTPAddr, auth, cfg.Email.From, []string{to}, []byte(msg.String())); err != nil { return err }
+	slog.Info("mail sent", "to", to, "subject", subject)
+	return nil
+}
@@ -1,28 +1,30 @@
 package main
 
 import (
+	"errors"
+	"context"
 	"database/sql"
 	"encoding/json"
-	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
+	"slices"
 	"strconv"
 	"sync"
 	"time"
 
 	_ "github.com/lib/pq"
-	"github.com/nats-io/nats.go"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 var (
-	nc       *nats.Conn
 	db       *sql.DB
 	hostname string
 	
 	// [수정] 채널 버퍼를 늘려 막힘 방지
-	clients   = make(map[chan string]bool)
-	broadcast = make(chan string, 100) 
+	clients   = make(map[chan outbound]*streamClient) // 클라이언트 채널 → 연결 (slowclient.go)
+	broadcast chan outbound // 버퍼 크기는 broadcast_buffer 설정
 	mutex     = sync.Mutex{}
 )
 
@@ -30,100 +32,325 @@ var (
 type Message struct {
 	ID          int    `json:"id"`
 	Content     string `json:"content"`
+	HTML        string `json:"html,omitempty"` // 렌더링된 본문 (render.go)
 	SenderPod   string `json:"sender_pod"`
 	SenderNick  string `json:"sender_nick"`
 	SenderColor string `json:"sender_color"`
+	SenderType  string `json:"sender_type,omitempty"` // 봇이면 bot (사람이면 생략)
+	AvatarURL   string `json:"avatar_url,omitempty"`
 	Time        string `json:"time"`
+	ParentID    *int   `json:"parent_id,omitempty"`   // 스레드 답글이면 원글 ID
+	ReplyCount  int    `json:"reply_count,omitempty"` // 원글이면 답글 수, 답글이면 소속 스레드의 현재 답글 수
+	Seq         uint64 `json:"seq,omitempty"`         // JetStream 스트림 시퀀스 (누락 감지용)
+	RecipientNick string `json:"recipient_nick,omitempty"` // DM이면 받는 사람
+	RoomID        *int   `json:"room_id,omitempty"`        // 없으면 전체(로비) 채팅
+	Type          string     `json:"type"`            // message 또는 event
+	Event         *RoomEvent `json:"event,omitempty"` // type이 event일 때 상세
+	GroupKey      int        `json:"group_key,omitempty"`   // 같은 값이면 한 묶음으로 렌더링
+	DayDivider    string     `json:"day_divider,omitempty"` // 이 항목 앞에 날짜 구분선 (YYYY-MM-DD)
+
+	Translations map[string]string `json:"translations,omitempty"` // 언어 → 번역본 (translate.go)
+	Previews     []LinkPreview     `json:"previews,omitempty"`     // 링크 미리보기 (unfurl.go)
+	Pins         []Pin             `json:"pins,omitempty"`         // 바뀐 고정 목록 (pins 스트림 메시지, pins.go)
+	ClientMsgID  string            `json:"client_msg_id,omitempty"` // 보낸 클라이언트의 재전송 키 (낙관적 표시 대조용)
 }
 
 type User struct {
-	Nickname  string `json:"nickname"`
-	ColorCode string `json:"color_code"`
+	Nickname   string `json:"nickname"`
+	ColorCode  string `json:"color_code"`
+	AvatarURL  string `json:"avatar_url,omitempty"`
+	Locale     string `json:"locale,omitempty"`      // 프로필 언어 (번역 중계 대상 언어)
+	Status     string `json:"status,omitempty"`      // online | away | dnd (status.go)
+	StatusText string `json:"status_text,omitempty"` // 한 줄 상태 문구
 }
 
 func main() {
 	hostname, _ = os.Hostname()
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "migrate":
+			runMigrateCommand(os.Args[2:])
+			return
+		case "import":
+			runImportCommand(os.Args[2:])
+			return
+		}
+	}
+	var err error
+	if cfg, err = loadConfig(os.Args[1:]); err != nil { fatal("invalid config", err) }
+	initNodeID()
+	initLogger()
+	broadcast = make(chan outbound, cfg.BroadcastBuffer)
+	shutdownTracing := initTracing()
+	defer shutdownTracing(context.Background())
+
+	initEndpointPolicies()
+	initRateLimits()
+	initFloodGuard()
+	initPasswordReset()
+	initTrustedProxies()
+	initAuth()
+	initPush()
+	initStorage()
+	initTranslation()
+	initUnfurl()
 	initDB()
-	initNATS()
+	initBroker()
+	checkClusterProtocol()
+	initMembership()
+	initPresence()
+	ensureSystemUser()
+	initRoomTiers()
+	initPrivateRooms()
 
 	go handleMessages()
+	startProbe()
+	go runConnReaper()
+	go warmHistoryCache()
+	go pruneGuestPosts()
+	// 스케줄러 작업은 Postgres 전용 SQL(advisory lock 포함)이라 다른 저장소에서는 돌리지 않음
+	if fullFeatureStore() {
+		scheduleJob("account-purge", time.Hour, purgeDeletedUsers)
+		scheduleJob("analytics-rollup", 15*time.Minute, func() { rollupAnalytics(48 * time.Hour) })
+		scheduleJob("room-recommendations", 30*time.Minute, refreshRoomRecommendations)
+		scheduleJob("pin-expiry", time.Minute, expirePins)
+		scheduleJob("room-exports", 30*time.Second, processRoomExports)
+		scheduleJob("timers", timerTick, tickTimers)
+		scheduleJob("render-cache-gc", time.Hour, pruneRenderCache)
+		scheduleJob("session-gc", time.Hour, pruneSessions)
+		scheduleJob("email-verification-gc", time.Hour, pruneEmailVerifications)
+		scheduleJob("password-reset-gc", time.Hour, prunePasswordResets)
+		scheduleJob("thread-digest", 5*time.Minute, postThreadDigests)
+		scheduleJob("lockdown-expiry", 30*time.Second, expireLockdowns)
+		scheduleJob("trust-levels", 24*time.Hour, recalcTrustLevels)
+		if cfg.Calendar.ReminderBefore > 0 { scheduleJob("calendar-reminders", time.Minute, sendCalendarReminders) }
+		if retentionEnabled() { scheduleJob("message-retention", time.Hour, purgeOldMessages) }
+		if cfg.Backup.Enabled {
+			scheduleJob("backup", time.Duration(cfg.Backup.Interval), runBackup)
+			scheduleJob("backup-verify", time.Duration(cfg.Backup.VerifyInterval), runBackupVerification)
+		}
+		startScheduler()
+	}
 
 	http.Handle("/", http.FileServer(http.Dir("./static")))
 	http.HandleFunc("/stream", streamHandler)
 	http.HandleFunc("/send", sendHandler)
+	http.HandleFunc("POST /palette/assign", assignColorHandler)
+	http.HandleFunc("POST /pong", pongHandler)
+	http.HandleFunc("POST /surveys/{id}/respond", surveyRespondHandler)
 	http.HandleFunc("/history", historyHandler)
 	http.HandleFunc("/login", loginHandler)
 	http.HandleFunc("/update", updateProfileHandler)
-
-	port := "8080"
-	log.Printf("🥤 CoTalk Server started on %s (Pod: %s)", port, hostname)
-	if err := http.ListenAndServe(":"+port, nil); err != nil {
-		log.Fatal(err)
+	http.HandleFunc("GET /avatars/{name}", avatarHandler)
+	http.Handle("GET /metrics", promhttp.Handler())
+	http.HandleFunc("GET /healthz", healthzHandler)
+	http.HandleFunc("GET /capabilities", capabilitiesHandler)
+	http.HandleFunc("GET /readyz", readyzHandler)
+	http.HandleFunc("GET /messages/{id}/thread", threadHandler)
+	http.HandleFunc("GET /mentions", mentionsHandler)
+	http.HandleFunc("GET /me/limits", myLimitsHandler)
+	http.HandleFunc("GET /dms", dmsHandler)
+	http.HandleFunc("GET /online", onlineHandler)
+	http.HandleFunc("POST /status", setStatusHandler)
+	http.HandleFunc("POST /messages/{id}/pin", pinMessageHandler)
+	http.HandleFunc("DELETE /messages/{id}/pin", unpinMessageHandler)
+	http.HandleFunc("GET /pins", pinsHandler)
+	http.HandleFunc("POST /messages/{id}/star", starMessageHandler)
+	http.HandleFunc("DELETE /messages/{id}/star", unstarMessageHandler)
+	http.HandleFunc("GET /stars", starsHandler)
+	http.HandleFunc("DELETE /messages/{id}", deleteMessageHandler)
+	http.HandleFunc("POST /users/{nick}/ban", banUserHandler)
+	http.HandleFunc("DELETE /users/{nick}/ban", unbanUserHandler)
+	http.HandleFunc("POST /block/{nick}", blockUserHandler)
+	http.HandleFunc("DELETE /block/{nick}", unblockUserHandler)
+	http.HandleFunc("GET /blocks", blocksHandler)
+	http.HandleFunc("POST /account/delete", deleteAccountHandler)
+	http.HandleFunc("POST /account/reactivate", reactivateAccountHandler)
+	http.HandleFunc("GET /account/email", emailStatusHandler)
+	http.HandleFunc("POST /account/email", setEmailHandler)
+	http.HandleFunc("POST /account/email/resend", resendVerificationHandler)
+	http.HandleFunc("GET /verify", verifyEmailHandler)
+	http.HandleFunc("POST /account/password/forgot", forgotPasswordHandler)
+	http.HandleFunc("POST /account/password/reset", resetPasswordHandler)
+	http.HandleFunc("GET /push/vapid-key", vapidKeyHandler)
+	http.HandleFunc("POST /push/subscribe", pushSubscribeHandler)
+	http.HandleFunc("POST /push/unsubscribe", pushUnsubscribeHandler)
+	http.HandleFunc("POST /register", registerHandler)
+	http.HandleFunc("POST /invites", createInviteHandler)
+	http.HandleFunc("GET /invites", listInvitesHandler)
+	http.HandleFunc("GET /rooms", listRoomsHandler)
+	http.HandleFunc("POST /rooms", createRoomHandler)
+	http.HandleFunc("POST /rooms/{id}/read", markRoomReadHandler)
+	http.HandleFunc("GET /unread", unreadHandler)
+	http.HandleFunc("GET /rooms/suggested", suggestedRoomsHandler)
+	http.HandleFunc("POST /rooms/{id}/join", joinRoomHandler)
+	http.HandleFunc("POST /rooms/{id}/leave", leaveRoomHandler)
+	http.HandleFunc("POST /rooms/{id}/invites", createRoomInviteHandler)
+	http.HandleFunc("DELETE /rooms/{id}/invites/{invite}", revokeRoomInviteHandler)
+	http.HandleFunc("POST /room-invites/{token}/join", joinRoomByInviteHandler)
+	http.HandleFunc("PATCH /rooms/{id}", updateRoomHandler)
+	http.HandleFunc("POST /rooms/{id}/topic", setRoomTopicHandler)
+	http.HandleFunc("POST /rooms/{id}/language", setRoomLanguageHandler)
+	http.HandleFunc("POST /rooms/{id}/thread-digest", setThreadDigestHandler)
+	http.HandleFunc("POST /rooms/{id}/lockdown", lockRoomHandler)
+	http.HandleFunc("DELETE /rooms/{id}/lockdown", unlockRoomHandler)
+	http.HandleFunc("POST /rooms/{id}/preapproval", setPreapprovalHandler)
+	http.HandleFunc("GET /rooms/{id}/pending", listPendingHandler)
+	http.HandleFunc("POST /rooms/{id}/pending/{pending}/approve", approvePendingHandler)
+	http.HandleFunc("POST /rooms/{id}/pending/{pending}/reject", rejectPendingHandler)
+	http.HandleFunc("POST /rooms/{id}/moderators", promoteModeratorHandler)
+	http.HandleFunc("POST /rooms/{id}/transfer-ownership", transferOwnershipHandler)
+	http.HandleFunc("POST /escalate", escalateHandler)
+	http.HandleFunc("POST /escalations/{id}/ack", ackEscalationHandler)
+	http.HandleFunc("POST /escalations/{id}/resolve", resolveEscalationHandler)
+	http.HandleFunc("GET /users/{nick}", userProfileHandler)
+	http.HandleFunc("PUT /users/{nick}/note", putUserNoteHandler)
+	http.HandleFunc("DELETE /users/{nick}/note", deleteUserNoteHandler)
+	http.HandleFunc("GET /users/{nick}/activity", userActivityHandler)
+	http.HandleFunc("GET /rooms/{id}/heatmap", roomHeatmapHandler)
+	http.HandleFunc("GET /rooms/{id}/media", roomMediaHandler)
+	http.HandleFunc("POST /rooms/{id}/export", requestRoomExportHandler)
+	http.HandleFunc("GET /exports/{id}", roomExportHandler)
+	http.HandleFunc("GET /exports/{id}/download", downloadRoomExportHandler)
+	http.HandleFunc("POST /rooms/{id}/embeds", createEmbedHandler)
+	http.HandleFunc("GET /rooms/{id}/embeds", listEmbedsHandler)
+	http.HandleFunc("DELETE /rooms/{id}/embeds/{token}", revokeEmbedHandler)
+	http.HandleFunc("GET /embed/{room}", embedPageHandler)
+	http.HandleFunc("GET /embed/{room}/widget.js", embedScriptHandler)
+	http.HandleFunc("GET /embed/{room}/history", embedHistoryHandler)
+	http.HandleFunc("GET /embed/{room}/stream", embedStreamHandler)
+	http.HandleFunc("POST /embed/{room}/send", embedSendHandler)
+	http.HandleFunc("POST /hooks/{token}", incomingHookHandler)
+	http.HandleFunc("POST /rooms/{id}/hooks", createRoomHookHandler)
+	http.HandleFunc("GET /rooms/{id}/hooks", listRoomHooksHandler)
+	http.HandleFunc("DELETE /rooms/{id}/hooks/{token}", revokeRoomHookHandler)
+	http.HandleFunc("POST /rooms/{id}/keys", createRoomKeyHandler)
+	http.HandleFunc("GET /rooms/{id}/keys", listRoomKeysHandler)
+	http.HandleFunc("DELETE /rooms/{id}/keys/{key}", revokeRoomKeyHandler)
+	http.HandleFunc("POST /rooms/{id}/calendar", createCalendarEventHandler)
+	http.HandleFunc("GET /rooms/{id}/calendar", listCalendarEventsHandler)
+	http.HandleFunc("GET /rooms/{id}/calendar.ics", calendarFeedHandler)
+	http.HandleFunc("POST /calendar/{id}/rsvp", rsvpCalendarEventHandler)
+	http.HandleFunc("GET /rooms/{id}/notes", roomNotesHandler)
+	http.HandleFunc("PUT /rooms/{id}/notes/{key}", putRoomNoteHandler)
+	http.HandleFunc("DELETE /rooms/{id}/notes/{key}", deleteRoomNoteHandler)
+	http.HandleFunc("GET /rooms/{id}/notes/{key}/history", roomNoteHistoryHandler)
+	http.HandleFunc("DELETE /calendar/{id}", cancelCalendarEventHandler)
+	adminMux.HandleFunc("POST /admin/invites", adminCreateInviteHandler)
+	adminMux.HandleFunc("GET /admin/invites/stats", inviteStatsHandler)
+	adminMux.HandleFunc("GET /admin/rooms/orphaned", orphanedRoomsHandler)
+	adminMux.HandleFunc("POST /admin/rooms/{id}/owner", assignRoomOwnerHandler)
+	adminMux.HandleFunc("DELETE /admin/messages/{id}", adminDeleteMessageHandler)
+	adminMux.HandleFunc("POST /admin/users/{nick}/ban", adminBanHandler)
+	adminMux.HandleFunc("DELETE /admin/users/{nick}/ban", adminUnbanHandler)
+	adminMux.HandleFunc("PUT /admin/users/{nick}/role", adminSetRoleHandler)
+	adminMux.HandleFunc("PUT /admin/users/{nick}/trust", adminSetTrustHandler)
+	adminMux.HandleFunc("POST /admin/users/{nick}/password", adminSetPasswordHandler)
+	adminMux.HandleFunc("DELETE /admin/users/{nick}/sessions", adminRevokeSessionsHandler)
+	adminMux.HandleFunc("GET /admin/users/{nick}/password-resets", adminPasswordResetsHandler)
+	adminMux.HandleFunc("GET /admin/escalations", adminEscalationsHandler)
+	adminMux.HandleFunc("GET /admin/clients", adminClientsHandler)
+	adminMux.HandleFunc("GET /admin/stats", adminStatsHandler)
+	adminMux.HandleFunc("GET /admin/cluster", adminClusterHandler)
+	adminMux.HandleFunc("GET /admin/export", adminExportHandler)
+	adminMux.HandleFunc("POST /admin/import", adminImportHandler)
+	adminMux.HandleFunc("POST /admin/import/profiles", adminImportProfilesHandler)
+	adminMux.HandleFunc("GET /admin/backups", adminBackupsHandler)
+	adminMux.HandleFunc("POST /admin/backups/verify", adminVerifyBackupHandler)
+	adminMux.HandleFunc("POST /admin/surveys", adminCreateSurveyHandler)
+	adminMux.HandleFunc("GET /admin/surveys", adminSurveysHandler)
+	adminMux.HandleFunc("POST /admin/surveys/{id}/close", adminCloseSurveyHandler)
+	adminMux.HandleFunc("GET /admin/surveys/{id}/results", adminSurveyResultsHandler)
+	adminMux.HandleFunc("POST /admin/hooks", adminCreateHookHandler)
+	adminMux.HandleFunc("GET /admin/hooks", adminHooksHandler)
+	adminMux.HandleFunc("DELETE /admin/hooks/{token}", adminRevokeHookHandler)
+	adminMux.HandleFunc("POST /admin/webhooks", adminCreateWebhookHandler)
+	adminMux.HandleFunc("GET /admin/webhooks", adminWebhooksHandler)
+	adminMux.HandleFunc("DELETE /admin/webhooks/{id}", adminDeleteWebhookHandler)
+	adminMux.HandleFunc("POST /admin/webhooks/{id}/test", adminTestWebhookHandler)
+	adminMux.HandleFunc("POST /admin/bots/{id}/simulate", adminSimulateBotHandler)
+	adminMux.HandleFunc("GET /admin/dead-letters", adminDeadLettersHandler)
+	adminMux.HandleFunc("POST /admin/dead-letters/{id}/retry", adminRetryDeadLetterHandler)
+	adminMux.HandleFunc("DELETE /admin/dead-letters/{id}", adminDiscardDeadLetterHandler)
+	http.Handle("/admin/", requireAdmin(adminMux))
+
+	if err := serve(recoverMiddleware(corsMiddleware(timeoutMiddleware(tracingMiddleware(metricsMiddleware(accessLogMiddleware(floodMiddleware(authMiddleware(rateLimitMiddleware(http.DefaultServeMux)))))))))); err != nil {
+		fatal("http server stopped", err)
 	}
 }
 
-// [방송실] NATS에서 받은 메시지를 현재 접속한 모든 사용자에게 전달
+// [방송실] 브로커에서 받은 메시지를 현재 접속한 모든 사용자에게 전달
 func handleMessages() {
 	for {
 		msg := <-broadcast
-		// [로그] 방송실이 메시지를 수신했는지 확인
-		log.Printf("📢 [Broadcaster] Broadcasting message to clients...")
+		messagesReceived.Inc()
+		invalidateHistoryFor(msg.Data)
+		msg.LiteData = liteData(msg.Data)
+		msg.ID = streamEventID(msg.Data)
+		msg.Sender = streamSender(msg.Data)
+		msg.Probe = isProbePayload(msg.Data)
+		msg.Received = time.Now()
+		msg.Room = streamRoomLabel(msg.Data)
+		msg.RoomID = streamRoomID(msg.Data)
+		ctx, span := tracer.Start(msg.Ctx, "broadcast")
+		msg.Ctx = ctx
 		
 		mutex.Lock()
 		count := 0
-		for clientChan := range clients {
-			select {
-			case clientChan <- msg:
-				count++
-			default:
-			}
+		for clientChan, c := range clients {
+			if c.offer(clientChan, msg) { count++ }
 		}
 		mutex.Unlock()
-		log.Printf("✅ [Broadcaster] Sent to %d clients.", count)
+		span.SetAttributes(attribute.Int("clients", count))
+		span.End()
+		messagesDelivered.WithLabelValues(msg.Room).Add(float64(count))
+		slog.DebugContext(ctx, "broadcast delivered", "clients", count)
 	}
 }
 
-func initNATS() {
-	natsURL := os.Getenv("NATS_URL")
-	if natsURL == "" { 
-		natsURL = nats.DefaultURL 
-		log.Println("⚠️ Warning: NATS_URL not set. Using default: " + natsURL)
-	} else {
-		log.Println("🔗 Connecting to NATS at: " + natsURL)
-	}
-	
-	var err error
-	nc, err = nats.Connect(natsURL, nats.Name("GoTalk"), nats.MaxReconnects(-1))
-	if err != nil { log.Fatal("❌ NATS Connect Error: ", err) }
-	
-	// [로그] NATS 구독 확인
-	nc.Subscribe("chat.global", func(m *nats.Msg) {
-		log.Printf("📨 [NATS Listener] Received msg from NATS: %s", string(m.Data))
-		broadcast <- string(m.Data)
-	})
-	
-	log.Println("✅ Connected to NATS & Listening (Hub Mode)...")
-}
-
 // [스트림 핸들러] 사용자가 웹소켓(SSE) 연결을 요청할 때
 func streamHandler(w http.ResponseWriter, r *http.Request) {
 	// 닉네임 파싱 (로그용)
 	nick := r.URL.Query().Get("nick")
 	if nick == "" { nick = "Unknown" }
+	if isUserBanned(nick) { respondError(w, r, http.StatusForbidden, errors.New("account banned")); return }
+	// 방 읽기 키(로비 화면 등)는 그 방 공개 메시지만, 닉네임이 없어 DM/멘션/접속 상태에서 빠짐
+	key, kiosk := roomKeyFrom(r.Context())
+	// 비공개 방 전달/재전송은 확인된 신원으로만 (none에서 nick만 보낸 연결은 공개 방만)
+	member, _ := identityFrom(r.Context())
+	if kiosk { nick, member = "", "" }
+	// 특정 방을 보겠다고 하면 그 방이 비공개일 때 멤버인지 먼저 확인 (방송 전달은 비공개 방 멤버 캐시로 거름)
+	if v := r.URL.Query().Get("room_id"); v != "" && !kiosk {
+		id, err := strconv.Atoi(v)
+		if err != nil { respondError(w, r, http.StatusBadRequest, errors.New("invalid room_id")); return }
+		if !roomAccessOK(w, r, id) { return }
+	}
 
 	w.Header().Set("Content-Type", "text/event-stream")
 	w.Header().Set("Cache-Control", "no-cache")
 	w.Header().Set("Connection", "keep-alive")
 
+	// [로그] 접속 알림
+	slog.InfoContext(r.Context(), "client connected", "nick", nick, "api_key", key.Name)
+	connID := newRequestID()
+	// 죽은 연결 회수/느린 연결 끊기 시: 루프를 끝내고, 막혀 있는 쓰기는 쓰기 기한으로 풀어 줌
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	rc := http.NewResponseController(w)
+	kick := func() { cancel(); rc.SetWriteDeadline(time.Now()) }
+	conn := registerConn(connID, nick, wantsLite(r), kick)
+	if !kiosk { retainBlocks(r.Context(), nick) } // 전달 경로는 캐시만 봄
+
 	// 내 전용 채널 생성 및 등록
-	myChan := make(chan string, 10)
+	myChan := make(chan outbound, cfg.ClientBuffer)
 	
+	if !kiosk { announceConnect(r.Context(), nick) }
 	mutex.Lock()
-	clients[myChan] = true
+	clients[myChan] = &streamClient{nick: nick, member: member, conn: conn, kick: kick, room: key.RoomID}
 	mutex.Unlock()
-
-	// [로그] 접속 알림
-	log.Printf("🔌 Connected: User [%s] attached to Pod [%s]", nick, hostname)
+	if !kiosk {
+		if err := presence.Connect(r.Context(), nick, connID); err != nil { slog.WarnContext(r.Context(), "presence connect failed", "nick", nick, "err", err) }
+	}
 
 	// 연결 종료 시 처리 (defer)
 	defer func() {
@@ -133,158 +360,344 @@ func streamHandler(w http.ResponseWriter, r *http.Request) {
 		mutex.Unlock()
 		
 		// [로그] 퇴장 알림
-		log.Printf("❌ Disconnected: User [%s] detached from Pod [%s]", nick, hostname)
+		slog.InfoContext(r.Context(), "client disconnected", "nick", nick, "api_key", key.Name)
+		if !kiosk {
+			presence.Disconnect(context.Background(), nick, connID)
+			announceDisconnect(nick)
+			releaseBlocks(nick)
+		}
+		unregisterConn(connID)
 	}()
 
-	notify := r.Context().Done()
+	notify := ctx.Done()
+	sw := newStreamWriter(r.Context(), w, conn)
+	defer sw.close()
+	// 저하 모드일 때 모아 둔 메시지를 내보내는 주기
+	batch := time.NewTicker(time.Duration(cfg.Stream.BatchInterval))
+	defer batch.Stop()
+	// 접속 상태 TTL이 끝나기 전에 갱신
+	heartbeat := time.NewTicker(time.Duration(cfg.Presence.TTL) / 3)
+	defer heartbeat.Stop()
+	ping := time.NewTicker(time.Duration(cfg.Stream.PingInterval))
+	defer ping.Stop()
+
+	// 재접속이면 끊긴 동안의 메시지를 먼저 보냄 (그 사이 방송으로도 온 것은 아래에서 건너뜀)
+	replayed := map[int]bool{}
+	if after := lastEventID(r); after > 0 {
+		var kioskRoom *int
+		if kiosk { kioskRoom = &key.RoomID }
+		missed, truncated, err := missedMessages(ctx, nick, member, kioskRoom, after)
+		switch {
+		case err != nil:
+			slog.WarnContext(ctx, "stream replay failed", "nick", nick, "last_event_id", after, "err", err)
+		case truncated:
+			sw.deliver(outbound{Ctx: ctx, Event: eventResync, Data: "{}"})
+		default:
+			for _, m := range dropBlocked(ctx, nick, missed) {
+				data, _ := json.Marshal(m)
+				sw.deliver(outbound{Ctx: ctx, Data: string(data), LiteData: liteData(string(data)), ID: m.ID})
+				replayed[m.ID] = true
+			}
+		}
+	}
 
 	for {
 		select {
 		case <-notify: // 브라우저 종료 시
 			return
 		case msg := <-myChan: // 방송실에서 메시지 도착
-			fmt.Fprintf(w, "data: %s\n\n", msg)
-			w.(http.Flusher).Flush()
-		case <-time.After(15 * time.Second): // 15초간 조용하면 생존신고
-			fmt.Fprintf(w, ":keepalive\n\n")
-			w.(http.Flusher).Flush()
+			refill(myChan)
+			if kiosk && !msg.Disconnect && !embedVisible(msg.Data, key.RoomID) { continue }
+			if replayed[msg.ID] { continue }
+			sw.deliver(msg)
+			if msg.Disconnect { sw.flushBatch(); return } // 관리자 차단 등으로 연결 종료
+		case <-batch.C:
+			sw.flushBatch()
+		case <-heartbeat.C:
+			if kiosk {
+				if roomKeyRevoked(r.Context(), key.Key) { return }
+				continue
+			}
+			presence.Heartbeat(r.Context(), nick, connID)
+		case <-ping.C:
+			sw.ping()
+		case <-time.After(time.Duration(cfg.KeepaliveInterval)): // 한동안 조용하면 생존신고
+			sw.keepalive()
 		}
+		sw.evaluate(len(myChan), cap(myChan))
 	}
 }
 
-func initDB() {
-	dbHost := os.Getenv("DB_HOST")
-	dbUser := os.Getenv("DB_USER")
-	dbPwd := os.Getenv("DB_PASSWORD")
-	dbName := os.Getenv("DB_NAME")
-	if dbName == "" { dbName = "cotalk" }
-
-	psqlInfo := fmt.Sprintf("host=%s user=%s password=%s dbname=postgres sslmode=disable", dbHost, dbUser, dbPwd)
-	tempDB, err := sql.Open("postgres", psqlInfo)
-	if err != nil { log.Fatal(err) }
-	var exists bool
-	tempDB.QueryRow("SELECT EXISTS(SELECT datname FROM pg_catalog.pg_database WHERE datname = $1)", dbName).Scan(&exists)
-	if !exists { tempDB.Exec(fmt.Sprintf("CREATE DATABASE %s", dbName)) }
-	tempDB.Close()
-
-	connStr := fmt.Sprintf("host=%s user=%s password=%s dbname=%s sslmode=disable", dbHost, dbUser, dbPwd, dbName)
-	db, err = sql.Open("postgres", connStr)
-	if err != nil { log.Fatal(err) }
-	
-	// 테이블 생성 (기존 유지)
-	queries := []string{
-		`CREATE TABLE IF NOT EXISTS messages (
-			id SERIAL PRIMARY KEY,
-			content TEXT,
-			sender_pod TEXT,
-			sender_nick TEXT,
-			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-		);`,
-		`CREATE TABLE IF NOT EXISTS users (
-			nickname TEXT PRIMARY KEY,
-			color_code TEXT
-		);`,
+// [로그인] GET /login?nick= 또는 POST /login (nick, password)
+// auth.provider=none에서 비밀번호를 건 닉네임은 그 세션이 있거나 POST로 비밀번호가 맞아야 하고, 맞으면 세션 발급
+// 나머지 닉네임은 게스트 토큰(닉네임+만료 서명, auth.guest_ttl)을 받아 게스트로 구분됨 (메시지 한도 따로)
+func loginHandler(w http.ResponseWriter, r *http.Request) {
+	nick := r.FormValue("nick")
+	// 익명 접속을 끈 none 방식에서는 비밀번호를 건(가입한) 닉네임만 로그인
+	if authProvider.Name() == authProviderNone && !cfg.Auth.AllowAnonymous && !passwordProtected(nick) {
+		respondError(w, r, http.StatusUnauthorized, errLoginRequired)
+		return
 	}
-	for _, query := range queries {
-		if _, err := db.Exec(query); err != nil {
-			log.Printf("Schema Warning: %v", err)
+	if authProvider.Name() == authProviderNone && passwordProtected(nick) {
+		if s, _ := sessionNick(r); s != nick {
+			if err := checkPassword(nick, r.PostFormValue("password")); err != nil { respondError(w, r, http.StatusUnauthorized, err); return }
+			if _, err := issueSession(w, r, nick); err != nil { respondError(w, r, 500, err); return }
 		}
 	}
-}
-
-func loginHandler(w http.ResponseWriter, r *http.Request) {
-	nick := r.URL.Query().Get("nick")
-	var color string
-	err := db.QueryRow("SELECT color_code FROM users WHERE nickname = $1", nick).Scan(&color)
+	color, deleted, err := store.User(r.Context(), nick)
+	// 처음 보는 닉네임은 여기서 계정을 만들어 첫 로그인에 환영 DM이 가게 함 (초대 전용 가입이나 예약 닉네임은 예전처럼 만들지 않음)
+	if err == sql.ErrNoRows && nick != "" && !isReservedNick(nick) && canAutoRegister(nick) {
+		color = assignedColor(nick)
+		err = store.UpsertUser(r.Context(), nick, color)
+		if err != nil { respondError(w, r, 500, err); return }
+	}
+	// 탈퇴 유예 중인 계정은 재활성화 전까지 로그인 불가
+	if err == nil && deleted { respondError(w, r, http.StatusGone, errors.New("account deleted; reactivate with recovery code")); return }
+	if err == nil && isUserBanned(nick) { respondError(w, r, http.StatusForbidden, errors.New("account banned")); return }
 	
-	resp := User{Nickname: nick}
-	if err == nil { resp.ColorCode = color }
+	var resp struct {
+		User
+		GuestToken     string     `json:"guest_token,omitempty"`
+		GuestExpiresAt *time.Time `json:"guest_expires_at,omitempty"`
+	}
+	resp.Nickname = nick
+	if err == nil {
+		resp.ColorCode, resp.AvatarURL = color, avatarURLFor(nick)
+		go maybeSendWelcome(nick, resolveLocale(r))
+		// 프로필 언어가 없으면 브라우저 언어로 채움 (번역 중계 대상)
+		if fullFeatureStore() {
+			db.QueryRow("UPDATE users SET locale = COALESCE(locale, NULLIF($2, '')) WHERE nickname = $1 RETURNING COALESCE(locale, '')", nick, requestLanguage(r)).Scan(&resp.Locale)
+		}
+	}
+	if authProvider.Name() == authProviderNone && nick != "" && !passwordProtected(nick) {
+		token, exp := issueGuestToken(w, r, nick)
+		resp.GuestToken, resp.GuestExpiresAt = token, &exp
+	}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(resp)
 }
 
 func updateProfileHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost { return }
-	nickname := r.FormValue("nick")
-	color := r.FormValue("color")
-	if nickname == "" { return }
-	if color == "" { color = "#ffffff" }
-
-	_, err := db.Exec(`
-		INSERT INTO users (nickname, color_code) VALUES ($1, $2)
-		ON CONFLICT (nickname) DO UPDATE SET color_code = $2`, 
-		nickname, color)
-	if err != nil { http.Error(w, err.Error(), 500); return }
-	w.WriteHeader(http.StatusOK)
+	r.Body = http.MaxBytesReader(w, r.Body, avatarMaxBytes+maxJSONBody)
+	var req UpdateProfileRequest
+	if err := decodeRequest(r, &req); err != nil { respondError(w, r, decodeStatus(err), err); return }
+	if err := checkCanPost(req.Nick); err != nil { respondError(w, r, http.StatusForbidden, err); return }
+	if req.Color == "" { req.Color = colorFor(r.Context(), req.Nick) }
+	// 세션(또는 none의 게스트 토큰)으로 확인된 본인만 프로필을 바꿈
+	if id, ok := identityFrom(r.Context()); !ok || id != req.Nick { respondError(w, r, http.StatusForbidden, errors.New("log in as this nickname to change its profile")); return }
+
+	oldColor, _, _ := store.User(r.Context(), req.Nick)
+	if err := store.UpsertUser(r.Context(), req.Nick, req.Color); err != nil { respondError(w, r, 500, err); return }
+
+	// 아바타: multipart의 avatar 파일로 바꾸거나 avatar_remove=true로 지움
+	resp := User{Nickname: req.Nick, ColorCode: req.Color}
+	hasFile := r.MultipartForm != nil && len(r.MultipartForm.File["avatar"]) > 0
+	remove := r.FormValue("avatar_remove") == "true"
+	if hasFile || remove {
+		if !fullFeatureStore() { respondError(w, r, http.StatusNotImplemented, errors.New("avatars require the postgres store")); return }
+		var name *string
+		if !remove {
+			if !trustOK(w, r, req.Nick, trustCapUploads, "avatar") { return }
+			n, err := saveAvatar(r, req.Nick)
+			var fe fieldError
+			if errors.As(err, &fe) { respondError(w, r, http.StatusBadRequest, err); return }
+			if err != nil { respondError(w, r, 500, err); return }
+			name = &n
+		}
+		if _, err := db.Exec("UPDATE users SET avatar = $1 WHERE nickname = $2", name, req.Nick); err != nil { respondError(w, r, 500, err); return }
+	}
+	if (req.Status != nil || req.StatusText != nil) && fullFeatureStore() {
+		st, err := setUserStatus(r.Context(), req.Nick, req.Status, req.StatusText)
+		if err != nil { respondError(w, r, 500, err); return }
+		resp.Status, resp.StatusText = st.Status, st.StatusText
+	}
+	if req.Locale != "" && fullFeatureStore() {
+		if _, err := db.Exec("UPDATE users SET locale = $1 WHERE nickname = $2", req.Locale, req.Nick); err != nil { respondError(w, r, 500, err); return }
+		resp.Locale = req.Locale
+	}
+	resp.AvatarURL = avatarURLFor(req.Nick)
+	// 보이는 정보가 바뀌었으면 다른 사람 화면의 기록/멤버 목록도 바로 고치도록 알림
+	if oldColor != req.Color || hasFile || remove {
+		publishUserUpdated(r.Context(), UserUpdate{Nickname: req.Nick, ColorCode: req.Color, AvatarURL: resp.AvatarURL})
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// [기록 페이지] before_id(과거로) 또는 after_id(재접속 후 빈 구간 채우기) 기준 키셋 페이지네이션
+type HistoryPage struct {
+	Messages   []Message `json:"messages"`    // 항상 오래된 것 → 최신 순
+	HasMore    bool      `json:"has_more"`    // 같은 방향으로 더 가져올 메시지가 있는지
+	NextCursor *int      `json:"next_cursor"` // 다음 요청의 before_id/after_id 값 (없으면 null)
 }
 
 func historyHandler(w http.ResponseWriter, r *http.Request) {
-	beforeIDStr := r.URL.Query().Get("before_id")
-	limit := 30 
-	baseQuery := `
-		SELECT 
-			m.id, m.content, m.sender_pod, m.sender_nick, 
-			COALESCE(u.color_code, '#ffffff'), to_char(m.created_at, 'HH24:MI:SS') 
-		FROM messages m
-		LEFT JOIN users u ON m.sender_nick = u.nickname
-	`
-
-	var rows *sql.Rows
+	q := r.URL.Query()
+	if q.Get("before_id") != "" && q.Get("after_id") != "" { respondError(w, r, http.StatusBadRequest, errors.New("use either before_id or after_id")); return }
+
+	// 방 지정이 없으면 로비(room_id IS NULL) 기록
+	var roomID *int
+	if v := q.Get("room_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil { respondError(w, r, http.StatusBadRequest, errors.New("invalid room_id")); return }
+		roomID = &id
+	}
+	// 비공개 방은 멤버만 (방 읽기 키는 그 방으로 고정돼 있어 통과)
+	if _, kiosk := roomKeyFrom(r.Context()); roomID != nil && !kiosk && !roomAccessOK(w, r, *roomID) { return }
+	// 차단 목록 적용용 (접근 확인과 달리 nick 파라미터도 씀)
+	viewer, ok := identityFrom(r.Context())
+	if !ok { viewer = q.Get("nick") }
+	var beforeID, afterID int
 	var err error
+	if v := q.Get("before_id"); v != "" {
+		if beforeID, err = strconv.Atoi(v); err != nil { respondError(w, r, http.StatusBadRequest, errors.New("invalid before_id")); return }
+	} else if v := q.Get("after_id"); v != "" {
+		if afterID, err = strconv.Atoi(v); err != nil { respondError(w, r, http.StatusBadRequest, errors.New("invalid after_id")); return }
+	}
 
-	if beforeIDStr != "" {
-		// [여기서 strconv 사용됨]
-		beforeID, _ := strconv.Atoi(beforeIDStr)
-		query := baseQuery + " WHERE m.id < $1 ORDER BY m.id DESC LIMIT $2"
-		rows, err = db.Query(query, beforeID, limit)
+	var page HistoryPage
+	if beforeID == 0 && afterID == 0 {
+		// 첫 페이지(최근 기록)는 방별 캐시에서
+		page, err = recentHistory.get(r.Context(), roomID)
 	} else {
-		query := baseQuery + " ORDER BY m.id DESC LIMIT $1"
-		rows, err = db.Query(query, limit)
+		page, err = loadHistory(r.Context(), roomID, beforeID, afterID)
 	}
+	if err != nil { respondError(w, r, 500, err); return }
+	// 차단한 사람의 글은 서버에서 뺌 (로그인 신원이 없으면 nick 파라미터 기준)
+	if viewer != "" { page.Messages = dropBlocked(r.Context(), viewer, page.Messages) }
+	if (translator != nil && roomID != nil) || (cfg.Unfurl.Enabled && fullFeatureStore()) {
+		// 첫 페이지는 캐시와 공유하므로 복사본에 붙임
+		page.Messages = slices.Clone(page.Messages)
+		attachTranslations(r.Context(), roomID, q.Get("nick"), page.Messages)
+		if cfg.Unfurl.Enabled && fullFeatureStore() { attachPreviews(r.Context(), page.Messages) }
+	}
+	if wantsLite(r) { writeJSON(w, http.StatusOK, litePage(page)); return }
+	writeJSON(w, http.StatusOK, page)
+}
 
-	if err != nil { http.Error(w, err.Error(), 500); return }
-	defer rows.Close()
-
-	var history []Message
-	for rows.Next() {
-		var m Message
-		rows.Scan(&m.ID, &m.Content, &m.SenderPod, &m.SenderNick, &m.SenderColor, &m.Time)
-		history = append(history, m)
+// before_id/after_id가 모두 0이면 최근 페이지
+func loadHistory(ctx context.Context, roomID *int, beforeID, afterID int) (HistoryPage, error) {
+	limit := cfg.HistoryLimit
+	// 한 개 더 읽어서 다음 페이지 존재 여부 판단
+	list, err := store.History(ctx, roomID, beforeID, afterID, limit+1)
+	page := HistoryPage{Messages: []Message{}}
+	if err != nil { return page, err }
+	page.Messages = append(page.Messages, list...)
+	renderMessages(ctx, page.Messages)
+	forward := beforeID == 0 && afterID > 0
+	if len(page.Messages) > limit {
+		page.HasMore = true
+		page.Messages = page.Messages[:limit]
 	}
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(history)
+	if !forward { slices.Reverse(page.Messages) }
+	if n := len(page.Messages); n > 0 {
+		cursor := page.Messages[0].ID
+		if forward { cursor = page.Messages[n-1].ID }
+		page.NextCursor = &cursor
+	}
+	return page, nil
 }
 
 func sendHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost { return }
-	content := r.FormValue("msg")
-	nickname := r.FormValue("nick")
-	color := r.FormValue("color")
-
-	if content == "" || nickname == "" { return }
-	if color == "" { color = "#ffffff" }
-
-	// 1. 유저 정보 저장 (UPSERT)
-	db.Exec(`
-		INSERT INTO users (nickname, color_code) VALUES ($1, $2)
-		ON CONFLICT (nickname) DO UPDATE SET color_code = $2`, 
-		nickname, color)
-	
-	// 2. 메시지 저장
-	var id int
-	err := db.QueryRow(
-		"INSERT INTO messages (content, sender_pod, sender_nick) VALUES ($1, $2, $3) RETURNING id",
-		content, hostname, nickname,
-	).Scan(&id)
-	
-	if err != nil { http.Error(w, err.Error(), 500); return }
+	// 폼 본문도 JSON 한도까지만 읽음 (큰 붙여넣기를 다 읽기 전에 413)
+	if r.ContentLength > maxJSONBody { respondError(w, r, http.StatusRequestEntityTooLarge, fieldError{"msg", "request body too large"}); return }
+	r.Body = http.MaxBytesReader(w, r.Body, maxJSONBody)
+	var req SendRequest
+	if err := decodeRequest(r, &req); err != nil { respondError(w, r, decodeStatus(err), err); return }
+	content, nickname, color := req.Msg, req.Nick, req.Color
+	if err := checkCanPost(nickname); err != nil { respondError(w, r, http.StatusForbidden, err); return }
+	// 같은 키로 다시 온 요청(네트워크 시간 초과 후 재시도)은 새로 올리지 않고 처음 메시지를 돌려줌 (한도도 다시 쓰지 않음)
+	if req.ClientMsgID != "" {
+		if prev, err := store.ClientMessage(r.Context(), nickname, req.ClientMsgID); err == nil { writeJSON(w, http.StatusOK, prev); return }
+	}
+	if l := messageLimiterFor(r.Context()); l.enabled() {
+		st := l.take(nickname)
+		setRateLimitHeaders(w, st)
+		if !st.Allowed { countSendError(req.RoomID, "rate_limited"); respondError(w, r, http.StatusTooManyRequests, errRateLimited); return }
+	}
+
+	// 방 메시지면 멤버만 보낼 수 있음
+	roomID := req.RoomID
+	if roomID != nil && roomRole(*roomID, nickname) == "" { countSendError(roomID, "forbidden"); respondError(w, r, http.StatusForbidden, errors.New("join the room first")); return }
+	if err := checkRoomLock(r.Context(), roomID, nickname); err != nil { countSendError(roomID, "locked"); respondError(w, r, http.StatusForbidden, err); return }
+	if err := checkMessageTrust(r.Context(), nickname, content); err != nil { countSendError(roomID, "trust"); respondError(w, r, http.StatusForbidden, err); return }
+
+	// 답글이면 원글 존재 여부 확인 (답글은 원글과 같은 방에 속함)
+	parentID := req.ReplyTo
+	if parentID != nil {
+		parentRoom, err := store.MessageRoom(r.Context(), *parentID)
+		if err == sql.ErrNoRows { respondError(w, r, http.StatusNotFound, fieldError{"reply_to", "parent message not found"}); return }
+		if err != nil { respondError(w, r, 500, err); return }
+		if parentRoom.Valid != (roomID != nil) || (roomID != nil && int64(*roomID) != parentRoom.Int64) {
+			respondError(w, r, http.StatusBadRequest, fieldError{"reply_to", "reply must be in the parent's room"})
+			return
+		}
+	}
+
+	// 슬래시 명령: 본문을 바꿔 계속 올리거나, 보낸 사람에게만 답하고 끝냄
+	ctx := r.Context()
+	res, isCommand, err := dispatchCommand(CommandCall{Ctx: ctx, Nick: nickname, Color: color, RoomID: roomID, ParentID: parentID}, content)
+	if err != nil { respondError(w, r, http.StatusBadRequest, err); return }
+	if isCommand {
+		if res.Content == "" { writeJSON(w, http.StatusOK, res); return }
+		content = res.Content
+	}
+
+	// 1. 유저 정보 저장 (UPSERT, 색을 안 보냈으면 저장된 색이나 배정 색)
+	if color == "" { color = colorFor(ctx, nickname) }
+	store.UpsertUser(ctx, nickname, color)
 
-	// 3. NATS로 전송 (이제 이건 서버들끼리만 듣는 방송)
+	// 사전 승인 방의 새 계정 글은 방송하지 않고 운영진 대기열로 (moderation.go)
+	if needsPreapproval(ctx, roomID, nickname) {
+		p, err := holdMessage(ctx, *roomID, nickname, color, content, parentID)
+		if err != nil { countSendError(roomID, "store"); respondError(w, r, 500, err); return }
+		writeJSON(w, http.StatusAccepted, p)
+		return
+	}
+
+	// 2. 메시지 저장 (스레드 답글은 타임라인 묶음에서 제외)
+	var groupKey *int
+	var dayDivider *string
+	if parentID == nil { groupKey, dayDivider = groupingHints(ctx, roomID, nickname, false) }
+	dbCtx, dbSpan := startDBSpan(ctx, "insert_message")
+	id, err := store.InsertMessage(dbCtx, NewMessage{
+		Content: content, SenderPod: nodeID, SenderNick: nickname,
+		ParentID: parentID, RoomID: roomID, GroupKey: groupKey, DayDivider: dayDivider, ClientMsgID: req.ClientMsgID,
+	})
+	dbSpan.End()
+	if err != nil && req.ClientMsgID != "" {
+		// 같은 키의 요청이 동시에 와서 먼저 저장된 경우
+		if prev, perr := store.ClientMessage(ctx, nickname, req.ClientMsgID); perr == nil { writeJSON(w, http.StatusOK, prev); return }
+	}
+	if err != nil { countSendError(roomID, "store"); respondError(w, r, 500, err); return }
+
+	// 스레드 메타데이터: 답글이면 원글의 현재 답글 수를 함께 방송
+	replyCount := 0
+	if parentID != nil { replyCount = store.ReplyCount(ctx, *parentID) }
+
+	// 3. 브로커로 전송 (이제 이건 서버들끼리만 듣는 방송)
 	msg := Message{
-		ID: id, Content: content, SenderPod: hostname, SenderNick: nickname, SenderColor: color,
-		Time: time.Now().Format("15:04:05"),
+		ID: id, Type: messageTypeText, Content: content, SenderPod: nodeID, SenderNick: nickname, SenderColor: color,
+		Time: time.Now().Format("15:04:05"), ParentID: parentID, ReplyCount: replyCount, RoomID: roomID, GroupKey: id,
+		AvatarURL: avatarURLFor(nickname), HTML: renderAndStore(ctx, content), ClientMsgID: req.ClientMsgID,
 	}
+	if groupKey != nil { msg.GroupKey = *groupKey }
+	if dayDivider != nil { msg.DayDivider = *dayDivider }
 	data, _ := json.Marshal(msg)
-	nc.Publish("chat.global", data)
-	w.WriteHeader(http.StatusOK)
+	if err := publishChat(ctx, data); err != nil { countSendError(roomID, "publish"); respondError(w, r, http.StatusServiceUnavailable, err); return }
+	messagesSent.WithLabelValues(roomLabel(roomID, false)).Inc()
+
+	// 4. @멘션 저장 및 대상자에게 알림
+	afterPost(msg)
+	writeJSON(w, http.StatusOK, msg)
+}
+
+// 방송한 글의 후속 처리: 멘션 알림, 웹훅, 번역, 링크 미리보기, 첨부 기록
+func afterPost(msg Message) {
+	notifyMentions(msg)
+	dispatchWebhooks(msg)
+	if translator != nil && msg.RoomID != nil { go relayTranslations(msg) }
+	if cfg.Unfurl.Enabled && fullFeatureStore() { go unfurlMessage(msg) }
+	if msg.RoomID != nil && fullFeatureStore() { go recordAttachments(msg) }
 }
\ No newline at end of file
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// [방 미디어] 방에 올라온 이미지/파일 주소와 링크 미리보기를 모아 보는 갤러리
+// 업로드 기능이 없으므로 첨부는 글 본문의 주소를 확장자로 나눠 attachments에 기록 (sendHandler가 방송 후 고루틴으로)
+// 링크는 미리보기를 만든 주소만 (message_link_previews, unfurl.go) - 둘 다 방별 (room_id, id DESC) 인덱스로 최신순 페이지
+const (
+	mediaTypeImage = "image"
+	mediaTypeFile  = "file"
+	mediaTypeLink  = "link"
+
+	mediaMaxName = 200
+)
+
+// 주소 경로의 확장자 → 첨부 종류 (여기 없으면 일반 링크)
+var attachmentExtensions = map[string]string{
+	".png": mediaTypeImage, ".jpg": mediaTypeImage, ".jpeg": mediaTypeImage, ".gif": mediaTypeImage, ".webp": mediaTypeImage, ".svg": mediaTypeImage,
+	".pdf": mediaTypeFile, ".zip": mediaTypeFile, ".gz": mediaTypeFile, ".txt": mediaTypeFile, ".csv": mediaTypeFile,
+	".doc": mediaTypeFile, ".docx": mediaTypeFile, ".xls": mediaTypeFile, ".xlsx": mediaTypeFile, ".ppt": mediaTypeFile, ".pptx": mediaTypeFile,
+	".mp3": mediaTypeFile, ".mp4": mediaTypeFile, ".mov": mediaTypeFile,
+}
+
+type MediaItem struct {
+	ID          int64     `json:"id"`
+	Type        string    `json:"type"`
+	URL         string    `json:"url"`
+	Name        string    `json:"name,omitempty"` // 첨부 파일 이름 (주소의 마지막 경로)
+	Title       string    `json:"title,omitempty"`
+	Description string    `json:"description,omitempty"`
+	ImageURL    string    `json:"image_url,omitempty"`
+	SiteName    string    `json:"site_name,omitempty"`
+	MessageID   int       `json:"message_id"`
+	SenderNick  string    `json:"sender_nick"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type MediaPage struct {
+	Items      []MediaItem `json:"items"` // 최신순
+	HasMore    bool        `json:"has_more"`
+	NextCursor *int64      `json:"next_cursor"` // 다음 요청의 before 값 (없으면 null)
+}
+
+// 첨부로 볼 주소면 종류와 파일 이름
+func attachmentKind(link string) (kind, name string) {
+	u, err := url.Parse(link)
+	if err != nil { return "", "" }
+	kind = attachmentExtensions[strings.ToLower(path.Ext(u.Path))]
+	if kind == "" { return "", "" }
+	name, _ = url.PathUnescape(path.Base(u.Path))
+	return kind, clipRunes(name, mediaMaxName)
+}
+
+// 방 메시지 본문의 이미지/파일 주소를 기록
+func recordAttachments(msg Message) {
+	if msg.RoomID == nil { return }
+	var seen []string
+	for _, link := range mdURL.FindAllString(msg.Content, -1) {
+		if slices.Contains(seen, link) { continue }
+		seen = append(seen, link)
+		kind, name := attachmentKind(link)
+		if kind == "" { continue }
+		_, err := db.ExecContext(context.Background(), `INSERT INTO attachments (message_id, room_id, kind, url, name) VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT DO NOTHING`, msg.ID, *msg.RoomID, kind, link, name)
+		if err != nil { slog.Warn("attachment store failed", "message_id", msg.ID, "err", err); return }
+	}
+}
+
+// [방 미디어] GET /rooms/{id}/media?type=image|file|link&before=&limit=&nick= - 비공개 방은 멤버만
+func roomMediaHandler(w http.ResponseWriter, r *http.Request) {
+	if !fullFeatureStore() { respondError(w, r, http.StatusNotImplemented, errors.New("room media requires the postgres store")); return }
+	roomID, ok := roomIDFrom(r)
+	if !ok { respondError(w, r, http.StatusBadRequest, errors.New("invalid room id")); return }
+	q := r.URL.Query()
+	kind := q.Get("type")
+	if kind != mediaTypeImage && kind != mediaTypeFile && kind != mediaTypeLink {
+		respondError(w, r, http.StatusBadRequest, fieldError{"type", "must be image, file or link"})
+		return
+	}
+	if !roomAccessOK(w, r, roomID) { return }
+	limit := 30
+	if l, err := strconv.Atoi(q.Get("limit")); err == nil && l > 0 && l <= 100 {
+		limit = l
+	}
+	before, err := strconv.ParseInt(q.Get("before"), 10, 64)
+	if err != nil || before <= 0 { before = math.MaxInt64 }
+
+	page, err := loadMedia(r.Context(), roomID, kind, before, limit)
+	if err != nil { respondError(w, r, 500, err); return }
+	writeJSON(w, http.StatusOK, page)
+}
+
+func loadMedia(ctx context.Context, roomID int, kind string, before int64, limit int) (MediaPage, error) {
+	page := MediaPage{Items: []MediaItem{}}
+	query, args := `
+		SELECT a.id, a.url, a.name, '', '', '', '', m.id, m.sender_nick, m.created_at
+		FROM attachments a JOIN messages m ON m.id = a.message_id
+		WHERE a.room_id = $1 AND a.id < $2 AND a.kind = $4
+		ORDER BY a.id DESC LIMIT $3`, []any{roomID, before, limit + 1, kind}
+	if kind == mediaTypeLink {
+		query, args = `
+			SELECT p.id, p.url, '', lp.title, lp.description, lp.image_url, lp.site_name, m.id, m.sender_nick, m.created_at
+			FROM message_link_previews p
+			JOIN link_previews lp ON lp.url = p.url
+			JOIN messages m ON m.id = p.message_id
+			WHERE p.room_id = $1 AND p.id < $2
+			ORDER BY p.id DESC LIMIT $3`, args[:3]
+	}
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil { return page, err }
+	defer rows.Close()
+	for rows.Next() {
+		if len(page.Items) == limit { page.HasMore = true; break }
+		it := MediaItem{Type: kind}
+		if err := rows.Scan(&it.ID, &it.URL, &it.Name, &it.Title, &it.Description, &it.ImageURL, &it.SiteName, &it.MessageID, &it.SenderNick, &it.CreatedAt); err != nil {
+			return page, err
+		}
+		page.Items = append(page.Items, it)
+	}
+	if page.HasMore {
+		last := page.Items[len(page.Items)-1].ID
+		page.NextCursor = &last
+	}
+	return page, rows.Err()
+}
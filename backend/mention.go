@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"math"
+	"net/http"
+	"regexp"
+	"strconv"
+)
+
+var mentionPattern = regexp.MustCompile(`@([\p{L}\p{N}_.\-]+)`)
+
+// 방 멤버 전원을 부르는 멘션 (로비에서는 아무에게도 가지 않음, 쓰려면 신뢰 등급 trust.mention_all)
+const (
+	mentionAll  = "all"
+	mentionHere = "here"
+)
+
+// 본문에서 @닉네임 추출 (중복 제거, 등장 순서 유지)
+func parseMentions(content string) []string {
+	seen := map[string]bool{}
+	var nicks []string
+	for _, m := range mentionPattern.FindAllStringSubmatch(content, -1) {
+		if !seen[m[1]] {
+			seen[m[1]] = true
+			nicks = append(nicks, m[1])
+		}
+	}
+	return nicks
+}
+
+// 존재하는 사용자에 대해서만 멘션 기록 후 NATS로 알림 발행
+// 비공개 방에서는 멤버가 아닌 닉네임을 불러도 기록/알림/푸시 모두 보내지 않음 (본문이 새지 않게)
+func notifyMentions(msg Message) {
+	targets := parseMentions(msg.Content)
+	if msg.RoomID != nil && mentionsEveryone(targets) { targets = append(targets, roomMemberNicks(*msg.RoomID)...) }
+	seen := map[string]bool{}
+	for _, nick := range targets {
+		if nick == msg.SenderNick || nick == mentionAll || nick == mentionHere || seen[nick] { continue }
+		seen[nick] = true
+		if msg.RoomID != nil {
+			if err := checkRoomAccess(context.Background(), *msg.RoomID, nick); err != nil { continue }
+		}
+		res, err := db.Exec(`
+			INSERT INTO mentions (message_id, nickname)
+			SELECT $1, nickname FROM users WHERE nickname = $2
+			ON CONFLICT DO NOTHING`, msg.ID, nick)
+		if err != nil {
+			slog.Warn("mention insert failed", "nick", nick, "message_id", msg.ID, "err", err)
+			continue
+		}
+		if n, _ := res.RowsAffected(); n == 0 { continue }
+
+		publishDirect(DirectEvent{Type: "mention", Target: nick, Message: msg})
+		go notifyOffline(nick, PushPayload{Title: msg.SenderNick + " mentioned you", Body: msg.Content, MessageID: msg.ID})
+	}
+}
+
+// 방 멤버 닉네임 (@all/@here 대상)
+func roomMemberNicks(roomID int) []string {
+	rows, err := db.Query("SELECT nickname FROM room_members WHERE room_id = $1", roomID)
+	if err != nil { slog.Warn("room member lookup failed", "room_id", roomID, "err", err); return nil }
+	defer rows.Close()
+	var nicks []string
+	for rows.Next() {
+		var nick string
+		rows.Scan(&nick)
+		nicks = append(nicks, nick)
+	}
+	return nicks
+}
+
+// [멘션 목록] GET /mentions?nick=&before_id=&limit=
+func mentionsHandler(w http.ResponseWriter, r *http.Request) {
+	nick := r.URL.Query().Get("nick")
+	if nick == "" { respondError(w, r, http.StatusBadRequest, errors.New("nick required")); return }
+
+	limit := 30
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 && l <= 100 {
+		limit = l
+	}
+	beforeID, err := strconv.Atoi(r.URL.Query().Get("before_id"))
+	if err != nil || beforeID <= 0 { beforeID = math.MaxInt32 }
+
+	rows, err := db.Query(`
+		SELECT m.id, m.content, m.sender_pod, m.sender_nick,
+			COALESCE(u.color_code, '#ffffff'), to_char(m.created_at, 'HH24:MI:SS')
+		FROM mentions mt
+		JOIN messages m ON m.id = mt.message_id
+		LEFT JOIN users u ON m.sender_nick = u.nickname
+		WHERE mt.nickname = $1 AND m.id < $2
+		ORDER BY m.id DESC LIMIT $3`, nick, beforeID, limit)
+	if err != nil { respondError(w, r, 500, err); return }
+	defer rows.Close()
+
+	mentions := []Message{}
+	for rows.Next() {
+		m := Message{Type: messageTypeText}
+		rows.Scan(&m.ID, &m.Content, &m.SenderPod, &m.SenderNick, &m.SenderColor, &m.Time)
+		mentions = append(mentions, m)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(mentions)
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseMentions(t *testing.T) {
+	tests := []struct {
+		content string
+		want    []string
+	}{
+		{"hello", nil},
+		{"@alice hi", []string{"alice"}},
+		{"@alice @bob @alice", []string{"alice", "bob"}},
+		{"@민수 안녕", []string{"민수"}},
+		{"cc @a.b-c_d, thanks", []string{"a.b-c_d"}},
+		{"@all 공지", []string{"all"}},
+		{"@ alone", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.content, func(t *testing.T) {
+			if got := parseMentions(tt.content); !slices.Equal(got, tt.want) { t.Errorf("parseMentions(%q) = %q, want %q", tt.content, got, tt.want) }
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// [메트릭] /metrics로 노출되는 Prometheus 지표
+var (
+	messagesSent = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "gotalk_messages_sent_total",
+		Help: "Messages accepted by /send on this pod and published to NATS, by room tier.",
+	}, []string{"room"})
+	messagesReceived = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "gotalk_messages_received_total",
+		Help: "Messages received from NATS for broadcasting.",
+	})
+	messagesDelivered = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "gotalk_messages_delivered_total",
+		Help: "Messages handed to local client channels, by room tier.",
+	}, []string{"room"})
+	broadcastDropped = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "gotalk_broadcast_dropped_total",
+		Help: "Messages dropped because a client channel was full, by room tier.",
+	}, []string{"room"})
+	historyCacheHits = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "gotalk_history_cache_hits_total",
+		Help: "First-page /history requests served from the per-room cache.",
+	})
+	historyCacheMisses = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "gotalk_history_cache_misses_total",
+		Help: "First-page /history requests that had to query Postgres.",
+	})
+	natsReconnects = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "gotalk_nats_reconnects_total",
+		Help: "NATS reconnections since process start.",
+	})
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "gotalk_http_request_duration_seconds",
+		Help:    "HTTP request latency by route pattern (streams excluded).",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route", "code"})
+)
+
+func init() {
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "gotalk_connected_clients",
+		Help: "SSE clients currently attached to this pod.",
+	}, func() float64 {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return float64(len(clients))
+	})
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "gotalk_broadcast_queue_depth",
+		Help: "Messages waiting in the broadcast channel.",
+	}, func() float64 {
+		return float64(len(broadcast))
+	})
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status  int
+	bytes   int
+	started bool // 상태 줄이 이미 나갔는지 (패닉 복구가 응답을 새로 쓸 수 있는지)
+}
+
+func (s *statusRecorder) Write(b []byte) (int, error) {
+	s.started = true
+	n, err := s.ResponseWriter.Write(b)
+	s.bytes += n
+	return n, err
+}
+
+func (s *statusRecorder) WriteHeader(code int) {
+	s.status = code
+	if code >= 200 { s.started = true }
+	s.ResponseWriter.WriteHeader(code)
+}
+
+// SSE 스트림이 Flush를 쓸 수 있도록 전달
+func (s *statusRecorder) Flush() {
+	s.started = true
+	if f, ok := s.ResponseWriter.(http.Flusher); ok { f.Flush() }
+}
+
+func (s *statusRecorder) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
+
+// [지연 측정 미들웨어] 라우트 패턴 기준으로 기록 (경로 ID로 카디널리티가 늘지 않게)
+// 먹스 바로 바깥에 둬야 요청에 r.Pattern이 채워진 뒤 읽을 수 있음
+func metricsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isLongLived(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		start := time.Now()
+		next.ServeHTTP(rec, r)
+
+		route := r.Pattern
+		if route == "" { route = "unmatched" }
+		httpDuration.WithLabelValues(r.Method, route, strconv.Itoa(rec.status)).Observe(time.Since(start).Seconds())
+	})
+}
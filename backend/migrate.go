@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// [마이그레이션] migrations/NNNN_이름.up.sql / .down.sql 을 바이너리에 내장해 순서대로 적용
+// 적용 이력은 schema_version 테이블에 남기고, 여러 파드가 동시에 뜰 때는 advisory lock으로 한 파드만 실행
+// 0001~0015는 예전 initDB의 IF NOT EXISTS 구문 그대로라 기존 DB에도 안전하게 적용됨
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+const migrationLockKey = 0x676f74616c6b // "gotalk"
+
+type migration struct {
+	version int
+	name    string
+	up      string
+	down    string
+}
+
+func loadMigrations() ([]migration, error) {
+	entries, err := fs.Glob(migrationFiles, "migrations/*.sql")
+	if err != nil { return nil, err }
+
+	byVersion := map[int]*migration{}
+	for _, path := range entries {
+		base := strings.TrimPrefix(path, "migrations/")
+		stem, direction, ok := strings.Cut(strings.TrimSuffix(base, ".sql"), ".")
+		if !ok || (direction != "up" && direction != "down") { return nil, fmt.Errorf("migration %s: expected NNNN_name.up.sql or .down.sql", base) }
+		num, name, _ := strings.Cut(stem, "_")
+		version, err := strconv.Atoi(num)
+		if err != nil { return nil, fmt.Errorf("migration %s: invalid version", base) }
+
+		body, err := migrationFiles.ReadFile(path)
+		if err != nil { return nil, err }
+		m := byVersion[version]
+		if m == nil {
+			m = &migration{version: version, name: name}
+			byVersion[version] = m
+		}
+		if direction == "up" { m.up = string(body) } else { m.down = string(body) }
+	}
+
+	list := make([]migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.up == "" { return nil, fmt.Errorf("migration %04d_%s: missing up.sql", m.version, m.name) }
+		list = append(list, *m)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].version < list[j].version })
+	return list, nil
+}
+
+// 잠금을 잡은 전용 커넥션에서 fn 실행 (다른 파드는 끝날 때까지 대기)
+func withMigrationLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := db.Conn(ctx)
+	if err != nil { return err }
+	defer conn.Close()
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLockKey); err != nil { return err }
+	defer conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", migrationLockKey)
+
+	_, err = conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_version (
+		version INT PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`)
+	if err != nil { return err }
+	return fn(conn)
+}
+
+func currentSchemaVersion(ctx context.Context, conn *sql.Conn) (int, error) {
+	var v int
+	err := conn.QueryRowContext(ctx, "SELECT COALESCE(MAX(version), 0) FROM schema_version").Scan(&v)
+	return v, err
+}
+
+// 아직 적용되지 않은 마이그레이션을 하나씩 트랜잭션으로 적용
+func migrateUp(ctx context.Context) error {
+	list, err := loadMigrations()
+	if err != nil { return err }
+	return withMigrationLock(ctx, func(conn *sql.Conn) error {
+		current, err := currentSchemaVersion(ctx, conn)
+		if err != nil { return err }
+		for _, m := range list {
+			if m.version <= current { continue }
+			tx, err := conn.BeginTx(ctx, nil)
+			if err != nil { return err }
+			if _, err := tx.ExecContext(ctx, m.up); err != nil {
+				tx.Rollback()
+				return fmt.Errorf("migration %04d_%s: %w", m.version, m.name, err)
+			}
+			if _, err := tx.ExecContext(ctx, "INSERT INTO schema_version (version, name) VALUES ($1, $2)", m.version, m.name); err != nil {
+				tx.Rollback()
+				return err
+			}
+			if err := tx.Commit(); err != nil { return err }
+			slog.Info("applied migration", "version", m.version, "name", m.name)
+		}
+		return nil
+	})
+}
+
+// 최근 적용된 마이그레이션부터 steps개 되돌림
+func migrateDown(ctx context.Context, steps int) error {
+	list, err := loadMigrations()
+	if err != nil { return err }
+	byVersion := map[int]migration{}
+	for _, m := range list {
+		byVersion[m.version] = m
+	}
+	return withMigrationLock(ctx, func(conn *sql.Conn) error {
+		for i := 0; i < steps; i++ {
+			current, err := currentSchemaVersion(ctx, conn)
+			if err != nil { return err }
+			if current == 0 { return nil }
+			m, ok := byVersion[current]
+			if !ok || m.down == "" { return fmt.Errorf("migration %04d has no down.sql", current) }
+
+			tx, err := conn.BeginTx(ctx, nil)
+			if err != nil { return err }
+			if _, err := tx.ExecContext(ctx, m.down); err != nil {
+				tx.Rollback()
+				return fmt.Errorf("migration %04d_%s down: %w", m.version, m.name, err)
+			}
+			if _, err := tx.ExecContext(ctx, "DELETE FROM schema_version WHERE version = $1", m.version); err != nil {
+				tx.Rollback()
+				return err
+			}
+			if err := tx.Commit(); err != nil { return err }
+			slog.Info("reverted migration", "version", m.version, "name", m.name)
+		}
+		return nil
+	})
+}
+
+// [마이그레이션 명령] gotalk migrate <up|down> [steps] [설정 플래그...]
+// 서버를 띄우지 않고 스키마만 올리거나 되돌린 뒤 종료
+func runMigrateCommand(args []string) {
+	if len(args) == 0 { fmt.Fprintln(os.Stderr, "usage: gotalk migrate <up|down> [steps] [flags]"); os.Exit(2) }
+	direction, rest := args[0], args[1:]
+	steps := 1
+	if len(rest) > 0 && !strings.HasPrefix(rest[0], "-") {
+		n, err := strconv.Atoi(rest[0])
+		if err != nil || n < 1 { fmt.Fprintln(os.Stderr, "steps must be a positive integer"); os.Exit(2) }
+		steps, rest = n, rest[1:]
+	}
+
+	var err error
+	if cfg, err = loadConfig(rest); err != nil { fatal("invalid config", err) }
+	initLogger()
+	if cfg.DB.Driver != dbDriverPostgres { fatal("migrate command", fmt.Errorf("versioned migrations are postgres-only; %s schema is created at startup", cfg.DB.Driver)) }
+	openDB()
+
+	switch direction {
+	case "up":
+		err = migrateUp(context.Background())
+	case "down":
+		err = migrateDown(context.Background(), steps)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown migrate direction %q\n", direction)
+		os.Exit(2)
+	}
+	if err != nil { fatal("migration failed", err) }
+}
@@ -0,0 +1,4 @@
+-- 스레드 답글용 원글 참조
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS parent_id INT REFERENCES messages(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_messages_parent_id ON messages (parent_id, id);
@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS mentions (
+    message_id INT REFERENCES messages(id) ON DELETE CASCADE,
+    nickname TEXT,
+    PRIMARY KEY (nickname, message_id)
+);
@@ -0,0 +1,4 @@
+-- 탈퇴 유예(톰스톤) 상태
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS recovery_code_hash TEXT;
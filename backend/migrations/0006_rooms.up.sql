@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS rooms (
+    id SERIAL PRIMARY KEY,
+    name TEXT UNIQUE NOT NULL,
+    owner_nick TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    orphaned_at TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS room_members (
+    room_id INT REFERENCES rooms(id) ON DELETE CASCADE,
+    nickname TEXT,
+    role TEXT NOT NULL DEFAULT 'member',
+    joined_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (room_id, nickname)
+);
+
+CREATE INDEX IF NOT EXISTS idx_rooms_owner_nick ON rooms (owner_nick);
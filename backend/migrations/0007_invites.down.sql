@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_users_invite_code;
+ALTER TABLE users DROP COLUMN IF EXISTS invite_code;
+ALTER TABLE users DROP COLUMN IF EXISTS created_at;
+DROP TABLE IF EXISTS invites;
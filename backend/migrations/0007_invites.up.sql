@@ -0,0 +1,17 @@
+-- 초대 코드와 가입 경로 추적
+CREATE TABLE IF NOT EXISTS invites (
+    code TEXT PRIMARY KEY,
+    created_by TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP,
+    max_uses INT NOT NULL DEFAULT 1,
+    uses INT NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_invites_created_by ON invites (created_by);
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP;
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS invite_code TEXT REFERENCES invites(code) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_users_invite_code ON users (invite_code);
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS analytics_daily (
+    day DATE NOT NULL,
+    metric TEXT NOT NULL,
+    dimension TEXT NOT NULL,
+    value BIGINT NOT NULL,
+    PRIMARY KEY (day, metric, dimension)
+);
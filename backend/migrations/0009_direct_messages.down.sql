@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_messages_recipient;
+ALTER TABLE messages DROP COLUMN IF EXISTS recipient_nick;
@@ -0,0 +1,4 @@
+-- DM: 받는 사람이 있는 메시지 (공개 기록에서 제외)
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS recipient_nick TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_messages_recipient ON messages (recipient_nick, id) WHERE recipient_nick IS NOT NULL;
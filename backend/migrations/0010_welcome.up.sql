@@ -0,0 +1,4 @@
+-- 환영 DM 발송 여부 (기존 사용자는 이미 받은 것으로 채운 뒤 기본값 제거)
+ALTER TABLE users ADD COLUMN IF NOT EXISTS welcomed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP;
+
+ALTER TABLE users ALTER COLUMN welcomed_at DROP DEFAULT;
@@ -0,0 +1,4 @@
+ALTER TABLE rooms DROP COLUMN IF EXISTS topic;
+ALTER TABLE rooms DROP COLUMN IF EXISTS is_public;
+DROP INDEX IF EXISTS idx_messages_room_id;
+ALTER TABLE messages DROP COLUMN IF EXISTS room_id;
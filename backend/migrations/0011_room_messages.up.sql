@@ -0,0 +1,8 @@
+-- 방 메시지 (NULL이면 로비)
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS room_id INT REFERENCES rooms(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_messages_room_id ON messages (room_id, id);
+
+ALTER TABLE rooms ADD COLUMN IF NOT EXISTS is_public BOOLEAN NOT NULL DEFAULT TRUE;
+
+ALTER TABLE rooms ADD COLUMN IF NOT EXISTS topic TEXT NOT NULL DEFAULT '';
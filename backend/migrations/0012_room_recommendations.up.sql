@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS room_recommendations (
+    nickname TEXT NOT NULL,
+    room_id INT REFERENCES rooms(id) ON DELETE CASCADE,
+    score DOUBLE PRECISION NOT NULL,
+    reasons TEXT[] NOT NULL DEFAULT '{}',
+    computed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (nickname, room_id)
+);
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS pins (
+    message_id INT PRIMARY KEY REFERENCES messages(id) ON DELETE CASCADE,
+    room_id INT REFERENCES rooms(id) ON DELETE CASCADE,
+    pinned_by TEXT NOT NULL,
+    pinned_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP,
+    expired_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_pins_room ON pins (room_id, pinned_at DESC);
+
+CREATE INDEX IF NOT EXISTS idx_pins_expires ON pins (expires_at) WHERE expired_at IS NULL;
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS analytics_hourly (
+    hour TIMESTAMP NOT NULL,
+    metric TEXT NOT NULL,
+    dimension TEXT NOT NULL,
+    value BIGINT NOT NULL,
+    PRIMARY KEY (hour, metric, dimension)
+);
+
+CREATE INDEX IF NOT EXISTS idx_analytics_hourly_dim ON analytics_hourly (metric, dimension, hour);
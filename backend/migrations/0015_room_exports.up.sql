@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS room_exports (
+    id SERIAL PRIMARY KEY,
+    room_id INT NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
+    requested_by TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    error TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_room_exports_pending ON room_exports (id) WHERE status = 'pending';
@@ -0,0 +1,3 @@
+DELETE FROM messages WHERE type <> 'message';
+ALTER TABLE messages DROP COLUMN IF EXISTS event;
+ALTER TABLE messages DROP COLUMN IF EXISTS type;
@@ -0,0 +1,4 @@
+-- 시스템 이벤트(입장/퇴장/주제 변경/고정)를 메시지 타임라인에 함께 저장
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS type TEXT NOT NULL DEFAULT 'message';
+
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS event JSONB;
@@ -0,0 +1,2 @@
+ALTER TABLE messages DROP COLUMN IF EXISTS day_divider;
+ALTER TABLE messages DROP COLUMN IF EXISTS group_key;
@@ -0,0 +1,4 @@
+-- 메시지 묶음/날짜 구분 힌트 (저장 시점에 계산)
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS group_key INT;
+
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS day_divider DATE;
@@ -0,0 +1,6 @@
+-- 관리자 차단 (banned_until이 NULL이면 영구)
+ALTER TABLE users ADD COLUMN IF NOT EXISTS banned_at TIMESTAMP;
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS ban_reason TEXT;
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS banned_until TIMESTAMP;
@@ -0,0 +1,12 @@
+-- 외부 사이트 임베드 위젯용 방 토큰 (read: 읽기 전용, guest: 게스트 글쓰기 허용)
+CREATE TABLE IF NOT EXISTS embed_tokens (
+    token TEXT PRIMARY KEY,
+    room_id INT NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
+    mode TEXT NOT NULL DEFAULT 'read',
+    allowed_origins TEXT[] NOT NULL DEFAULT '{}',
+    created_by TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    revoked_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS embed_tokens_room_id_idx ON embed_tokens (room_id);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS survey_responses;
+DROP TABLE IF EXISTS survey_deliveries;
+DROP TABLE IF EXISTS surveys;
@@ -0,0 +1,25 @@
+-- 관리자 한 문항 설문 (options가 비어 있으면 자유 응답)
+CREATE TABLE IF NOT EXISTS surveys (
+    id SERIAL PRIMARY KEY,
+    question TEXT NOT NULL,
+    options TEXT[] NOT NULL DEFAULT '{}',
+    sample_rate REAL NOT NULL DEFAULT 1,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    closed_at TIMESTAMP
+);
+
+-- 설문을 받은 사용자 (응답 자격 확인과 응답률 계산용)
+CREATE TABLE IF NOT EXISTS survey_deliveries (
+    survey_id INT NOT NULL REFERENCES surveys(id) ON DELETE CASCADE,
+    nickname TEXT NOT NULL,
+    delivered_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (survey_id, nickname)
+);
+
+CREATE TABLE IF NOT EXISTS survey_responses (
+    survey_id INT NOT NULL REFERENCES surveys(id) ON DELETE CASCADE,
+    nickname TEXT NOT NULL,
+    answer TEXT NOT NULL,
+    responded_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (survey_id, nickname)
+);
@@ -0,0 +1,12 @@
+-- 재시도 후에도 처리하지 못한 비동기 작업 (푸시 전송, 방 내보내기 등) - 관리자가 확인 후 재시도/폐기
+CREATE TABLE IF NOT EXISTS dead_letters (
+    id SERIAL PRIMARY KEY,
+    kind TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    error TEXT NOT NULL,
+    attempts INT NOT NULL DEFAULT 1,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    last_failed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS dead_letters_kind_idx ON dead_letters (kind, id);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS messages_created_at_idx;
+DROP TABLE IF EXISTS messages_archive;
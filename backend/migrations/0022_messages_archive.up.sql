@@ -0,0 +1,13 @@
+-- 보관 기간이 지난 메시지 보관소 (retention.archive) - 이후 컬럼이 늘어도 깨지지 않게 행 전체를 JSONB로
+CREATE TABLE IF NOT EXISTS messages_archive (
+    id INT PRIMARY KEY,
+    room_id INT,
+    created_at TIMESTAMP,
+    data JSONB NOT NULL,
+    archived_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS messages_archive_room_idx ON messages_archive (room_id, id);
+
+-- 보관 기간 정리 작업이 오래된 행을 찾을 때
+CREATE INDEX IF NOT EXISTS messages_created_at_idx ON messages (created_at);
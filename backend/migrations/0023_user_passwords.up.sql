@@ -0,0 +1,2 @@
+-- auth.provider=password용 비밀번호 해시 (bcrypt)
+ALTER TABLE users ADD COLUMN IF NOT EXISTS password_hash TEXT;
@@ -0,0 +1,11 @@
+-- 새 메시지를 외부로 보내는 웹훅 (room_id/pattern이 비어 있으면 전체)
+CREATE TABLE IF NOT EXISTS webhooks (
+    id SERIAL PRIMARY KEY,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    room_id INT REFERENCES rooms(id) ON DELETE CASCADE,
+    pattern TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    last_delivered_at TIMESTAMP,
+    last_error TEXT
+);
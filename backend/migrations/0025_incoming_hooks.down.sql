@@ -0,0 +1,2 @@
+ALTER TABLE messages DROP COLUMN IF EXISTS sender_type;
+DROP TABLE IF EXISTS incoming_hooks;
@@ -0,0 +1,15 @@
+-- CI/모니터링 도구가 POST /hooks/{token}으로 글을 올리는 수신 웹훅 (room_id가 없으면 로비)
+CREATE TABLE IF NOT EXISTS incoming_hooks (
+    token TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    room_id INT REFERENCES rooms(id) ON DELETE CASCADE,
+    created_by TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    last_used_at TIMESTAMP,
+    revoked_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS incoming_hooks_room_id_idx ON incoming_hooks (room_id);
+
+-- 보낸 주체 종류 (user 또는 bot)
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS sender_type TEXT NOT NULL DEFAULT 'user';
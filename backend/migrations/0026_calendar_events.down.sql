@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS calendar_rsvps;
+DROP TABLE IF EXISTS calendar_events;
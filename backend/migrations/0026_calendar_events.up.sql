@@ -0,0 +1,22 @@
+-- 방 일정 (모임 등)과 참석 응답
+CREATE TABLE IF NOT EXISTS calendar_events (
+    id SERIAL PRIMARY KEY,
+    room_id INT NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
+    title TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    starts_at TIMESTAMPTZ NOT NULL,
+    created_by TEXT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    canceled_at TIMESTAMPTZ,
+    reminded_at TIMESTAMPTZ -- 시작 전 알림을 보낸 시각 (한 번만)
+);
+
+CREATE INDEX IF NOT EXISTS calendar_events_room_starts_idx ON calendar_events (room_id, starts_at);
+
+CREATE TABLE IF NOT EXISTS calendar_rsvps (
+    event_id INT REFERENCES calendar_events(id) ON DELETE CASCADE,
+    nickname TEXT NOT NULL,
+    status TEXT NOT NULL, -- going | maybe | declined
+    responded_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (event_id, nickname)
+);
@@ -0,0 +1,13 @@
+-- /timer 명령으로 시작한 타이머 (남은 시간은 message_id 글을 고쳐 가며 표시)
+CREATE TABLE IF NOT EXISTS timers (
+    id SERIAL PRIMARY KEY,
+    message_id INT NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+    room_id INT REFERENCES rooms(id) ON DELETE CASCADE,
+    label TEXT NOT NULL DEFAULT '',
+    created_by TEXT NOT NULL,
+    ends_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    done_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS timers_active_idx ON timers (ends_at) WHERE done_at IS NULL;
@@ -0,0 +1,2 @@
+-- 아바타 이미지 객체 이름 (내용 해시 + 확장자, /avatars/{이름}으로 제공)
+ALTER TABLE users ADD COLUMN IF NOT EXISTS avatar TEXT;
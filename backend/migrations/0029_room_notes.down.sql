@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS room_note_history;
+DROP TABLE IF EXISTS room_notes;
@@ -0,0 +1,21 @@
+-- 방 공용 메모 (키-값, 주제 링크/당번표/상태판 등)
+CREATE TABLE IF NOT EXISTS room_notes (
+    room_id INT REFERENCES rooms(id) ON DELETE CASCADE,
+    key TEXT NOT NULL,
+    value TEXT NOT NULL,
+    updated_by TEXT NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (room_id, key)
+);
+
+-- 변경 기록 (value가 NULL이면 삭제)
+CREATE TABLE IF NOT EXISTS room_note_history (
+    id SERIAL PRIMARY KEY,
+    room_id INT REFERENCES rooms(id) ON DELETE CASCADE,
+    key TEXT NOT NULL,
+    value TEXT,
+    changed_by TEXT NOT NULL,
+    changed_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS room_note_history_key_idx ON room_note_history (room_id, key, id DESC);
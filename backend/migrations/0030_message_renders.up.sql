@@ -0,0 +1,8 @@
+-- 본문 렌더링 결과 캐시 (본문 해시 + 렌더러 버전), 버전이 바뀌면 정리 작업이 옛 행을 지움
+CREATE TABLE IF NOT EXISTS message_renders (
+    content_hash TEXT NOT NULL,
+    renderer_version INT NOT NULL,
+    html TEXT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (content_hash, renderer_version)
+);
@@ -0,0 +1,9 @@
+-- 외부 로그인(OIDC, GitHub) 신원 → GoTalk 사용자, IdP 쪽 표시 이름이 바뀌어도 같은 계정으로 묶음
+CREATE TABLE IF NOT EXISTS user_identities (
+    provider TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    nickname TEXT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (provider, subject)
+);
+CREATE INDEX IF NOT EXISTS idx_user_identities_nickname ON user_identities (nickname);
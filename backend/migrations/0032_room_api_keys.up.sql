@@ -0,0 +1,12 @@
+-- 로비 화면/대시보드용 방 전용 읽기 키 (그 방의 기록과 스트림만)
+CREATE TABLE IF NOT EXISTS room_api_keys (
+    key TEXT PRIMARY KEY,
+    room_id INT NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    created_by TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    last_used_at TIMESTAMP,
+    revoked_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS room_api_keys_room_id_idx ON room_api_keys (room_id);
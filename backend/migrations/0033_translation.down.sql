@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS translations;
+ALTER TABLE users DROP COLUMN IF EXISTS locale;
+ALTER TABLE rooms DROP COLUMN IF EXISTS translate;
+ALTER TABLE rooms DROP COLUMN IF EXISTS language;
@@ -0,0 +1,14 @@
+-- 방 기본 언어와 번역 중계 여부, 사용자 프로필 언어
+ALTER TABLE rooms ADD COLUMN IF NOT EXISTS language TEXT;
+ALTER TABLE rooms ADD COLUMN IF NOT EXISTS translate BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS locale TEXT;
+
+-- 번역 결과 캐시 (본문 해시 + 원문/대상 언어), 같은 글은 번역 API를 다시 부르지 않음
+CREATE TABLE IF NOT EXISTS translations (
+    content_hash TEXT NOT NULL,
+    source TEXT NOT NULL,
+    target TEXT NOT NULL,
+    content TEXT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (content_hash, source, target)
+);
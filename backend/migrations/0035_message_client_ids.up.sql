@@ -0,0 +1,8 @@
+-- /send 재전송 중복 방지: 보낸 사람별 client_msg_id → 처음 저장된 메시지
+CREATE TABLE IF NOT EXISTS message_client_ids (
+    sender_nick TEXT NOT NULL,
+    client_msg_id TEXT NOT NULL,
+    message_id INT NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (sender_nick, client_msg_id)
+);
@@ -0,0 +1,2 @@
+-- 배정한 색과 직접 고른 색을 구분할 수 없어 되돌리지 않음
+SELECT 1;
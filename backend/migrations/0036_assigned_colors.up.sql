@@ -0,0 +1,6 @@
+-- 색을 고르지 않은(없거나 예전 기본값 #ffffff) 사용자에게 닉네임 해시로 팔레트 색 배정 (palette.go의 assignedColor와 같은 계산)
+UPDATE users
+SET color_code = (ARRAY['#fde68a', '#fecaca', '#bbf7d0', '#bfdbfe', '#ddd6fe', '#fbcfe8',
+                        '#a5f3fc', '#fed7aa', '#d9f99d', '#c7d2fe', '#99f6e4', '#e9d5ff'])
+                 [1 + (('x' || substr(md5(nickname), 1, 8))::bit(32)::bigint % 12)]
+WHERE color_code IS NULL OR color_code = '#ffffff';
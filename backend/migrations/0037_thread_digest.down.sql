@@ -0,0 +1,3 @@
+ALTER TABLE rooms DROP COLUMN IF EXISTS thread_digest_at;
+ALTER TABLE rooms DROP COLUMN IF EXISTS thread_digest_min_replies;
+ALTER TABLE rooms DROP COLUMN IF EXISTS thread_digest_interval;
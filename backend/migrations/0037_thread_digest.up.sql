@@ -0,0 +1,4 @@
+-- 방별 스레드 활동 요약 (주기는 초, NULL이면 꺼짐)
+ALTER TABLE rooms ADD COLUMN IF NOT EXISTS thread_digest_interval INT;
+ALTER TABLE rooms ADD COLUMN IF NOT EXISTS thread_digest_min_replies INT NOT NULL DEFAULT 3;
+ALTER TABLE rooms ADD COLUMN IF NOT EXISTS thread_digest_at TIMESTAMPTZ;
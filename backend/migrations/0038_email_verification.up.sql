@@ -0,0 +1,13 @@
+-- 가입 계정 이메일과 인증 토큰 (토큰은 해시만 저장, 인증된 주소는 한 계정에만)
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email TEXT;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMPTZ;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_verified_email ON users (LOWER(email)) WHERE email_verified_at IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS email_verifications (
+    token_hash TEXT PRIMARY KEY,
+    nickname TEXT NOT NULL,
+    email TEXT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_email_verifications_nickname ON email_verifications (nickname, created_at);
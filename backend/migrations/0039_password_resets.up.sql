@@ -0,0 +1,11 @@
+-- 비밀번호 재설정 토큰 (해시만 저장, 한 번 쓰면 used_at), 요청/사용 IP는 감사용으로 남김
+CREATE TABLE IF NOT EXISTS password_resets (
+    token_hash TEXT PRIMARY KEY,
+    nickname TEXT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMPTZ NOT NULL,
+    requested_ip TEXT NOT NULL DEFAULT '',
+    used_at TIMESTAMPTZ,
+    used_ip TEXT
+);
+CREATE INDEX IF NOT EXISTS idx_password_resets_nickname ON password_resets (nickname, created_at);
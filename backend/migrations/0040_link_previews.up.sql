@@ -0,0 +1,17 @@
+-- 링크 미리보기 (주소별 캐시, 가져오지 못한 주소도 ok=false로 남겨 다시 시도하지 않음)와 메시지별 미리보기 목록
+CREATE TABLE IF NOT EXISTS link_previews (
+    url TEXT PRIMARY KEY,
+    ok BOOLEAN NOT NULL DEFAULT TRUE,
+    title TEXT NOT NULL DEFAULT '',
+    description TEXT NOT NULL DEFAULT '',
+    image_url TEXT NOT NULL DEFAULT '',
+    site_name TEXT NOT NULL DEFAULT '',
+    fetched_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS message_link_previews (
+    message_id INT NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    position INT NOT NULL DEFAULT 0,
+    PRIMARY KEY (message_id, url)
+);
@@ -0,0 +1,3 @@
+-- 방 잠금 (운영진만 글쓰기, 이 시각이 지나면 스케줄러가 풀고 알림)
+ALTER TABLE rooms ADD COLUMN IF NOT EXISTS locked_until TIMESTAMPTZ;
+ALTER TABLE rooms ADD COLUMN IF NOT EXISTS locked_by TEXT;
@@ -0,0 +1,9 @@
+-- 개인 즐겨찾기(별표) 메시지, 닉네임 기준이라 기기 간 동기화됨
+CREATE TABLE IF NOT EXISTS stars (
+    id BIGSERIAL UNIQUE,
+    nickname TEXT NOT NULL,
+    message_id INT NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+    starred_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (nickname, message_id)
+);
+CREATE INDEX IF NOT EXISTS idx_stars_nickname ON stars (nickname, id DESC);
@@ -0,0 +1,7 @@
+-- 사용자 차단 (blocker는 blocked가 보낸 메시지/이벤트를 스트림과 기록에서 받지 않음)
+CREATE TABLE IF NOT EXISTS user_blocks (
+    blocker TEXT NOT NULL,
+    blocked TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (blocker, blocked)
+);
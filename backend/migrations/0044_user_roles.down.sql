@@ -0,0 +1,2 @@
+ALTER TABLE rooms DROP COLUMN IF EXISTS announcement;
+ALTER TABLE users DROP COLUMN IF EXISTS role;
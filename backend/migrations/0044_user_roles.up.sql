@@ -0,0 +1,3 @@
+-- 사이트 역할 (admin/moderator/user)과 공지 방 (방 소유자/운영진만 글쓰기)
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role TEXT NOT NULL DEFAULT 'user' CHECK (role IN ('admin', 'moderator', 'user'));
+ALTER TABLE rooms ADD COLUMN IF NOT EXISTS announcement BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,11 @@
+-- 방 초대 링크 (토큰은 서명으로 확인하고, 폐기/사용 횟수는 이 테이블로)
+CREATE TABLE IF NOT EXISTS room_invites (
+    id BIGSERIAL PRIMARY KEY,
+    room_id INT NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
+    created_by TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ,
+    uses INT NOT NULL DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS idx_room_invites_room ON room_invites (room_id);
@@ -0,0 +1,3 @@
+ALTER TABLE push_subscriptions DROP COLUMN IF EXISTS device_id;
+ALTER TABLE sessions DROP COLUMN IF EXISTS device_id;
+DROP TABLE IF EXISTS devices;
@@ -0,0 +1,14 @@
+-- 사용자별 기기 (기기 쿠키/X-Device-ID로 구분), 세션과 푸시 구독이 어느 기기 것인지 연결
+CREATE TABLE IF NOT EXISTS devices (
+    id TEXT NOT NULL,
+    nickname TEXT NOT NULL,
+    fingerprint TEXT NOT NULL DEFAULT '',
+    platform TEXT NOT NULL DEFAULT '',
+    user_agent TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_active_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (nickname, id)
+);
+CREATE INDEX IF NOT EXISTS idx_devices_fingerprint ON devices (nickname, fingerprint);
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS device_id TEXT;
+ALTER TABLE push_subscriptions ADD COLUMN IF NOT EXISTS device_id TEXT;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_message_link_previews_room;
+ALTER TABLE message_link_previews DROP COLUMN IF EXISTS room_id;
+ALTER TABLE message_link_previews DROP COLUMN IF EXISTS id;
+DROP TABLE IF EXISTS attachments;
@@ -0,0 +1,16 @@
+-- 방 미디어 모아 보기: 글에 올린 이미지/파일 주소와 링크 미리보기를 방별 최신순으로 조회
+CREATE TABLE IF NOT EXISTS attachments (
+    id BIGSERIAL PRIMARY KEY,
+    message_id INT NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+    room_id INT NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
+    kind TEXT NOT NULL CHECK (kind IN ('image', 'file')),
+    url TEXT NOT NULL,
+    name TEXT NOT NULL DEFAULT '',
+    UNIQUE (message_id, url)
+);
+CREATE INDEX IF NOT EXISTS idx_attachments_room ON attachments (room_id, kind, id DESC);
+
+ALTER TABLE message_link_previews ADD COLUMN IF NOT EXISTS id BIGSERIAL;
+ALTER TABLE message_link_previews ADD COLUMN IF NOT EXISTS room_id INT REFERENCES rooms(id) ON DELETE CASCADE;
+UPDATE message_link_previews p SET room_id = m.room_id FROM messages m WHERE m.id = p.message_id AND p.room_id IS NULL;
+CREATE INDEX IF NOT EXISTS idx_message_link_previews_room ON message_link_previews (room_id, id DESC);
@@ -0,0 +1,2 @@
+-- 방 설명 (주제보다 긴 소개, PATCH /rooms/{id})
+ALTER TABLE rooms ADD COLUMN IF NOT EXISTS description TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+-- 방별 읽은 위치 (GET /rooms의 안 읽은 수), NULL이면 참여 이후 전부 안 읽음
+ALTER TABLE room_members ADD COLUMN IF NOT EXISTS last_read_id INT;
@@ -0,0 +1,8 @@
+-- 다른 사용자에 대한 개인 메모 (쓴 사람만 봄)
+CREATE TABLE IF NOT EXISTS user_notes (
+    author TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    body TEXT NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (author, subject)
+);
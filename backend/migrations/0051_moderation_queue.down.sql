@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS pending_messages;
+ALTER TABLE rooms DROP COLUMN IF EXISTS preapprove_hours;
@@ -0,0 +1,14 @@
+-- 방별 사전 승인: 가입한 지 preapprove_hours 시간이 안 된 계정의 글은 운영진이 승인할 때까지 대기열에 둠 (NULL이면 끔)
+ALTER TABLE rooms ADD COLUMN IF NOT EXISTS preapprove_hours INT;
+
+-- 승인 대기 글 (승인되면 messages로 옮기고, 승인/거절 모두 행을 지움)
+CREATE TABLE IF NOT EXISTS pending_messages (
+    id BIGSERIAL PRIMARY KEY,
+    room_id INT NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
+    sender_nick TEXT NOT NULL,
+    sender_color TEXT NOT NULL,
+    content TEXT NOT NULL,
+    parent_id INT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_pending_messages_room ON pending_messages (room_id, id);
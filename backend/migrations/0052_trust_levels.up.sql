@@ -0,0 +1,2 @@
+-- 자동 신뢰 등급 (new → basic → regular), 매일 스케줄러가 계정 나이와 메시지 수로 올림
+ALTER TABLE users ADD COLUMN IF NOT EXISTS trust_level TEXT NOT NULL DEFAULT 'new' CHECK (trust_level IN ('new', 'basic', 'regular'));
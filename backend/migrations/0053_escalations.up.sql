@@ -0,0 +1,16 @@
+-- 운영진 호출: 신고자와 당번 운영진만 들어가는 비공개 분류 방, 상태와 SLA 시각 기록
+CREATE TABLE IF NOT EXISTS escalations (
+    id BIGSERIAL PRIMARY KEY,
+    reporter TEXT NOT NULL,
+    reason TEXT NOT NULL,
+    message_id INT,
+    room_id INT REFERENCES rooms(id) ON DELETE SET NULL,
+    status TEXT NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'ack', 'resolved')),
+    assignee TEXT,
+    resolution TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    acked_at TIMESTAMPTZ,
+    resolved_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_escalations_status ON escalations (status, created_at);
+CREATE INDEX IF NOT EXISTS idx_escalations_reporter ON escalations (reporter) WHERE status <> 'resolved';
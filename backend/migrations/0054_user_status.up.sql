@@ -0,0 +1,3 @@
+-- 사용자가 직접 정하는 상태 (online/away/dnd)와 한 줄 상태 문구
+ALTER TABLE users ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'online' CHECK (status IN ('online', 'away', 'dnd'));
+ALTER TABLE users ADD COLUMN IF NOT EXISTS status_text TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+-- 표시 이름 (닉네임과 별개, 사내 배포에서 인사 자료로 채움)
+ALTER TABLE users ADD COLUMN IF NOT EXISTS display_name TEXT;
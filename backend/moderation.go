@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// [사전 승인] 방장/운영진이 켜 두면 가입한 지 preapprove_hours 시간이 안 된 계정의 방 글은 방송하지 않고 pending_messages에 보관
+// 대기 글이 생기면 그 방 운영진 스트림에 "pending" 개별 이벤트를 보내고, 승인하면 그때 일반 글처럼 저장/방송 (시각도 승인 시점)
+// 승인/거절하면 다른 운영진 화면에서도 빠지도록 "pending_resolved"를 보냄, 거절은 보낸 사람에게 시스템 DM으로 알림
+// 소유자/운영진의 글과 게스트 임베드 글은 대상이 아님
+const (
+	directPending         = "pending"
+	directPendingResolved = "pending_resolved"
+
+	preapproveMaxHours = 24 * 30
+	pendingMaxReason   = 200
+)
+
+var errPendingNotFound = errors.New("pending message not found")
+
+type PendingMessage struct {
+	ID          int64     `json:"id"`
+	RoomID      int       `json:"room_id"`
+	SenderNick  string    `json:"sender_nick"`
+	SenderColor string    `json:"sender_color"`
+	Content     string    `json:"content"`
+	ParentID    *int      `json:"parent_id,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	Status      string    `json:"status,omitempty"` // pending_resolved 이벤트에서만: approved | rejected
+}
+
+// 이 방에서 이 닉네임의 글을 승인 대기로 돌려야 하는지 (계정이 아직 없으면 새 계정으로 봄)
+func needsPreapproval(ctx context.Context, roomID *int, nick string) bool {
+	if roomID == nil || !fullFeatureStore() { return false }
+	if role := roomRole(*roomID, nick); role == roomRoleOwner || role == roomRoleModerator { return false }
+	var hold bool
+	db.QueryRowContext(ctx, `
+		SELECT r.preapprove_hours IS NOT NULL AND COALESCE(u.created_at > CURRENT_TIMESTAMP - r.preapprove_hours * INTERVAL '1 hour', TRUE)
+		FROM rooms r LEFT JOIN users u ON u.nickname = $2 WHERE r.id = $1`, *roomID, nick).Scan(&hold)
+	return hold
+}
+
+// 글을 대기열에 넣고 그 방 운영진에게 알림
+func holdMessage(ctx context.Context, roomID int, nick, color, content string, parentID *int) (PendingMessage, error) {
+	p := PendingMessage{RoomID: roomID, SenderNick: nick, SenderColor: color, Content: content, ParentID: parentID}
+	err := db.QueryRowContext(ctx, `INSERT INTO pending_messages (room_id, sender_nick, sender_color, content, parent_id) VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at`, roomID, nick, color, content, parentID).Scan(&p.ID, &p.CreatedAt)
+	if err != nil { return p, err }
+	notifyModerators(ctx, directPending, p)
+	slog.InfoContext(ctx, "message held for approval", "room_id", roomID, "nick", nick, "pending_id", p.ID)
+	return p, nil
+}
+
+// 방 소유자/운영진의 모든 연결에 대기열 변화를 알림 (다른 파드 연결은 브로커로)
+func notifyModerators(ctx context.Context, kind string, p PendingMessage) {
+	rows, err := db.QueryContext(ctx, "SELECT nickname FROM room_members WHERE room_id = $1 AND role IN ($2, $3)", p.RoomID, roomRoleOwner, roomRoleModerator)
+	if err != nil { slog.WarnContext(ctx, "moderator lookup failed", "room_id", p.RoomID, "err", err); return }
+	defer rows.Close()
+	for rows.Next() {
+		var mod string
+		rows.Scan(&mod)
+		publishDirect(DirectEvent{Type: kind, Target: mod, Pending: &p})
+	}
+}
+
+// 대기 글을 하나 꺼냄 (꺼낸 행은 지워지므로 운영진 둘이 동시에 눌러도 한 명만 처리)
+func takePending(ctx context.Context, roomID int, id int64) (PendingMessage, error) {
+	p := PendingMessage{RoomID: roomID}
+	err := db.QueryRowContext(ctx, `DELETE FROM pending_messages WHERE id = $1 AND room_id = $2
+		RETURNING id, sender_nick, sender_color, content, parent_id, created_at`, id, roomID).
+		Scan(&p.ID, &p.SenderNick, &p.SenderColor, &p.Content, &p.ParentID, &p.CreatedAt)
+	if err == sql.ErrNoRows { return p, errPendingNotFound }
+	return p, err
+}
+
+// 승인된 글을 일반 글처럼 저장하고 방송
+func publishPending(ctx context.Context, p PendingMessage) (Message, error) {
+	roomID := p.RoomID
+	var groupKey *int
+	var dayDivider *string
+	if p.ParentID == nil { groupKey, dayDivider = groupingHints(ctx, &roomID, p.SenderNick, false) }
+	id, err := store.InsertMessage(ctx, NewMessage{
+		Content: p.Content, SenderPod: nodeID, SenderNick: p.SenderNick, ParentID: p.ParentID, RoomID: &roomID, GroupKey: groupKey, DayDivider: dayDivider,
+	})
+	if err != nil { countSendError(&roomID, "store"); return Message{}, err }
+	replyCount := 0
+	if p.ParentID != nil { replyCount = store.ReplyCount(ctx, *p.ParentID) }
+
+	msg := Message{
+		ID: id, Type: messageTypeText, Content: p.Content, SenderPod: nodeID, SenderNick: p.SenderNick, SenderColor: p.SenderColor,
+		Time: time.Now().Format("15:04:05"), ParentID: p.ParentID, ReplyCount: replyCount, RoomID: &roomID, GroupKey: id,
+		AvatarURL: avatarURLFor(p.SenderNick), HTML: renderAndStore(ctx, p.Content),
+	}
+	if groupKey != nil { msg.GroupKey = *groupKey }
+	if dayDivider != nil { msg.DayDivider = *dayDivider }
+	data, _ := json.Marshal(msg)
+	if err := publishChat(ctx, data); err != nil { countSendError(&roomID, "publish"); return msg, err }
+	messagesSent.WithLabelValues(roomLabel(&roomID, false)).Inc()
+	afterPost(msg)
+	return msg, nil
+}
+
+// 운영 요청 공통: 방 ID, 대기 글 ID, 소유자/운영진 확인
+func pendingRequest(w http.ResponseWriter, r *http.Request, nick string) (roomID int, id int64, ok bool) {
+	roomID, ok = roomIDFrom(r)
+	id, err := strconv.ParseInt(r.PathValue("pending"), 10, 64)
+	if !ok || err != nil || nick == "" { respondError(w, r, http.StatusBadRequest, errors.New("room id, pending id and nick required")); return 0, 0, false }
+	if !canManageEmbeds(roomID, nick) { respondError(w, r, http.StatusForbidden, errors.New("only owners and moderators can review pending messages")); return 0, 0, false }
+	return roomID, id, true
+}
+
+// [사전 승인 설정] POST /rooms/{id}/preapproval (nick, hours) - 방장/운영진, hours=0이면 끔
+func setPreapprovalHandler(w http.ResponseWriter, r *http.Request) {
+	roomID, ok := roomIDFrom(r)
+	nick := r.FormValue("nick")
+	if !ok || nick == "" { respondError(w, r, http.StatusBadRequest, errors.New("room id and nick required")); return }
+	if !canManageEmbeds(roomID, nick) { respondError(w, r, http.StatusForbidden, errors.New("only owners and moderators can change pre-approval")); return }
+	n, err := strconv.Atoi(r.FormValue("hours"))
+	if err != nil || n < 0 || n > preapproveMaxHours {
+		respondError(w, r, http.StatusBadRequest, fieldError{"hours", "must be between 0 and " + strconv.Itoa(preapproveMaxHours)})
+		return
+	}
+	var hours *int
+	if n > 0 { hours = &n }
+
+	res, err := db.ExecContext(r.Context(), "UPDATE rooms SET preapprove_hours = $2 WHERE id = $1", roomID, hours)
+	if err != nil { respondError(w, r, 500, err); return }
+	if c, _ := res.RowsAffected(); c == 0 { respondError(w, r, http.StatusNotFound, errors.New("room not found")); return }
+	slog.InfoContext(r.Context(), "room pre-approval changed", "room_id", roomID, "nick", nick, "hours", n)
+	writeJSON(w, http.StatusOK, map[string]any{"room_id": roomID, "hours": n})
+}
+
+// [승인 대기열] GET /rooms/{id}/pending?nick= - 방장/운영진, 오래된 순
+func listPendingHandler(w http.ResponseWriter, r *http.Request) {
+	roomID, ok := roomIDFrom(r)
+	nick := r.URL.Query().Get("nick")
+	if !ok || nick == "" { respondError(w, r, http.StatusBadRequest, errors.New("room id and nick required")); return }
+	if !canManageEmbeds(roomID, nick) { respondError(w, r, http.StatusForbidden, errors.New("only owners and moderators can review pending messages")); return }
+	rows, err := db.QueryContext(r.Context(), `SELECT id, sender_nick, sender_color, content, parent_id, created_at
+		FROM pending_messages WHERE room_id = $1 ORDER BY id`, roomID)
+	if err != nil { respondError(w, r, 500, err); return }
+	defer rows.Close()
+	list := []PendingMessage{}
+	for rows.Next() {
+		p := PendingMessage{RoomID: roomID}
+		rows.Scan(&p.ID, &p.SenderNick, &p.SenderColor, &p.Content, &p.ParentID, &p.CreatedAt)
+		list = append(list, p)
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+// [승인] POST /rooms/{id}/pending/{pending}/approve (nick) - 방송된 메시지를 돌려줌
+func approvePendingHandler(w http.ResponseWriter, r *http.Request) {
+	nick := r.FormValue("nick")
+	roomID, id, ok := pendingRequest(w, r, nick)
+	if !ok { return }
+	ctx := r.Context()
+	p, err := takePending(ctx, roomID, id)
+	if err == errPendingNotFound { respondError(w, r, http.StatusNotFound, err); return }
+	if err != nil { respondError(w, r, 500, err); return }
+	msg, err := publishPending(ctx, p)
+	if err != nil { respondError(w, r, 500, err); return }
+	p.Status = "approved"
+	notifyModerators(ctx, directPendingResolved, p)
+	slog.InfoContext(ctx, "pending message approved", "room_id", roomID, "nick", nick, "pending_id", id, "message_id", msg.ID)
+	writeJSON(w, http.StatusOK, msg)
+}
+
+// [거절] POST /rooms/{id}/pending/{pending}/reject (nick, reason?) - 보낸 사람에게 시스템 DM
+func rejectPendingHandler(w http.ResponseWriter, r *http.Request) {
+	nick := r.FormValue("nick")
+	roomID, id, ok := pendingRequest(w, r, nick)
+	if !ok { return }
+	reason := r.FormValue("reason")
+	if err := validateText("reason", reason, pendingMaxReason); err != nil { respondError(w, r, http.StatusBadRequest, err); return }
+	ctx := r.Context()
+	p, err := takePending(ctx, roomID, id)
+	if err == errPendingNotFound { respondError(w, r, http.StatusNotFound, err); return }
+	if err != nil { respondError(w, r, 500, err); return }
+	p.Status = "rejected"
+	notifyModerators(ctx, directPendingResolved, p)
+
+	var room string
+	db.QueryRowContext(ctx, "SELECT name FROM rooms WHERE id = $1", roomID).Scan(&room)
+	content := "Your message in #" + room + " was not approved by the moderators."
+	if reason != "" { content += " Reason: " + reason }
+	if _, err := sendDirectMessage(systemNick(), p.SenderNick, content); err != nil { slog.WarnContext(ctx, "rejection notice failed", "nick", p.SenderNick, "err", err) }
+	slog.InfoContext(ctx, "pending message rejected", "room_id", roomID, "nick", nick, "pending_id", id)
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"time"
+	"unicode/utf8"
+)
+
+// [방 메모] 방 멤버가 함께 고치는 작은 키-값 저장소 (/rooms/{id}/notes)
+// 바꿀 때마다 room_note_history에 남기고 타임라인 이벤트(kind=note)로 방송해 다른 클라이언트가 바로 반영
+const (
+	noteMaxValue   = 4000
+	noteMaxPerRoom = 100
+	noteHistoryMax = 50
+)
+
+var noteKeyPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]{0,63}$`)
+
+type RoomNote struct {
+	Key       string    `json:"key"`
+	Value     string    `json:"value"`
+	UpdatedBy string    `json:"updated_by"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type NoteChange struct {
+	Value     *string   `json:"value"` // null이면 삭제
+	ChangedBy string    `json:"changed_by"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+// 방 멤버인지 확인하고 방 id 반환 (실패면 응답을 쓰고 false)
+// 비공개 방은 nick이 아니라 확인된 신원으로 먼저 거름 (none에서 멤버 닉네임을 대고 읽지 못하게)
+func noteRoom(w http.ResponseWriter, r *http.Request, nick string) (int, bool) {
+	roomID, ok := roomIDFrom(r)
+	if !ok || nick == "" { respondError(w, r, http.StatusBadRequest, errors.New("room id and nick required")); return 0, false }
+	if !roomAccessOK(w, r, roomID) { return 0, false }
+	if roomRole(roomID, nick) == "" { respondError(w, r, http.StatusForbidden, errors.New("not a room member")); return 0, false }
+	return roomID, true
+}
+
+// [메모 목록] GET /rooms/{id}/notes?nick=
+func roomNotesHandler(w http.ResponseWriter, r *http.Request) {
+	roomID, ok := noteRoom(w, r, r.URL.Query().Get("nick"))
+	if !ok { return }
+	rows, err := db.Query("SELECT key, value, updated_by, updated_at FROM room_notes WHERE room_id = $1 ORDER BY key", roomID)
+	if err != nil { respondError(w, r, 500, err); return }
+	defer rows.Close()
+	list := []RoomNote{}
+	for rows.Next() {
+		var n RoomNote
+		rows.Scan(&n.Key, &n.Value, &n.UpdatedBy, &n.UpdatedAt)
+		list = append(list, n)
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+// [메모 쓰기] PUT /rooms/{id}/notes/{key} (nick, value) - 멤버 누구나, 방마다 최대 100개
+func putRoomNoteHandler(w http.ResponseWriter, r *http.Request) {
+	nick, key, value := r.FormValue("nick"), r.PathValue("key"), r.FormValue("value")
+	roomID, ok := noteRoom(w, r, nick)
+	if !ok { return }
+	if !noteKeyPattern.MatchString(key) { respondError(w, r, http.StatusBadRequest, errors.New("key must be 1-64 of a-z, 0-9, _ . - (starting with a letter or digit)")); return }
+	if utf8.RuneCountInString(value) > noteMaxValue { respondError(w, r, http.StatusBadRequest, errors.New("value too long")); return }
+
+	tx, err := db.Begin()
+	if err != nil { respondError(w, r, 500, err); return }
+	defer tx.Rollback()
+	var count int
+	tx.QueryRow("SELECT COUNT(*) FROM room_notes WHERE room_id = $1 AND key <> $2", roomID, key).Scan(&count)
+	if count >= noteMaxPerRoom { respondError(w, r, http.StatusConflict, fmt.Errorf("a room can have at most %d notes", noteMaxPerRoom)); return }
+
+	var old sql.NullString
+	tx.QueryRow("SELECT value FROM room_notes WHERE room_id = $1 AND key = $2 FOR UPDATE", roomID, key).Scan(&old)
+	if old.Valid && old.String == value { w.WriteHeader(http.StatusNoContent); return }
+	n := RoomNote{Key: key, Value: value, UpdatedBy: nick}
+	err = tx.QueryRow(`INSERT INTO room_notes (room_id, key, value, updated_by) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (room_id, key) DO UPDATE SET value = $3, updated_by = $4, updated_at = CURRENT_TIMESTAMP
+		RETURNING updated_at`, roomID, key, value, nick).Scan(&n.UpdatedAt)
+	if err != nil { respondError(w, r, 500, err); return }
+	if _, err := tx.Exec("INSERT INTO room_note_history (room_id, key, value, changed_by) VALUES ($1, $2, $3, $4)", roomID, key, value, nick); err != nil {
+		respondError(w, r, 500, err)
+		return
+	}
+	if err := tx.Commit(); err != nil { respondError(w, r, 500, err); return }
+
+	ev := RoomEvent{Kind: eventNote, Actor: nick, Data: map[string]any{"key": key, "value": value}}
+	recordEvent(r.Context(), &roomID, ev, fmt.Sprintf("📝 %s set %s: %s", nick, key, notePreview(value)))
+	writeJSON(w, http.StatusOK, n)
+}
+
+// 타임라인 대체 문구에는 앞부분만
+func notePreview(v string) string {
+	if r := []rune(v); len(r) > 100 { return string(r[:100]) + "…" }
+	return v
+}
+
+// [메모 삭제] DELETE /rooms/{id}/notes/{key}?nick=
+func deleteRoomNoteHandler(w http.ResponseWriter, r *http.Request) {
+	nick, key := r.URL.Query().Get("nick"), r.PathValue("key")
+	roomID, ok := noteRoom(w, r, nick)
+	if !ok { return }
+
+	tx, err := db.Begin()
+	if err != nil { respondError(w, r, 500, err); return }
+	defer tx.Rollback()
+	res, err := tx.Exec("DELETE FROM room_notes WHERE room_id = $1 AND key = $2", roomID, key)
+	if err != nil { respondError(w, r, 500, err); return }
+	if n, _ := res.RowsAffected(); n == 0 { respondError(w, r, http.StatusNotFound, errors.New("note not found")); return }
+	if _, err := tx.Exec("INSERT INTO room_note_history (room_id, key, value, changed_by) VALUES ($1, $2, NULL, $3)", roomID, key, nick); err != nil {
+		respondError(w, r, 500, err)
+		return
+	}
+	if err := tx.Commit(); err != nil { respondError(w, r, 500, err); return }
+
+	ev := RoomEvent{Kind: eventNote, Actor: nick, Data: map[string]any{"key": key, "value": nil}}
+	recordEvent(r.Context(), &roomID, ev, fmt.Sprintf("📝 %s removed %s", nick, key))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// [메모 변경 기록] GET /rooms/{id}/notes/{key}/history?nick= - 최근 50건 (최신순, 삭제된 키도 조회 가능)
+func roomNoteHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	roomID, ok := noteRoom(w, r, r.URL.Query().Get("nick"))
+	if !ok { return }
+	rows, err := db.Query(`SELECT value, changed_by, changed_at FROM room_note_history
+		WHERE room_id = $1 AND key = $2 ORDER BY id DESC LIMIT $3`, roomID, r.PathValue("key"), noteHistoryMax)
+	if err != nil { respondError(w, r, 500, err); return }
+	defer rows.Close()
+	list := []NoteChange{}
+	for rows.Next() {
+		var c NoteChange
+		rows.Scan(&c.Value, &c.ChangedBy, &c.ChangedAt)
+		list = append(list, c)
+	}
+	writeJSON(w, http.StatusOK, list)
+}
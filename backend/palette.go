@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/binary"
+	"errors"
+	"net/http"
+)
+
+// [색상 배정] 색을 고르지 않은 사용자는 모두 #ffffff였던 것을, 닉네임 해시로 팔레트에서 골라 저장
+// 같은 닉네임은 어느 파드/재시작에서도 같은 색 (md5 앞 4바이트 mod 팔레트 크기, 0036 마이그레이션의 SQL과 같은 계산)
+// 팔레트는 어두운 글자와 대비가 충분한(WCAG AA 이상) 밝은 색만
+var colorPalette = []string{
+	"#fde68a", "#fecaca", "#bbf7d0", "#bfdbfe", "#ddd6fe", "#fbcfe8",
+	"#a5f3fc", "#fed7aa", "#d9f99d", "#c7d2fe", "#99f6e4", "#e9d5ff",
+}
+
+// 예전 기본값 (이 색이면 고르지 않은 것으로 봄)
+const unassignedColor = "#ffffff"
+
+func assignedColor(nick string) string {
+	sum := md5.Sum([]byte(nick))
+	return colorPalette[binary.BigEndian.Uint32(sum[:4])%uint32(len(colorPalette))]
+}
+
+// 요청에 색이 없을 때 쓸 색: 저장된 색, 없으면 배정 색
+func colorFor(ctx context.Context, nick string) string {
+	if color, _, err := store.User(ctx, nick); err == nil && color != "" && color != unassignedColor { return color }
+	return assignedColor(nick)
+}
+
+// [색상 배정] POST /palette/assign (nick) - 색이 없으면 배정해 저장하고, 있으면 그대로 돌려줌
+func assignColorHandler(w http.ResponseWriter, r *http.Request) {
+	nick := r.FormValue("nick")
+	if nick == "" { respondError(w, r, http.StatusBadRequest, errors.New("nick required")); return }
+	if err := checkCanPost(nick); err != nil { respondError(w, r, http.StatusForbidden, err); return }
+	color := colorFor(r.Context(), nick)
+	if err := store.UpsertUser(r.Context(), nick, color); err != nil { respondError(w, r, 500, err); return }
+	writeJSON(w, http.StatusOK, map[string]any{"nickname": nick, "color_code": color, "palette": colorPalette})
+}
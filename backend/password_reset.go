@@ -0,0 +1,135 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// [비밀번호 재설정] POST /account/password/forgot (nick 또는 email) → 인증된 이메일로 한 번만 쓰는 링크를 보냄
+// POST /account/password/reset (token, password)로 바꾸면 토큰을 쓴 것으로 표시하고 그 계정의 모든 세션을 끊음
+// - 토큰은 해시만 저장하고 email.reset_ttl 뒤 만료, 같은 계정의 남은 토큰도 함께 무효
+// - 요청은 계정별(passwordResetPerHour)과 IP별(passwordResetIPLimiter)로 제한
+// - 있는 계정인지 드러나지 않게 forgot은 항상 202 (계정별 한도 초과, 저장/메일 실패도 로그만 남기고 조용히 건너뜀, 메일은 응답 뒤에 보냄)
+// - 요청/사용 IP와 시각은 password_resets에 남고(감사용, 30일 보관) 관리자는 GET /admin/users/{nick}/password-resets로 봄
+const passwordResetPerHour = 3
+
+var passwordResetIPLimiter *rateLimiter
+
+func initPasswordReset() {
+	passwordResetIPLimiter = newRateLimiter(RateQuota{Limit: 10, Window: Duration(time.Hour)})
+}
+
+type PasswordReset struct {
+	CreatedAt   time.Time  `json:"created_at"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	RequestedIP string     `json:"requested_ip"`
+	UsedAt      *time.Time `json:"used_at,omitempty"`
+	UsedIP      string     `json:"used_ip,omitempty"`
+}
+
+// [비밀번호 찾기] POST /account/password/forgot (nick 또는 email)
+func forgotPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	if !fullFeatureStore() { respondError(w, r, http.StatusNotImplemented, errors.New("password reset requires the postgres store")); return }
+	nick, email := strings.TrimSpace(r.FormValue("nick")), strings.TrimSpace(r.FormValue("email"))
+	if nick == "" && email == "" { respondError(w, r, http.StatusBadRequest, errors.New("nick or email required")); return }
+	st := passwordResetIPLimiter.take(clientIP(r))
+	setRateLimitHeaders(w, st)
+	if !st.Allowed { respondError(w, r, http.StatusTooManyRequests, errRateLimited); return }
+
+	// 비밀번호가 있고 이메일이 인증된 살아 있는 계정만 (아니면 조용히 끝냄)
+	var to string
+	err := db.QueryRowContext(r.Context(), `SELECT nickname, email FROM users
+		WHERE (nickname = $1 OR ($1 = '' AND LOWER(email) = LOWER($2))) AND email_verified_at IS NOT NULL
+		  AND password_hash IS NOT NULL AND deleted_at IS NULL`, nick, email).Scan(&nick, &to)
+	if err == sql.ErrNoRows {
+		slog.InfoContext(r.Context(), "password reset requested for unknown account", "ip", clientIP(r))
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	if err != nil { respondError(w, r, 500, err); return }
+
+	w.WriteHeader(http.StatusAccepted)
+
+	var recent int
+	db.QueryRowContext(r.Context(), "SELECT COUNT(*) FROM password_resets WHERE nickname = $1 AND created_at > CURRENT_TIMESTAMP - INTERVAL '1 hour'", nick).Scan(&recent)
+	if recent >= passwordResetPerHour { slog.WarnContext(r.Context(), "password reset limit reached", "nick", nick, "ip", clientIP(r)); return }
+
+	token := newEmbedToken()
+	ttl := time.Duration(cfg.Email.ResetTTL)
+	_, err = db.ExecContext(r.Context(), "INSERT INTO password_resets (token_hash, nickname, expires_at, requested_ip) VALUES ($1, $2, $3, $4)",
+		hashRecoveryCode(token), nick, time.Now().Add(ttl), clientIP(r))
+	if err != nil { slog.ErrorContext(r.Context(), "password reset not saved", "nick", nick, "err", err); return }
+	link := baseURL(r) + "/?reset_token=" + token
+	body := fmt.Sprintf("Hi %s,\n\nSomeone (hopefully you) asked to reset your GoTalk password. Open this link to choose a new one:\n\n%s\n\nThe link works once and expires in %s. If you did not ask for this, ignore this email; your password stays the same.\n",
+		nick, link, ttl)
+	slog.InfoContext(r.Context(), "password reset requested", "nick", nick, "ip", clientIP(r))
+	go func() {
+		if err := sendMail(to, "Reset your GoTalk password", body); err != nil { slog.Error("password reset email failed", "nick", nick, "err", err) }
+	}()
+}
+
+// [비밀번호 재설정] POST /account/password/reset (token, password)
+func resetPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	if !fullFeatureStore() { respondError(w, r, http.StatusNotImplemented, errors.New("password reset requires the postgres store")); return }
+	token, password := r.FormValue("token"), r.FormValue("password")
+	if token == "" { respondError(w, r, http.StatusBadRequest, fieldError{"token", "required"}); return }
+	st := passwordResetIPLimiter.take(clientIP(r))
+	setRateLimitHeaders(w, st)
+	if !st.Allowed { respondError(w, r, http.StatusTooManyRequests, errRateLimited); return }
+	h, err := hashPassword(password)
+	var fe fieldError
+	if errors.As(err, &fe) { respondError(w, r, http.StatusBadRequest, err); return }
+	if err != nil { respondError(w, r, 500, err); return }
+
+	tx, err := db.BeginTx(r.Context(), nil)
+	if err != nil { respondError(w, r, 500, err); return }
+	defer tx.Rollback()
+	// 쓰지 않은 토큰을 쓴 것으로 바꾸면서 가져가므로 동시에 두 번 써도 한 번만 성공
+	var nick string
+	err = tx.QueryRowContext(r.Context(), `UPDATE password_resets SET used_at = CURRENT_TIMESTAMP, used_ip = $2
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > CURRENT_TIMESTAMP RETURNING nickname`, hashRecoveryCode(token), clientIP(r)).Scan(&nick)
+	if err == sql.ErrNoRows {
+		slog.WarnContext(r.Context(), "invalid password reset token", "ip", clientIP(r))
+		respondError(w, r, http.StatusGone, errors.New("invalid or expired reset link"))
+		return
+	}
+	if err != nil { respondError(w, r, 500, err); return }
+	if _, err := tx.ExecContext(r.Context(), "UPDATE users SET password_hash = $2 WHERE nickname = $1", nick, h); err != nil { respondError(w, r, 500, err); return }
+	// 같은 계정에 남은 링크는 쓸모없으니 함께 닫음 (used_ip 없이)
+	if _, err := tx.ExecContext(r.Context(), "UPDATE password_resets SET used_at = CURRENT_TIMESTAMP WHERE nickname = $1 AND used_at IS NULL", nick); err != nil { respondError(w, r, 500, err); return }
+	if err := tx.Commit(); err != nil { respondError(w, r, 500, err); return }
+
+	// 재설정은 도용 대응일 수 있으므로 모든 기기에서 로그아웃
+	n, err := revokeSessions(r.Context(), nick, "")
+	if err != nil { respondError(w, r, 500, err); return }
+	clearAuthCookies(w)
+	slog.InfoContext(r.Context(), "password reset completed", "nick", nick, "ip", clientIP(r), "sessions_revoked", n)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// [재설정 기록] GET /admin/users/{nick}/password-resets - 최근 것부터
+func adminPasswordResetsHandler(w http.ResponseWriter, r *http.Request) {
+	rows, err := db.QueryContext(r.Context(), `SELECT created_at, expires_at, requested_ip, used_at, COALESCE(used_ip, '')
+		FROM password_resets WHERE nickname = $1 ORDER BY created_at DESC`, r.PathValue("nick"))
+	if err != nil { respondError(w, r, 500, err); return }
+	defer rows.Close()
+	list := []PasswordReset{}
+	for rows.Next() {
+		var p PasswordReset
+		rows.Scan(&p.CreatedAt, &p.ExpiresAt, &p.RequestedIP, &p.UsedAt, &p.UsedIP)
+		list = append(list, p)
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+// 30일 지난 재설정 기록 정리
+func prunePasswordResets() {
+	res, err := db.Exec("DELETE FROM password_resets WHERE created_at < CURRENT_TIMESTAMP - INTERVAL '30 days'")
+	if err != nil { slog.Warn("password reset cleanup failed", "err", err); return }
+	if n, _ := res.RowsAffected(); n > 0 { slog.Info("old password resets removed", "count", n) }
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+)
+
+// [권한] 사이트 역할(users.role)별 권한 표: 다른 사람 메시지 삭제, 차단, 고정, 공지 방 만들기 같은 파괴적인 작업은 역할로 제한
+// 역할은 로그인 신원(세션/게스트 토큰/프록시 헤더)으로만 확인하고 폼의 nick은 믿지 않음
+// 역할 지정은 관리자 API(PUT /admin/users/{nick}/role)에서만
+const (
+	userRoleAdmin     = "admin"
+	userRoleModerator = "moderator"
+	userRoleUser      = "user"
+)
+
+type permission string
+
+const (
+	permDeleteMessages          permission = "messages.delete_any"
+	permBanUsers                permission = "users.ban"
+	permPinMessages             permission = "messages.pin" // 로비와 모든 방
+	permCreateAnnouncementRooms permission = "rooms.create_announcement"
+)
+
+var rolePermissions = map[string]map[permission]bool{
+	userRoleAdmin:     {permDeleteMessages: true, permBanUsers: true, permPinMessages: true, permCreateAnnouncementRooms: true},
+	userRoleModerator: {permDeleteMessages: true, permBanUsers: true, permPinMessages: true},
+	userRoleUser:      {},
+}
+
+// 역할 순위 (운영진은 같거나 높은 역할을 차단할 수 없음)
+var roleRank = map[string]int{userRoleUser: 0, userRoleModerator: 1, userRoleAdmin: 2}
+
+var errPermissionDenied = errors.New("permission denied")
+
+func validUserRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// 가입하지 않았거나 Postgres가 아니면 user
+func userRole(ctx context.Context, nick string) string {
+	role := userRoleUser
+	if nick == "" || !fullFeatureStore() { return role }
+	db.QueryRowContext(ctx, "SELECT role FROM users WHERE nickname = $1", nick).Scan(&role)
+	return role
+}
+
+// 요청한 신원이 권한을 가졌는지 (게스트는 user)
+func can(r *http.Request, perm permission) bool {
+	nick, ok := identityFrom(r.Context())
+	if !ok || isGuest(r.Context()) { return false }
+	return rolePermissions[userRole(r.Context(), nick)][perm]
+}
+
+// 권한이 없으면 401/403을 쓰고 false
+func requirePermission(w http.ResponseWriter, r *http.Request, perm permission) (string, bool) {
+	nick, ok := identityFrom(r.Context())
+	if !ok { respondError(w, r, http.StatusUnauthorized, errLoginRequired); return "", false }
+	if !can(r, perm) { respondError(w, r, http.StatusForbidden, errPermissionDenied); return "", false }
+	return nick, true
+}
+
+// [역할 지정] PUT /admin/users/{nick}/role (role)
+func adminSetRoleHandler(w http.ResponseWriter, r *http.Request) {
+	nick, role := r.PathValue("nick"), r.FormValue("role")
+	if !validUserRole(role) { respondError(w, r, http.StatusBadRequest, fieldError{"role", "must be admin, moderator or user"}); return }
+	res, err := db.ExecContext(r.Context(), "UPDATE users SET role = $2 WHERE nickname = $1", nick, role)
+	if err != nil { respondError(w, r, 500, err); return }
+	if n, _ := res.RowsAffected(); n == 0 { respondError(w, r, http.StatusNotFound, errors.New("user not found")); return }
+	writeJSON(w, http.StatusOK, map[string]string{"nickname": nick, "role": role})
+}
+
+// [메시지 삭제] DELETE /messages/{id} - 자기 메시지, 또는 messages.delete_any 권한
+func deleteMessageHandler(w http.ResponseWriter, r *http.Request) {
+	nick, ok := identityFrom(r.Context())
+	if !ok { respondError(w, r, http.StatusUnauthorized, errLoginRequired); return }
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil { respondError(w, r, http.StatusBadRequest, errors.New("invalid message id")); return }
+	var sender string
+	err = db.QueryRowContext(r.Context(), "SELECT sender_nick FROM messages WHERE id = $1", id).Scan(&sender)
+	if err == sql.ErrNoRows { respondError(w, r, http.StatusNotFound, errors.New("message not found")); return }
+	if err != nil { respondError(w, r, 500, err); return }
+	if sender != nick && !can(r, permDeleteMessages) { respondError(w, r, http.StatusForbidden, errPermissionDenied); return }
+
+	err = deleteMessage(r.Context(), id)
+	if err == sql.ErrNoRows { respondError(w, r, http.StatusNotFound, errors.New("message not found")); return }
+	if err != nil { respondError(w, r, 500, err); return }
+	slog.InfoContext(r.Context(), "message deleted", "message_id", id, "by", nick, "sender", sender)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// [차단] POST /users/{nick}/ban (reason?, duration?) - users.ban 권한, 같거나 높은 역할은 차단 불가
+func banUserHandler(w http.ResponseWriter, r *http.Request) {
+	by, ok := requirePermission(w, r, permBanUsers)
+	if !ok { return }
+	nick := r.PathValue("nick")
+	if roleRank[userRole(r.Context(), nick)] >= roleRank[userRole(r.Context(), by)] {
+		respondError(w, r, http.StatusForbidden, errors.New("cannot ban a user with the same or a higher role"))
+		return
+	}
+	until, ok := banUntil(w, r)
+	if !ok { return }
+	if !banUser(w, r, nick, until) { return }
+	slog.InfoContext(r.Context(), "user banned", "nick", nick, "by", by, "until", until)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// [차단 해제] DELETE /users/{nick}/ban - users.ban 권한
+func unbanUserHandler(w http.ResponseWriter, r *http.Request) {
+	by, ok := requirePermission(w, r, permBanUsers)
+	if !ok { return }
+	nick := r.PathValue("nick")
+	if !unbanUser(w, r, nick) { return }
+	slog.InfoContext(r.Context(), "user unbanned", "nick", nick, "by", by)
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type Pin struct {
+	Message   Message    `json:"message"`
+	PinnedBy  string     `json:"pinned_by"`
+	PinnedAt  time.Time  `json:"pinned_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	ExpiredAt *time.Time `json:"expired_at,omitempty"` // 만료/교체로 내려간 시각
+}
+
+// 만료 시각 파싱: expires_at(RFC3339) 또는 expires_in(기간), 둘 다 없으면 무기한
+func parsePinExpiry(r *http.Request) (*time.Time, error) {
+	if v := r.FormValue("expires_at"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil { return nil, err }
+		if !t.After(time.Now()) { return nil, fmt.Errorf("expires_at must be in the future") }
+		return &t, nil
+	}
+	if v := r.FormValue("expires_in"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil { return nil, err }
+		if d <= 0 { return nil, fmt.Errorf("expires_in must be positive") }
+		t := time.Now().Add(d)
+		return &t, nil
+	}
+	return nil, nil
+}
+
+// 방 메시지는 방 소유자/운영진, 로비는 messages.pin 권한(사이트 운영진)만 고정/해제
+func canManagePins(r *http.Request, roomID sql.NullInt64, nick string) bool {
+	if can(r, permPinMessages) { return true }
+	if !roomID.Valid { return false }
+	role := roomRole(int(roomID.Int64), nick)
+	return role == roomRoleOwner || role == roomRoleModerator
+}
+
+// 고정 목록이 바뀌면 현재 목록을 브로커로 방송해 모든 파드의 연결이 새로 받아 감 (pins만 채운 스트림 전용 메시지)
+func publishPins(ctx context.Context, roomID sql.NullInt64) {
+	pins, err := loadPins(ctx, nullableRoom(roomID), time.Now().Add(time.Hour))
+	if err != nil { slog.WarnContext(ctx, "pin list load failed", "err", err); return }
+	data, _ := json.Marshal(Message{Type: messageTypePins, RoomID: nullableRoom(roomID), Pins: pins})
+	if err := publishChat(ctx, data); err != nil { slog.WarnContext(ctx, "pin list publish failed", "err", err) }
+}
+
+// [고정] POST /messages/{id}/pin (nick, expires_in? | expires_at?) - 방 소유자/운영진 또는 messages.pin 권한
+// 방의 고정 개수가 pins.max_per_room을 넘으면 가장 오래된 고정이 내려감
+func pinMessageHandler(w http.ResponseWriter, r *http.Request) {
+	msgID, err := strconv.Atoi(r.PathValue("id"))
+	nick := r.FormValue("nick")
+	if err != nil || nick == "" { respondError(w, r, http.StatusBadRequest, errors.New("message id and nick required")); return }
+	expiresAt, err := parsePinExpiry(r)
+	if err != nil { respondError(w, r, http.StatusBadRequest, errors.New("invalid expiry: "+err.Error())); return }
+
+	var roomID sql.NullInt64
+	err = db.QueryRow("SELECT room_id FROM messages WHERE id = $1 AND recipient_nick IS NULL AND type = $2", msgID, messageTypeText).Scan(&roomID)
+	if err == sql.ErrNoRows { respondError(w, r, http.StatusNotFound, errors.New("message not found")); return }
+	if err != nil { respondError(w, r, 500, err); return }
+	if !canManagePins(r, roomID, nick) { respondError(w, r, http.StatusForbidden, errors.New("only owners and moderators can pin messages")); return }
+
+	_, err = db.Exec(`
+		INSERT INTO pins (message_id, room_id, pinned_by, expires_at) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (message_id) DO UPDATE
+		SET pinned_by = $3, pinned_at = CURRENT_TIMESTAMP, expires_at = $4, expired_at = NULL`,
+		msgID, roomID, nick, expiresAt)
+	if err != nil { respondError(w, r, 500, err); return }
+
+	recordEvent(r.Context(), nullableRoom(roomID), RoomEvent{Kind: eventPin, Actor: nick, Data: map[string]any{"message_id": msgID}},
+		fmt.Sprintf("📌 %s pinned message #%d.", nick, msgID))
+	rotatePins(r.Context(), roomID)
+	publishPins(r.Context(), roomID)
+	w.WriteHeader(http.StatusOK)
+}
+
+// [고정 해제] DELETE /messages/{id}/pin (nick) - 방 소유자/운영진 또는 messages.pin 권한
+func unpinMessageHandler(w http.ResponseWriter, r *http.Request) {
+	msgID, err := strconv.Atoi(r.PathValue("id"))
+	nick := r.URL.Query().Get("nick")
+	if err != nil || nick == "" { respondError(w, r, http.StatusBadRequest, errors.New("message id and nick required")); return }
+
+	var roomID sql.NullInt64
+	err = db.QueryRow("SELECT room_id FROM pins WHERE message_id = $1", msgID).Scan(&roomID)
+	if err == sql.ErrNoRows { respondError(w, r, http.StatusNotFound, errors.New("message is not pinned")); return }
+	if err != nil { respondError(w, r, 500, err); return }
+	if !canManagePins(r, roomID, nick) { respondError(w, r, http.StatusForbidden, errors.New("only owners and moderators can unpin messages")); return }
+
+	if _, err := db.Exec("DELETE FROM pins WHERE message_id = $1", msgID); err != nil { respondError(w, r, 500, err); return }
+	recordEvent(r.Context(), nullableRoom(roomID), RoomEvent{Kind: eventUnpin, Actor: nick, Data: map[string]any{"message_id": msgID}},
+		fmt.Sprintf("📌 %s unpinned message #%d.", nick, msgID))
+	publishPins(r.Context(), roomID)
+	w.WriteHeader(http.StatusOK)
+}
+
+// 방 단위로 최대 개수를 넘은 오래된 고정을 내리고 공지
+func rotatePins(ctx context.Context, roomID sql.NullInt64) {
+	rows, err := db.QueryContext(ctx, `
+		UPDATE pins SET expired_at = CURRENT_TIMESTAMP
+		WHERE message_id IN (
+			SELECT message_id FROM pins
+			WHERE room_id IS NOT DISTINCT FROM $1 AND expired_at IS NULL
+			ORDER BY pinned_at DESC OFFSET $2
+		) RETURNING message_id`, roomID, cfg.Pins.MaxPerRoom)
+	if err != nil { slog.WarnContext(ctx, "pin rotation failed", "err", err); return }
+	var rotated []int
+	for rows.Next() {
+		var id int
+		rows.Scan(&id)
+		rotated = append(rotated, id)
+	}
+	rows.Close()
+	for _, id := range rotated {
+		announceUnpin(ctx, roomID, id, "rotated")
+	}
+}
+
+func nullableRoom(roomID sql.NullInt64) *int {
+	if !roomID.Valid { return nil }
+	id := int(roomID.Int64)
+	return &id
+}
+
+// 만료/교체로 내려간 고정은 시스템 계정 이름의 unpin 이벤트로 남김
+func announceUnpin(ctx context.Context, roomID sql.NullInt64, messageID int, reason string) {
+	ev := RoomEvent{Kind: eventUnpin, Actor: systemNick(), Data: map[string]any{"message_id": messageID, "reason": reason}}
+	recordEvent(ctx, nullableRoom(roomID), ev, fmt.Sprintf("📌 Message #%d was unpinned (%s).", messageID, reason))
+}
+
+// [스케줄러 작업] 만료된 고정을 내리고 공지, 오래된 만료 기록은 정리
+func expirePins() {
+	ctx := context.Background()
+	rows, err := db.QueryContext(ctx, `
+		UPDATE pins SET expired_at = CURRENT_TIMESTAMP
+		WHERE expired_at IS NULL AND expires_at <= CURRENT_TIMESTAMP
+		RETURNING message_id, room_id`)
+	if err != nil { slog.Warn("pin expiry failed", "err", err); return }
+	type expired struct {
+		messageID int
+		roomID    sql.NullInt64
+	}
+	var list []expired
+	for rows.Next() {
+		var e expired
+		rows.Scan(&e.messageID, &e.roomID)
+		list = append(list, e)
+	}
+	rows.Close()
+	changed := map[sql.NullInt64]bool{}
+	for _, e := range list {
+		announceUnpin(ctx, e.roomID, e.messageID, "expired")
+		changed[e.roomID] = true
+	}
+	for roomID := range changed {
+		publishPins(ctx, roomID)
+	}
+
+	db.ExecContext(ctx, "DELETE FROM pins WHERE expired_at < CURRENT_TIMESTAMP - make_interval(secs => $1)",
+		time.Duration(cfg.Pins.ExpiredRetention).Seconds())
+}
+
+// [고정 목록] GET /pins?room_id=&include_expired=true&expired_within=24h
+// 고정 시각 최신순, include_expired면 최근 만료된 고정도 함께
+func pinsHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	var roomID *int
+	if v := q.Get("room_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil { respondError(w, r, http.StatusBadRequest, errors.New("invalid room_id")); return }
+		roomID = &id
+	}
+	if roomID != nil && !roomAccessOK(w, r, *roomID) { return }
+	// 만료 고정을 포함하지 않으면 기준 시각을 미래로 둬서 걸러냄
+	expiredSince := time.Now().Add(time.Hour)
+	if q.Get("include_expired") == "true" {
+		within := 24 * time.Hour
+		if v := q.Get("expired_within"); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil || d <= 0 { respondError(w, r, http.StatusBadRequest, errors.New("invalid expired_within")); return }
+			within = d
+		}
+		expiredSince = time.Now().Add(-within)
+	}
+
+	pins, err := loadPins(r.Context(), roomID, expiredSince)
+	if err != nil { respondError(w, r, 500, err); return }
+	writeJSON(w, http.StatusOK, pins)
+}
+
+// 고정 시각 최신순, expired_at이 expiredSince 이후인 만료 고정까지
+func loadPins(ctx context.Context, roomID *int, expiredSince time.Time) ([]Pin, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT m.id, m.content, m.sender_pod, m.sender_nick, COALESCE(u.color_code, '#ffffff'),
+			to_char(m.created_at, 'HH24:MI:SS'), m.room_id,
+			p.pinned_by, p.pinned_at, p.expires_at, p.expired_at
+		FROM pins p
+		JOIN messages m ON m.id = p.message_id
+		LEFT JOIN users u ON u.nickname = m.sender_nick
+		WHERE p.room_id IS NOT DISTINCT FROM $1
+		  AND (p.expired_at IS NULL OR p.expired_at >= $2)
+		ORDER BY p.pinned_at DESC`, roomID, expiredSince)
+	if err != nil { return nil, err }
+	defer rows.Close()
+
+	pins := []Pin{}
+	for rows.Next() {
+		p := Pin{Message: Message{Type: messageTypeText}}
+		rows.Scan(&p.Message.ID, &p.Message.Content, &p.Message.SenderPod, &p.Message.SenderNick, &p.Message.SenderColor,
+			&p.Message.Time, &p.Message.RoomID, &p.PinnedBy, &p.PinnedAt, &p.ExpiresAt, &p.ExpiredAt)
+		pins = append(pins, p)
+	}
+	return pins, rows.Err()
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// 접속 여부 확인용 브로커 주제: 해당 닉네임의 연결을 가진 파드만 응답
+const subjectPresenceCheck = "chat.presence.check"
+
+// 접속자 목록용 브로커 주제: 모든 파드가 자기 clients 맵의 닉네임으로 응답
+const subjectPresenceList = "chat.presence.list"
+
+// 다른 파드 응답을 기다리는 최대 시간 (응답이 없으면 오프라인으로 간주)
+const presenceCheckTimeout = 300 * time.Millisecond
+
+// 접속자 목록 응답을 모으는 시간 (늦게 답한 파드의 접속자는 빠짐)
+const presenceListTimeout = 500 * time.Millisecond
+
+// 이 파드에 해당 닉네임의 SSE 연결이 있는지
+func isOnlineLocal(nick string) bool {
+	mutex.Lock()
+	defer mutex.Unlock()
+	for _, c := range clients {
+		if c.nick == nick { return true }
+	}
+	return false
+}
+
+// 클러스터 전체에서 해당 닉네임이 접속 중인지 (설정된 접속 상태 저장소 기준)
+func isOnline(nick string) bool {
+	return presence.IsOnline(context.Background(), nick)
+}
+
+func handlePresenceCheck(m BrokerMsg) {
+	if isOnlineLocal(string(m.Data)) {
+		m.Respond([]byte("1"))
+	}
+}
+
+// 이 파드에 SSE 연결이 있는 닉네임 (임베드 방문자와 프로브 연결은 빼고, 중복 없이)
+func onlineLocal() []string {
+	seen := map[string]bool{}
+	mutex.Lock()
+	for _, c := range clients {
+		if c.nick == "" || c.probe { continue }
+		seen[c.nick] = true
+	}
+	mutex.Unlock()
+	return sortedKeys(seen)
+}
+
+func handlePresenceList(m BrokerMsg) {
+	data, _ := json.Marshal(onlineLocal())
+	m.Respond(data)
+}
+
+// 모든 파드에 접속자를 물어 합침 (이 파드 것은 응답을 기다리지 않고 바로 넣음)
+func onlineCluster(ctx context.Context) []string {
+	seen := map[string]bool{}
+	for _, nick := range onlineLocal() {
+		seen[nick] = true
+	}
+	for _, data := range broker.Gather(ctx, subjectPresenceList, nil, presenceListTimeout) {
+		var nicks []string
+		if json.Unmarshal(data, &nicks) != nil { continue }
+		for _, nick := range nicks {
+			seen[nick] = true
+		}
+	}
+	return sortedKeys(seen)
+}
+
+// [접속/퇴장 알림] 닉네임의 첫 연결이 열리면 "X joined", 마지막 연결이 닫히면 "X left"를 type=system으로 방송 (저장하지 않음)
+// 퇴장은 presence.announce_delay만큼 미뤘다가 그때도 클러스터 어디에도 연결이 없을 때만 알림 (새로고침/잠깐 끊김은 조용히)
+// 미뤄 둔 퇴장 안에 같은 파드로 다시 붙으면 입장도 알리지 않음
+var (
+	pendingLeavesMu sync.Mutex
+	pendingLeaves   = map[string]*time.Timer{}
+)
+
+// 스트림 연결을 clients에 넣기 전에 부름
+func announceConnect(ctx context.Context, nick string) {
+	if !cfg.Presence.Announce { return }
+	pendingLeavesMu.Lock()
+	t, waiting := pendingLeaves[nick]
+	if waiting { delete(pendingLeaves, nick) }
+	pendingLeavesMu.Unlock()
+	if waiting { t.Stop(); return }
+	if isOnline(nick) { return }
+	publishPresenceNotice(ctx, nick, eventOnline, nick+" joined")
+}
+
+// 스트림 연결을 clients에서 뺀 뒤 부름
+func announceDisconnect(nick string) {
+	if !cfg.Presence.Announce || isOnlineLocal(nick) { return }
+	pendingLeavesMu.Lock()
+	defer pendingLeavesMu.Unlock()
+	if t := pendingLeaves[nick]; t != nil { t.Stop() }
+	var t *time.Timer
+	t = time.AfterFunc(time.Duration(cfg.Presence.AnnounceDelay), func() {
+		pendingLeavesMu.Lock()
+		if pendingLeaves[nick] != t { pendingLeavesMu.Unlock(); return }
+		delete(pendingLeaves, nick)
+		pendingLeavesMu.Unlock()
+		if isOnline(nick) { return }
+		publishPresenceNotice(context.Background(), nick, eventOffline, nick+" left")
+	})
+	pendingLeaves[nick] = t
+}
+
+func publishPresenceNotice(ctx context.Context, nick, kind, content string) {
+	msg := Message{
+		Type: messageTypeSystem, Event: &RoomEvent{Kind: kind, Actor: nick}, Content: content, SenderPod: nodeID, SenderNick: nick,
+		Time: time.Now().Format("15:04:05"),
+	}
+	data, _ := json.Marshal(msg)
+	if err := publishChat(ctx, data); err != nil { slog.WarnContext(ctx, "presence notice publish failed", "nick", nick, "kind", kind, "err", err) }
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// [접속 상태 저장소] 기본은 NATS(각 파드 메모리 + 요청/응답), 선택적으로 Redis
+// NATS면 GET /online이 chat.presence.list로 모든 파드의 clients 맵을 모아 닉네임 중복을 없앰
+// Redis를 쓰면 파드가 재시작돼도 TTL 동안 상태가 남고 GET /online이 Redis 조회 한 번으로 끝남
+type PresenceStore interface {
+	Connect(ctx context.Context, nick, connID string) error
+	Heartbeat(ctx context.Context, nick, connID string) error
+	Disconnect(ctx context.Context, nick, connID string) error
+	IsOnline(ctx context.Context, nick string) bool
+	Online(ctx context.Context) ([]string, error)
+}
+
+const (
+	presenceBackendNATS  = "nats"
+	presenceBackendRedis = "redis"
+)
+
+var presence PresenceStore = natsPresence{}
+
+func initPresence() {
+	if cfg.Presence.Backend != presenceBackendRedis { return }
+	opts, err := redis.ParseURL(cfg.Presence.RedisURL)
+	if err != nil { fatal("invalid presence.redis_url", err) }
+	rdb := redis.NewClient(opts)
+	if err := rdb.Ping(context.Background()).Err(); err != nil { fatal("redis connect failed", err) }
+	presence = redisPresence{rdb: rdb}
+	slog.Info("presence store: redis", "addr", opts.Addr)
+}
+
+// 기존 방식: 연결 목록은 각 파드의 clients 맵이 원본이라 기록할 것이 없음
+type natsPresence struct{}
+
+func (natsPresence) Connect(context.Context, string, string) error    { return nil }
+func (natsPresence) Heartbeat(context.Context, string, string) error  { return nil }
+func (natsPresence) Disconnect(context.Context, string, string) error { return nil }
+
+func (natsPresence) IsOnline(_ context.Context, nick string) bool {
+	if isOnlineLocal(nick) { return true }
+	_, err := broker.Request(context.Background(), subjectPresenceCheck, []byte(nick), presenceCheckTimeout)
+	return err == nil
+}
+
+func (natsPresence) Online(ctx context.Context) ([]string, error) {
+	return onlineCluster(ctx), nil
+}
+
+// Redis 키 구성
+//   presence:conn:<connID>  SETEX, 값은 닉네임 (연결 단위 TTL)
+//   presence:nick:<nick>    ZSET, 연결 ID → 만료 시각
+//   presence:online         ZSET, 닉네임 → 가장 늦은 만료 시각 (GET /online 한 번에 조회)
+type redisPresence struct{ rdb *redis.Client }
+
+func (p redisPresence) ttl() time.Duration { return time.Duration(cfg.Presence.TTL) }
+
+func (p redisPresence) Connect(ctx context.Context, nick, connID string) error {
+	return p.Heartbeat(ctx, nick, connID)
+}
+
+func (p redisPresence) Heartbeat(ctx context.Context, nick, connID string) error {
+	expiry := float64(time.Now().Add(p.ttl()).Unix())
+	pipe := p.rdb.TxPipeline()
+	pipe.SetEx(ctx, "presence:conn:"+connID, nick, p.ttl())
+	pipe.ZAdd(ctx, "presence:nick:"+nick, redis.Z{Score: expiry, Member: connID})
+	pipe.Expire(ctx, "presence:nick:"+nick, p.ttl())
+	pipe.ZAddGT(ctx, "presence:online", redis.Z{Score: expiry, Member: nick})
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// 같은 닉네임의 다른 연결이 남아 있으면 온라인 유지
+func (p redisPresence) Disconnect(ctx context.Context, nick, connID string) error {
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	pipe := p.rdb.TxPipeline()
+	pipe.Del(ctx, "presence:conn:"+connID)
+	pipe.ZRem(ctx, "presence:nick:"+nick, connID)
+	pipe.ZRemRangeByScore(ctx, "presence:nick:"+nick, "-inf", now)
+	remaining := pipe.ZCard(ctx, "presence:nick:"+nick)
+	if _, err := pipe.Exec(ctx); err != nil { return err }
+	if remaining.Val() == 0 { return p.rdb.ZRem(ctx, "presence:online", nick).Err() }
+	return nil
+}
+
+func (p redisPresence) IsOnline(ctx context.Context, nick string) bool {
+	score, err := p.rdb.ZScore(ctx, "presence:online", nick).Result()
+	return err == nil && int64(score) > time.Now().Unix()
+}
+
+func (p redisPresence) Online(ctx context.Context) ([]string, error) {
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	// 만료된 항목은 조회 겸 정리
+	p.rdb.ZRemRangeByScore(ctx, "presence:online", "-inf", now)
+	return p.rdb.ZRangeByScore(ctx, "presence:online", &redis.ZRangeBy{Min: "(" + now, Max: "+inf"}).Result()
+}
+
+func sortedKeys(m map[string]bool) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// [접속자 목록] GET /online - 클러스터 전체 접속자 (NATS면 파드별 응답을 합침, Redis면 저장소 조회)
+func onlineHandler(w http.ResponseWriter, r *http.Request) {
+	nicks, err := presence.Online(r.Context())
+	if err != nil { respondError(w, r, 500, err); return }
+	sort.Strings(nicks)
+	writeJSON(w, http.StatusOK, map[string]any{"count": len(nicks), "nicks": nicks, "users": userStatuses(r.Context(), nicks)})
+}
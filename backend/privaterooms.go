@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+// [비공개 방] is_public = false인 방은 멤버에게만 기록과 스트림을 내보냄 (참여는 초대 링크로만, roominvites.go)
+// /history와 room_id를 붙인 /stream은 요청마다 room_members를 보고 멤버가 아니면 403 (확인된 신원이 없으면 401)
+// 방송 전달과 재접속 재전송도 쿼리의 nick이 아니라 확인된 신원이 멤버일 때만 비공개 방 메시지를 보냄
+// 방송 전달 경로는 파드마다 들고 있는 비공개 방 멤버 캐시만 보고, 멤버가 바뀌면 브로커로 알려 모든 파드가 그 방을 다시 읽음 (blocks.go와 같은 방식)
+// 방 읽기 키/임베드 연결은 방장/운영진이 그 방에 발급한 것이라 멤버 확인 없이 그 방만 받음
+
+// 비공개 방 멤버가 바뀐 방 ID를 알리는 브로커 주제
+const subjectRoomMembersChanged = "chat.room_members_changed"
+
+var errNotRoomMember = errors.New("not a room member")
+
+var (
+	privateRoomsMu sync.RWMutex
+	privateRooms   = map[int]map[string]bool{} // 비공개 방 → 멤버 닉네임
+)
+
+// 기동 시 모든 비공개 방 멤버를 읽어 둠
+func initPrivateRooms() {
+	if !fullFeatureStore() { return }
+	rows, err := db.Query("SELECT r.id, COALESCE(m.nickname, '') FROM rooms r LEFT JOIN room_members m ON m.room_id = r.id WHERE NOT r.is_public")
+	if err != nil { slog.Warn("private room load failed", "err", err); return }
+	defer rows.Close()
+	all := map[int]map[string]bool{}
+	for rows.Next() {
+		var id int
+		var nick string
+		rows.Scan(&id, &nick)
+		if all[id] == nil { all[id] = map[string]bool{} }
+		if nick != "" { all[id][nick] = true }
+	}
+	privateRoomsMu.Lock()
+	privateRooms = all
+	privateRoomsMu.Unlock()
+}
+
+// 방 하나를 다시 읽음 (공개 방이거나 없어졌으면 캐시에서 뺌)
+func loadPrivateRoom(ctx context.Context, roomID int) {
+	var public bool
+	err := db.QueryRowContext(ctx, "SELECT is_public FROM rooms WHERE id = $1", roomID).Scan(&public)
+	if err != nil && err != sql.ErrNoRows { slog.WarnContext(ctx, "private room load failed", "room_id", roomID, "err", err); return }
+	var members map[string]bool
+	if err == nil && !public {
+		members = map[string]bool{}
+		rows, err := db.QueryContext(ctx, "SELECT nickname FROM room_members WHERE room_id = $1", roomID)
+		if err != nil { slog.WarnContext(ctx, "private room load failed", "room_id", roomID, "err", err); return }
+		for rows.Next() {
+			var nick string
+			rows.Scan(&nick)
+			members[nick] = true
+		}
+		rows.Close()
+	}
+	privateRoomsMu.Lock()
+	if members == nil {
+		delete(privateRooms, roomID)
+	} else {
+		privateRooms[roomID] = members
+	}
+	privateRoomsMu.Unlock()
+}
+
+// 멤버를 바꾼 뒤 부름: 이 파드는 바로, 다른 파드는 브로커로
+func roomMembersChanged(ctx context.Context, roomID int) {
+	if !fullFeatureStore() { return }
+	loadPrivateRoom(ctx, roomID)
+	if err := broker.Publish(ctx, subjectRoomMembersChanged, []byte(strconv.Itoa(roomID))); err != nil {
+		slog.WarnContext(ctx, "room member change publish failed", "room_id", roomID, "err", err)
+	}
+}
+
+func handleRoomMembersChanged(m BrokerMsg) {
+	id, err := strconv.Atoi(string(m.Data))
+	if err != nil { return }
+	loadPrivateRoom(m.Ctx, id)
+}
+
+// 요청 경로용 (DB 확인): 비공개 방인데 멤버가 아니면 errNotRoomMember, 방이 없으면 sql.ErrNoRows
+func checkRoomAccess(ctx context.Context, roomID int, nick string) error {
+	if !fullFeatureStore() { return nil }
+	var public bool
+	if err := db.QueryRowContext(ctx, "SELECT is_public FROM rooms WHERE id = $1", roomID).Scan(&public); err != nil { return err }
+	if !public && roomRole(roomID, nick) == "" { return errNotRoomMember }
+	return nil
+}
+
+// 전달 경로용 (캐시만 봄): 비공개 방 메시지를 받을 수 없는 연결이면 true
+func hiddenRoom(roomID int, nick string) bool {
+	if roomID == 0 { return false }
+	privateRoomsMu.RLock()
+	defer privateRoomsMu.RUnlock()
+	members, private := privateRooms[roomID]
+	return private && !members[nick]
+}
+
+// 방송 페이로드의 방 ID (로비/DM이면 0)
+func streamRoomID(data string) int {
+	var m struct {
+		RoomID *int `json:"room_id"`
+	}
+	if json.Unmarshal([]byte(data), &m) != nil || m.RoomID == nil { return 0 }
+	return *m.RoomID
+}
+
+// 요청 핸들러용: 접근할 수 없으면 401/403/404를 쓰고 false
+// 멤버 확인은 확인된 신원(세션, 게스트 토큰, 인증 공급자)으로만 하고 쿼리의 nick은 보지 않음 (none에서 남의 닉네임을 대고 읽지 못하게)
+func roomAccessOK(w http.ResponseWriter, r *http.Request, roomID int) bool {
+	viewer, verified := identityFrom(r.Context())
+	err := checkRoomAccess(r.Context(), roomID, viewer)
+	switch {
+	case err == nil:
+		return true
+	case err == errNotRoomMember && !verified:
+		respondError(w, r, http.StatusUnauthorized, errLoginRequired)
+	case err == errNotRoomMember:
+		respondError(w, r, http.StatusForbidden, err)
+	case err == sql.ErrNoRows:
+		respondError(w, r, http.StatusNotFound, errors.New("room not found"))
+	default:
+		respondError(w, r, 500, err)
+	}
+	return false
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// [합성 프로브] probe.interval마다 카나리 메시지를 실제 경로로 흘려 단계별 지연을 잼
+//   store: messages 행 저장 / broadcast: 발행 → 이 파드의 handleMessages 수신 / delivery: handleMessages → 연결 채널 수신
+// 카나리는 프로브 전용 닉네임에게 보내는 DM 행이라 /history와 기록 캐시에 닿지 않고, 재고 나면 바로 지움
+// 방송은 일반 연결과 같은 offer 경로를 타지만 프로브 연결에만 전달됨 (slowclient.go)
+// 연속 실패가 probe.alert_after에 이르면 에러 리포터로 한 번 알리고, 회복하면 로그를 남김
+const probeNick = "__probe__"
+
+var (
+	probeLatency = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "gotalk_probe_latency_seconds",
+		Help:    "Synthetic probe latency by pipeline stage (store, broadcast, delivery, total).",
+		Buckets: []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+	}, []string{"stage"})
+	probeFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "gotalk_probe_failures_total",
+		Help: "Synthetic probe runs that failed, by the stage that failed.",
+	}, []string{"stage"})
+	probeUp = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "gotalk_probe_up",
+		Help: "1 if the last synthetic probe was delivered end to end, 0 otherwise.",
+	})
+	probeLastSuccess = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "gotalk_probe_last_success_timestamp_seconds",
+		Help: "Unix time of the last successful synthetic probe.",
+	})
+)
+
+// 실패한 단계와 원인
+type probeError struct {
+	stage string
+	err   error
+}
+
+func (e probeError) Error() string { return e.stage + ": " + e.err.Error() }
+
+// 방송 페이로드가 카나리인지 (대부분의 메시지는 문자열 검사에서 끝남)
+func isProbePayload(data string) bool {
+	if !strings.Contains(data, `"`+messageTypeProbe+`"`) { return false }
+	var m struct {
+		Type string `json:"type"`
+	}
+	return json.Unmarshal([]byte(data), &m) == nil && m.Type == messageTypeProbe
+}
+
+func startProbe() {
+	if !cfg.Probe.Enabled || !fullFeatureStore() { return }
+	ch := make(chan outbound, 16)
+	mutex.Lock()
+	clients[ch] = &streamClient{probe: true} // 닉네임이 없어 접속자 목록/접속 상태에서 빠짐
+	mutex.Unlock()
+	slog.Info("synthetic probe enabled", "interval", time.Duration(cfg.Probe.Interval))
+
+	go func() {
+		failures := 0
+		for range time.Tick(time.Duration(cfg.Probe.Interval)) {
+			err := probeOnce(ch)
+			if err == nil {
+				if failures >= cfg.Probe.AlertAfter { slog.Info("synthetic probe recovered", "failures", failures) }
+				failures = 0
+				continue
+			}
+			failures++
+			var pe probeError
+			errors.As(err, &pe)
+			probeFailures.WithLabelValues(pe.stage).Inc()
+			probeUp.Set(0)
+			slog.Warn("synthetic probe failed", "stage", pe.stage, "err", pe.err, "consecutive", failures)
+			if failures == cfg.Probe.AlertAfter {
+				errorReporter.CaptureException(fmt.Errorf("synthetic probe failing: %w", err), map[string]string{
+					"node":  nodeID,
+					"stage": pe.stage,
+				})
+			}
+		}
+	}()
+}
+
+func probeOnce(ch chan outbound) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.Probe.Timeout))
+	defer cancel()
+	// 지난 회차에 늦게 도착한 카나리는 버림
+	for len(ch) > 0 {
+		<-ch
+	}
+
+	start := time.Now()
+	var id int
+	err := db.QueryRowContext(ctx, `
+		INSERT INTO messages (content, sender_pod, sender_nick, recipient_nick) VALUES ($1, $2, $3, $3)
+		RETURNING id`, "canary", nodeID, probeNick).Scan(&id)
+	if err != nil { return probeError{"store", err} }
+	defer db.Exec("DELETE FROM messages WHERE id = $1", id)
+	stored := time.Now()
+	probeLatency.WithLabelValues("store").Observe(stored.Sub(start).Seconds())
+
+	data, _ := json.Marshal(Message{ID: id, Type: messageTypeProbe, SenderPod: nodeID, SenderNick: probeNick, RecipientNick: probeNick})
+	if err := publishChat(ctx, data); err != nil { return probeError{"broadcast", err} }
+	for {
+		select {
+		case out := <-ch:
+			var m Message
+			if json.Unmarshal([]byte(out.Data), &m) != nil || m.ID != id || m.SenderPod != nodeID { continue } // 다른 파드의 카나리
+			now := time.Now()
+			probeLatency.WithLabelValues("broadcast").Observe(out.Received.Sub(stored).Seconds())
+			probeLatency.WithLabelValues("delivery").Observe(now.Sub(out.Received).Seconds())
+			probeLatency.WithLabelValues("total").Observe(now.Sub(start).Seconds())
+			probeUp.Set(1)
+			probeLastSuccess.Set(float64(now.Unix()))
+			return nil
+		case <-ctx.Done():
+			return probeError{"delivery", fmt.Errorf("canary %d not delivered within %s", id, time.Duration(cfg.Probe.Timeout))}
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+)
+
+// [프로필 변경 전파] 색/아바타를 바꾸면 모든 파드에 알리고, 각 파드는 자기 연결에 `event: user_updated`를 보냄
+// 클라이언트는 이미 그려 둔 기록과 멤버 목록에서 그 사용자의 색/아바타를 바로 고침 (다음 글을 기다리지 않음)
+// 기록 캐시에도 보낸 사람의 색/아바타가 들어 있으므로 모든 방 항목을 비움
+const (
+	subjectUserUpdated = "chat.user_updated"
+	eventUserUpdated   = "user_updated"
+)
+
+type UserUpdate struct {
+	Nickname  string `json:"nickname"`
+	ColorCode string `json:"color_code"`
+	AvatarURL string `json:"avatar_url"` // 지웠으면 ""
+}
+
+func publishUserUpdated(ctx context.Context, u UserUpdate) {
+	data, _ := json.Marshal(u)
+	if err := broker.Publish(ctx, subjectUserUpdated, data); err != nil { slog.WarnContext(ctx, "user update publish failed", "nick", u.Nickname, "err", err) }
+}
+
+func handleUserUpdated(m BrokerMsg) {
+	recentHistory.invalidateAll()
+	out := newOutbound(m.Ctx, string(m.Data))
+	out.Event = eventUserUpdated
+	sendToAll(out)
+}
+
+// 이 파드의 모든 연결에 전달 (가득 찬 채널은 느린 클라이언트 정책대로)
+func sendToAll(data outbound) int {
+	mutex.Lock()
+	defer mutex.Unlock()
+	count := 0
+	for clientChan, c := range clients {
+		if c.offer(clientChan, data) { count++ }
+	}
+	return count
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/mail"
+	"strings"
+)
+
+// [프로필 일괄 가져오기] 사내 배포를 인사 자료(CSV)로 미리 채우는 용도
+// 첫 줄은 머리글: nickname은 꼭 있어야 하고 color, display_name, email은 있는 열만 반영 (순서 무관)
+// 한 행이라도 잘못되면 아무것도 바꾸지 않고 행별 오류 목록을 돌려줌 (dry_run=true면 검사만)
+// 있는 사용자는 CSV에 값이 있는 칸만 덮어쓰고, verify_email=true면 주소를 인증된 것으로 넣음 (인사 자료를 믿는 경우)
+const (
+	profileImportMaxRows  = 10000
+	profileMaxNickname    = 64
+	profileMaxDisplayName = 64
+)
+
+var profileImportColumns = []string{"nickname", "color", "display_name", "email"}
+
+type profileImportRow struct {
+	Line        int
+	Nickname    string
+	Color       string
+	DisplayName string
+	Email       string
+}
+
+type ProfileImportError struct {
+	Line     int    `json:"line"`
+	Nickname string `json:"nickname,omitempty"`
+	Field    string `json:"field"`
+	Message  string `json:"message"`
+}
+
+type ProfileImportReport struct {
+	DryRun  bool                 `json:"dry_run"`
+	Rows    int                  `json:"rows"`
+	Created int                  `json:"created"` // dry_run이면 만들어질 수
+	Updated int                  `json:"updated"`
+	Errors  []ProfileImportError `json:"errors"`
+}
+
+// 머리글과 행을 읽고 행별로 검사 (파일 안의 닉네임/이메일 중복 포함)
+func parseProfileCSV(r io.Reader) ([]profileImportRow, []ProfileImportError, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err != nil { return nil, nil, fmt.Errorf("csv header: %w", err) }
+	col := map[string]int{}
+	for i, h := range header {
+		h = strings.ToLower(strings.TrimSpace(h))
+		known := false
+		for _, c := range profileImportColumns {
+			if h == c { known = true }
+		}
+		if !known { return nil, nil, fmt.Errorf("unknown column %q (expected %s)", h, strings.Join(profileImportColumns, ", ")) }
+		col[h] = i
+	}
+	if _, ok := col["nickname"]; !ok { return nil, nil, errors.New("csv header must include nickname") }
+
+	var rows []profileImportRow
+	var problems []ProfileImportError
+	nicks, emails := map[string]int{}, map[string]int{}
+	for line := 2; ; line++ {
+		rec, err := cr.Read()
+		if err == io.EOF { break }
+		if err != nil { return nil, nil, err }
+		if len(rows) == profileImportMaxRows { return nil, nil, fmt.Errorf("too many rows (max %d)", profileImportMaxRows) }
+		get := func(name string) string {
+			i, ok := col[name]
+			if !ok || i >= len(rec) { return "" }
+			return strings.TrimSpace(rec[i])
+		}
+		row := profileImportRow{Line: line, Nickname: get("nickname"), Color: get("color"), DisplayName: get("display_name"), Email: get("email")}
+		bad := func(field, msg string) { problems = append(problems, ProfileImportError{Line: line, Nickname: row.Nickname, Field: field, Message: msg}) }
+		switch {
+		case row.Nickname == "":
+			bad("nickname", "required")
+		case isReservedNick(row.Nickname) || row.Nickname == deletedNick:
+			bad("nickname", "reserved")
+		case nicks[row.Nickname] > 0:
+			bad("nickname", fmt.Sprintf("duplicate of line %d", nicks[row.Nickname]))
+		default:
+			nicks[row.Nickname] = line
+		}
+		if err := validateText("nickname", row.Nickname, profileMaxNickname); err != nil { bad("nickname", err.Error()) }
+		if row.Color != "" && !colorPattern.MatchString(row.Color) { bad("color", "must be #rrggbb") }
+		if err := validateText("display_name", row.DisplayName, profileMaxDisplayName); err != nil { bad("display_name", err.Error()) }
+		if row.Email != "" {
+			key := strings.ToLower(row.Email)
+			if a, err := mail.ParseAddress(row.Email); err != nil || a.Address != row.Email || len(row.Email) > emailMaxLen {
+				bad("email", "must be a plain address like name@example.com")
+			} else if emails[key] > 0 {
+				bad("email", fmt.Sprintf("duplicate of line %d", emails[key]))
+			} else {
+				emails[key] = line
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, problems, nil
+}
+
+// 한 트랜잭션으로 반영 (dry_run이면 같은 검사를 하고 되돌림)
+func importProfiles(ctx context.Context, rows []profileImportRow, verifyEmail, dryRun bool) (ProfileImportReport, error) {
+	rep := ProfileImportReport{DryRun: dryRun, Rows: len(rows), Errors: []ProfileImportError{}}
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil { return rep, err }
+	defer tx.Rollback()
+
+	for _, row := range rows {
+		// 인증된 주소는 한 계정에만 (idx_users_verified_email)
+		if row.Email != "" && verifyEmail {
+			var owner string
+			tx.QueryRowContext(ctx, "SELECT nickname FROM users WHERE LOWER(email) = LOWER($1) AND email_verified_at IS NOT NULL AND nickname <> $2",
+				row.Email, row.Nickname).Scan(&owner)
+			if owner != "" {
+				rep.Errors = append(rep.Errors, ProfileImportError{Line: row.Line, Nickname: row.Nickname, Field: "email", Message: "already verified for " + owner})
+				continue
+			}
+		}
+		color := row.Color
+		if color == "" { color = assignedColor(row.Nickname) }
+		var created bool
+		err := tx.QueryRowContext(ctx, `
+			INSERT INTO users (nickname, color_code, display_name, email, email_verified_at)
+			VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), CASE WHEN $5 AND $4 <> '' THEN CURRENT_TIMESTAMP END)
+			ON CONFLICT (nickname) DO UPDATE SET
+				color_code = CASE WHEN $6 <> '' THEN $6 ELSE users.color_code END,
+				display_name = COALESCE(NULLIF($3, ''), users.display_name),
+				email_verified_at = CASE
+					WHEN $4 = '' THEN users.email_verified_at
+					WHEN LOWER(users.email) = LOWER($4) THEN COALESCE(users.email_verified_at, CASE WHEN $5 THEN CURRENT_TIMESTAMP END)
+					WHEN $5 THEN CURRENT_TIMESTAMP END,
+				email = COALESCE(NULLIF($4, ''), users.email)
+			RETURNING xmax = 0`, row.Nickname, color, row.DisplayName, row.Email, verifyEmail, row.Color).Scan(&created)
+		if err != nil { return rep, fmt.Errorf("line %d: %w", row.Line, err) }
+		if created { rep.Created++ } else { rep.Updated++ }
+	}
+	if dryRun || len(rep.Errors) > 0 { return rep, nil }
+	return rep, tx.Commit()
+}
+
+// [프로필 가져오기] POST /admin/import/profiles?dry_run=true&verify_email=true - 본문은 CSV
+// 오류가 있으면 422와 함께 보고서만 (아무것도 바뀌지 않음)
+func adminImportProfilesHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	dryRun, verifyEmail := q.Get("dry_run") == "true", q.Get("verify_email") == "true"
+	rows, problems, err := parseProfileCSV(r.Body)
+	if err != nil { respondError(w, r, http.StatusBadRequest, errors.New("import failed: "+err.Error())); return }
+	if len(problems) > 0 {
+		writeJSON(w, http.StatusUnprocessableEntity, ProfileImportReport{DryRun: dryRun, Rows: len(rows), Errors: problems})
+		return
+	}
+	rep, err := importProfiles(r.Context(), rows, verifyEmail, dryRun)
+	if err != nil { respondError(w, r, 500, err); return }
+	status := http.StatusOK
+	if len(rep.Errors) > 0 {
+		status = http.StatusUnprocessableEntity
+		rep.Created, rep.Updated = 0, 0
+	}
+	slog.InfoContext(r.Context(), "profile import finished", "dry_run", dryRun, "rows", rep.Rows, "created", rep.Created, "updated", rep.Updated, "errors", len(rep.Errors))
+	writeJSON(w, status, rep)
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// [프로토콜 버전] 배포 중 옛 파드와 새 파드가 섞여도 서로 못 읽는 페이로드를 조용히 잘못 처리하지 않도록
+// 브로커 메시지마다 major.minor 버전을 싣고 (NATS는 헤더, Redis/Kafka는 봉투의 v 필드)
+// 받는 쪽은 호환 표에 없는 major를 버림. minor는 필드 추가처럼 옛 파드가 무시해도 되는 변경에만 올림
+// 기동 시 이미 떠 있는 노드의 버전을 물어 major가 호환되지 않으면 클러스터에 합류하지 않고 종료
+const (
+	protocolMajor  = 1
+	protocolMinor  = 0
+	protocolHeader = "Gotalk-Protocol"
+)
+
+var protocolVersion = fmt.Sprintf("%d.%d", protocolMajor, protocolMinor)
+
+// 호환 표: 이 빌드가 읽고 쓸 수 있는 상대 major (major를 올릴 때 이전 major를 남겨 두면 롤링 배포 가능)
+var compatibleMajors = map[int]bool{1: true}
+
+var (
+	brokerIncompatible = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "gotalk_broker_incompatible_total",
+		Help: "Broker messages dropped because their protocol version is not compatible.",
+	}, []string{"subject"})
+
+	incompatibleSeen sync.Map // 버전 → 로그를 남겼는지 (버전마다 한 번만 경고)
+)
+
+// 버전이 없으면 버전 필드가 생기기 전 파드가 보낸 1.0으로 봄
+func parseProtocol(v string) (major, minor int, ok bool) {
+	if v == "" { return 1, 0, true }
+	ma, mi, found := strings.Cut(v, ".")
+	if !found { return 0, 0, false }
+	major, err1 := strconv.Atoi(ma)
+	minor, err2 := strconv.Atoi(mi)
+	return major, minor, err1 == nil && err2 == nil
+}
+
+func compatibleProtocol(v string) bool {
+	major, _, ok := parseProtocol(v)
+	return ok && compatibleMajors[major]
+}
+
+// 받은 메시지를 처리해도 되는지 (호환되지 않으면 지표를 올리고 버림)
+func acceptProtocol(subject, v string) bool {
+	if compatibleProtocol(v) { return true }
+	brokerIncompatible.WithLabelValues(subject).Inc()
+	if _, logged := incompatibleSeen.LoadOrStore(v, true); !logged {
+		slog.Warn("dropping broker message with incompatible protocol version", "subject", subject, "version", v, "local", protocolVersion)
+	}
+	return false
+}
+
+// 이미 떠 있는 노드들의 프로토콜 버전을 확인, 호환되지 않는 노드가 있으면 합류하지 않음
+func checkClusterProtocol() {
+	for _, data := range broker.Gather(context.Background(), subjectAdminStats, nil, adminStatsTimeout) {
+		var s PodStats
+		if json.Unmarshal(data, &s) != nil { continue }
+		if !compatibleProtocol(s.Protocol) {
+			fatal("refusing to join cluster", fmt.Errorf("node %s speaks protocol %q, this build speaks %s", s.Pod, s.Protocol, protocolVersion))
+		}
+	}
+}
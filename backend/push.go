@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+)
+
+type PushSubscription struct {
+	Endpoint string `json:"endpoint"`
+	Keys     struct {
+		P256dh string `json:"p256dh"`
+		Auth   string `json:"auth"`
+	} `json:"keys"`
+}
+
+// 구독 등록 요청 (JSON은 {"nick", "subscription"}, 폼은 nick, endpoint, p256dh, auth)
+// decodeRequest를 거치므로 로그인한 요청이면 nick은 확인된 신원으로 고정됨
+type PushSubscribeRequest struct {
+	Nick         string           `json:"nick"`
+	Subscription PushSubscription `json:"subscription"`
+}
+
+func (req *PushSubscribeRequest) fromForm(r *http.Request) error {
+	req.Nick, req.Subscription.Endpoint = r.FormValue("nick"), r.FormValue("endpoint")
+	req.Subscription.Keys.P256dh, req.Subscription.Keys.Auth = r.FormValue("p256dh"), r.FormValue("auth")
+	return nil
+}
+
+func (req *PushSubscribeRequest) validate() error {
+	if req.Nick == "" { return fieldError{"nick", "required"} }
+	sub := req.Subscription
+	if sub.Endpoint == "" || sub.Keys.P256dh == "" || sub.Keys.Auth == "" { return fieldError{"subscription", "endpoint and keys required"} }
+	return nil
+}
+
+func (req *PushSubscribeRequest) pinNick(nick string) { req.Nick = nick }
+
+// 구독 해제 요청: 자기 닉네임으로 등록된 엔드포인트만 지울 수 있음
+type PushUnsubscribeRequest struct {
+	Nick     string `json:"nick"`
+	Endpoint string `json:"endpoint"`
+}
+
+func (req *PushUnsubscribeRequest) fromForm(r *http.Request) error {
+	req.Nick, req.Endpoint = r.FormValue("nick"), r.FormValue("endpoint")
+	return nil
+}
+
+func (req *PushUnsubscribeRequest) validate() error {
+	if req.Nick == "" { return fieldError{"nick", "required"} }
+	if req.Endpoint == "" { return fieldError{"endpoint", "required"} }
+	return nil
+}
+
+func (req *PushUnsubscribeRequest) pinNick(nick string) { req.Nick = nick }
+
+// 알림에 실어 보내는 내용 (서비스워커가 표시)
+type PushPayload struct {
+	Title     string `json:"title"`
+	Body      string `json:"body"`
+	MessageID int    `json:"message_id"`
+}
+
+// VAPID 키가 비어 있으면 푸시 비활성화
+func initPush() {
+	if !pushEnabled() {
+		slog.Warn("VAPID keys not set, web push disabled")
+	}
+}
+
+func pushEnabled() bool {
+	return cfg.Push.VAPIDPublicKey != "" && cfg.Push.VAPIDPrivateKey != ""
+}
+
+// [VAPID 공개키] GET /push/vapid-key - 브라우저 구독 시 applicationServerKey로 사용
+func vapidKeyHandler(w http.ResponseWriter, r *http.Request) {
+	if !pushEnabled() { respondError(w, r, http.StatusNotFound, errors.New("push disabled")); return }
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"public_key": cfg.Push.VAPIDPublicKey})
+}
+
+// [구독 등록] POST /push/subscribe {"nick":..., "subscription": PushSubscription}
+func pushSubscribeHandler(w http.ResponseWriter, r *http.Request) {
+	var req PushSubscribeRequest
+	if err := decodeRequest(r, &req); err != nil { respondError(w, r, http.StatusBadRequest, err); return }
+	sub := req.Subscription
+
+	// 기기마다 구독 하나만 (브라우저가 엔드포인트를 바꾸면 예전 구독으로 같은 알림이 두 번 가지 않게)
+	device := deviceIDFrom(r)
+	if device != "" {
+		db.Exec("DELETE FROM push_subscriptions WHERE nickname = $1 AND device_id = $2 AND endpoint <> $3", req.Nick, device, sub.Endpoint)
+	}
+	_, err := db.Exec(`
+		INSERT INTO push_subscriptions (endpoint, nickname, p256dh, auth, device_id) VALUES ($1, $2, $3, $4, NULLIF($5, ''))
+		ON CONFLICT (endpoint) DO UPDATE SET nickname = $2, p256dh = $3, auth = $4, device_id = NULLIF($5, '')`,
+		sub.Endpoint, req.Nick, sub.Keys.P256dh, sub.Keys.Auth, device)
+	if err != nil { respondError(w, r, 500, err); return }
+	w.WriteHeader(http.StatusCreated)
+}
+
+// [구독 해제] POST /push/unsubscribe {"nick":..., "endpoint": ...} - 다른 사람의 구독이면 404
+func pushUnsubscribeHandler(w http.ResponseWriter, r *http.Request) {
+	var req PushUnsubscribeRequest
+	if err := decodeRequest(r, &req); err != nil { respondError(w, r, http.StatusBadRequest, err); return }
+	res, err := db.Exec("DELETE FROM push_subscriptions WHERE endpoint = $1 AND nickname = $2", req.Endpoint, req.Nick)
+	if err != nil { respondError(w, r, 500, err); return }
+	if n, _ := res.RowsAffected(); n == 0 { respondError(w, r, http.StatusNotFound, errors.New("subscription not found")); return }
+	w.WriteHeader(http.StatusOK)
+}
+
+// [오프라인 알림] SSE 연결이 하나도 없는 사용자에게만 등록된 모든 기기로 푸시 전송
+func notifyOffline(nick string, payload PushPayload) {
+	if !pushEnabled() || isOnline(nick) { return }
+
+	rows, err := db.Query("SELECT endpoint, p256dh, auth FROM push_subscriptions WHERE nickname = $1", nick)
+	if err != nil { slog.Warn("push subscription lookup failed", "nick", nick, "err", err); return }
+	var subs []PushSubscription
+	for rows.Next() {
+		var s PushSubscription
+		rows.Scan(&s.Endpoint, &s.Keys.P256dh, &s.Keys.Auth)
+		subs = append(subs, s)
+	}
+	rows.Close()
+
+	data, _ := json.Marshal(payload)
+	for _, s := range subs {
+		dl := pushDeadLetter{Nick: nick, Subscription: s, Payload: data}
+		if err := withRetries(context.Background(), deadLetterPush, dl, func() error { return deliverPush(s, data) }); err != nil {
+			slog.Warn("web push failed", "nick", nick, "err", err)
+		}
+	}
+}
+
+// DLQ에 남기는 푸시 한 건 (구독 하나 + 보낼 내용)
+type pushDeadLetter struct {
+	Nick         string           `json:"nick"`
+	Subscription PushSubscription `json:"subscription"`
+	Payload      json.RawMessage  `json:"payload"`
+}
+
+// 한 구독으로 한 번 전송, 푸시 서비스의 429/5xx도 재시도 대상 오류로 취급
+func deliverPush(s PushSubscription, data []byte) error {
+	status, err := sendWebPush(s, data, 3600)
+	if err != nil { return err }
+	// 만료/해지된 구독은 정리 (재시도할 필요 없음)
+	if status == http.StatusNotFound || status == http.StatusGone {
+		db.Exec("DELETE FROM push_subscriptions WHERE endpoint = $1", s.Endpoint)
+		return nil
+	}
+	if status == http.StatusTooManyRequests || status >= 500 { return fmt.Errorf("push service returned %d", status) }
+	return nil
+}
+
+func retryPushDeadLetter(ctx context.Context, payload json.RawMessage) error {
+	var dl pushDeadLetter
+	if err := json.Unmarshal(payload, &dl); err != nil || dl.Subscription.Endpoint == "" { return errDeadLetterPayload }
+	if !pushEnabled() { return errors.New("push disabled") }
+	return deliverPush(dl.Subscription, dl.Payload)
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// 연결 품질 단계 (quality 이벤트의 level)
+const (
+	qualityGood     = "good"
+	qualityDegraded = "degraded"
+)
+
+// 회복 판단: 이 시간 동안 예산의 절반 이하로 조용해야 정상 전달로 복귀
+const qualityRecoverAfter = 10 * time.Second
+
+var (
+	streamDegraded = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "gotalk_stream_degraded_clients",
+		Help: "SSE connections currently in batched/degraded delivery mode.",
+	})
+	streamLowPriorityDropped = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "gotalk_stream_low_priority_dropped_total",
+		Help: "Low-priority events (typing/presence) dropped for degraded connections.",
+	})
+)
+
+// [연결 대역폭 예산] SSE 연결마다 초당 전송 바이트를 재서
+// 예산(stream.bytes_per_sec)을 넘거나 채널이 밀리면(느린 네트워크) 묶음 전달로 낮추고 저우선 이벤트는 버림
+// ping/pong으로 잰 RTT가 stream.max_rtt를 넘어도 같은 방식으로 낮춤
+// 단계가 바뀔 때마다 `event: quality`를 보내 UI가 연결 상태를 표시할 수 있게 함
+type streamWriter struct {
+	ctx     context.Context
+	w       http.ResponseWriter
+	flusher http.Flusher
+	conn    *connInfo
+
+	degraded    bool
+	pending     []outbound
+	windowStart time.Time
+	windowBytes int
+	rate        int // 직전 측정 구간의 bytes/sec
+	calmSince   time.Time
+	lastID      int // 마지막으로 보낸 SSE id
+}
+
+func newStreamWriter(ctx context.Context, w http.ResponseWriter, conn *connInfo) *streamWriter {
+	return &streamWriter{ctx: ctx, w: w, flusher: w.(http.Flusher), conn: conn, windowStart: time.Now()}
+}
+
+func (s *streamWriter) payload(msg outbound) string {
+	if s.conn.Lite && msg.LiteData != "" { return msg.LiteData }
+	return msg.Data
+}
+
+// 이름 있는 이벤트는 event: 줄을 앞에 붙임
+// id는 늘어날 때만 붙임 (파드 간 방송 순서가 뒤바뀌어도 클라이언트의 Last-Event-ID가 뒤로 가지 않게)
+func (s *streamWriter) writeMsg(msg outbound) {
+	if msg.ID > s.lastID {
+		s.write("id: %d\n", msg.ID)
+		s.lastID = msg.ID
+	}
+	if msg.Event != "" { s.write("event: %s\n", msg.Event) }
+	s.write("data: %s\n\n", s.payload(msg))
+}
+
+func (s *streamWriter) write(format string, args ...any) {
+	n, _ := fmt.Fprintf(s.w, format, args...)
+	s.windowBytes += n
+}
+
+// 정상 모드는 바로 쓰고, 저하 모드는 다음 묶음 전송까지 모아 둠
+func (s *streamWriter) deliver(msg outbound) {
+	if !s.degraded {
+		_, span := tracer.Start(msg.Ctx, "sse.write")
+		s.writeMsg(msg)
+		s.flush()
+		span.End()
+		return
+	}
+	if msg.LowPriority {
+		streamLowPriorityDropped.Inc()
+		return
+	}
+	s.pending = append(s.pending, msg)
+}
+
+// 모아 둔 메시지를 한 번에 쓰고 한 번만 flush
+func (s *streamWriter) flushBatch() {
+	if len(s.pending) == 0 { return }
+	_, span := tracer.Start(s.ctx, "sse.write_batch")
+	span.SetAttributes(attribute.Int("messages", len(s.pending)))
+	for _, msg := range s.pending {
+		s.writeMsg(msg)
+	}
+	s.flush()
+	span.End()
+	s.pending = s.pending[:0]
+}
+
+// flush가 돌아왔으면 쓰기 성공으로 기록 (죽은 TCP는 송신 버퍼가 차면 여기서 멈춰 기록이 끊김)
+func (s *streamWriter) flush() {
+	s.flusher.Flush()
+	s.conn.lastWrite.Store(time.Now().UnixNano())
+}
+
+func (s *streamWriter) keepalive() {
+	s.write(":keepalive\n\n")
+	s.flush()
+}
+
+// 측정 구간(1초)이 지났으면 속도를 갱신하고 단계 전환 여부 판단
+func (s *streamWriter) evaluate(backlog, capacity int) {
+	elapsed := time.Since(s.windowStart)
+	if elapsed < time.Second { return }
+	s.rate = int(float64(s.windowBytes) / elapsed.Seconds())
+	s.windowStart, s.windowBytes = time.Now(), 0
+
+	budget, maxRTT := cfg.Stream.BytesPerSec, time.Duration(cfg.Stream.MaxRTT)
+	rtt := s.conn.RTT()
+	congested := s.rate > budget || backlog > capacity*3/4 || rtt > maxRTT
+	switch {
+	case !s.degraded && congested:
+		s.setDegraded(true)
+	case s.degraded && (s.rate > budget/2 || backlog > 0 || rtt > maxRTT/2):
+		s.calmSince = time.Time{}
+	case s.degraded && s.calmSince.IsZero():
+		s.calmSince = time.Now()
+	case s.degraded && time.Since(s.calmSince) >= qualityRecoverAfter:
+		s.flushBatch()
+		s.setDegraded(false)
+	}
+}
+
+func (s *streamWriter) setDegraded(on bool) {
+	s.degraded, s.calmSince = on, time.Time{}
+	s.conn.degraded.Store(on)
+	level := qualityGood
+	if on {
+		level = qualityDegraded
+		streamDegraded.Inc()
+	} else {
+		streamDegraded.Dec()
+	}
+	rttMs := s.conn.RTT().Milliseconds()
+	s.write("event: quality\ndata: {\"level\":%q,\"bytes_per_sec\":%d,\"rtt_ms\":%d}\n\n", level, s.rate, rttMs)
+	s.flush()
+	slog.InfoContext(s.ctx, "stream quality changed", "nick", s.conn.Nick, "level", level, "bytes_per_sec", s.rate, "rtt_ms", rttMs)
+}
+
+func (s *streamWriter) close() {
+	if s.degraded { streamDegraded.Dec() }
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// [요청 한도] 고정 창(window) 방식 카운터로 API 호출(IP별)과 메시지 전송(닉네임별)을 제한
+// 응답마다 X-RateLimit-Limit/Remaining/Reset 헤더를 달아 봇이 스스로 속도를 맞출 수 있게 하고,
+// GET /me/limits로 현재 남은 한도를 조회할 수 있음
+// 카운터는 파드 메모리에 있어 여러 파드 뒤에서는 파드 수만큼 느슨해짐 (게스트 글 간격과 같음)
+// 메시지 한도는 게스트(auth.provider=none에서 세션 없는 닉네임)와 로그인/가입한 사용자를 따로 셈
+
+// 한도 설정 (limit 0이면 제한 없음)
+type RateQuota struct {
+	Limit  int      `yaml:"limit" json:"limit"`
+	Window Duration `yaml:"window" json:"window"`
+}
+
+type rateBucket struct {
+	count int
+	reset time.Time
+}
+
+type rateLimiter struct {
+	quota   RateQuota
+	mu      sync.Mutex
+	buckets map[string]*rateBucket
+}
+
+// 한 번의 확인 결과 (헤더와 /me/limits에 그대로 씀)
+type RateStatus struct {
+	Limit     int       `json:"limit"`
+	Remaining int       `json:"remaining"`
+	Reset     time.Time `json:"reset"`
+	Allowed   bool      `json:"-"`
+}
+
+var (
+	apiLimiter          *rateLimiter
+	messageLimiter      *rateLimiter
+	guestMessageLimiter *rateLimiter
+	// API 한도를 세지 않는 라우트 (정적 파일, 장기 연결, 프로브, 관리자 API)
+	rateLimitExempt = map[string]bool{"/": true, "GET /metrics": true, "GET /healthz": true, "GET /readyz": true, "POST /pong": true, "/admin/": true}
+)
+
+func initRateLimits() {
+	apiLimiter = newRateLimiter(cfg.RateLimit.API)
+	messageLimiter = newRateLimiter(cfg.RateLimit.Messages)
+	guestMessageLimiter = newRateLimiter(cfg.RateLimit.GuestMessages)
+}
+
+// 요청자에게 적용할 메시지 한도
+func messageLimiterFor(ctx context.Context) *rateLimiter {
+	if isGuest(ctx) { return guestMessageLimiter }
+	return messageLimiter
+}
+
+func newRateLimiter(q RateQuota) *rateLimiter {
+	l := &rateLimiter{quota: q, buckets: map[string]*rateBucket{}}
+	if q.Limit > 0 { go l.prune() }
+	return l
+}
+
+func (l *rateLimiter) enabled() bool { return l.quota.Limit > 0 }
+
+// 한 번 쓰고 결과 반환 (한도를 넘으면 세지 않고 Allowed=false)
+func (l *rateLimiter) take(key string) RateStatus { return l.check(key, true) }
+
+// 쓰지 않고 현재 상태만 조회
+func (l *rateLimiter) peek(key string) RateStatus { return l.check(key, false) }
+
+func (l *rateLimiter) check(key string, consume bool) RateStatus {
+	now := time.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b := l.buckets[key]
+	if b == nil || !now.Before(b.reset) {
+		b = &rateBucket{reset: now.Add(time.Duration(l.quota.Window))}
+		if consume { l.buckets[key] = b }
+	}
+	st := RateStatus{Limit: l.quota.Limit, Reset: b.reset, Allowed: b.count < l.quota.Limit}
+	if consume && st.Allowed { b.count++ }
+	st.Remaining = max(l.quota.Limit-b.count, 0)
+	return st
+}
+
+// 창이 지난 카운터 정리
+func (l *rateLimiter) prune() {
+	for range time.Tick(time.Duration(l.quota.Window)) {
+		now := time.Now()
+		l.mu.Lock()
+		for k, b := range l.buckets {
+			if !now.Before(b.reset) { delete(l.buckets, k) }
+		}
+		l.mu.Unlock()
+	}
+}
+
+// 여러 한도가 걸린 요청이면 나중에 부른 쪽(더 좁은 한도)이 헤더를 덮어씀
+func setRateLimitHeaders(w http.ResponseWriter, st RateStatus) {
+	h := w.Header()
+	h.Set("X-RateLimit-Limit", strconv.Itoa(st.Limit))
+	h.Set("X-RateLimit-Remaining", strconv.Itoa(st.Remaining))
+	h.Set("X-RateLimit-Reset", strconv.FormatInt(st.Reset.Unix(), 10))
+	if !st.Allowed {
+		h.Set("Retry-After", strconv.Itoa(max(int(time.Until(st.Reset).Seconds()+0.999), 1)))
+	}
+}
+
+// 라우트를 미리 찾아 면제 대상이 아니면 IP별 API 한도를 적용
+func rateLimitMiddleware(next http.Handler) http.Handler {
+	if !apiLimiter.enabled() { return next }
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, pattern := http.DefaultServeMux.Handler(r); rateLimitExempt[pattern] || isLongLived(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		st := apiLimiter.take(clientIP(r))
+		setRateLimitHeaders(w, st)
+		if !st.Allowed { respondError(w, r, http.StatusTooManyRequests, errRateLimited); return }
+		next.ServeHTTP(w, r)
+	})
+}
+
+var errRateLimited = errors.New("rate limit exceeded")
+
+type LimitsResponse struct {
+	API      *RateStatus        `json:"api,omitempty"`      // 이 IP의 API 호출
+	Messages *RateStatus        `json:"messages,omitempty"` // 이 닉네임의 메시지 전송 (게스트면 게스트 한도)
+	Invites  *InviteQuotaStatus `json:"invites,omitempty"`  // 유효한 초대 코드 수 (창 없이 누적)
+}
+
+type InviteQuotaStatus struct {
+	Limit     int `json:"limit"`
+	Remaining int `json:"remaining"`
+}
+
+// [내 한도] GET /me/limits?nick= - 꺼진 한도는 생략, nick이 없으면 API 한도만
+func myLimitsHandler(w http.ResponseWriter, r *http.Request) {
+	var resp LimitsResponse
+	if apiLimiter.enabled() {
+		st := apiLimiter.peek(clientIP(r))
+		resp.API = &st
+	}
+	if nick := r.URL.Query().Get("nick"); nick != "" {
+		if l := messageLimiterFor(r.Context()); l.enabled() {
+			st := l.peek(nick)
+			resp.Messages = &st
+		}
+		if fullFeatureStore() {
+			active := activeInviteCount(nick)
+			resp.Invites = &InviteQuotaStatus{Limit: cfg.Registration.InviteQuota, Remaining: max(cfg.Registration.InviteQuota-active, 0)}
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
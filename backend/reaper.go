@@ -0,0 +1,41 @@
+package main
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// [죽은 연결 회수] 상대가 사라진 TCP(절전/망 단절)는 FIN 없이 남아 스트림 고루틴과 채널이 오래 붙잡혀 있음
+// 스트림은 ping/keepalive로 적어도 ping_interval마다 flush하므로, 이 파드의 연결을 같은 주기로 훑어
+// stream.dead_after 주기 동안 flush가 한 번도 끝나지 않은 연결은 쓰기 기한을 지나게 해 막힌 쓰기를 풀고 끊음
+var streamReaped = promauto.NewCounter(prometheus.CounterOpts{
+	Name: "gotalk_stream_reaped_total",
+	Help: "SSE connections force-closed after no successful writes for stream.dead_after ping intervals.",
+})
+
+// 파드마다 자기 연결만 보므로 scheduleJob(리더 전용)이 아니라 파드마다 돌림
+func runConnReaper() {
+	interval := time.Duration(cfg.Stream.PingInterval)
+	for range time.Tick(interval) {
+		reapDeadConns(time.Duration(cfg.Stream.DeadAfter) * interval)
+	}
+}
+
+func reapDeadConns(deadAfter time.Duration) {
+	var dead []*connInfo
+	connsMu.Lock()
+	for _, c := range conns {
+		if time.Since(time.Unix(0, c.lastWrite.Load())) > deadAfter { dead = append(dead, c) }
+	}
+	connsMu.Unlock()
+
+	for _, c := range dead {
+		idle := time.Since(time.Unix(0, c.lastWrite.Load())).Round(time.Second)
+		slog.Warn("reaping dead stream connection", "conn", c.ID, "nick", c.Nick, "idle", idle, "connected_at", c.ConnectedAt)
+		streamReaped.Inc()
+		c.reap()
+	}
+}
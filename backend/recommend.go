@@ -0,0 +1,221 @@
+package main
+
+import (
+	"log/slog"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/lib/pq"
+)
+
+// [방 추천] 주기 작업이 사용자별 추천을 room_recommendations에 미리 계산해 둠
+// 점수 = 최근 활동(0.5) + 멤버 겹침(0.3) + 주제 키워드 일치(0.2)
+const (
+	recommendWeightActivity = 0.5
+	recommendWeightOverlap  = 0.3
+	recommendWeightKeywords = 0.2
+	recommendPerUser        = 10
+	// 방이 하나도 없는 사용자용 전체 순위 (활동량 기준)
+	recommendGlobalNick = "*"
+)
+
+type SuggestedRoom struct {
+	Room
+	Score   float64  `json:"score"`
+	Reasons []string `json:"reasons"`
+}
+
+type roomCandidate struct {
+	id       int
+	activity float64 // 최근 7일 메시지 수의 log 정규화 값 (0~1)
+	members  map[string]bool
+	keywords map[string]bool
+}
+
+// 이름/주제에서 3글자 이상 단어만 키워드로 사용
+func keywordSet(texts ...string) map[string]bool {
+	set := map[string]bool{}
+	for _, t := range texts {
+		for _, w := range strings.FieldsFunc(strings.ToLower(t), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+		}) {
+			if len([]rune(w)) >= 3 { set[w] = true }
+		}
+	}
+	return set
+}
+
+type scoredRoom struct {
+	roomID  int
+	score   float64
+	reasons []string
+}
+
+func computeRoomRecommendations() (map[string][]scoredRoom, error) {
+	// 1. 공개 방과 최근 활동량
+	rows, err := db.Query(`
+		SELECT r.id, r.name, r.topic,
+			(SELECT COUNT(*) FROM messages m WHERE m.room_id = r.id AND m.type = 'message' AND m.created_at > CURRENT_TIMESTAMP - INTERVAL '7 days')
+		FROM rooms r WHERE r.is_public`)
+	if err != nil { return nil, err }
+	rooms := map[int]*roomCandidate{}
+	maxActivity := 0.0
+	for rows.Next() {
+		var id int
+		var name, topic string
+		var count float64
+		rows.Scan(&id, &name, &topic, &count)
+		rooms[id] = &roomCandidate{id: id, activity: count, members: map[string]bool{}, keywords: keywordSet(name, topic)}
+		maxActivity = math.Max(maxActivity, count)
+	}
+	rows.Close()
+	for _, c := range rooms {
+		if maxActivity > 0 { c.activity = math.Log1p(c.activity) / math.Log1p(maxActivity) }
+	}
+
+	// 2. 멤버십과 사용자별 관심 키워드
+	rows, err = db.Query(`
+		SELECT rm.room_id, rm.nickname, r.name, r.topic FROM room_members rm
+		JOIN rooms r ON r.id = rm.room_id
+		JOIN users u ON u.nickname = rm.nickname AND u.deleted_at IS NULL`)
+	if err != nil { return nil, err }
+	userRooms := map[string][]int{}
+	userKeywords := map[string]map[string]bool{}
+	for rows.Next() {
+		var roomID int
+		var nick, name, topic string
+		rows.Scan(&roomID, &nick, &name, &topic)
+		userRooms[nick] = append(userRooms[nick], roomID)
+		if userKeywords[nick] == nil { userKeywords[nick] = map[string]bool{} }
+		for k := range keywordSet(name, topic) { userKeywords[nick][k] = true }
+		if c, ok := rooms[roomID]; ok { c.members[nick] = true }
+	}
+	rows.Close()
+
+	// 최근 30일 안에 활동한 사용자만 계산 대상
+	active := map[string]bool{}
+	rows, err = db.Query(`
+		SELECT DISTINCT sender_nick FROM messages WHERE type = 'message' AND created_at > CURRENT_TIMESTAMP - INTERVAL '30 days'
+		UNION SELECT nickname FROM room_members WHERE joined_at > CURRENT_TIMESTAMP - INTERVAL '30 days'`)
+	if err != nil { return nil, err }
+	for rows.Next() {
+		var nick string
+		rows.Scan(&nick)
+		active[nick] = true
+	}
+	rows.Close()
+
+	// 3. 사용자별 점수 계산
+	result := map[string][]scoredRoom{}
+	for nick := range active {
+		joined := map[int]bool{}
+		neighbors := map[string]bool{}
+		for _, rid := range userRooms[nick] {
+			joined[rid] = true
+			if c, ok := rooms[rid]; ok {
+				for m := range c.members { neighbors[m] = true }
+			}
+		}
+		delete(neighbors, nick)
+
+		var scored []scoredRoom
+		for _, c := range rooms {
+			if joined[c.id] { continue }
+			s := scoredRoom{roomID: c.id, score: recommendWeightActivity * c.activity}
+			if c.activity > 0 { s.reasons = append(s.reasons, "active") }
+			if len(c.members) > 0 {
+				shared := 0
+				for m := range c.members {
+					if neighbors[m] { shared++ }
+				}
+				if shared > 0 {
+					s.score += recommendWeightOverlap * float64(shared) / float64(len(c.members))
+					s.reasons = append(s.reasons, "members_overlap")
+				}
+			}
+			if len(c.keywords) > 0 {
+				hits := 0
+				for k := range c.keywords {
+					if userKeywords[nick][k] { hits++ }
+				}
+				if hits > 0 {
+					s.score += recommendWeightKeywords * float64(hits) / float64(len(c.keywords))
+					s.reasons = append(s.reasons, "topic_match")
+				}
+			}
+			if s.score > 0 { scored = append(scored, s) }
+		}
+		sort.Slice(scored, func(i, j int) bool { return scored[i].score > scored[j].score })
+		if len(scored) > recommendPerUser { scored = scored[:recommendPerUser] }
+		result[nick] = scored
+	}
+
+	// 방이 없는 사용자용 전체 순위
+	var global []scoredRoom
+	for _, c := range rooms {
+		if c.activity > 0 { global = append(global, scoredRoom{roomID: c.id, score: c.activity, reasons: []string{"active"}}) }
+	}
+	sort.Slice(global, func(i, j int) bool { return global[i].score > global[j].score })
+	if len(global) > recommendPerUser { global = global[:recommendPerUser] }
+	result[recommendGlobalNick] = global
+	return result, nil
+}
+
+func refreshRoomRecommendations() {
+	start := time.Now()
+	result, err := computeRoomRecommendations()
+	if err != nil { slog.Warn("room recommendation refresh failed", "err", err); return }
+
+	tx, err := db.Begin()
+	if err != nil { slog.Warn("room recommendation refresh failed", "err", err); return }
+	defer tx.Rollback()
+	if _, err := tx.Exec("DELETE FROM room_recommendations"); err != nil { slog.Warn("room recommendation refresh failed", "err", err); return }
+	stmt, err := tx.Prepare("INSERT INTO room_recommendations (nickname, room_id, score, reasons) VALUES ($1, $2, $3, $4)")
+	if err != nil { slog.Warn("room recommendation refresh failed", "err", err); return }
+	defer stmt.Close()
+	for nick, rooms := range result {
+		for _, s := range rooms {
+			if _, err := stmt.Exec(nick, s.roomID, s.score, pq.Array(s.reasons)); err != nil {
+				slog.Warn("room recommendation refresh failed", "err", err)
+				return
+			}
+		}
+	}
+	if err := tx.Commit(); err != nil { slog.Warn("room recommendation refresh failed", "err", err); return }
+	slog.Info("refreshed room recommendations", "users", len(result)-1, "duration", time.Since(start))
+}
+
+// [추천 방] GET /rooms/suggested?nick=&limit=
+// 계산된 추천이 없으면 전체 활동량 순위로 대체, 이미 들어간 방은 제외
+func suggestedRoomsHandler(w http.ResponseWriter, r *http.Request) {
+	nick := r.URL.Query().Get("nick")
+	limit := queryInt(r, "limit", recommendPerUser)
+	if limit < 1 || limit > recommendPerUser { limit = recommendPerUser }
+
+	var hasOwn bool
+	db.QueryRow("SELECT EXISTS(SELECT 1 FROM room_recommendations WHERE nickname = $1)", nick).Scan(&hasOwn)
+	key := nick
+	if !hasOwn { key = recommendGlobalNick }
+
+	rows, err := db.Query(`
+		SELECT r.id, r.name, r.topic, r.description, r.is_public, COALESCE(r.owner_nick, ''), r.created_at, rr.score, rr.reasons
+		FROM room_recommendations rr
+		JOIN rooms r ON r.id = rr.room_id AND r.is_public
+		WHERE rr.nickname = $1
+		  AND NOT EXISTS (SELECT 1 FROM room_members rm WHERE rm.room_id = r.id AND rm.nickname = $2)
+		ORDER BY rr.score DESC LIMIT $3`, key, nick, limit)
+	if err != nil { respondError(w, r, 500, err); return }
+	defer rows.Close()
+
+	suggestions := []SuggestedRoom{}
+	for rows.Next() {
+		var s SuggestedRoom
+		rows.Scan(&s.ID, &s.Name, &s.Topic, &s.Description, &s.IsPublic, &s.OwnerNick, &s.CreatedAt, &s.Score, pq.Array(&s.Reasons))
+		suggestions = append(suggestions, s)
+	}
+	writeJSON(w, http.StatusOK, suggestions)
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"runtime/debug"
+)
+
+// [에러 리포터] Sentry 등 외부 수집기와 호환되는 최소 인터페이스
+type ErrorReporter interface {
+	CaptureException(err error, tags map[string]string)
+}
+
+// 기본 리포터: 로그로만 남김 (외부 연동 시 errorReporter 교체)
+type logReporter struct{}
+
+func (logReporter) CaptureException(err error, tags map[string]string) {
+	slog.Error("captured exception", "err", err, "tags", tags)
+}
+
+var errorReporter ErrorReporter = logReporter{}
+
+type ctxKey int
+
+const (
+	requestIDKey ctxKey = iota
+	identityKey         // 인증 공급자가 확인한 닉네임 (auth.go)
+	roomKeyKey          // 방 읽기 키로 들어온 요청의 키 (roomkeys.go)
+	guestKey            // 게스트 토큰으로 확인한 닉네임 (auth.go)
+)
+
+// 요청 ID 조회 (없으면 빈 문자열)
+func requestIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// 클라이언트가 보낸 X-Request-ID는 이 모양일 때만 이어받음 (로그/리포터에 임의 문자열이 들어가지 않게)
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+func newRequestID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// [패닉 복구 미들웨어] 핸들러 패닉을 500 JSON 응답으로 바꾸고 스택과 함께 보고
+// 응답이 이미 나가기 시작했으면(SSE 스트림 도중 등) 500을 덧붙일 수 없으므로 보고만 하고 연결을 끊음
+func recoverMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reqID := r.Header.Get("X-Request-ID")
+		if !validRequestID.MatchString(reqID) {
+			reqID = newRequestID()
+		}
+		w.Header().Set("X-Request-ID", reqID)
+		r = r.WithContext(context.WithValue(r.Context(), requestIDKey, reqID))
+		rw := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			// 클라이언트 연결 중단용 패닉은 net/http가 처리하도록 그대로 전달
+			if rec == http.ErrAbortHandler {
+				panic(rec)
+			}
+			err, ok := rec.(error)
+			if !ok {
+				err = fmt.Errorf("%v", rec)
+			}
+			slog.ErrorContext(r.Context(), "handler panic", "method", r.Method, "path", r.URL.Path, "err", err, "stack", string(debug.Stack()))
+			errorReporter.CaptureException(err, map[string]string{
+				"request_id": reqID,
+				"method":     r.Method,
+				"path":       r.URL.Path,
+				"node":       nodeID,
+			})
+			if rw.started {
+				panic(http.ErrAbortHandler)
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]any{
+				"error":      "internal server error",
+				"code":       kindInternal,
+				"retryable":  false,
+				"request_id": reqID,
+			})
+		}()
+
+		next.ServeHTTP(rw, r)
+	})
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type fakeReporter struct{ tags []map[string]string }
+
+func (f *fakeReporter) CaptureException(err error, tags map[string]string) { f.tags = append(f.tags, tags) }
+
+func useFakeReporter(t *testing.T) *fakeReporter {
+	f := &fakeReporter{}
+	prev := errorReporter
+	errorReporter = f
+	t.Cleanup(func() { errorReporter = prev })
+	return f
+}
+
+func TestRecoverMiddlewarePanic(t *testing.T) {
+	rep := useFakeReporter(t)
+	h := recoverMiddleware(http.HandlerFunc(func(http.ResponseWriter, *http.Request) { panic("boom") }))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/history", nil))
+
+	if w.Code != http.StatusInternalServerError { t.Fatalf("status = %d, want 500", w.Code) }
+	var body struct {
+		Error     string `json:"error"`
+		Code      errorKind `json:"code"`
+		RequestID string `json:"request_id"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil { t.Fatalf("body %q: %v", w.Body, err) }
+	if body.RequestID == "" || body.RequestID != w.Header().Get("X-Request-ID") { t.Errorf("request_id = %q, header = %q", body.RequestID, w.Header().Get("X-Request-ID")) }
+	if body.Code != kindInternal { t.Errorf("code = %q, want %q", body.Code, kindInternal) }
+	if len(rep.tags) != 1 || rep.tags[0]["request_id"] != body.RequestID || rep.tags[0]["path"] != "/history" { t.Errorf("reported %v", rep.tags) }
+}
+
+func TestRecoverMiddlewareRequestID(t *testing.T) {
+	tests := []struct {
+		header string
+		keep   bool
+	}{
+		{"abc-123_x.y", true},
+		{strings.Repeat("a", 64), true},
+		{strings.Repeat("a", 65), false},
+		{"has space", false},
+		{"line\nbreak", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			var got string
+			h := recoverMiddleware(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) { got = requestIDFrom(r.Context()) }))
+			r := httptest.NewRequest("GET", "/", nil)
+			r.Header["X-Request-Id"] = []string{tt.header}
+			h.ServeHTTP(httptest.NewRecorder(), r)
+			if tt.keep && got != tt.header { t.Errorf("request id = %q, want %q", got, tt.header) }
+			if !tt.keep && (got == tt.header || !validRequestID.MatchString(got)) { t.Errorf("request id = %q, want a generated one", got) }
+		})
+	}
+}
+
+// 응답을 쓰기 시작한 뒤의 패닉과 연결 중단 패닉은 ErrAbortHandler로 net/http에 넘겨야 함
+func TestRecoverMiddlewareAbort(t *testing.T) {
+	tests := []struct {
+		name     string
+		handler  http.HandlerFunc
+		reported int
+		body     string
+	}{
+		{"panic after write", func(w http.ResponseWriter, _ *http.Request) { w.Write([]byte("data: hi\n\n")); panic("boom") }, 1, "data: hi\n\n"},
+		{"panic after flush", func(w http.ResponseWriter, _ *http.Request) { w.(http.Flusher).Flush(); panic("boom") }, 1, ""},
+		{"panic after header", func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusAccepted); panic("boom") }, 1, ""},
+		{"abort handler", func(http.ResponseWriter, *http.Request) { panic(http.ErrAbortHandler) }, 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rep := useFakeReporter(t)
+			w := httptest.NewRecorder()
+			func() {
+				defer func() {
+					if rec := recover(); rec != http.ErrAbortHandler { t.Errorf("recovered %v, want http.ErrAbortHandler", rec) }
+				}()
+				recoverMiddleware(tt.handler).ServeHTTP(w, httptest.NewRequest("GET", "/stream", nil))
+			}()
+			if len(rep.tags) != tt.reported { t.Errorf("reported %d times, want %d", len(rep.tags), tt.reported) }
+			if w.Body.String() != tt.body { t.Errorf("body = %q, want %q", w.Body, tt.body) }
+		})
+	}
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"html"
+	"log/slog"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// [본문 렌더링] 메시지 본문(간단한 마크다운)을 안전한 HTML로 바꿔 Message.HTML에 담음
+// 같은 본문은 같은 결과이므로 (본문 해시, 렌더러 버전)을 키로 파드 메모리 → message_renders 순으로 캐시해
+// /history가 페이지마다 수천 건을 다시 렌더링하지 않게 함
+// 렌더링 규칙을 바꾸면 rendererVersion을 올릴 것: 새 버전은 캐시를 새로 채우고, render-cache-gc 작업이 옛 버전 행을 지움
+const rendererVersion = 2 // 2: [글](주소) 링크
+
+const (
+	renderMemoryMax = 10000 // 넘으면 메모리 캐시를 통째로 비움
+	renderGCBatch   = 5000
+)
+
+var (
+	renderMu    sync.Mutex
+	renderCache = map[string]string{}
+
+	renderCacheLookups = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "gotalk_render_cache_lookups_total",
+		Help: "Message render cache lookups by result (memory, db, miss).",
+	}, []string{"result"})
+)
+
+var (
+	mdCodeFence  = regexp.MustCompile("(?s)```(?:[a-zA-Z0-9_+-]*\n)?(.*?)```")
+	mdInlineCode = regexp.MustCompile("`([^`\n]+)`")
+	mdURL        = regexp.MustCompile(`https?://[^\s<]+[^\s<.,;:!?)\]'"]`)
+	mdLink       = regexp.MustCompile(`\[([^\]\n]+)\]\((https?://[^\s()<>]+)\)`)
+	mdBold       = regexp.MustCompile(`\*\*([^*\n]+)\*\*`)
+	mdItalic     = regexp.MustCompile(`\*([^*\s][^*\n]*)\*`)
+	mdStrike     = regexp.MustCompile(`~~([^~\n]+)~~`)
+)
+
+func contentHash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// 본문 하나 렌더링 (메모리 캐시만, 방송 직전 새 메시지용)
+func renderContent(content string) string {
+	key := contentHash(content)
+	renderMu.Lock()
+	out, ok := renderCache[key]
+	renderMu.Unlock()
+	if ok { renderCacheLookups.WithLabelValues("memory").Inc(); return out }
+	renderCacheLookups.WithLabelValues("miss").Inc()
+	out = renderMarkdown(content)
+	rememberRender(key, out)
+	return out
+}
+
+// 새 메시지 본문을 렌더링하고 DB 캐시에도 저장해 둠 (/history가 처음 읽을 때 다시 렌더링하지 않게)
+func renderAndStore(ctx context.Context, content string) string {
+	out := renderContent(content)
+	if fullFeatureStore() {
+		_, err := db.ExecContext(ctx, "INSERT INTO message_renders (content_hash, renderer_version, html) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING",
+			contentHash(content), rendererVersion, out)
+		if err != nil { slog.WarnContext(ctx, "render cache store failed", "err", err) }
+	}
+	return out
+}
+
+func rememberRender(key, out string) {
+	renderMu.Lock()
+	if len(renderCache) >= renderMemoryMax { renderCache = map[string]string{} }
+	renderCache[key] = out
+	renderMu.Unlock()
+}
+
+// 기록 페이지의 일반 메시지 본문을 한꺼번에 렌더링 (메모리 → DB → 렌더링 후 DB에 저장)
+func renderMessages(ctx context.Context, msgs []Message) {
+	keys := make([]string, len(msgs))
+	var missing []string
+	found := map[string]string{}
+	renderMu.Lock()
+	for i, m := range msgs {
+		if m.Type != messageTypeText { continue }
+		keys[i] = contentHash(m.Content)
+		out, ok := renderCache[keys[i]]
+		if !ok { missing = append(missing, keys[i]); continue }
+		found[keys[i]] = out
+	}
+	renderMu.Unlock()
+	renderCacheLookups.WithLabelValues("memory").Add(float64(len(found)))
+
+	if len(missing) > 0 && fullFeatureStore() {
+		rows, err := db.QueryContext(ctx, "SELECT content_hash, html FROM message_renders WHERE renderer_version = $1 AND content_hash = ANY($2)",
+			rendererVersion, pq.Array(missing))
+		if err == nil {
+			n := 0
+			for rows.Next() {
+				var key, out string
+				rows.Scan(&key, &out)
+				found[key] = out
+				rememberRender(key, out)
+				n++
+			}
+			rows.Close()
+			renderCacheLookups.WithLabelValues("db").Add(float64(n))
+		}
+	}
+
+	var newKeys, newHTML []string
+	for i := range msgs {
+		if keys[i] == "" { continue }
+		out, ok := found[keys[i]]
+		if !ok {
+			out = renderMarkdown(msgs[i].Content)
+			found[keys[i]] = out
+			rememberRender(keys[i], out)
+			newKeys, newHTML = append(newKeys, keys[i]), append(newHTML, out)
+		}
+		msgs[i].HTML = out
+	}
+	renderCacheLookups.WithLabelValues("miss").Add(float64(len(newKeys)))
+	if len(newKeys) > 0 && fullFeatureStore() {
+		_, err := db.ExecContext(ctx, `INSERT INTO message_renders (content_hash, renderer_version, html)
+			SELECT k, $1, h FROM unnest($2::text[], $3::text[]) AS t(k, h) ON CONFLICT DO NOTHING`,
+			rendererVersion, pq.Array(newKeys), pq.Array(newHTML))
+		if err != nil { slog.WarnContext(ctx, "render cache store failed", "count", len(newKeys), "err", err) }
+	}
+}
+
+// [렌더 캐시 정리] 스케줄러 작업: 현재 버전이 아닌 행을 배치로 지움 (버전을 올린 뒤 첫 실행에서 정리됨)
+func pruneRenderCache() {
+	total := 0
+	for {
+		res, err := db.Exec(`DELETE FROM message_renders WHERE ctid IN (
+			SELECT ctid FROM message_renders WHERE renderer_version <> $1 LIMIT $2)`, rendererVersion, renderGCBatch)
+		if err != nil { slog.Warn("render cache prune failed", "err", err); return }
+		n, _ := res.RowsAffected()
+		total += int(n)
+		if n < renderGCBatch { break }
+	}
+	if total > 0 { slog.Info("render cache pruned", "rows", total, "renderer_version", rendererVersion) }
+}
+
+// 간단한 마크다운: ```코드 블록```, `코드`, **굵게**, *기울임*, ~~취소선~~, [글](주소), 링크 자동 연결, @멘션, 줄바꿈
+// 모든 본문은 먼저 이스케이프하므로 사용자가 넣은 HTML은 그대로 글자로 보임
+func renderMarkdown(content string) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range mdCodeFence.FindAllStringSubmatchIndex(content, -1) {
+		renderInline(&b, content[last:m[0]])
+		b.WriteString("<pre><code>" + html.EscapeString(content[m[2]:m[3]]) + "</code></pre>")
+		last = m[1]
+		if strings.HasPrefix(content[last:], "\n") { last++ } // 블록 뒤 줄바꿈은 <pre>가 대신함
+	}
+	renderInline(&b, content[last:])
+	return b.String()
+}
+
+// 인라인 코드 안쪽은 다른 규칙을 적용하지 않음
+func renderInline(b *strings.Builder, s string) {
+	last := 0
+	for _, m := range mdInlineCode.FindAllStringSubmatchIndex(s, -1) {
+		renderLinks(b, s[last:m[0]])
+		b.WriteString("<code>" + html.EscapeString(s[m[2]:m[3]]) + "</code>")
+		last = m[1]
+	}
+	renderLinks(b, s[last:])
+}
+
+// [글](주소) 링크: 주소는 http(s)만, 글에는 강조/멘션 규칙을 적용
+func renderLinks(b *strings.Builder, s string) {
+	last := 0
+	for _, m := range mdLink.FindAllStringSubmatchIndex(s, -1) {
+		renderAutoLinks(b, s[last:m[0]])
+		b.WriteString(`<a href="` + html.EscapeString(s[m[4]:m[5]]) + `" target="_blank" rel="nofollow noopener noreferrer">`)
+		renderText(b, s[m[2]:m[3]])
+		b.WriteString(`</a>`)
+		last = m[1]
+	}
+	renderAutoLinks(b, s[last:])
+}
+
+// 링크 주소 안에는 강조/멘션 규칙을 적용하지 않음
+func renderAutoLinks(b *strings.Builder, s string) {
+	last := 0
+	for _, m := range mdURL.FindAllStringIndex(s, -1) {
+		renderText(b, s[last:m[0]])
+		u := html.EscapeString(s[m[0]:m[1]])
+		b.WriteString(`<a href="` + u + `" target="_blank" rel="nofollow noopener noreferrer">` + u + `</a>`)
+		last = m[1]
+	}
+	renderText(b, s[last:])
+}
+
+func renderText(b *strings.Builder, s string) {
+	s = html.EscapeString(s)
+	s = mdBold.ReplaceAllString(s, "<strong>$1</strong>")
+	s = mdItalic.ReplaceAllString(s, "<em>$1</em>")
+	s = mdStrike.ReplaceAllString(s, "<del>$1</del>")
+	s = mentionPattern.ReplaceAllString(s, `<span class="mention">@$1</span>`)
+	b.WriteString(strings.ReplaceAll(s, "\n", "<br>"))
+}
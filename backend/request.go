@@ -0,0 +1,252 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"regexp"
+	"strconv"
+	"unicode"
+	"unicode/utf8"
+)
+
+// [요청 본문] application/json 또는 폼(urlencoded/multipart) 모두 받아 타입 있는 요청 구조체로 검증
+// JSON으로 보냈거나 Accept: application/json이면 오류도 JSON({error, field, code, retryable})으로 돌려줌
+
+// JSON 본문 최대 크기
+const maxJSONBody = 64 << 10
+
+// client_msg_id 최대 길이
+const maxClientMsgID = 64
+
+var colorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+var errUnsupportedMediaType = errors.New("content type must be application/json or form data")
+
+type APIError struct {
+	Error     string    `json:"error"`
+	Field     string    `json:"field,omitempty"` // 잘못된 입력 필드 (있을 때만)
+	Code      errorKind `json:"code"`            // 오류 분류 (apierror.go)
+	Retryable bool      `json:"retryable"`       // 같은 요청을 나중에 다시 보내도 되는지
+}
+
+// 필드 단위 검증 오류
+type fieldError struct{ field, msg string }
+
+func (e fieldError) Error() string { return e.field + ": " + e.msg }
+
+type SendRequest struct {
+	Nick        string `json:"nick"`
+	Msg         string `json:"msg"`
+	Color       string `json:"color"`
+	RoomID      *int   `json:"room_id"`
+	ReplyTo     *int   `json:"reply_to"`
+	ClientMsgID string `json:"client_msg_id"` // 재전송 중복 방지 키 (선택)
+}
+
+type UpdateProfileRequest struct {
+	Nick       string  `json:"nick"`
+	Color      string  `json:"color"`
+	Locale     string  `json:"locale"`      // 프로필 언어 (비우면 그대로)
+	Status     *string `json:"status"`      // online | away | dnd (없으면 그대로, status.go)
+	StatusText *string `json:"status_text"` // 없으면 그대로, 빈 값이면 지움
+}
+
+// 상태 변경 (POST /status), 보낸 필드만 바꿈
+type StatusRequest struct {
+	Nick       string  `json:"nick"`
+	Status     *string `json:"status"`
+	StatusText *string `json:"status_text"`
+}
+
+// 방 정보 수정 (PATCH /rooms/{id}), 보낸 필드만 바꿈
+type UpdateRoomRequest struct {
+	Nick        string  `json:"nick"`
+	Name        *string `json:"name"`
+	Topic       *string `json:"topic"`
+	Description *string `json:"description"`
+}
+
+func (req *SendRequest) fromForm(r *http.Request) error {
+	req.Nick, req.Msg, req.Color = r.FormValue("nick"), r.FormValue("msg"), r.FormValue("color")
+	req.ClientMsgID = r.FormValue("client_msg_id")
+	var err error
+	if req.RoomID, err = formInt(r, "room_id"); err != nil { return err }
+	req.ReplyTo, err = formInt(r, "reply_to")
+	return err
+}
+
+func (req *SendRequest) validate() error {
+	if req.Nick == "" { return fieldError{"nick", "required"} }
+	if req.Msg == "" { return fieldError{"msg", "required"} }
+	if err := validateText("msg", req.Msg, cfg.MaxMessageLength); err != nil { return err }
+	// 색이 없으면 핸들러가 저장된 색이나 배정 색을 씀 (palette.go)
+	if req.Color != "" && !colorPattern.MatchString(req.Color) { return fieldError{"color", "must be #rrggbb"} }
+	if len(req.ClientMsgID) > maxClientMsgID { return fieldError{"client_msg_id", "up to 64 bytes"} }
+	return nil
+}
+
+func (req *UpdateProfileRequest) fromForm(r *http.Request) error {
+	req.Nick, req.Color, req.Locale = r.FormValue("nick"), r.FormValue("color"), r.FormValue("locale")
+	req.Status, req.StatusText = optionalFormValue(r, "status"), optionalFormValue(r, "status_text")
+	return nil
+}
+
+func (req *UpdateProfileRequest) validate() error {
+	if req.Nick == "" { return fieldError{"nick", "required"} }
+	// 색이 없으면 핸들러가 저장된 색이나 배정 색을 씀 (palette.go)
+	if req.Color != "" && !colorPattern.MatchString(req.Color) { return fieldError{"color", "must be #rrggbb"} }
+	if req.Locale != "" {
+		if req.Locale = normalizeLanguage(req.Locale); req.Locale == "" { return fieldError{"locale", "must be an ISO 639 code like en or ko"} }
+	}
+	return validateStatus(req.Status, req.StatusText)
+}
+
+func (req *StatusRequest) fromForm(r *http.Request) error {
+	req.Nick = r.FormValue("nick")
+	req.Status, req.StatusText = optionalFormValue(r, "status"), optionalFormValue(r, "status_text")
+	return nil
+}
+
+func (req *StatusRequest) validate() error {
+	if req.Nick == "" { return fieldError{"nick", "required"} }
+	if req.Status == nil && req.StatusText == nil { return fieldError{"status", "nothing to update"} }
+	return validateStatus(req.Status, req.StatusText)
+}
+
+func validateStatus(status, text *string) error {
+	if status != nil && !validStatus(*status) { return fieldError{"status", "must be online, away or dnd"} }
+	if text != nil {
+		if err := validateText("status_text", *text, statusMaxText); err != nil { return err }
+	}
+	return nil
+}
+
+func (req *UpdateRoomRequest) fromForm(r *http.Request) error {
+	req.Nick = r.FormValue("nick")
+	req.Name, req.Topic, req.Description = optionalFormValue(r, "name"), optionalFormValue(r, "topic"), optionalFormValue(r, "description")
+	return nil
+}
+
+// 폼에 키가 있으면 그 값 (빈 값 포함), 없으면 nil
+func optionalFormValue(r *http.Request, key string) *string {
+	if _, ok := r.Form[key]; !ok { return nil }
+	v := r.Form.Get(key)
+	return &v
+}
+
+func (req *UpdateRoomRequest) validate() error {
+	if req.Nick == "" { return fieldError{"nick", "required"} }
+	if req.Name == nil && req.Topic == nil && req.Description == nil { return fieldError{"name", "nothing to update"} }
+	if req.Name != nil {
+		if *req.Name == "" { return fieldError{"name", "must not be empty"} }
+		if err := validateText("name", *req.Name, roomMaxName); err != nil { return err }
+	}
+	if req.Topic != nil {
+		if err := validateText("topic", *req.Topic, roomMaxTopic); err != nil { return err }
+	}
+	if req.Description != nil {
+		if err := validateText("description", *req.Description, roomMaxDescription); err != nil { return err }
+	}
+	return nil
+}
+
+func (req *SendRequest) pinNick(nick string)          { req.Nick = nick }
+func (req *UpdateProfileRequest) pinNick(nick string) { req.Nick = nick }
+func (req *UpdateRoomRequest) pinNick(nick string)    { req.Nick = nick }
+func (req *StatusRequest) pinNick(nick string)        { req.Nick = nick }
+
+type formRequest interface {
+	fromForm(r *http.Request) error
+	validate() error
+	pinNick(nick string)
+}
+
+// [본문 검사] 잘못된 UTF-8, 제어 문자(줄바꿈/탭 제외), maxLen 글자 초과를 거부
+// 큰 붙여넣기 하나가 SSE 클라이언트와 DB를 괴롭히지 않게 /send, 위젯, 수신 웹훅에 공통으로 씀
+func validateText(field, s string, maxLen int) error {
+	if !utf8.ValidString(s) { return fieldError{field, "must be valid UTF-8"} }
+	n := 0
+	for _, c := range s {
+		if unicode.IsControl(c) && c != '\n' && c != '\r' && c != '\t' { return fieldError{field, fmt.Sprintf("must not contain control character U+%04X", c)} }
+		n++
+	}
+	if n > maxLen { return fieldError{field, fmt.Sprintf("too long (%d characters, max %d)", n, maxLen)} }
+	return nil
+}
+
+// 정수 폼 값 (비어 있으면 nil)
+func formInt(r *http.Request, key string) (*int, error) {
+	v := r.FormValue(key)
+	if v == "" { return nil, nil }
+	n, err := strconv.Atoi(v)
+	if err != nil { return nil, fieldError{key, "must be an integer"} }
+	return &n, nil
+}
+
+func isJSONRequest(r *http.Request) bool {
+	mt, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return mt == "application/json"
+}
+
+// Content-Type에 따라 본문을 읽고 검증
+func decodeRequest(r *http.Request, req formRequest) error {
+	mt, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mt {
+	case "application/json":
+		dec := json.NewDecoder(io.LimitReader(r.Body, maxJSONBody))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(req); err != nil {
+			var typeErr *json.UnmarshalTypeError
+			if errors.As(err, &typeErr) { return fieldError{typeErr.Field, "must be " + typeErr.Type.String()} }
+			return fmt.Errorf("invalid json: %w", err)
+		}
+		// 인증 공급자가 확인한 신원이 있으면 본문의 nick 대신 씀 (폼은 미들웨어가 이미 고침)
+		if nick, ok := identityFrom(r.Context()); ok { req.pinNick(nick) }
+	case "", "application/x-www-form-urlencoded", "multipart/form-data":
+		if err := req.fromForm(r); err != nil { return err }
+	default:
+		return errUnsupportedMediaType
+	}
+	return req.validate()
+}
+
+func wantsJSONError(r *http.Request) bool {
+	if isJSONRequest(r) { return true }
+	mt, _, _ := mime.ParseMediaType(r.Header.Get("Accept"))
+	return mt == "application/json"
+}
+
+// JSON 클라이언트면 APIError, 아니면 기존처럼 평문
+func respondError(w http.ResponseWriter, r *http.Request, status int, err error) {
+	kind, status := classifyError(status, err)
+	if !wantsJSONError(r) { http.Error(w, err.Error(), status); return }
+	resp := APIError{Error: err.Error(), Code: kind, Retryable: kind.retryable()}
+	var fe fieldError
+	if errors.As(err, &fe) { resp.Error, resp.Field = fe.msg, fe.field }
+	writeJSON(w, status, resp)
+}
+
+// 글쓰기/프로필 변경 공통 계정 상태 확인
+func checkCanPost(nick string) error {
+	switch {
+	case isUserDeleted(nick):
+		return errors.New("account deleted")
+	case isUserBanned(nick):
+		return errors.New("account banned")
+	case isReservedNick(nick):
+		return errors.New("nickname is reserved")
+	case !canAutoRegister(nick):
+		return errors.New("registration requires an invite code")
+	}
+	return nil
+}
+
+// 본문 디코딩 오류의 상태 코드 (지원하지 않는 형식은 415)
+func decodeStatus(err error) int {
+	if errors.Is(err, errUnsupportedMediaType) { return http.StatusUnsupportedMediaType }
+	return http.StatusBadRequest
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateText(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		max     int
+		wantErr string
+	}{
+		{"ok", "hello", 10, ""},
+		{"newline and tab", "a\nb\r\n\tc", 10, ""},
+		{"counts runes not bytes", "안녕하세요", 5, ""},
+		{"too long", "안녕하세요!", 5, "too long (6 characters, max 5)"},
+		{"empty", "", 0, ""},
+		{"nul", "a\x00b", 10, "must not contain control character U+0000"},
+		{"escape", "\x1b[31m", 10, "must not contain control character U+001B"},
+		{"c1 control", "a\u0085", 10, "must not contain control character U+0085"},
+		{"invalid utf-8", "a\xffb", 10, "must be valid UTF-8"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateText("content", tt.s, tt.max)
+			if tt.wantErr == "" {
+				if err != nil { t.Errorf("validateText() = %v, want nil", err) }
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) { t.Errorf("validateText() = %v, want %q", err, tt.wantErr) }
+			if fe, ok := err.(fieldError); !ok || fe.field != "content" { t.Errorf("validateText() = %#v, want fieldError on content", err) }
+		})
+	}
+}
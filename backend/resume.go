@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+)
+
+// [스트림 이어받기] 새 메시지/이벤트는 `id: <메시지 ID>`를 붙여 보내고, 다시 붙은 클라이언트가
+// Last-Event-ID 헤더(또는 ?last_event_id=, 직접 다시 여는 EventSource용)를 보내면
+// 그 뒤의 메시지를 DB에서 읽어 먼저 보낸 뒤 실시간 방송으로 넘어감 (방송 채널은 먼저 등록해 두어 사이에 온 것도 놓치지 않음)
+// 대상은 로비 + 내가 속한 방 (방 읽기 키면 그 방만), 놓친 양이 stream.replay_max를 넘으면 `event: resync`로 기록을 새로 받게 함
+const eventResync = "resync"
+
+// 요청이 이어받으려는 마지막 메시지 ID (없으면 0)
+func lastEventID(r *http.Request) int {
+	v := r.Header.Get("Last-Event-ID")
+	if v == "" { v = r.URL.Query().Get("last_event_id") }
+	id, _ := strconv.Atoi(v)
+	return max(id, 0)
+}
+
+// 방송 페이로드 중 이어받기 기준이 되는 것(기록에 남는 새 메시지/이벤트)의 ID
+func streamEventID(data string) int {
+	var m struct {
+		ID            int    `json:"id"`
+		Type          string `json:"type"`
+		RecipientNick string `json:"recipient_nick"`
+	}
+	if json.Unmarshal([]byte(data), &m) != nil || m.RecipientNick != "" { return 0 }
+	if m.Type != messageTypeText && m.Type != messageTypeEvent { return 0 }
+	return m.ID
+}
+
+// afterID 뒤에 놓친 메시지 (오래된 것부터), replay_max를 넘으면 truncated
+func missedMessages(ctx context.Context, nick, member string, kioskRoom *int, afterID int) (msgs []Message, truncated bool, err error) {
+	rooms := []*int{nil}
+	switch {
+	case kioskRoom != nil:
+		rooms = []*int{kioskRoom}
+	case fullFeatureStore() && nick != "":
+		// 비공개 방은 확인된 신원이 그 닉네임일 때만
+		rows, err := db.QueryContext(ctx, `SELECT rm.room_id FROM room_members rm JOIN rooms r ON r.id = rm.room_id
+			WHERE rm.nickname = $1 AND (r.is_public OR $1 = $2)`, nick, member)
+		if err != nil { return nil, false, err }
+		for rows.Next() {
+			var id int
+			rows.Scan(&id)
+			rooms = append(rooms, &id)
+		}
+		rows.Close()
+	}
+
+	limit := cfg.Stream.ReplayMax
+	for _, roomID := range rooms {
+		list, err := store.History(ctx, roomID, 0, afterID, limit+1)
+		if err != nil { return nil, false, err }
+		msgs = append(msgs, list...)
+		if len(msgs) > limit { return nil, true, nil }
+	}
+	sort.Slice(msgs, func(i, j int) bool { return msgs[i].ID < msgs[j].ID })
+	renderMessages(ctx, msgs)
+	return msgs, false, nil
+}
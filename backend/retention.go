@@ -0,0 +1,79 @@
+package main
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// [보관 기간] retention.message_ttl보다 오래된 메시지를 배치 단위로 지움 (archive면 messages_archive로 옮긴 뒤)
+// - 보관 기간 안의 답글이 달린 원글은 남김 (parent_id CASCADE로 최근 답글까지 지워지지 않게)
+// - 아직 고정 중인 메시지도 남김
+// 배치마다 짧은 트랜잭션이라 큰 테이블도 잠금을 오래 잡지 않음
+
+var messagesPurged = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "gotalk_messages_purged_total",
+	Help: "Messages removed by the retention job, by action (deleted or archived).",
+}, []string{"action"})
+
+// 한 번 실행에서 처리할 최대 배치 수 (나머지는 다음 실행에서)
+const retentionMaxBatches = 100
+
+func retentionEnabled() bool { return cfg.Retention.MessageTTL > 0 }
+
+// [스케줄러 작업] 보관 기간이 지난 메시지 정리
+func purgeOldMessages() {
+	cutoff := time.Now().Add(-time.Duration(cfg.Retention.MessageTTL))
+	action := "deleted"
+	if cfg.Retention.Archive { action = "archived" }
+
+	total := 0
+	for range retentionMaxBatches {
+		n, err := purgeMessageBatch(cutoff)
+		if err != nil { slog.Warn("message retention failed", "err", err); break }
+		total += n
+		messagesPurged.WithLabelValues(action).Add(float64(n))
+		if n < cfg.Retention.BatchSize { break }
+	}
+	if total > 0 { slog.Info("purged old messages", "count", total, "action", action, "cutoff", cutoff) }
+}
+
+func purgeMessageBatch(cutoff time.Time) (int, error) {
+	const doomed = `
+		SELECT m.id FROM messages m
+		WHERE m.created_at < $1
+			AND NOT EXISTS (SELECT 1 FROM messages r WHERE r.parent_id = m.id AND r.created_at >= $1)
+			AND NOT EXISTS (SELECT 1 FROM pins p WHERE p.message_id = m.id AND p.expired_at IS NULL)
+		ORDER BY m.id LIMIT $2 FOR UPDATE SKIP LOCKED`
+
+	tx, err := db.Begin()
+	if err != nil { return 0, err }
+	defer tx.Rollback()
+
+	rows, err := tx.Query(doomed, cutoff, cfg.Retention.BatchSize)
+	if err != nil { return 0, err }
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		rows.Scan(&id)
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if len(ids) == 0 { return 0, nil }
+
+	if cfg.Retention.Archive {
+		_, err := tx.Exec(`
+			INSERT INTO messages_archive (id, room_id, created_at, data)
+			SELECT m.id, m.room_id, m.created_at, to_jsonb(m) FROM messages m WHERE m.id = ANY($1)
+			ON CONFLICT (id) DO NOTHING`, pq.Array(ids))
+		if err != nil { return 0, err }
+	}
+	res, err := tx.Exec("DELETE FROM messages WHERE id = ANY($1)", pq.Array(ids))
+	if err != nil { return 0, err }
+	if err := tx.Commit(); err != nil { return 0, err }
+	n, _ := res.RowsAffected()
+	return int(n), nil
+}
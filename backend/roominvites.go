@@ -0,0 +1,108 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// [방 초대 링크] 비공개 방에 들어올 수 있는 링크, 방장/운영진이 만듦
+// 토큰은 세션과 같은 "초대ID.만료.서명" 형식이지만 "room-invite:"를 붙여 서명해 세션/게스트 토큰으로는 못 씀
+// 만료는 토큰에, 폐기와 사용 횟수는 room_invites 테이블에 (만료 없는 초대는 폐기할 때까지 유효)
+var errRoomInviteInvalid = errors.New("invalid, expired or revoked invite")
+
+// 만료 없는 초대의 토큰 만료 시각
+var roomInviteNoExpiry = time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)
+
+type RoomInvite struct {
+	ID        int64      `json:"id"`
+	RoomID    int        `json:"room_id"`
+	Token     string     `json:"token"`
+	URL       string     `json:"url"`
+	CreatedBy string     `json:"created_by"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+func signRoomInvite(payload string) string { return signSession("room-invite:" + payload) }
+
+// [방 초대 링크 생성] POST /rooms/{id}/invites (nick, ttl?) - 방장/운영진, ttl이 없거나 0이면 만료 없음
+func createRoomInviteHandler(w http.ResponseWriter, r *http.Request) {
+	roomID, ok := roomIDFrom(r)
+	nick := r.FormValue("nick")
+	if !ok || nick == "" { respondError(w, r, http.StatusBadRequest, errors.New("room id and nick required")); return }
+	if !canManageEmbeds(roomID, nick) { respondError(w, r, http.StatusForbidden, errors.New("only owners and moderators can create invites")); return }
+	var ttl time.Duration
+	if v := r.FormValue("ttl"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 { respondError(w, r, http.StatusBadRequest, fieldError{"ttl", "must be a non-negative duration"}); return }
+		ttl = d
+	}
+
+	inv := RoomInvite{RoomID: roomID, CreatedBy: nick}
+	exp := roomInviteNoExpiry
+	if ttl > 0 {
+		exp = time.Now().Add(ttl)
+		inv.ExpiresAt = &exp
+	}
+	err := db.QueryRow("INSERT INTO room_invites (room_id, created_by, expires_at) VALUES ($1, $2, $3) RETURNING id, created_at",
+		roomID, nick, inv.ExpiresAt).Scan(&inv.ID, &inv.CreatedAt)
+	if err != nil { respondError(w, r, 500, err); return }
+	inv.Token = mintToken(strconv.FormatInt(inv.ID, 10), exp, signRoomInvite)
+	inv.URL = baseURL(r) + "/?room_invite=" + url.QueryEscape(inv.Token)
+	slog.InfoContext(r.Context(), "room invite created", "room_id", roomID, "nick", nick, "invite_id", inv.ID)
+	writeJSON(w, http.StatusCreated, inv)
+}
+
+// [방 초대 링크 폐기] DELETE /rooms/{id}/invites/{invite}?nick= - 방장/운영진
+func revokeRoomInviteHandler(w http.ResponseWriter, r *http.Request) {
+	roomID, ok := roomIDFrom(r)
+	nick := r.URL.Query().Get("nick")
+	inviteID, err := strconv.ParseInt(r.PathValue("invite"), 10, 64)
+	if !ok || nick == "" || err != nil { respondError(w, r, http.StatusBadRequest, errors.New("room id, invite id and nick required")); return }
+	if !canManageEmbeds(roomID, nick) { respondError(w, r, http.StatusForbidden, errors.New("only owners and moderators can revoke invites")); return }
+
+	res, err := db.Exec("UPDATE room_invites SET revoked_at = CURRENT_TIMESTAMP WHERE id = $1 AND room_id = $2 AND revoked_at IS NULL", inviteID, roomID)
+	if err != nil { respondError(w, r, 500, err); return }
+	if n, _ := res.RowsAffected(); n == 0 { respondError(w, r, http.StatusNotFound, errors.New("invite not found")); return }
+	slog.InfoContext(r.Context(), "room invite revoked", "room_id", roomID, "nick", nick, "invite_id", inviteID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// [초대로 방 참여] POST /room-invites/{token}/join (nick) - 참여한 방을 돌려줌, 이미 멤버여도 성공
+func joinRoomByInviteHandler(w http.ResponseWriter, r *http.Request) {
+	nick := r.FormValue("nick")
+	if nick == "" { respondError(w, r, http.StatusBadRequest, errors.New("nick required")); return }
+	v, err := parseToken(r.PathValue("token"), signRoomInvite)
+	if err != nil { respondError(w, r, http.StatusNotFound, errRoomInviteInvalid); return }
+	inviteID, err := strconv.ParseInt(v, 10, 64)
+	if err != nil { respondError(w, r, http.StatusNotFound, errRoomInviteInvalid); return }
+
+	tx, err := db.Begin()
+	if err != nil { respondError(w, r, 500, err); return }
+	defer tx.Rollback()
+
+	var room Room
+	err = tx.QueryRow(`SELECT r.id, r.name, r.topic, r.description, r.is_public FROM room_invites i JOIN rooms r ON r.id = i.room_id
+		WHERE i.id = $1 AND i.revoked_at IS NULL`, inviteID).Scan(&room.ID, &room.Name, &room.Topic, &room.Description, &room.IsPublic)
+	if err == sql.ErrNoRows { respondError(w, r, http.StatusNotFound, errRoomInviteInvalid); return }
+	if err != nil { respondError(w, r, 500, err); return }
+	res, err := tx.Exec("INSERT INTO room_members (room_id, nickname, role) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING", room.ID, nick, roomRoleMember)
+	if err != nil { respondError(w, r, 500, err); return }
+	joined, _ := res.RowsAffected()
+	if joined > 0 {
+		if _, err := tx.Exec("UPDATE room_invites SET uses = uses + 1 WHERE id = $1", inviteID); err != nil { respondError(w, r, 500, err); return }
+	}
+	if err := tx.Commit(); err != nil { respondError(w, r, 500, err); return }
+
+	if joined > 0 {
+		if !room.IsPublic { roomMembersChanged(r.Context(), room.ID) }
+		recordEvent(r.Context(), &room.ID, RoomEvent{Kind: eventJoin, Actor: nick}, nick+" joined the room.")
+		slog.InfoContext(r.Context(), "room joined by invite", "room_id", room.ID, "nick", nick, "invite_id", inviteID)
+	}
+	writeJSON(w, http.StatusOK, room)
+}
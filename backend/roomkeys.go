@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// [방 읽기 키] 로비 화면/대시보드가 로그인 없이 방 하나의 기록과 스트림만 보도록 하는 키
+// Authorization: Bearer gtk_... 또는 ?api_key= (EventSource는 헤더를 못 붙임)로 보내고,
+// 인증 미들웨어가 키를 먼저 보고 GET /history, GET /stream만 그 방으로 고정해 통과시킴 (글쓰기/다른 방 불가)
+// 스트림은 그 방의 공개 메시지만 내보내고, 하트비트마다 키를 다시 확인해 폐기되면 끊음
+const (
+	roomKeyPrefix  = "gtk_"
+	roomKeyMaxName = 64
+)
+
+type RoomAPIKey struct {
+	Key        string     `json:"key"`
+	RoomID     int        `json:"room_id"`
+	Name       string     `json:"name"`
+	CreatedBy  string     `json:"created_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+var (
+	errRoomKeyInvalid = errors.New("invalid or revoked api key")
+	errRoomKeyScope   = errors.New("api key only allows GET /history and GET /stream of its room")
+)
+
+// 키로 열 수 있는 경로
+var roomKeyRoutes = map[string]bool{"/history": true, "/stream": true}
+
+// 요청에 실린 방 읽기 키 (없으면 "")
+func roomKeyFromRequest(r *http.Request) string {
+	if k, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "+roomKeyPrefix); ok { return roomKeyPrefix + k }
+	return r.URL.Query().Get("api_key")
+}
+
+func lookupRoomKey(ctx context.Context, key string) (RoomAPIKey, error) {
+	k := RoomAPIKey{Key: key}
+	if !fullFeatureStore() || !strings.HasPrefix(key, roomKeyPrefix) { return k, errRoomKeyInvalid }
+	err := db.QueryRowContext(ctx, "SELECT room_id, name FROM room_api_keys WHERE key = $1 AND revoked_at IS NULL", key).Scan(&k.RoomID, &k.Name)
+	if err == sql.ErrNoRows { return k, errRoomKeyInvalid }
+	return k, err
+}
+
+// 키가 있으면 범위를 확인하고 요청을 그 방 읽기로 고정, 없으면 fallback(일반 인증)으로
+func roomKeyMiddleware(next, fallback http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := roomKeyFromRequest(r)
+		if key == "" { fallback.ServeHTTP(w, r); return }
+		k, err := lookupRoomKey(r.Context(), key)
+		if err == errRoomKeyInvalid { respondError(w, r, http.StatusUnauthorized, err); return }
+		if err != nil { respondError(w, r, 500, err); return }
+		if r.Method != http.MethodGet || !roomKeyRoutes[r.URL.Path] { respondError(w, r, http.StatusForbidden, errRoomKeyScope); return }
+
+		q := r.URL.Query()
+		if v := q.Get("room_id"); v != "" && v != strconv.Itoa(k.RoomID) { respondError(w, r, http.StatusForbidden, errRoomKeyScope); return }
+		q.Set("room_id", strconv.Itoa(k.RoomID))
+		q.Del("api_key")
+		q.Del("nick")
+		r.URL.RawQuery = q.Encode()
+		db.Exec("UPDATE room_api_keys SET last_used_at = CURRENT_TIMESTAMP WHERE key = $1", key)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), roomKeyKey, k)))
+	})
+}
+
+// 방 읽기 키로 들어온 요청이면 그 키
+func roomKeyFrom(ctx context.Context) (RoomAPIKey, bool) {
+	k, ok := ctx.Value(roomKeyKey).(RoomAPIKey)
+	return k, ok
+}
+
+// 스트림 도중 키가 폐기됐는지
+func roomKeyRevoked(ctx context.Context, key string) bool {
+	_, err := lookupRoomKey(ctx, key)
+	return err == errRoomKeyInvalid
+}
+
+// [방 읽기 키 발급] POST /rooms/{id}/keys (nick, name) - 방장/운영진
+func createRoomKeyHandler(w http.ResponseWriter, r *http.Request) {
+	roomID, ok := roomIDFrom(r)
+	nick := r.FormValue("nick")
+	if !ok || nick == "" { respondError(w, r, http.StatusBadRequest, errors.New("room id and nick required")); return }
+	if !canManageEmbeds(roomID, nick) { respondError(w, r, http.StatusForbidden, errors.New("only owners and moderators can manage api keys")); return }
+	k := RoomAPIKey{Key: roomKeyPrefix + newEmbedToken(), RoomID: roomID, Name: strings.TrimSpace(r.FormValue("name")), CreatedBy: nick}
+	if k.Name == "" || utf8.RuneCountInString(k.Name) > roomKeyMaxName { respondError(w, r, http.StatusBadRequest, fieldError{"name", "required, up to 64 characters"}); return }
+
+	err := db.QueryRow("INSERT INTO room_api_keys (key, room_id, name, created_by) VALUES ($1, $2, $3, $4) RETURNING created_at",
+		k.Key, roomID, k.Name, nick).Scan(&k.CreatedAt)
+	if err != nil { respondError(w, r, 500, err); return }
+	slog.InfoContext(r.Context(), "room api key created", "room_id", roomID, "nick", nick, "name", k.Name)
+	writeJSON(w, http.StatusCreated, k)
+}
+
+// [방 읽기 키 목록] GET /rooms/{id}/keys?nick=
+func listRoomKeysHandler(w http.ResponseWriter, r *http.Request) {
+	roomID, ok := roomIDFrom(r)
+	nick := r.URL.Query().Get("nick")
+	if !ok || nick == "" { respondError(w, r, http.StatusBadRequest, errors.New("room id and nick required")); return }
+	if !canManageEmbeds(roomID, nick) { respondError(w, r, http.StatusForbidden, errors.New("only owners and moderators can manage api keys")); return }
+
+	rows, err := db.Query(`SELECT key, room_id, name, created_by, created_at, last_used_at FROM room_api_keys
+		WHERE room_id = $1 AND revoked_at IS NULL ORDER BY created_at`, roomID)
+	if err != nil { respondError(w, r, 500, err); return }
+	defer rows.Close()
+	list := []RoomAPIKey{}
+	for rows.Next() {
+		var k RoomAPIKey
+		rows.Scan(&k.Key, &k.RoomID, &k.Name, &k.CreatedBy, &k.CreatedAt, &k.LastUsedAt)
+		list = append(list, k)
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+// [방 읽기 키 폐기] DELETE /rooms/{id}/keys/{key}?nick= - 열려 있는 스트림도 다음 하트비트에 끊김
+func revokeRoomKeyHandler(w http.ResponseWriter, r *http.Request) {
+	roomID, ok := roomIDFrom(r)
+	nick := r.URL.Query().Get("nick")
+	if !ok || nick == "" { respondError(w, r, http.StatusBadRequest, errors.New("room id and nick required")); return }
+	if !canManageEmbeds(roomID, nick) { respondError(w, r, http.StatusForbidden, errors.New("only owners and moderators can manage api keys")); return }
+	res, err := db.Exec("UPDATE room_api_keys SET revoked_at = CURRENT_TIMESTAMP WHERE key = $1 AND room_id = $2 AND revoked_at IS NULL",
+		r.PathValue("key"), roomID)
+	if err != nil { respondError(w, r, 500, err); return }
+	if n, _ := res.RowsAffected(); n == 0 { respondError(w, r, http.StatusNotFound, errors.New("api key not found")); return }
+	slog.InfoContext(r.Context(), "room api key revoked", "room_id", roomID, "nick", nick)
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"time"
+)
+
+// [방 목록] 채널 사이드바용: 공개 방 전체와 내가 들어간 비공개 방, 최근 활동 순
+// 안 읽은 수는 room_members.last_read_id 이후 다른 사람이 쓴 글 (POST /rooms/{id}/read로 옮김)
+// 방마다 세므로 roomUnreadCap에서 멈춤 (클라이언트는 "99+"로 표시), 배지만 필요하면 GET /unread
+const roomUnreadCap = 100
+
+// 안 읽은 수 하위 쿼리 (방 목록과 /unread 공용): me는 room_members 행, $1 닉네임, $2 일반 글 타입, $3 상한
+// 읽음 기준을 바꿀 때는 여기만 고침
+const unreadCountSQL = `(SELECT COUNT(*) FROM (
+	SELECT 1 FROM messages x
+	WHERE x.room_id = me.room_id AND x.type = $2 AND x.sender_nick <> $1
+	  AND x.id > COALESCE(me.last_read_id, 0) AND x.created_at >= me.joined_at
+	LIMIT $3) u)`
+
+type RoomListing struct {
+	Room
+	MemberCount    int        `json:"member_count"`
+	LastActivityAt *time.Time `json:"last_activity_at"` // 마지막 글 시각 (글이 없으면 null)
+	Joined         bool       `json:"joined"`
+	Unread         int        `json:"unread"` // 멤버가 아니면 0
+}
+
+// [방 목록] GET /rooms?nick=
+func listRoomsHandler(w http.ResponseWriter, r *http.Request) {
+	if !fullFeatureStore() { respondError(w, r, http.StatusNotImplemented, errors.New("rooms require the postgres store")); return }
+	nick, ok := identityFrom(r.Context())
+	if !ok { nick = r.URL.Query().Get("nick") }
+
+	rows, err := db.QueryContext(r.Context(), `
+		SELECT r.id, r.name, r.topic, r.description, r.is_public, r.announcement, COALESCE(r.owner_nick, ''), r.created_at,
+			(SELECT COUNT(*) FROM room_members m WHERE m.room_id = r.id),
+			(SELECT x.created_at FROM messages x WHERE x.room_id = r.id ORDER BY x.id DESC LIMIT 1) AS last_activity,
+			me.room_id IS NOT NULL,
+			CASE WHEN me.room_id IS NULL THEN 0 ELSE `+unreadCountSQL+` END
+		FROM rooms r
+		LEFT JOIN room_members me ON me.room_id = r.id AND me.nickname = $1
+		WHERE r.is_public OR me.room_id IS NOT NULL
+		ORDER BY last_activity DESC NULLS LAST, r.id`, nick, messageTypeText, roomUnreadCap)
+	if err != nil { respondError(w, r, 500, err); return }
+	defer rows.Close()
+	list := []RoomListing{}
+	for rows.Next() {
+		var l RoomListing
+		rows.Scan(&l.ID, &l.Name, &l.Topic, &l.Description, &l.IsPublic, &l.Announcement, &l.OwnerNick, &l.CreatedAt,
+			&l.MemberCount, &l.LastActivityAt, &l.Joined, &l.Unread)
+		list = append(list, l)
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+type RoomUnread struct {
+	RoomID     int  `json:"room_id"`
+	Unread     int  `json:"unread"`
+	LastReadID *int `json:"last_read_id"` // 한 번도 읽음 표시를 안 했으면 null
+}
+
+// [안 읽은 수] GET /unread?nick= - 내가 들어간 방만, 배지용이라 방 정보나 글 내용 없이 수만 (방 목록과 같은 기준과 상한)
+func unreadHandler(w http.ResponseWriter, r *http.Request) {
+	if !fullFeatureStore() { respondError(w, r, http.StatusNotImplemented, errors.New("rooms require the postgres store")); return }
+	nick, ok := identityFrom(r.Context())
+	if !ok { nick = r.URL.Query().Get("nick") }
+	if nick == "" { respondError(w, r, http.StatusBadRequest, errors.New("nick required")); return }
+
+	rows, err := db.QueryContext(r.Context(), `
+		SELECT me.room_id, me.last_read_id, `+unreadCountSQL+`
+		FROM room_members me WHERE me.nickname = $1
+		ORDER BY me.room_id`, nick, messageTypeText, roomUnreadCap)
+	if err != nil { respondError(w, r, 500, err); return }
+	defer rows.Close()
+	list, total := []RoomUnread{}, 0
+	for rows.Next() {
+		var u RoomUnread
+		if err := rows.Scan(&u.RoomID, &u.LastReadID, &u.Unread); err != nil { respondError(w, r, 500, err); return }
+		total += u.Unread
+		list = append(list, u)
+	}
+	if err := rows.Err(); err != nil { respondError(w, r, 500, err); return }
+	writeJSON(w, http.StatusOK, map[string]any{"rooms": list, "total": total})
+}
+
+// [읽음 표시] POST /rooms/{id}/read (nick, message_id?) - message_id가 없으면 최신 글까지, 뒤로는 안 감
+func markRoomReadHandler(w http.ResponseWriter, r *http.Request) {
+	roomID, ok := roomIDFrom(r)
+	nick := r.FormValue("nick")
+	if !ok || nick == "" { respondError(w, r, http.StatusBadRequest, errors.New("room id and nick required")); return }
+	msgID, err := formInt(r, "message_id")
+	if err != nil { respondError(w, r, http.StatusBadRequest, err); return }
+
+	res, err := db.ExecContext(r.Context(), `
+		UPDATE room_members SET last_read_id = GREATEST(COALESCE(last_read_id, 0),
+			COALESCE($3, (SELECT MAX(id) FROM messages WHERE room_id = $1), 0))
+		WHERE room_id = $1 AND nickname = $2`, roomID, nick, msgID)
+	if err != nil { respondError(w, r, 500, err); return }
+	if n, _ := res.RowsAffected(); n == 0 { respondError(w, r, http.StatusNotFound, errors.New("not a room member")); return }
+	w.WriteHeader(http.StatusNoContent)
+}
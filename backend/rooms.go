@@ -0,0 +1,333 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// 방 내 역할
+const (
+	roomRoleOwner     = "owner"
+	roomRoleModerator = "moderator"
+	roomRoleMember    = "member"
+)
+
+// 방 이름/주제/설명 최대 글자 수 (PATCH /rooms/{id})
+const (
+	roomMaxName        = 64
+	roomMaxTopic       = 250
+	roomMaxDescription = 2000
+)
+
+type Room struct {
+	ID           int        `json:"id"`
+	Name         string     `json:"name"`
+	Topic        string     `json:"topic,omitempty"`
+	Description  string     `json:"description,omitempty"`
+	IsPublic     bool       `json:"is_public"`
+	Announcement bool       `json:"announcement,omitempty"` // 방 소유자/운영진만 글쓰기
+	OwnerNick    string     `json:"owner_nick,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	OrphanedAt   *time.Time `json:"orphaned_at,omitempty"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// 정수 쿼리 파라미터 (없거나 잘못되면 def)
+func queryInt(r *http.Request, key string, def int) int {
+	v, err := strconv.Atoi(r.URL.Query().Get(key))
+	if err != nil { return def }
+	return v
+}
+
+func roomIDFrom(r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	return id, err == nil
+}
+
+// 방 안에서의 역할 조회 (멤버가 아니면 빈 문자열)
+func roomRole(roomID int, nick string) string {
+	var role string
+	db.QueryRow("SELECT role FROM room_members WHERE room_id = $1 AND nickname = $2", roomID, nick).Scan(&role)
+	return role
+}
+
+// [방 생성] POST /rooms (nick, name, topic?, description?, public?, announcement?) - 만든 사람이 소유자, 공지 방은 rooms.create_announcement 권한
+func createRoomHandler(w http.ResponseWriter, r *http.Request) {
+	nick := r.FormValue("nick")
+	name := r.FormValue("name")
+	if nick == "" || name == "" { respondError(w, r, http.StatusBadRequest, errors.New("nick and name required")); return }
+	isPublic := r.FormValue("public") != "false"
+	announcement := r.FormValue("announcement") == "true"
+	if announcement && !can(r, permCreateAnnouncementRooms) { respondError(w, r, http.StatusForbidden, errPermissionDenied); return }
+
+	tx, err := db.Begin()
+	if err != nil { respondError(w, r, 500, err); return }
+	defer tx.Rollback()
+
+	room := Room{Name: name, Topic: r.FormValue("topic"), Description: r.FormValue("description"), IsPublic: isPublic, OwnerNick: nick, Announcement: announcement}
+	err = tx.QueryRow(`INSERT INTO rooms (name, topic, description, is_public, owner_nick, announcement) VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (name) DO NOTHING RETURNING id, created_at`,
+		name, room.Topic, room.Description, isPublic, nick, announcement).Scan(&room.ID, &room.CreatedAt)
+	if err == sql.ErrNoRows { respondError(w, r, http.StatusConflict, errors.New("room name already taken")); return }
+	if err != nil { respondError(w, r, 500, err); return }
+	if _, err := tx.Exec("INSERT INTO room_members (room_id, nickname, role) VALUES ($1, $2, $3)", room.ID, nick, roomRoleOwner); err != nil {
+		respondError(w, r, 500, err)
+		return
+	}
+	if err := tx.Commit(); err != nil { respondError(w, r, 500, err); return }
+	if !isPublic { roomMembersChanged(r.Context(), room.ID) }
+	writeJSON(w, http.StatusCreated, room)
+}
+
+// [방 참여] POST /rooms/{id}/join (nick) - 비공개 방은 초대 링크로만 (roominvites.go)
+func joinRoomHandler(w http.ResponseWriter, r *http.Request) {
+	roomID, ok := roomIDFrom(r)
+	nick := r.FormValue("nick")
+	if !ok || nick == "" { respondError(w, r, http.StatusBadRequest, errors.New("room id and nick required")); return }
+
+	res, err := db.Exec(`
+		INSERT INTO room_members (room_id, nickname, role)
+		SELECT id, $2, $3 FROM rooms WHERE id = $1 AND is_public
+		ON CONFLICT DO NOTHING`, roomID, nick, roomRoleMember)
+	if err != nil { respondError(w, r, 500, err); return }
+	n, _ := res.RowsAffected()
+	if n == 0 && roomRole(roomID, nick) == "" {
+		if checkRoomAccess(r.Context(), roomID, nick) == errNotRoomMember {
+			respondError(w, r, http.StatusForbidden, errors.New("private rooms can only be joined with an invite"))
+			return
+		}
+		respondError(w, r, http.StatusNotFound, errors.New("room not found"))
+		return
+	}
+	if n > 0 { recordEvent(r.Context(), &roomID, RoomEvent{Kind: eventJoin, Actor: nick}, nick+" joined the room.") }
+	w.WriteHeader(http.StatusOK)
+}
+
+// [방 나가기] POST /rooms/{id}/leave (nick) - 소유자는 먼저 소유권을 넘겨야 함
+func leaveRoomHandler(w http.ResponseWriter, r *http.Request) {
+	roomID, ok := roomIDFrom(r)
+	nick := r.FormValue("nick")
+	if !ok || nick == "" { respondError(w, r, http.StatusBadRequest, errors.New("room id and nick required")); return }
+	if roomRole(roomID, nick) == roomRoleOwner { respondError(w, r, http.StatusConflict, errors.New("transfer ownership before leaving")); return }
+
+	res, err := db.Exec("DELETE FROM room_members WHERE room_id = $1 AND nickname = $2", roomID, nick)
+	if err != nil { respondError(w, r, 500, err); return }
+	if n, _ := res.RowsAffected(); n == 0 { respondError(w, r, http.StatusNotFound, errors.New("not a room member")); return }
+	roomMembersChanged(r.Context(), roomID)
+	recordEvent(r.Context(), &roomID, RoomEvent{Kind: eventLeave, Actor: nick}, nick+" left the room.")
+	w.WriteHeader(http.StatusOK)
+}
+
+// [주제 변경] POST /rooms/{id}/topic (nick, topic) - 소유자/모더레이터만
+func setRoomTopicHandler(w http.ResponseWriter, r *http.Request) {
+	roomID, ok := roomIDFrom(r)
+	nick, topic := r.FormValue("nick"), r.FormValue("topic")
+	if !ok || nick == "" { respondError(w, r, http.StatusBadRequest, errors.New("room id and nick required")); return }
+	if role := roomRole(roomID, nick); role != roomRoleOwner && role != roomRoleModerator {
+		respondError(w, r, http.StatusForbidden, errors.New("only owners and moderators can change the topic"))
+		return
+	}
+
+	var old string
+	err := db.QueryRow(`UPDATE rooms r SET topic = $2 FROM rooms prev
+		WHERE r.id = $1 AND prev.id = r.id RETURNING prev.topic`, roomID, topic).Scan(&old)
+	if err == sql.ErrNoRows { respondError(w, r, http.StatusNotFound, errors.New("room not found")); return }
+	if err != nil { respondError(w, r, 500, err); return }
+	if old != topic {
+		ev := RoomEvent{Kind: eventTopic, Actor: nick, Data: map[string]any{"old": old, "new": topic}}
+		recordEvent(r.Context(), &roomID, ev, nick+" changed the topic to: "+topic)
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// [방 정보 수정] PATCH /rooms/{id} (nick, name?, topic?, description?) - 보낸 필드만 바꿈
+// 주제/설명은 소유자/모더레이터, 이름은 소유자만, 바뀐 항목마다 시스템 이벤트로 타임라인에 남기고 방송
+func updateRoomHandler(w http.ResponseWriter, r *http.Request) {
+	roomID, ok := roomIDFrom(r)
+	if !ok { respondError(w, r, http.StatusBadRequest, errors.New("invalid room id")); return }
+	var req UpdateRoomRequest
+	if err := decodeRequest(r, &req); err != nil { respondError(w, r, decodeStatus(err), err); return }
+	role := roomRole(roomID, req.Nick)
+	if role != roomRoleOwner && role != roomRoleModerator { respondError(w, r, http.StatusForbidden, errors.New("only owners and moderators can edit the room")); return }
+	if req.Name != nil && role != roomRoleOwner { respondError(w, r, http.StatusForbidden, errors.New("only the owner can rename the room")); return }
+
+	tx, err := db.Begin()
+	if err != nil { respondError(w, r, 500, err); return }
+	defer tx.Rollback()
+	var old Room
+	err = tx.QueryRow(`SELECT id, name, topic, description, is_public, announcement, COALESCE(owner_nick, ''), created_at FROM rooms WHERE id = $1 FOR UPDATE`, roomID).
+		Scan(&old.ID, &old.Name, &old.Topic, &old.Description, &old.IsPublic, &old.Announcement, &old.OwnerNick, &old.CreatedAt)
+	if err == sql.ErrNoRows { respondError(w, r, http.StatusNotFound, errors.New("room not found")); return }
+	if err != nil { respondError(w, r, 500, err); return }
+	room := old
+	if req.Name != nil { room.Name = *req.Name }
+	if req.Topic != nil { room.Topic = *req.Topic }
+	if req.Description != nil { room.Description = *req.Description }
+	if room.Name != old.Name {
+		var taken bool
+		tx.QueryRow("SELECT EXISTS(SELECT 1 FROM rooms WHERE name = $1 AND id <> $2)", room.Name, roomID).Scan(&taken)
+		if taken { respondError(w, r, http.StatusConflict, errors.New("room name already taken")); return }
+	}
+	if _, err := tx.Exec("UPDATE rooms SET name = $2, topic = $3, description = $4 WHERE id = $1", roomID, room.Name, room.Topic, room.Description); err != nil {
+		respondError(w, r, 500, err)
+		return
+	}
+	if err := tx.Commit(); err != nil { respondError(w, r, 500, err); return }
+
+	ctx := r.Context()
+	if room.Name != old.Name {
+		ev := RoomEvent{Kind: eventRename, Actor: req.Nick, Data: map[string]any{"old": old.Name, "new": room.Name}}
+		recordEvent(ctx, &roomID, ev, req.Nick+" renamed the room to: "+room.Name)
+	}
+	if room.Topic != old.Topic {
+		ev := RoomEvent{Kind: eventTopic, Actor: req.Nick, Data: map[string]any{"old": old.Topic, "new": room.Topic}}
+		recordEvent(ctx, &roomID, ev, req.Nick+" changed the topic to: "+room.Topic)
+	}
+	if room.Description != old.Description {
+		recordEvent(ctx, &roomID, RoomEvent{Kind: eventDescription, Actor: req.Nick}, req.Nick+" updated the room description.")
+	}
+	slog.InfoContext(ctx, "room updated", "room_id", roomID, "nick", req.Nick)
+	writeJSON(w, http.StatusOK, room)
+}
+
+// [모더레이터 지정] POST /rooms/{id}/moderators (nick=소유자, target)
+func promoteModeratorHandler(w http.ResponseWriter, r *http.Request) {
+	roomID, ok := roomIDFrom(r)
+	nick, target := r.FormValue("nick"), r.FormValue("target")
+	if !ok || nick == "" || target == "" { respondError(w, r, http.StatusBadRequest, errors.New("room id, nick and target required")); return }
+	if roomRole(roomID, nick) != roomRoleOwner { respondError(w, r, http.StatusForbidden, errors.New("only the owner can promote moderators")); return }
+
+	res, err := db.Exec("UPDATE room_members SET role = $3 WHERE room_id = $1 AND nickname = $2 AND role = $4",
+		roomID, target, roomRoleModerator, roomRoleMember)
+	if err != nil { respondError(w, r, 500, err); return }
+	if n, _ := res.RowsAffected(); n == 0 { respondError(w, r, http.StatusNotFound, errors.New("target is not a member")); return }
+	w.WriteHeader(http.StatusOK)
+}
+
+// 소유권 이전 (기존 소유자는 모더레이터로 강등, 대상은 반드시 방 멤버)
+func setRoomOwner(tx *sql.Tx, roomID int, newOwner string) error {
+	if _, err := tx.Exec("UPDATE room_members SET role = $2 WHERE room_id = $1 AND role = $3",
+		roomID, roomRoleModerator, roomRoleOwner); err != nil {
+		return err
+	}
+	res, err := tx.Exec("UPDATE room_members SET role = $3 WHERE room_id = $1 AND nickname = $2",
+		roomID, newOwner, roomRoleOwner)
+	if err != nil { return err }
+	if n, _ := res.RowsAffected(); n == 0 { return sql.ErrNoRows }
+	_, err = tx.Exec("UPDATE rooms SET owner_nick = $2, orphaned_at = NULL WHERE id = $1", roomID, newOwner)
+	return err
+}
+
+// [소유권 이전] POST /rooms/{id}/transfer-ownership (nick=현재 소유자, to)
+func transferOwnershipHandler(w http.ResponseWriter, r *http.Request) {
+	roomID, ok := roomIDFrom(r)
+	nick, to := r.FormValue("nick"), r.FormValue("to")
+	if !ok || nick == "" || to == "" { respondError(w, r, http.StatusBadRequest, errors.New("room id, nick and to required")); return }
+	if roomRole(roomID, nick) != roomRoleOwner { respondError(w, r, http.StatusForbidden, errors.New("only the owner can transfer ownership")); return }
+	if isUserDeleted(to) { respondError(w, r, http.StatusConflict, errors.New("target account deleted")); return }
+
+	tx, err := db.Begin()
+	if err != nil { respondError(w, r, 500, err); return }
+	defer tx.Rollback()
+	err = setRoomOwner(tx, roomID, to)
+	if err == sql.ErrNoRows { respondError(w, r, http.StatusNotFound, errors.New("target is not a member")); return }
+	if err != nil { respondError(w, r, 500, err); return }
+	if err := tx.Commit(); err != nil { respondError(w, r, 500, err); return }
+
+	slog.InfoContext(r.Context(), "room ownership transferred", "room_id", roomID, "from", nick, "to", to)
+	w.WriteHeader(http.StatusOK)
+}
+
+// [고아 방 처리] 탈퇴한 사용자가 소유한 방마다 가장 오래된 모더레이터를 소유자로 승격
+// 모더레이터가 없으면 소유자를 비우고 관리자 대기열(orphaned_at)에 올림
+func reassignOwnedRooms(nick string) {
+	rows, err := db.Query("SELECT id FROM rooms WHERE owner_nick = $1", nick)
+	if err != nil { slog.Warn("room ownership reassignment failed", "nick", nick, "err", err); return }
+	var roomIDs []int
+	for rows.Next() {
+		var id int
+		rows.Scan(&id)
+		roomIDs = append(roomIDs, id)
+	}
+	rows.Close()
+
+	for _, roomID := range roomIDs {
+		tx, err := db.Begin()
+		if err != nil { slog.Warn("room ownership reassignment failed", "nick", nick, "err", err); return }
+
+		var successor string
+		err = tx.QueryRow(`
+			SELECT rm.nickname FROM room_members rm
+			JOIN users u ON u.nickname = rm.nickname AND u.deleted_at IS NULL
+			WHERE rm.room_id = $1 AND rm.role = $2
+			ORDER BY rm.joined_at ASC LIMIT 1`, roomID, roomRoleModerator).Scan(&successor)
+		switch {
+		case err == nil:
+			err = setRoomOwner(tx, roomID, successor)
+			if err == nil {
+				// 탈퇴한 전 소유자는 일반 멤버로
+				_, err = tx.Exec("UPDATE room_members SET role = $3 WHERE room_id = $1 AND nickname = $2", roomID, nick, roomRoleMember)
+			}
+			slog.Info("room owner deleted, promoted moderator", "room_id", roomID, "nick", nick, "successor", successor)
+		case err == sql.ErrNoRows:
+			_, err = tx.Exec("UPDATE rooms SET owner_nick = NULL, orphaned_at = CURRENT_TIMESTAMP WHERE id = $1", roomID)
+			if err == nil {
+				_, err = tx.Exec("UPDATE room_members SET role = $3 WHERE room_id = $1 AND nickname = $2", roomID, nick, roomRoleMember)
+			}
+			slog.Warn("room orphaned, no moderators left", "room_id", roomID, "nick", nick)
+		}
+		if err != nil {
+			tx.Rollback()
+			slog.Warn("room ownership reassignment failed", "nick", nick, "err", err)
+			continue
+		}
+		tx.Commit()
+	}
+}
+
+// [관리자] GET /admin/rooms/orphaned - 소유자가 없는 방 목록 (오래된 순)
+func orphanedRoomsHandler(w http.ResponseWriter, r *http.Request) {
+	rows, err := db.Query("SELECT id, name, created_at, orphaned_at FROM rooms WHERE owner_nick IS NULL ORDER BY orphaned_at ASC")
+	if err != nil { respondError(w, r, 500, err); return }
+	defer rows.Close()
+
+	rooms := []Room{}
+	for rows.Next() {
+		var room Room
+		rows.Scan(&room.ID, &room.Name, &room.CreatedAt, &room.OrphanedAt)
+		rooms = append(rooms, room)
+	}
+	writeJSON(w, http.StatusOK, rooms)
+}
+
+// [관리자] POST /admin/rooms/{id}/owner (to) - 고아 방에 새 소유자 지정 (멤버가 아니면 추가)
+func assignRoomOwnerHandler(w http.ResponseWriter, r *http.Request) {
+	roomID, ok := roomIDFrom(r)
+	to := r.FormValue("to")
+	if !ok || to == "" { respondError(w, r, http.StatusBadRequest, errors.New("room id and to required")); return }
+
+	tx, err := db.Begin()
+	if err != nil { respondError(w, r, 500, err); return }
+	defer tx.Rollback()
+	if _, err := tx.Exec(`
+		INSERT INTO room_members (room_id, nickname, role) SELECT id, $2, $3 FROM rooms WHERE id = $1
+		ON CONFLICT DO NOTHING`, roomID, to, roomRoleMember); err != nil {
+		respondError(w, r, 500, err)
+		return
+	}
+	err = setRoomOwner(tx, roomID, to)
+	if err == sql.ErrNoRows { respondError(w, r, http.StatusNotFound, errors.New("room not found")); return }
+	if err != nil { respondError(w, r, 500, err); return }
+	if err := tx.Commit(); err != nil { respondError(w, r, 500, err); return }
+	w.WriteHeader(http.StatusOK)
+}
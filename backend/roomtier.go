@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// [방 단위 지표 라벨] 방마다 라벨을 달면 시계열이 방 수만큼 늘어나므로 등급으로 나눔
+//   최근 24시간 가장 활발한 metrics.room_top_n개 방은 방 ID, 나머지는 other, 로비는 lobby, DM은 dm
+// 전달/전송/오류 지표가 모두 roomLabel 하나로 라벨을 정해 같은 방이 지표마다 다른 이름으로 나오지 않음
+// 목록은 파드마다 metrics.room_tier_refresh 주기로 다시 읽음 (순위가 바뀌면 빠진 방의 시계열은 더 늘지 않음)
+const (
+	roomLabelLobby = "lobby"
+	roomLabelDM    = "dm"
+	roomLabelOther = "other"
+)
+
+var (
+	topRooms atomic.Pointer[map[int]bool]
+
+	sendErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "gotalk_send_errors_total",
+		Help: "Rejected or failed sends by room tier and error kind.",
+	}, []string{"room", "kind"})
+)
+
+func initRoomTiers() {
+	topRooms.Store(&map[int]bool{})
+	if !fullFeatureStore() || cfg.Metrics.RoomTopN == 0 { return }
+	refreshRoomTiers()
+	go func() {
+		for range time.Tick(time.Duration(cfg.Metrics.RoomTierRefresh)) {
+			refreshRoomTiers()
+		}
+	}()
+}
+
+func refreshRoomTiers() {
+	rows, err := db.QueryContext(context.Background(), `
+		SELECT dimension::int FROM analytics_hourly
+		WHERE metric = 'messages_by_room' AND hour >= CURRENT_TIMESTAMP - INTERVAL '24 hours'
+		GROUP BY dimension ORDER BY SUM(value) DESC LIMIT $1`, cfg.Metrics.RoomTopN)
+	if err != nil { slog.Warn("room tier refresh failed", "err", err); return }
+	defer rows.Close()
+	top := map[int]bool{}
+	for rows.Next() {
+		var id int
+		rows.Scan(&id)
+		top[id] = true
+	}
+	topRooms.Store(&top)
+}
+
+// 지표 라벨: 상위 방이면 방 ID, 아니면 등급 이름
+func roomLabel(roomID *int, direct bool) string {
+	switch {
+	case direct:
+		return roomLabelDM
+	case roomID == nil:
+		return roomLabelLobby
+	}
+	if top := topRooms.Load(); top != nil && (*top)[*roomID] { return strconv.Itoa(*roomID) }
+	return roomLabelOther
+}
+
+// 전송 거부/실패 (kind: rate_limited, forbidden, locked, store, publish)
+func countSendError(roomID *int, kind string) {
+	sendErrors.WithLabelValues(roomLabel(roomID, false), kind).Inc()
+}
+
+// 방송 페이로드의 방 라벨
+func streamRoomLabel(data string) string {
+	var m struct {
+		RoomID        *int   `json:"room_id"`
+		RecipientNick string `json:"recipient_nick"`
+	}
+	json.Unmarshal([]byte(data), &m)
+	return roomLabel(m.RoomID, m.RecipientNick != "")
+}
@@ -0,0 +1,28 @@
+package main
+
+import "testing"
+
+func TestRoomLabel(t *testing.T) {
+	top := map[int]bool{7: true}
+	topRooms.Store(&top)
+	t.Cleanup(func() { topRooms.Store(nil) })
+
+	room := func(id int) *int { return &id }
+	tests := []struct {
+		name   string
+		roomID *int
+		direct bool
+		want   string
+	}{
+		{"lobby", nil, false, roomLabelLobby},
+		{"dm", nil, true, roomLabelDM},
+		{"dm wins over room", room(7), true, roomLabelDM},
+		{"top room", room(7), false, "7"},
+		{"other room", room(8), false, roomLabelOther},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := roomLabel(tt.roomID, tt.direct); got != tt.want { t.Errorf("roomLabel() = %q, want %q", got, tt.want) }
+		})
+	}
+}
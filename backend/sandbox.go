@@ -0,0 +1,130 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// [연동 테스트 콘솔] 통합 작성자가 실제 대화를 만들지 않고 디버깅하도록
+// - POST /admin/webhooks/{id}/test: 가짜 메시지로 webhook.test 이벤트를 진짜 서명해 보내고, 보낸 요청과 받은 응답을 그대로 돌려줌
+//   재시도/DLQ를 거치지 않고 웹훅의 last_delivered_at/last_error와 전송 지표도 건드리지 않음
+// - POST /admin/bots/{id}/simulate ({id}는 수신 웹훅 토큰): 수신 웹훅(봇)에 보낼 본문을 검사/렌더링만 해서 올라갈 메시지를 보여 줌 (저장/방송/한도 없음)
+const (
+	webhookTestEvent   = "webhook.test"
+	sandboxMaxResponse = 16 << 10 // 돌려줄 응답 본문 최대 크기
+)
+
+type sandboxRequest struct {
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers"`
+	Body    json.RawMessage   `json:"body"`
+}
+
+type sandboxResponse struct {
+	Status    int               `json:"status"`
+	Headers   map[string]string `json:"headers"`
+	Body      string            `json:"body"`
+	Truncated bool              `json:"truncated,omitempty"`
+}
+
+type webhookTestResult struct {
+	OK         bool             `json:"ok"`
+	Error      string           `json:"error,omitempty"`
+	DurationMS int64            `json:"duration_ms"`
+	Request    sandboxRequest   `json:"request"`
+	Response   *sandboxResponse `json:"response,omitempty"` // 연결 자체가 실패하면 없음
+}
+
+func flatHeaders(h http.Header) map[string]string {
+	out := make(map[string]string, len(h))
+	for k, v := range h {
+		out[k] = strings.Join(v, ", ")
+	}
+	return out
+}
+
+// [웹훅 테스트] POST /admin/webhooks/{id}/test (content?) - 웹훅이 2xx가 아니어도 200으로 결과를 돌려줌
+func adminTestWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	var h Webhook
+	err := db.QueryRowContext(r.Context(), "SELECT id, url, secret, room_id FROM webhooks WHERE id = $1", r.PathValue("id")).Scan(&h.ID, &h.URL, &h.Secret, &h.RoomID)
+	if err == sql.ErrNoRows { respondError(w, r, http.StatusNotFound, errors.New("webhook not found")); return }
+	if err != nil { respondError(w, r, 500, err); return }
+
+	content := r.FormValue("content")
+	if content == "" { content = "This is a test event from gotalk." }
+	msg := Message{
+		Type: messageTypeText, Content: content, HTML: renderContent(content), SenderPod: nodeID, SenderNick: systemNick(),
+		SenderColor: assignedColor(systemNick()), Time: time.Now().Format("15:04:05"), RoomID: h.RoomID,
+	}
+	body, _ := json.Marshal(WebhookEvent{Event: webhookTestEvent, Message: msg})
+	req, err := signedWebhookRequest(h, webhookTestEvent, body)
+	if err != nil { respondError(w, r, 500, err); return }
+	req = req.WithContext(r.Context())
+
+	res := webhookTestResult{Request: sandboxRequest{URL: h.URL, Headers: flatHeaders(req.Header), Body: body}}
+	start := time.Now()
+	resp, err := webhookClient.Do(req)
+	res.DurationMS = time.Since(start).Milliseconds()
+	if err != nil {
+		res.Error = err.Error()
+		writeJSON(w, http.StatusOK, res)
+		return
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, sandboxMaxResponse+1))
+	res.Response = &sandboxResponse{Status: resp.StatusCode, Headers: flatHeaders(resp.Header), Body: string(data)}
+	if len(data) > sandboxMaxResponse {
+		res.Response.Body = string(data[:sandboxMaxResponse])
+		res.Response.Truncated = true
+	}
+	res.OK = resp.StatusCode >= 200 && resp.StatusCode <= 299
+	if !res.OK { res.Error = "webhook returned " + resp.Status }
+	writeJSON(w, http.StatusOK, res)
+}
+
+type botSimulation struct {
+	OK      bool     `json:"ok"`
+	Status  int      `json:"status"` // 실제 /hooks/{token} 호출이었다면 받았을 상태 코드
+	Error   string   `json:"error,omitempty"`
+	Message *Message `json:"message,omitempty"`
+}
+
+// [봇 시뮬레이션] POST /admin/bots/{id}/simulate {"text": "..."} - 수신 웹훅과 같은 본문 검사
+func adminSimulateBotHandler(w http.ResponseWriter, r *http.Request) {
+	var name string
+	var roomID *int
+	err := db.QueryRowContext(r.Context(), "SELECT name, room_id FROM incoming_hooks WHERE token = $1 AND revoked_at IS NULL", r.PathValue("id")).Scan(&name, &roomID)
+	if err == sql.ErrNoRows { respondError(w, r, http.StatusNotFound, errors.New("bot not found")); return }
+	if err != nil { respondError(w, r, 500, err); return }
+
+	content, status, err := readHookPayload(r)
+	if err != nil {
+		writeJSON(w, http.StatusOK, botSimulation{Status: status, Error: err.Error()})
+		return
+	}
+	groupKey, dayDivider := groupingHints(r.Context(), roomID, name, false)
+	msg := Message{
+		Type: messageTypeText, Content: content, HTML: renderContent(content), SenderPod: nodeID, SenderNick: name, SenderColor: "#ffffff",
+		SenderType: senderTypeBot, Time: time.Now().Format("15:04:05"), RoomID: roomID,
+	}
+	if groupKey != nil { msg.GroupKey = *groupKey }
+	if dayDivider != nil { msg.DayDivider = *dayDivider }
+	writeJSON(w, http.StatusOK, botSimulation{OK: true, Status: http.StatusCreated, Message: &msg})
+}
+
+// 수신 웹훅 본문에서 올릴 글을 꺼냄 (실패하면 돌려줄 상태 코드와 함께)
+func readHookPayload(r *http.Request) (string, int, error) {
+	var p hookPayload
+	if !isJSONRequest(r) { return "", http.StatusUnsupportedMediaType, errors.New("content type must be application/json") }
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxJSONBody)).Decode(&p); err != nil { return "", http.StatusBadRequest, errors.New("invalid json") }
+	content := strings.TrimSpace(p.Text)
+	if content == "" { content = strings.TrimSpace(p.Content) }
+	if content == "" { return "", http.StatusBadRequest, fieldError{"text", "required"} }
+	if err := validateText("text", content, hookMaxMessage); err != nil { return "", http.StatusBadRequest, err }
+	return content, 0, nil
+}
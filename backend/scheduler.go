@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"hash/fnv"
+	"log/slog"
+	"runtime/debug"
+	"time"
+)
+
+// [스케줄러] 주기 작업 등록/실행
+// 여러 파드가 같은 작업을 동시에 돌리지 않도록 Postgres advisory lock으로 한 파드만 실행
+type scheduledJob struct {
+	name     string
+	interval time.Duration
+	run      func()
+}
+
+var jobs []scheduledJob
+
+func scheduleJob(name string, interval time.Duration, run func()) {
+	jobs = append(jobs, scheduledJob{name: name, interval: interval, run: run})
+}
+
+func startScheduler() {
+	for _, j := range jobs {
+		go j.loop()
+	}
+	slog.Info("scheduler started", "jobs", len(jobs))
+}
+
+func (j scheduledJob) loop() {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+	for {
+		j.runOnce()
+		<-ticker.C
+	}
+}
+
+func (j scheduledJob) lockKey() int64 {
+	h := fnv.New64a()
+	h.Write([]byte("gotalk:job:" + j.name))
+	return int64(h.Sum64())
+}
+
+// 잠금을 얻은 파드만 실행, 패닉은 작업 단위로 격리
+func (j scheduledJob) runOnce() {
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil { slog.Warn("scheduler job could not acquire connection", "job", j.name, "err", err); return }
+	defer conn.Close()
+
+	var locked bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", j.lockKey()).Scan(&locked); err != nil || !locked {
+		return
+	}
+	defer conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", j.lockKey())
+
+	defer func() {
+		if rec := recover(); rec != nil {
+			slog.Error("scheduler job panicked", "job", j.name, "err", rec, "stack", string(debug.Stack()))
+		}
+	}()
+	start := time.Now()
+	j.run()
+	if d := time.Since(start); d > time.Second {
+		slog.Warn("scheduler job slow", "job", j.name, "duration", d)
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// [서버 세션] 로그인할 때 sessions 행을 만들고 쿠키/Bearer 토큰에는 서명한 세션 ID만 실음
+// 요청마다 행을 확인해 닉네임을 얻으므로 폐기(로그아웃, 다른 기기 끊기, 관리자 비밀번호 재설정)가 바로 반영됨
+// GET /auth/sessions로 내 세션(기기, IP, 마지막 사용)을 보고 DELETE /auth/sessions/{id}로 도난당한 세션을 끊음
+// (세션은 비밀번호/외부 로그인이 있는 Postgres 저장소에서만 발급됨)
+const (
+	sessionTouchEvery = time.Minute // last_seen_at 갱신 간격 (요청마다 쓰지 않게)
+	sessionMaxAgent   = 256
+)
+
+type Session struct {
+	ID         string    `json:"id"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	DeviceID   string    `json:"device_id,omitempty"` // devices.go
+	Platform   string    `json:"platform,omitempty"`
+	Current    bool      `json:"current"` // 이 요청을 보낸 세션
+}
+
+func createSession(r *http.Request, nick, device string, exp time.Time) (string, error) {
+	id := newEmbedToken()
+	ua := r.UserAgent()
+	if len(ua) > sessionMaxAgent { ua = ua[:sessionMaxAgent] }
+	_, err := db.ExecContext(r.Context(), "INSERT INTO sessions (id, nickname, expires_at, user_agent, ip, device_id) VALUES ($1, $2, $3, $4, $5, $6)",
+		id, nick, exp, ua, clientIP(r), device)
+	return id, err
+}
+
+// 살아 있는 세션의 닉네임 (폐기/만료면 errInvalidSession)
+func lookupSession(ctx context.Context, id string) (string, error) {
+	if !fullFeatureStore() { return "", errInvalidSession }
+	var nick string
+	var lastSeen time.Time
+	err := db.QueryRowContext(ctx, "SELECT nickname, last_seen_at FROM sessions WHERE id = $1 AND revoked_at IS NULL AND expires_at > CURRENT_TIMESTAMP", id).Scan(&nick, &lastSeen)
+	if err == sql.ErrNoRows { return "", errInvalidSession }
+	if err != nil { return "", err }
+	if time.Since(lastSeen) > sessionTouchEvery {
+		db.ExecContext(ctx, "UPDATE sessions SET last_seen_at = CURRENT_TIMESTAMP WHERE id = $1", id)
+		touchSessionDevice(ctx, id)
+	}
+	return nick, nil
+}
+
+// 한 사용자의 모든 세션 폐기 (keep은 남길 세션, 없으면 "")
+func revokeSessions(ctx context.Context, nick, keep string) (int64, error) {
+	if !fullFeatureStore() { return 0, nil }
+	res, err := db.ExecContext(ctx, "UPDATE sessions SET revoked_at = CURRENT_TIMESTAMP WHERE nickname = $1 AND id <> $2 AND revoked_at IS NULL", nick, keep)
+	if err != nil { return 0, err }
+	return res.RowsAffected()
+}
+
+// 세션 API는 /auth/ 아래라 인증 미들웨어를 거치지 않으므로 여기서 세션을 확인
+func requireSession(w http.ResponseWriter, r *http.Request) (nick, id string, ok bool) {
+	id, err := sessionID(r)
+	if err == nil && id != "" { nick, err = lookupSession(r.Context(), id) }
+	if err != nil || nick == "" { respondError(w, r, http.StatusUnauthorized, errLoginRequired); return "", "", false }
+	return nick, id, true
+}
+
+// [내 세션 목록] GET /auth/sessions - 살아 있는 세션, 최근 사용 순
+func listSessionsHandler(w http.ResponseWriter, r *http.Request) {
+	nick, current, ok := requireSession(w, r)
+	if !ok { return }
+	rows, err := db.QueryContext(r.Context(), `
+		SELECT s.id, s.created_at, s.last_seen_at, s.expires_at, s.user_agent, s.ip, COALESCE(s.device_id, ''), COALESCE(d.platform, '')
+		FROM sessions s LEFT JOIN devices d ON d.nickname = s.nickname AND d.id = s.device_id
+		WHERE s.nickname = $1 AND s.revoked_at IS NULL AND s.expires_at > CURRENT_TIMESTAMP ORDER BY s.last_seen_at DESC`, nick)
+	if err != nil { respondError(w, r, 500, err); return }
+	defer rows.Close()
+	list := []Session{}
+	for rows.Next() {
+		var s Session
+		rows.Scan(&s.ID, &s.CreatedAt, &s.LastSeenAt, &s.ExpiresAt, &s.UserAgent, &s.IP, &s.DeviceID, &s.Platform)
+		s.Current = s.ID == current
+		list = append(list, s)
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+// [세션 폐기] DELETE /auth/sessions/{id} - 내 세션 하나 (도난당한 기기 끊기)
+func revokeSessionHandler(w http.ResponseWriter, r *http.Request) {
+	nick, current, ok := requireSession(w, r)
+	if !ok { return }
+	id := r.PathValue("id")
+	res, err := db.ExecContext(r.Context(), "UPDATE sessions SET revoked_at = CURRENT_TIMESTAMP WHERE id = $1 AND nickname = $2 AND revoked_at IS NULL", id, nick)
+	if err != nil { respondError(w, r, 500, err); return }
+	if n, _ := res.RowsAffected(); n == 0 { respondError(w, r, http.StatusNotFound, errors.New("session not found")); return }
+	if id == current { clearAuthCookies(w) }
+	slog.InfoContext(r.Context(), "session revoked", "nick", nick)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// [다른 세션 모두 폐기] DELETE /auth/sessions - 지금 세션만 남김
+func revokeOtherSessionsHandler(w http.ResponseWriter, r *http.Request) {
+	nick, current, ok := requireSession(w, r)
+	if !ok { return }
+	n, err := revokeSessions(r.Context(), nick, current)
+	if err != nil { respondError(w, r, 500, err); return }
+	slog.InfoContext(r.Context(), "other sessions revoked", "nick", nick, "count", n)
+	writeJSON(w, http.StatusOK, map[string]int64{"revoked": n})
+}
+
+// [관리자 세션 폐기] DELETE /admin/users/{nick}/sessions - 그 사용자의 모든 기기에서 로그아웃
+func adminRevokeSessionsHandler(w http.ResponseWriter, r *http.Request) {
+	nick := r.PathValue("nick")
+	n, err := revokeSessions(r.Context(), nick, "")
+	if err != nil { respondError(w, r, 500, err); return }
+	slog.InfoContext(r.Context(), "admin revoked sessions", "nick", nick, "count", n)
+	writeJSON(w, http.StatusOK, map[string]int64{"revoked": n})
+}
+
+// 만료됐거나 폐기된 지 하루 지난 세션 정리
+func pruneSessions() {
+	res, err := db.Exec("DELETE FROM sessions WHERE expires_at < CURRENT_TIMESTAMP OR revoked_at < CURRENT_TIMESTAMP - INTERVAL '1 day'")
+	if err != nil { slog.Warn("session cleanup failed", "err", err); return }
+	if n, _ := res.RowsAffected(); n > 0 { slog.Info("expired sessions removed", "count", n) }
+}
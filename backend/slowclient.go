@@ -0,0 +1,125 @@
+package main
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// [느린 클라이언트 정책] 방송실이 연결 채널(client_buffer)이 꽉 찬 연결을 어떻게 다룰지 (stream.slow_client_policy)
+// - drop: 새 메시지를 버림 (기존 동작)
+// - drop_oldest: 채널에서 가장 오래된 것을 버리고 새 메시지를 넣음
+// - disconnect: 연결을 끊음 (클라이언트는 Last-Event-ID로 다시 붙어 놓친 것을 받음)
+// - grow: 연결별 대기열로 stream.max_client_buffer까지 늘리고, 그래도 넘치면 끊음
+// 버린 수는 연결별(/admin/clients의 dropped)과 전체(gotalk_broadcast_dropped_total)로 세고, 밀리는 연결은 주기적으로 로그를 남김
+const (
+	slowClientDrop       = "drop"
+	slowClientDropOldest = "drop_oldest"
+	slowClientDisconnect = "disconnect"
+	slowClientGrow       = "grow"
+
+	slowClientLogEvery = time.Minute // 같은 연결의 지연 로그 간격
+)
+
+var slowClientDisconnects = promauto.NewCounter(prometheus.CounterOpts{
+	Name: "gotalk_slow_client_disconnects_total",
+	Help: "Stream connections closed by the slow-client policy.",
+})
+
+// 방송 대상 연결 (clients 값, mutex로 보호)
+type streamClient struct {
+	nick     string
+	member   string    // 확인된 신원 (비공개 방 전달 기준, 없으면 "")
+	conn     *connInfo // 위젯 스트림은 nil
+	kick     func()    // 연결을 끊음
+	overflow []outbound
+	kicked   bool
+	probe    bool // 합성 프로브 연결 (카나리만 받음, probe.go)
+	room     int  // 방 읽기 키/임베드로 한 방에 묶인 연결 (그 방은 비공개여도 받음)
+	dropped  int
+	loggedAt time.Time
+}
+
+// 연결 채널에 넣기 (mutex를 잡은 채로 부름), 못 넣었으면 false
+func (c *streamClient) offer(ch chan outbound, msg outbound) bool {
+	if c.kicked || msg.Probe != c.probe || isBlocked(c.nick, msg.Sender) { return false }
+	if msg.RoomID != c.room && hiddenRoom(msg.RoomID, c.member) { return false }
+	if c.probe {
+		// 다른 파드의 카나리로 차 있으면 버림 (느린 클라이언트 정책으로 끊지 않음)
+		select {
+		case ch <- msg:
+			return true
+		default:
+			return false
+		}
+	}
+	// grow 대기분이 있으면 순서를 지키려고 뒤에 붙임
+	if len(c.overflow) == 0 {
+		select {
+		case ch <- msg:
+			return true
+		default:
+		}
+	}
+	switch cfg.Stream.SlowClientPolicy {
+	case slowClientGrow:
+		if len(c.overflow) < cfg.Stream.MaxClientBuffer {
+			c.overflow = append(c.overflow, msg)
+			return true
+		}
+		c.disconnect()
+	case slowClientDropOldest:
+		select {
+		case old := <-ch:
+			c.drop(old.Room)
+		default:
+		}
+		select {
+		case ch <- msg:
+			return true
+		default:
+		}
+	case slowClientDisconnect:
+		c.disconnect()
+	}
+	c.drop(msg.Room)
+	return false
+}
+
+func (c *streamClient) drop(room string) {
+	broadcastDropped.WithLabelValues(room).Inc()
+	c.dropped++
+	if c.conn != nil { c.conn.dropped.Add(1) }
+	if time.Since(c.loggedAt) < slowClientLogEvery { return }
+	c.loggedAt = time.Now()
+	connID := ""
+	if c.conn != nil { connID = c.conn.ID }
+	slog.Warn("stream client lagging", "nick", c.nick, "conn", connID, "dropped", c.dropped, "policy", cfg.Stream.SlowClientPolicy)
+}
+
+func (c *streamClient) disconnect() {
+	if c.kicked { return }
+	c.kicked = true
+	slowClientDisconnects.Inc()
+	slog.Warn("disconnecting slow stream client", "nick", c.nick, "backlog", cfg.ClientBuffer+len(c.overflow), "policy", cfg.Stream.SlowClientPolicy)
+	c.kick()
+}
+
+// 스트림이 채널에서 하나 꺼낼 때마다 불러 grow 대기분을 빈자리로 옮김
+func refill(ch chan outbound) {
+	if cfg.Stream.SlowClientPolicy != slowClientGrow { return }
+	mutex.Lock()
+	defer mutex.Unlock()
+	c := clients[ch]
+	for c != nil && len(c.overflow) > 0 {
+		select {
+		case ch <- c.overflow[0]:
+			c.overflow = c.overflow[1:]
+		default:
+			return
+		}
+	}
+	if c != nil { c.overflow = nil } // 다 옮겼으면 배열을 놓아 줌
+}
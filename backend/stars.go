@@ -0,0 +1,107 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// [즐겨찾기] 로그인한 사용자가 볼 수 있는 메시지에 별표를 달고 모아 봄 (닉네임 기준 저장이라 기기 간 동기화)
+
+type StarredMessage struct {
+	Message   Message   `json:"message"`
+	StarredAt time.Time `json:"starred_at"`
+}
+
+type StarsPage struct {
+	Stars      []StarredMessage `json:"stars"`       // 별표 단 시각 최신순
+	HasMore    bool             `json:"has_more"`
+	NextCursor *int64           `json:"next_cursor"` // 다음 요청의 before 값 (없으면 null)
+}
+
+// 방 메시지는 멤버, DM은 보낸/받은 사람만 별표 가능
+func messageVisibleTo(msgID int, nick string) (bool, error) {
+	var roomID sql.NullInt64
+	var sender string
+	var recipient sql.NullString
+	err := db.QueryRow("SELECT room_id, sender_nick, recipient_nick FROM messages WHERE id = $1 AND type = $2", msgID, messageTypeText).
+		Scan(&roomID, &sender, &recipient)
+	if err == sql.ErrNoRows { return false, nil }
+	if err != nil { return false, err }
+	if recipient.Valid { return nick == sender || nick == recipient.String, nil }
+	if roomID.Valid { return roomRole(int(roomID.Int64), nick) != "", nil }
+	return true, nil
+}
+
+// [별표] POST /messages/{id}/star - 이미 달려 있으면 그대로
+func starMessageHandler(w http.ResponseWriter, r *http.Request) {
+	nick, ok := requireAccount(w, r)
+	if !ok { return }
+	msgID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil { respondError(w, r, http.StatusBadRequest, errors.New("invalid message id")); return }
+	visible, err := messageVisibleTo(msgID, nick)
+	if err != nil { respondError(w, r, 500, err); return }
+	if !visible { respondError(w, r, http.StatusNotFound, errors.New("message not found")); return }
+
+	var starredAt time.Time
+	err = db.QueryRowContext(r.Context(), `
+		INSERT INTO stars (nickname, message_id) VALUES ($1, $2)
+		ON CONFLICT (nickname, message_id) DO UPDATE SET nickname = EXCLUDED.nickname
+		RETURNING starred_at`, nick, msgID).Scan(&starredAt)
+	if err != nil { respondError(w, r, 500, err); return }
+	writeJSON(w, http.StatusOK, map[string]any{"message_id": msgID, "starred_at": starredAt})
+}
+
+// [별표 해제] DELETE /messages/{id}/star
+func unstarMessageHandler(w http.ResponseWriter, r *http.Request) {
+	nick, ok := requireAccount(w, r)
+	if !ok { return }
+	msgID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil { respondError(w, r, http.StatusBadRequest, errors.New("invalid message id")); return }
+	res, err := db.ExecContext(r.Context(), "DELETE FROM stars WHERE nickname = $1 AND message_id = $2", nick, msgID)
+	if err != nil { respondError(w, r, 500, err); return }
+	if n, _ := res.RowsAffected(); n == 0 { respondError(w, r, http.StatusNotFound, errors.New("message is not starred")); return }
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// [별표 목록] GET /stars?before=&limit= - 별표 단 시각 최신순, 볼 수 없게 된 방의 메시지는 뺌
+func starsHandler(w http.ResponseWriter, r *http.Request) {
+	nick, ok := requireAccount(w, r)
+	if !ok { return }
+	q := r.URL.Query()
+	limit := 30
+	if l, err := strconv.Atoi(q.Get("limit")); err == nil && l > 0 && l <= 100 {
+		limit = l
+	}
+	before, err := strconv.ParseInt(q.Get("before"), 10, 64)
+	if err != nil || before <= 0 { before = math.MaxInt64 }
+
+	rows, err := db.QueryContext(r.Context(), `
+		SELECT s.id, s.starred_at, m.id, m.content, m.sender_pod, m.sender_nick,
+			COALESCE(u.color_code, '#ffffff'), to_char(m.created_at, 'HH24:MI:SS'), m.room_id, m.recipient_nick
+		FROM stars s
+		JOIN messages m ON m.id = s.message_id
+		LEFT JOIN users u ON u.nickname = m.sender_nick
+		WHERE s.nickname = $1 AND s.id < $2
+		  AND (m.room_id IS NULL OR EXISTS (SELECT 1 FROM room_members rm WHERE rm.room_id = m.room_id AND rm.nickname = $1))
+		ORDER BY s.id DESC LIMIT $3`, nick, before, limit+1)
+	if err != nil { respondError(w, r, 500, err); return }
+	defer rows.Close()
+
+	page := StarsPage{Stars: []StarredMessage{}}
+	var lastID int64
+	for rows.Next() {
+		if len(page.Stars) == limit { page.HasMore = true; break }
+		s := StarredMessage{Message: Message{Type: messageTypeText}}
+		var recipient sql.NullString
+		rows.Scan(&lastID, &s.StarredAt, &s.Message.ID, &s.Message.Content, &s.Message.SenderPod, &s.Message.SenderNick,
+			&s.Message.SenderColor, &s.Message.Time, &s.Message.RoomID, &recipient)
+		s.Message.RecipientNick = recipient.String
+		page.Stars = append(page.Stars, s)
+	}
+	if page.HasMore { page.NextCursor = &lastID }
+	writeJSON(w, http.StatusOK, page)
+}
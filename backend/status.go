@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/lib/pq"
+)
+
+// [사용자 상태] 접속 여부와 별개로 사용자가 정하는 상태 (online/away/dnd) + 한 줄 문구
+// POST /status 또는 POST /update의 status/status_text로 바꾸고, 바뀌면 모든 파드에 알려 각 연결에 `event: presence`로 보냄
+// GET /online은 접속 중인 닉네임마다 저장된 상태를 함께 돌려줌 (저장소에 없으면 online)
+const (
+	statusOnline = "online"
+	statusAway   = "away"
+	statusDND    = "dnd"
+
+	statusMaxText = 80
+
+	subjectUserStatus = "chat.presence.status"
+	eventPresence     = "presence"
+)
+
+type UserStatus struct {
+	Nickname   string `json:"nickname"`
+	Status     string `json:"status"`
+	StatusText string `json:"status_text,omitempty"`
+}
+
+func validStatus(s string) bool { return s == statusOnline || s == statusAway || s == statusDND }
+
+// 보낸 값만 바꾸고 바뀐 상태를 방송
+func setUserStatus(ctx context.Context, nick string, status, text *string) (UserStatus, error) {
+	u := UserStatus{Nickname: nick}
+	err := db.QueryRowContext(ctx, `UPDATE users SET status = COALESCE($2, status), status_text = COALESCE($3, status_text)
+		WHERE nickname = $1 RETURNING status, status_text`, nick, status, text).Scan(&u.Status, &u.StatusText)
+	if err != nil { return u, err }
+	data, _ := json.Marshal(u)
+	if err := broker.Publish(ctx, subjectUserStatus, data); err != nil { slog.WarnContext(ctx, "status publish failed", "nick", nick, "err", err) }
+	return u, nil
+}
+
+func handleUserStatus(m BrokerMsg) {
+	out := newOutbound(m.Ctx, string(m.Data))
+	out.Event = eventPresence
+	sendToAll(out)
+}
+
+// 닉네임들의 저장된 상태 (없으면 online)
+func userStatuses(ctx context.Context, nicks []string) []UserStatus {
+	list := make([]UserStatus, len(nicks))
+	index := map[string]int{}
+	for i, nick := range nicks {
+		list[i] = UserStatus{Nickname: nick, Status: statusOnline}
+		index[nick] = i
+	}
+	if len(nicks) == 0 || !fullFeatureStore() { return list }
+	rows, err := db.QueryContext(ctx, "SELECT nickname, status, status_text FROM users WHERE nickname = ANY($1)", pq.Array(nicks))
+	if err != nil { slog.WarnContext(ctx, "status lookup failed", "err", err); return list }
+	defer rows.Close()
+	for rows.Next() {
+		var u UserStatus
+		rows.Scan(&u.Nickname, &u.Status, &u.StatusText)
+		list[index[u.Nickname]] = u
+	}
+	return list
+}
+
+// [상태 변경] POST /status (nick, status?, status_text?) - 본인만, 보낸 값만 바꿈 (status_text=""면 문구를 지움)
+func setStatusHandler(w http.ResponseWriter, r *http.Request) {
+	if !fullFeatureStore() { respondError(w, r, http.StatusNotImplemented, errors.New("statuses require the postgres store")); return }
+	var req StatusRequest
+	if err := decodeRequest(r, &req); err != nil { respondError(w, r, decodeStatus(err), err); return }
+	if id, ok := identityFrom(r.Context()); !ok || id != req.Nick { respondError(w, r, http.StatusForbidden, errors.New("log in as this nickname to change its status")); return }
+	u, err := setUserStatus(r.Context(), req.Nick, req.Status, req.StatusText)
+	if err != nil { respondError(w, r, 500, err); return }
+	writeJSON(w, http.StatusOK, u)
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// [오브젝트 스토리지] S3/GCS 등과 호환되는 최소 인터페이스
+type ObjectStore interface {
+	Put(ctx context.Context, key string, r io.Reader) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+}
+
+// 기본 저장소: 로컬 디렉터리 (여러 파드면 공유 볼륨 필요, 아니면 storage.backend=s3)
+type fsStore struct{ dir string }
+
+func (s fsStore) path(key string) (string, error) {
+	p := filepath.Join(s.dir, filepath.FromSlash(key))
+	if !strings.HasPrefix(p, filepath.Clean(s.dir)+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid object key %q", key)
+	}
+	return p, nil
+}
+
+func (s fsStore) Put(ctx context.Context, key string, r io.Reader) error {
+	p, err := s.path(key)
+	if err != nil { return err }
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil { return err }
+	// 임시 파일에 다 쓴 뒤 이름을 바꿔 반쯤 쓰인 객체가 보이지 않게 함
+	f, err := os.CreateTemp(filepath.Dir(p), ".upload-*")
+	if err != nil { return err }
+	defer os.Remove(f.Name())
+	if _, err := io.Copy(f, r); err != nil { f.Close(); return err }
+	if err := f.Close(); err != nil { return err }
+	return os.Rename(f.Name(), p)
+}
+
+func (s fsStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	p, err := s.path(key)
+	if err != nil { return nil, err }
+	return os.Open(p)
+}
+
+var objectStore ObjectStore
+
+const (
+	storageBackendFS = "fs"
+	storageBackendS3 = "s3"
+)
+
+func initStorage() {
+	switch cfg.Storage.Backend {
+	case storageBackendS3:
+		objectStore = newS3Store()
+	default:
+		objectStore = fsStore{dir: cfg.Storage.Dir}
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// [S3 저장소] storage.backend=s3 - AWS S3와 MinIO 등 호환 서비스에 경로 방식(endpoint/bucket/key)으로 저장
+// SDK 없이 SigV4 서명만 직접 함 (필요한 건 PUT/GET뿐)
+type s3Store struct {
+	endpoint, region, bucket string
+	accessKey, secretKey     string
+	client                   *http.Client
+}
+
+func newS3Store() s3Store {
+	s := cfg.Storage.S3
+	return s3Store{
+		endpoint: strings.TrimSuffix(s.Endpoint, "/"), region: s.Region, bucket: s.Bucket,
+		accessKey: s.AccessKey, secretKey: s.SecretKey,
+		client: &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+// 서명에 본문 해시가 필요하고 S3가 길이 없는 업로드를 받지 않으므로 메모리에 모아서 보냄
+func (s s3Store) Put(ctx context.Context, key string, r io.Reader) error {
+	body, err := io.ReadAll(r)
+	if err != nil { return err }
+	resp, err := s.do(ctx, http.MethodPut, key, body)
+	if err != nil { return err }
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK { return s3Error(resp) }
+	return nil
+}
+
+// 없는 객체는 os.ErrNotExist (fsStore와 같게)
+func (s s3Store) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	resp, err := s.do(ctx, http.MethodGet, key, nil)
+	if err != nil { return nil, err }
+	if resp.StatusCode == http.StatusNotFound { resp.Body.Close(); return nil, fmt.Errorf("s3 %s: %w", key, os.ErrNotExist) }
+	if resp.StatusCode != http.StatusOK { defer resp.Body.Close(); return nil, s3Error(resp) }
+	return resp.Body, nil
+}
+
+func s3Error(resp *http.Response) error {
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return fmt.Errorf("s3 %s %s: %s %s", resp.Request.Method, resp.Request.URL.Path, resp.Status, bytes.TrimSpace(msg))
+}
+
+func (s s3Store) do(ctx context.Context, method, key string, body []byte) (*http.Response, error) {
+	path := "/" + s3Escape(s.bucket) + "/" + s3Escape(key)
+	req, err := http.NewRequestWithContext(ctx, method, s.endpoint+path, bytes.NewReader(body))
+	if err != nil { return nil, err }
+	req.ContentLength = int64(len(body))
+
+	now := time.Now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	sum := sha256.Sum256(body)
+	payloadHash := hex.EncodeToString(sum[:])
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	const signedHeaders = "host;x-amz-content-sha256;x-amz-date"
+	canonical := strings.Join([]string{
+		method, path, "",
+		"host:" + req.URL.Host + "\nx-amz-content-sha256:" + payloadHash + "\nx-amz-date:" + amzDate + "\n",
+		signedHeaders, payloadHash,
+	}, "\n")
+	scope := amzDate[:8] + "/" + s.region + "/s3/aws4_request"
+	canonicalHash := sha256.Sum256([]byte(canonical))
+	toSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(canonicalHash[:])
+
+	sig := hex.EncodeToString(hmacSHA256(s3SigningKey(s.secretKey, amzDate[:8], s.region, "s3"), toSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", s.accessKey, scope, signedHeaders, sig))
+	return s.client.Do(req)
+}
+
+func s3SigningKey(secret, date, region, service string) []byte {
+	k := hmacSHA256([]byte("AWS4"+secret), date)
+	for _, part := range []string{region, service, "aws4_request"} {
+		k = hmacSHA256(k, part)
+	}
+	return k
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	m := hmac.New(sha256.New, key)
+	m.Write([]byte(data))
+	return m.Sum(nil)
+}
+
+// SigV4 경로 인코딩: 비예약 문자(A-Z a-z 0-9 - _ . ~)와 "/" 말고는 모두 %XX
+func s3Escape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || strings.IndexByte("-_.~/", c) >= 0 {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// [저장소] 기본 채팅 경로(스키마, 로그인, 전송, 기록 조회)의 SQL을 드라이버별로 분리
+// postgres(기본)는 모든 기능, sqlite/mysql은 Postgres 서버 없이 돌리는 단일 노드용으로
+// 로비 채팅만 지원함 (방/고정/통계 등 나머지 기능은 Postgres 전용 SQL이라 비활성)
+type Store interface {
+	DB() *sql.DB
+	Migrate(ctx context.Context) error
+	// 사용자 색상과 탈퇴 여부 (없으면 sql.ErrNoRows)
+	User(ctx context.Context, nick string) (color string, deleted bool, err error)
+	UpsertUser(ctx context.Context, nick, color string) error
+	// 답글 대상 확인용: 일반 메시지의 방 (없으면 sql.ErrNoRows)
+	MessageRoom(ctx context.Context, id int) (sql.NullInt64, error)
+	// ClientMsgID가 있으면 같은 트랜잭션에서 (보낸 사람, 키)를 기록 (중복이면 오류)
+	InsertMessage(ctx context.Context, m NewMessage) (int, error)
+	// 보낸 사람이 그 client_msg_id로 이미 올린 메시지 (없으면 sql.ErrNoRows)
+	ClientMessage(ctx context.Context, nick, clientMsgID string) (Message, error)
+	ReplyCount(ctx context.Context, parentID int) int
+	// 기록 한 페이지 + 1개 (beforeID면 id 내림차순, afterID면 오름차순, 둘 다 0이면 최신부터)
+	History(ctx context.Context, roomID *int, beforeID, afterID, limit int) ([]Message, error)
+}
+
+const (
+	dbDriverPostgres = "postgres"
+	dbDriverSQLite   = "sqlite"
+	dbDriverMySQL    = "mysql"
+)
+
+var store Store
+
+type NewMessage struct {
+	Content    string
+	SenderPod  string
+	SenderNick string
+	ParentID   *int
+	RoomID     *int
+	GroupKey   *int
+	DayDivider *string
+
+	ClientMsgID string // 클라이언트 재전송 중복 방지 키 (없으면 "")
+}
+
+func initDB() {
+	switch cfg.DB.Driver {
+	case dbDriverSQLite:
+		store = newSQLiteStore()
+	case dbDriverMySQL:
+		store = newMySQLStore()
+	default:
+		store = newPostgresStore()
+	}
+	db = store.DB()
+	if err := store.Migrate(context.Background()); err != nil { fatal("schema migration failed", err) }
+}
+
+// Postgres 전용 SQL을 쓰는 기능(스케줄러 작업 등)을 켤지
+func fullFeatureStore() bool { return cfg.DB.Driver == dbDriverPostgres }
+
+// 드라이버마다 다른 것은 자리표시자와 시각/날짜 포맷 식
+type historyDialect struct {
+	placeholder func(n int) string
+	timeExpr    string // created_at → HH:MM:SS
+	dayExpr     string // day_divider → YYYY-MM-DD
+	botExpr     string // 봇 메시지면 'bot', 아니면 ''
+	avatarExpr  string // 보낸 사람 아바타 주소, 없으면 ''
+}
+
+func pgPlaceholder(n int) string { return "$" + strconv.Itoa(n) }
+func qmPlaceholder(int) string   { return "?" }
+
+func queryHistory(ctx context.Context, conn *sql.DB, d historyDialect, roomID *int, beforeID, afterID, limit int) ([]Message, error) {
+	query := fmt.Sprintf(`
+		SELECT
+			m.id, m.content, m.sender_pod,
+			CASE WHEN u.deleted_at IS NOT NULL THEN '[deleted]' ELSE m.sender_nick END,
+			COALESCE(u.color_code, '#ffffff'), %s,
+			m.parent_id, (SELECT COUNT(*) FROM messages r WHERE r.parent_id = m.id),
+			m.type, m.event, COALESCE(m.group_key, m.id), COALESCE(%s, ''), %s, %s
+		FROM messages m
+		LEFT JOIN users u ON m.sender_nick = u.nickname
+		WHERE m.recipient_nick IS NULL
+	`, d.timeExpr, d.dayExpr, d.botExpr, d.avatarExpr)
+
+	var args []any
+	where := " AND m.room_id IS NULL"
+	if roomID != nil {
+		args = append(args, *roomID)
+		where = " AND m.room_id = " + d.placeholder(len(args))
+	}
+	// id는 단조 증가하므로 id만으로 정렬해도 페이지 경계가 흔들리지 않음
+	order := " ORDER BY m.id DESC"
+	if beforeID > 0 {
+		args = append(args, beforeID)
+		where += " AND m.id < " + d.placeholder(len(args))
+	} else if afterID > 0 {
+		args = append(args, afterID)
+		where += " AND m.id > " + d.placeholder(len(args))
+		order = " ORDER BY m.id ASC"
+	}
+	args = append(args, limit)
+	rows, err := conn.QueryContext(ctx, query+where+order+" LIMIT "+d.placeholder(len(args)), args...)
+	if err != nil { return nil, err }
+	defer rows.Close()
+
+	var list []Message
+	for rows.Next() {
+		var m Message
+		var parentID sql.NullInt64
+		var event []byte
+		rows.Scan(&m.ID, &m.Content, &m.SenderPod, &m.SenderNick, &m.SenderColor, &m.Time, &parentID, &m.ReplyCount, &m.Type, &event, &m.GroupKey, &m.DayDivider, &m.SenderType, &m.AvatarURL)
+		if parentID.Valid {
+			pid := int(parentID.Int64)
+			m.ParentID = &pid
+		}
+		if event != nil {
+			m.Event = &RoomEvent{}
+			json.Unmarshal(event, m.Event)
+		}
+		list = append(list, m)
+	}
+	return list, rows.Err()
+}
+
+// 메시지와 같은 트랜잭션에서 재전송 키 기록 (이미 있으면 기본 키 충돌로 실패해 메시지도 되돌려짐)
+func recordClientMsgID(ctx context.Context, tx *sql.Tx, d historyDialect, m NewMessage, id int) error {
+	if m.ClientMsgID == "" { return nil }
+	_, err := tx.ExecContext(ctx, fmt.Sprintf("INSERT INTO message_client_ids (sender_nick, client_msg_id, message_id) VALUES (%s, %s, %s)",
+		d.placeholder(1), d.placeholder(2), d.placeholder(3)), m.SenderNick, m.ClientMsgID, id)
+	return err
+}
+
+func queryClientMessage(ctx context.Context, conn *sql.DB, d historyDialect, nick, clientMsgID string) (Message, error) {
+	m := Message{Type: messageTypeText, SenderNick: nick, ClientMsgID: clientMsgID}
+	var roomID, parentID sql.NullInt64
+	err := conn.QueryRowContext(ctx, fmt.Sprintf(`SELECT m.id, m.content, m.sender_pod, m.room_id, m.parent_id FROM message_client_ids c
+		JOIN messages m ON m.id = c.message_id WHERE c.sender_nick = %s AND c.client_msg_id = %s`, d.placeholder(1), d.placeholder(2)),
+		nick, clientMsgID).Scan(&m.ID, &m.Content, &m.SenderPod, &roomID, &parentID)
+	if roomID.Valid {
+		rid := int(roomID.Int64)
+		m.RoomID = &rid
+	}
+	if parentID.Valid {
+		pid := int(parentID.Int64)
+		m.ParentID = &pid
+	}
+	return m, err
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+
+	_ "github.com/go-sql-driver/mysql"
+	_ "modernc.org/sqlite"
+)
+
+// [단일 노드 저장소] SQLite/MySQL 공용 구현, 드라이버별로 DDL과 일부 SQL만 다름
+// 스키마는 로비 채팅에 필요한 users/messages(+ 재전송 키)만 (버전 관리 마이그레이션은 Postgres 전용)
+type liteStore struct {
+	db         *sql.DB
+	dialect    historyDialect
+	schema     []string
+	upsertUser string
+}
+
+// db.dsn 예: "gotalk.db" 또는 "file:/data/gotalk.db?_pragma=journal_mode(WAL)"
+func newSQLiteStore() *liteStore {
+	dsn := cfg.DB.DSN
+	if dsn == "" { dsn = cfg.DB.Name + ".db" }
+	conn, err := sql.Open("sqlite", dsn)
+	if err != nil { fatal("db open failed", err) }
+	conn.SetMaxOpenConns(1) // SQLite는 쓰기 잠금이 파일 단위라 연결 하나로 직렬화
+	slog.Info("using sqlite store", "dsn", dsn)
+	return &liteStore{
+		db: conn,
+		dialect: historyDialect{
+			placeholder: qmPlaceholder,
+			timeExpr:    "strftime('%H:%M:%S', m.created_at, 'localtime')",
+			dayExpr:     "m.day_divider",
+			botExpr:     "''", // 수신 웹훅, 아바타는 Postgres 전용
+			avatarExpr:  "''",
+		},
+		schema: []string{
+			`CREATE TABLE IF NOT EXISTS users (
+				nickname TEXT PRIMARY KEY,
+				color_code TEXT,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				deleted_at TIMESTAMP,
+				recovery_code_hash TEXT,
+				invite_code TEXT,
+				welcomed_at TIMESTAMP,
+				banned_at TIMESTAMP,
+				ban_reason TEXT,
+				banned_until TIMESTAMP
+			)`,
+			`CREATE TABLE IF NOT EXISTS messages (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				content TEXT,
+				sender_pod TEXT,
+				sender_nick TEXT,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				parent_id INTEGER REFERENCES messages(id) ON DELETE CASCADE,
+				recipient_nick TEXT,
+				room_id INTEGER,
+				type TEXT NOT NULL DEFAULT 'message',
+				event TEXT,
+				group_key INTEGER,
+				day_divider TEXT
+			)`,
+			`CREATE INDEX IF NOT EXISTS messages_room_id_idx ON messages (room_id, id)`,
+			`CREATE TABLE IF NOT EXISTS message_client_ids (
+				sender_nick TEXT NOT NULL,
+				client_msg_id TEXT NOT NULL,
+				message_id INTEGER NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				PRIMARY KEY (sender_nick, client_msg_id)
+			)`,
+		},
+		upsertUser: `INSERT INTO users (nickname, color_code) VALUES (?, ?)
+			ON CONFLICT (nickname) DO UPDATE SET color_code = excluded.color_code`,
+	}
+}
+
+// db.dsn이 없으면 host/user/password/name으로 구성
+func newMySQLStore() *liteStore {
+	dsn := cfg.DB.DSN
+	if dsn == "" { dsn = fmt.Sprintf("%s:%s@tcp(%s:3306)/%s?parseTime=true", cfg.DB.User, cfg.DB.Password, cfg.DB.Host, cfg.DB.Name) }
+	conn, err := sql.Open("mysql", dsn)
+	if err != nil { fatal("db open failed", err) }
+	slog.Info("using mysql store", "host", cfg.DB.Host, "db", cfg.DB.Name)
+	return &liteStore{
+		db: conn,
+		dialect: historyDialect{
+			placeholder: qmPlaceholder,
+			timeExpr:    "DATE_FORMAT(m.created_at, '%H:%i:%s')",
+			dayExpr:     "DATE_FORMAT(m.day_divider, '%Y-%m-%d')",
+			botExpr:     "''",
+			avatarExpr:  "''",
+		},
+		schema: []string{
+			`CREATE TABLE IF NOT EXISTS users (
+				nickname VARCHAR(255) PRIMARY KEY,
+				color_code VARCHAR(32),
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				deleted_at TIMESTAMP NULL,
+				recovery_code_hash TEXT,
+				invite_code VARCHAR(64),
+				welcomed_at TIMESTAMP NULL,
+				banned_at TIMESTAMP NULL,
+				ban_reason TEXT,
+				banned_until TIMESTAMP NULL
+			) CHARACTER SET utf8mb4`,
+			`CREATE TABLE IF NOT EXISTS messages (
+				id INT AUTO_INCREMENT PRIMARY KEY,
+				content TEXT,
+				sender_pod VARCHAR(255),
+				sender_nick VARCHAR(255),
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				parent_id INT NULL,
+				recipient_nick VARCHAR(255) NULL,
+				room_id INT NULL,
+				type VARCHAR(16) NOT NULL DEFAULT 'message',
+				event JSON NULL,
+				group_key INT NULL,
+				day_divider DATE NULL,
+				INDEX messages_room_id_idx (room_id, id),
+				FOREIGN KEY (parent_id) REFERENCES messages(id) ON DELETE CASCADE
+			) CHARACTER SET utf8mb4`,
+			`CREATE TABLE IF NOT EXISTS message_client_ids (
+				sender_nick VARCHAR(255) NOT NULL,
+				client_msg_id VARCHAR(64) NOT NULL,
+				message_id INT NOT NULL,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				PRIMARY KEY (sender_nick, client_msg_id),
+				FOREIGN KEY (message_id) REFERENCES messages(id) ON DELETE CASCADE
+			) CHARACTER SET utf8mb4`,
+		},
+		upsertUser: `INSERT INTO users (nickname, color_code) VALUES (?, ?)
+			ON DUPLICATE KEY UPDATE color_code = VALUES(color_code)`,
+	}
+}
+
+func (s *liteStore) DB() *sql.DB { return s.db }
+
+func (s *liteStore) Migrate(ctx context.Context) error {
+	for _, stmt := range s.schema {
+		if _, err := s.db.ExecContext(ctx, stmt); err != nil { return err }
+	}
+	return nil
+}
+
+func (s *liteStore) User(ctx context.Context, nick string) (color string, deleted bool, err error) {
+	var deletedAt sql.NullTime
+	err = s.db.QueryRowContext(ctx, "SELECT COALESCE(color_code, ''), deleted_at FROM users WHERE nickname = ?", nick).Scan(&color, &deletedAt)
+	return color, deletedAt.Valid, err
+}
+
+func (s *liteStore) UpsertUser(ctx context.Context, nick, color string) error {
+	_, err := s.db.ExecContext(ctx, s.upsertUser, nick, color)
+	return err
+}
+
+func (s *liteStore) MessageRoom(ctx context.Context, id int) (sql.NullInt64, error) {
+	var roomID sql.NullInt64
+	err := s.db.QueryRowContext(ctx, "SELECT room_id FROM messages WHERE id = ? AND type = ?", id, messageTypeText).Scan(&roomID)
+	return roomID, err
+}
+
+func (s *liteStore) InsertMessage(ctx context.Context, m NewMessage) (int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil { return 0, err }
+	defer tx.Rollback()
+	res, err := tx.ExecContext(ctx,
+		"INSERT INTO messages (content, sender_pod, sender_nick, parent_id, room_id, group_key, day_divider) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		m.Content, m.SenderPod, m.SenderNick, m.ParentID, m.RoomID, m.GroupKey, m.DayDivider)
+	if err != nil { return 0, err }
+	id, err := res.LastInsertId()
+	if err != nil { return 0, err }
+	if err := recordClientMsgID(ctx, tx, s.dialect, m, int(id)); err != nil { return 0, err }
+	return int(id), tx.Commit()
+}
+
+func (s *liteStore) ClientMessage(ctx context.Context, nick, clientMsgID string) (Message, error) {
+	return queryClientMessage(ctx, s.db, s.dialect, nick, clientMsgID)
+}
+
+func (s *liteStore) ReplyCount(ctx context.Context, parentID int) int {
+	n := 0
+	s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM messages WHERE parent_id = ?", parentID).Scan(&n)
+	return n
+}
+
+func (s *liteStore) History(ctx context.Context, roomID *int, beforeID, afterID, limit int) ([]Message, error) {
+	return queryHistory(ctx, s.db, s.dialect, roomID, beforeID, afterID, limit)
+}
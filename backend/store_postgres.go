@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// 스키마는 migrations/ 의 버전 관리 마이그레이션으로 관리
+type postgresStore struct{ db *sql.DB }
+
+var postgresDialect = historyDialect{
+	placeholder: pgPlaceholder,
+	timeExpr:    "to_char(m.created_at, 'HH24:MI:SS')",
+	dayExpr:     "to_char(m.day_divider, 'YYYY-MM-DD')",
+	botExpr:     "CASE WHEN m.sender_type = 'bot' THEN 'bot' ELSE '' END",
+	avatarExpr:  "CASE WHEN u.deleted_at IS NULL AND u.avatar IS NOT NULL THEN '" + avatarPrefix + "' || u.avatar ELSE '' END",
+}
+
+func newPostgresStore() postgresStore {
+	openDB()
+	return postgresStore{db: db}
+}
+
+func openDB() {
+	dbHost := cfg.DB.Host
+	dbUser := cfg.DB.User
+	dbPwd := cfg.DB.Password
+	dbName := cfg.DB.Name
+
+	psqlInfo := fmt.Sprintf("host=%s user=%s password=%s dbname=postgres sslmode=disable", dbHost, dbUser, dbPwd)
+	tempDB, err := sql.Open("postgres", psqlInfo)
+	if err != nil { fatal("db open failed", err) }
+	var exists bool
+	tempDB.QueryRow("SELECT EXISTS(SELECT datname FROM pg_catalog.pg_database WHERE datname = $1)", dbName).Scan(&exists)
+	if !exists { tempDB.Exec(fmt.Sprintf("CREATE DATABASE %s", dbName)) }
+	tempDB.Close()
+
+	connStr := fmt.Sprintf("host=%s user=%s password=%s dbname=%s sslmode=disable", dbHost, dbUser, dbPwd, dbName)
+	db, err = sql.Open("postgres", connStr)
+	if err != nil { fatal("db open failed", err) }
+}
+
+func (s postgresStore) DB() *sql.DB                        { return s.db }
+func (s postgresStore) Migrate(ctx context.Context) error { return migrateUp(ctx) }
+
+func (s postgresStore) User(ctx context.Context, nick string) (color string, deleted bool, err error) {
+	err = s.db.QueryRowContext(ctx, "SELECT color_code, deleted_at IS NOT NULL FROM users WHERE nickname = $1", nick).Scan(&color, &deleted)
+	return
+}
+
+func (s postgresStore) UpsertUser(ctx context.Context, nick, color string) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO users (nickname, color_code) VALUES ($1, $2)
+		ON CONFLICT (nickname) DO UPDATE SET color_code = $2`,
+		nick, color)
+	return err
+}
+
+func (s postgresStore) MessageRoom(ctx context.Context, id int) (sql.NullInt64, error) {
+	var roomID sql.NullInt64
+	err := s.db.QueryRowContext(ctx, "SELECT room_id FROM messages WHERE id = $1 AND type = $2", id, messageTypeText).Scan(&roomID)
+	return roomID, err
+}
+
+func (s postgresStore) InsertMessage(ctx context.Context, m NewMessage) (int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil { return 0, err }
+	defer tx.Rollback()
+	var id int
+	err = tx.QueryRowContext(ctx,
+		"INSERT INTO messages (content, sender_pod, sender_nick, parent_id, room_id, group_key, day_divider) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id",
+		m.Content, m.SenderPod, m.SenderNick, m.ParentID, m.RoomID, m.GroupKey, m.DayDivider,
+	).Scan(&id)
+	if err != nil { return 0, err }
+	if err := recordClientMsgID(ctx, tx, postgresDialect, m, id); err != nil { return 0, err }
+	return id, tx.Commit()
+}
+
+func (s postgresStore) ClientMessage(ctx context.Context, nick, clientMsgID string) (Message, error) {
+	return queryClientMessage(ctx, s.db, postgresDialect, nick, clientMsgID)
+}
+
+func (s postgresStore) ReplyCount(ctx context.Context, parentID int) int {
+	n := 0
+	s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM messages WHERE parent_id = $1", parentID).Scan(&n)
+	return n
+}
+
+func (s postgresStore) History(ctx context.Context, roomID *int, beforeID, afterID, limit int) ([]Message, error) {
+	return queryHistory(ctx, s.db, postgresDialect, roomID, beforeID, afterID, limit)
+}
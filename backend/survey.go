@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/lib/pq"
+)
+
+// [설문] 관리자가 만든 한 문항 설문을 접속 중인 사용자 일부(sample_rate)에게 `event: survey`로 띄움
+// 메시지로 저장되지 않는 일회성 이벤트라 연결이 느리면 버려짐 (저우선)
+// 각 파드가 자기 연결에서 표본을 뽑고, survey_deliveries에 먼저 기록한 파드만 보내 중복 표시를 막음
+
+// 설문 배포용 브로커 주제 (모든 파드가 구독)
+const subjectSurvey = "chat.survey"
+
+const surveyMaxAnswer = 1000
+
+type Survey struct {
+	ID         int        `json:"id"`
+	Question   string     `json:"question"`
+	Options    []string   `json:"options"` // 비어 있으면 자유 응답
+	SampleRate float64    `json:"sample_rate"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ClosedAt   *time.Time `json:"closed_at,omitempty"`
+}
+
+type SurveyResults struct {
+	Survey    Survey         `json:"survey"`
+	Delivered int            `json:"delivered"`
+	Responses int            `json:"responses"`
+	Rate      float64        `json:"response_rate"`
+	Counts    map[string]int `json:"counts,omitempty"`  // 선택형: 보기별 응답 수
+	Answers   []string       `json:"answers,omitempty"` // 자유 응답: 최근 응답 (최대 100개)
+}
+
+// [설문 생성] POST /admin/surveys (question, options=a|b|c, sample_rate=0.2) - 만들자마자 배포
+func adminCreateSurveyHandler(w http.ResponseWriter, r *http.Request) {
+	s := Survey{Question: strings.TrimSpace(r.FormValue("question")), Options: []string{}, SampleRate: 1}
+	if s.Question == "" { respondError(w, r, http.StatusBadRequest, errors.New("question required")); return }
+	for _, o := range strings.Split(r.FormValue("options"), "|") {
+		if o = strings.TrimSpace(o); o != "" { s.Options = append(s.Options, o) }
+	}
+	if len(s.Options) == 1 { respondError(w, r, http.StatusBadRequest, errors.New("a choice survey needs at least two options")); return }
+	if v := r.FormValue("sample_rate"); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || f <= 0 || f > 1 { respondError(w, r, http.StatusBadRequest, errors.New("sample_rate must be in (0, 1]")); return }
+		s.SampleRate = f
+	}
+
+	err := db.QueryRow("INSERT INTO surveys (question, options, sample_rate) VALUES ($1, $2, $3) RETURNING id, created_at",
+		s.Question, pq.Array(s.Options), s.SampleRate).Scan(&s.ID, &s.CreatedAt)
+	if err != nil { respondError(w, r, 500, err); return }
+
+	data, _ := json.Marshal(s)
+	if err := broker.Publish(r.Context(), subjectSurvey, data); err != nil { respondError(w, r, http.StatusServiceUnavailable, err); return }
+	slog.InfoContext(r.Context(), "survey created", "survey_id", s.ID, "sample_rate", s.SampleRate)
+	writeJSON(w, http.StatusCreated, s)
+}
+
+// 이 파드에 접속한 사용자 중 표본을 골라 설문 표시
+func handleSurveyEvent(m BrokerMsg) {
+	var s Survey
+	if !fullFeatureStore() { return }
+	if err := json.Unmarshal(m.Data, &s); err != nil { return }
+
+	mutex.Lock()
+	nicks := map[string]bool{}
+	for _, c := range clients {
+		if c.nick != "" { nicks[c.nick] = true }
+	}
+	mutex.Unlock()
+
+	prompt, _ := json.Marshal(s)
+	sent := 0
+	for nick := range nicks {
+		if rand.Float64() >= s.SampleRate { continue }
+		// 같은 사용자가 여러 파드에 붙어 있어도 먼저 기록한 파드만 보냄
+		res, err := db.ExecContext(m.Ctx, `INSERT INTO survey_deliveries (survey_id, nickname) VALUES ($1, $2)
+			ON CONFLICT DO NOTHING`, s.ID, nick)
+		if err != nil { slog.WarnContext(m.Ctx, "survey delivery failed", "survey_id", s.ID, "nick", nick, "err", err); continue }
+		if n, _ := res.RowsAffected(); n == 0 { continue }
+		out := newOutbound(m.Ctx, string(prompt))
+		out.Event, out.LowPriority = "survey", true
+		sent += sendToNick(nick, out)
+	}
+	slog.InfoContext(m.Ctx, "survey prompted", "survey_id", s.ID, "connections", sent)
+}
+
+// [설문 응답] POST /surveys/{id}/respond (nick, answer) - 설문을 받은 사용자만, 다시 보내면 덮어씀
+func surveyRespondHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	nick := r.FormValue("nick")
+	answer := strings.TrimSpace(r.FormValue("answer"))
+	if err != nil || nick == "" || answer == "" { respondError(w, r, http.StatusBadRequest, errors.New("survey id, nick and answer required")); return }
+	if utf8.RuneCountInString(answer) > surveyMaxAnswer { respondError(w, r, http.StatusBadRequest, errors.New("answer too long")); return }
+
+	s, err := loadSurvey(r.Context(), id)
+	if err == sql.ErrNoRows { respondError(w, r, http.StatusNotFound, errors.New("survey not found")); return }
+	if err != nil { respondError(w, r, 500, err); return }
+	if s.ClosedAt != nil { respondError(w, r, http.StatusGone, errors.New("survey closed")); return }
+	if len(s.Options) > 0 && !containsString(s.Options, answer) { respondError(w, r, http.StatusBadRequest, errors.New("answer must be one of the options")); return }
+
+	var delivered bool
+	db.QueryRow("SELECT EXISTS(SELECT 1 FROM survey_deliveries WHERE survey_id = $1 AND nickname = $2)", id, nick).Scan(&delivered)
+	if !delivered { respondError(w, r, http.StatusForbidden, errors.New("survey was not offered to this user")); return }
+
+	_, err = db.Exec(`INSERT INTO survey_responses (survey_id, nickname, answer) VALUES ($1, $2, $3)
+		ON CONFLICT (survey_id, nickname) DO UPDATE SET answer = $3, responded_at = CURRENT_TIMESTAMP`, id, nick, answer)
+	if err != nil { respondError(w, r, 500, err); return }
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func loadSurvey(ctx context.Context, id int) (Survey, error) {
+	var s Survey
+	err := db.QueryRowContext(ctx, "SELECT id, question, options, sample_rate, created_at, closed_at FROM surveys WHERE id = $1", id).
+		Scan(&s.ID, &s.Question, pq.Array(&s.Options), &s.SampleRate, &s.CreatedAt, &s.ClosedAt)
+	return s, err
+}
+
+func containsString(list []string, v string) bool {
+	for _, s := range list {
+		if s == v { return true }
+	}
+	return false
+}
+
+// [설문 목록] GET /admin/surveys
+func adminSurveysHandler(w http.ResponseWriter, r *http.Request) {
+	rows, err := db.Query("SELECT id, question, options, sample_rate, created_at, closed_at FROM surveys ORDER BY id DESC")
+	if err != nil { respondError(w, r, 500, err); return }
+	defer rows.Close()
+	list := []Survey{}
+	for rows.Next() {
+		var s Survey
+		rows.Scan(&s.ID, &s.Question, pq.Array(&s.Options), &s.SampleRate, &s.CreatedAt, &s.ClosedAt)
+		list = append(list, s)
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+// [설문 마감] POST /admin/surveys/{id}/close
+func adminCloseSurveyHandler(w http.ResponseWriter, r *http.Request) {
+	res, err := db.Exec("UPDATE surveys SET closed_at = CURRENT_TIMESTAMP WHERE id = $1 AND closed_at IS NULL", r.PathValue("id"))
+	if err != nil { respondError(w, r, 500, err); return }
+	if n, _ := res.RowsAffected(); n == 0 { respondError(w, r, http.StatusNotFound, errors.New("open survey not found")); return }
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// [설문 결과] GET /admin/surveys/{id}/results - 응답률과 보기별 집계(선택형) 또는 최근 응답(자유형)
+func adminSurveyResultsHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil { respondError(w, r, http.StatusBadRequest, errors.New("invalid survey id")); return }
+	s, err := loadSurvey(r.Context(), id)
+	if err == sql.ErrNoRows { respondError(w, r, http.StatusNotFound, errors.New("survey not found")); return }
+	if err != nil { respondError(w, r, 500, err); return }
+
+	res := SurveyResults{Survey: s}
+	db.QueryRow("SELECT COUNT(*) FROM survey_deliveries WHERE survey_id = $1", id).Scan(&res.Delivered)
+	db.QueryRow("SELECT COUNT(*) FROM survey_responses WHERE survey_id = $1", id).Scan(&res.Responses)
+	if res.Delivered > 0 { res.Rate = float64(res.Responses) / float64(res.Delivered) }
+
+	if len(s.Options) > 0 {
+		res.Counts = map[string]int{}
+		for _, o := range s.Options {
+			res.Counts[o] = 0
+		}
+		rows, err := db.Query("SELECT answer, COUNT(*) FROM survey_responses WHERE survey_id = $1 GROUP BY answer", id)
+		if err != nil { respondError(w, r, 500, err); return }
+		defer rows.Close()
+		for rows.Next() {
+			var answer string
+			var n int
+			rows.Scan(&answer, &n)
+			res.Counts[answer] = n
+		}
+	} else {
+		rows, err := db.Query("SELECT answer FROM survey_responses WHERE survey_id = $1 ORDER BY responded_at DESC LIMIT 100", id)
+		if err != nil { respondError(w, r, 500, err); return }
+		defer rows.Close()
+		res.Answers = []string{}
+		for rows.Next() {
+			var answer string
+			rows.Scan(&answer)
+			res.Answers = append(res.Answers, answer)
+		}
+	}
+	writeJSON(w, http.StatusOK, res)
+}
@@ -0,0 +1,6 @@
+package main
+
+// 공지/자동 메시지를 보내는 내장 시스템 계정
+func systemNick() string {
+	return cfg.Welcome.SystemNick
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+type ThreadResponse struct {
+	Parent  Message   `json:"parent"`
+	Replies []Message `json:"replies"`
+	HasMore bool      `json:"has_more"`
+}
+
+// [스레드 조회] GET /messages/{id}/thread?after_id=&limit=
+// 원글과 답글을 오래된 순으로 반환 (after_id 기준 페이지네이션)
+// 비공개 방 스레드는 확인된 신원이 멤버일 때만, DM 스레드는 보낸/받은 사람만 (아니면 없는 메시지처럼 404)
+func threadHandler(w http.ResponseWriter, r *http.Request) {
+	parentID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil { respondError(w, r, http.StatusBadRequest, errors.New("invalid message id")); return }
+
+	limit := 30
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 && l <= 100 {
+		limit = l
+	}
+	afterID, _ := strconv.Atoi(r.URL.Query().Get("after_id"))
+
+	resp := ThreadResponse{Parent: Message{Type: messageTypeText}}
+	var roomID sql.NullInt64
+	var recipient sql.NullString
+	err = db.QueryRow(`
+		SELECT m.id, m.content, m.sender_pod, m.sender_nick,
+			COALESCE(u.color_code, '#ffffff'), to_char(m.created_at, 'HH24:MI:SS'),
+			(SELECT COUNT(*) FROM messages r WHERE r.parent_id = m.id), m.room_id, m.recipient_nick
+		FROM messages m
+		LEFT JOIN users u ON m.sender_nick = u.nickname
+		WHERE m.id = $1 AND m.type = 'message'`, parentID).Scan(
+		&resp.Parent.ID, &resp.Parent.Content, &resp.Parent.SenderPod, &resp.Parent.SenderNick,
+		&resp.Parent.SenderColor, &resp.Parent.Time, &resp.Parent.ReplyCount, &roomID, &recipient)
+	if err == sql.ErrNoRows { respondError(w, r, http.StatusNotFound, errors.New("message not found")); return }
+	if err != nil { respondError(w, r, 500, err); return }
+	if recipient.Valid {
+		viewer, _ := identityFrom(r.Context())
+		visible, err := messageVisibleTo(parentID, viewer)
+		if err != nil { respondError(w, r, 500, err); return }
+		if !visible { respondError(w, r, http.StatusNotFound, errors.New("message not found")); return }
+		resp.Parent.RecipientNick = recipient.String
+	}
+	if roomID.Valid {
+		id := int(roomID.Int64)
+		if !roomAccessOK(w, r, id) { return }
+		resp.Parent.RoomID = &id
+	}
+
+	// 다음 페이지 존재 여부 판단을 위해 limit+1개 조회
+	rows, err := db.Query(`
+		SELECT m.id, m.content, m.sender_pod, m.sender_nick,
+			COALESCE(u.color_code, '#ffffff'), to_char(m.created_at, 'HH24:MI:SS')
+		FROM messages m
+		LEFT JOIN users u ON m.sender_nick = u.nickname
+		WHERE m.parent_id = $1 AND m.id > $2
+		ORDER BY m.id ASC LIMIT $3`, parentID, afterID, limit+1)
+	if err != nil { respondError(w, r, 500, err); return }
+	defer rows.Close()
+
+	resp.Replies = []Message{}
+	for rows.Next() {
+		m := Message{Type: messageTypeText}
+		rows.Scan(&m.ID, &m.Content, &m.SenderPod, &m.SenderNick, &m.SenderColor, &m.Time)
+		m.ParentID = &parentID
+		resp.Replies = append(resp.Replies, m)
+	}
+	if len(resp.Replies) > limit {
+		resp.Replies = resp.Replies[:limit]
+		resp.HasMore = true
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// [스레드 활동 요약] 방장이 켜 두면 주기마다 그동안 답글이 많이 달린 스레드를 골라
+// "🧵 Thread activity: 12 replies in "…"" 이벤트를 본 타임라인에 올림 (답글은 타임라인 묶음에서 빠져 묻히기 쉬움)
+// 스케줄러 작업이 rooms의 마지막 요약 시각을 먼저 갱신해 가져가므로 파드가 여럿이어도 한 번만 올라감
+const (
+	eventThreadDigest = "thread_digest"
+
+	threadDigestMinInterval = 15 * time.Minute
+	threadDigestMaxThreads  = 5
+	threadDigestExcerpt     = 40 // 원글 발췌 길이 (글자)
+)
+
+// [스레드 요약 설정] POST /rooms/{id}/thread-digest (nick, interval=6h, min_replies=3) - 방장/운영진, interval=0이면 끔
+func setThreadDigestHandler(w http.ResponseWriter, r *http.Request) {
+	roomID, ok := roomIDFrom(r)
+	nick := r.FormValue("nick")
+	if !ok || nick == "" { respondError(w, r, http.StatusBadRequest, errors.New("room id and nick required")); return }
+	if role := roomRole(roomID, nick); role != roomRoleOwner && role != roomRoleModerator {
+		respondError(w, r, http.StatusForbidden, errors.New("only owners and moderators can change the thread digest"))
+		return
+	}
+	var interval *int
+	if v := r.FormValue("interval"); v != "" && v != "0" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < threadDigestMinInterval { respondError(w, r, http.StatusBadRequest, fieldError{"interval", "must be a duration of at least 15m, or 0 to turn off"}); return }
+		secs := int(d.Seconds())
+		interval = &secs
+	}
+	minReplies := 3
+	if v := r.FormValue("min_replies"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 { respondError(w, r, http.StatusBadRequest, fieldError{"min_replies", "must be a positive integer"}); return }
+		minReplies = n
+	}
+
+	// 새로 켜면 지금부터 주기를 셈
+	res, err := db.Exec(`UPDATE rooms SET thread_digest_interval = $2, thread_digest_min_replies = $3, thread_digest_at = CURRENT_TIMESTAMP
+		WHERE id = $1`, roomID, interval, minReplies)
+	if err != nil { respondError(w, r, 500, err); return }
+	if n, _ := res.RowsAffected(); n == 0 { respondError(w, r, http.StatusNotFound, errors.New("room not found")); return }
+	resp := map[string]any{"interval": "0", "min_replies": minReplies}
+	if interval != nil { resp["interval"] = (time.Duration(*interval) * time.Second).String() }
+	writeJSON(w, http.StatusOK, resp)
+}
+
+type threadActivity struct {
+	ID      int    `json:"id"`
+	Replies int    `json:"replies"`
+	Excerpt string `json:"excerpt"`
+}
+
+// 주기가 된 방마다 지난 주기 동안의 스레드 활동을 요약해 올림
+func postThreadDigests() {
+	ctx := context.Background()
+	rows, err := db.QueryContext(ctx, `
+		UPDATE rooms SET thread_digest_at = CURRENT_TIMESTAMP
+		WHERE thread_digest_interval IS NOT NULL
+		  AND (thread_digest_at IS NULL OR thread_digest_at <= CURRENT_TIMESTAMP - thread_digest_interval * INTERVAL '1 second')
+		RETURNING id, thread_digest_interval, thread_digest_min_replies`)
+	if err != nil { slog.Warn("thread digest query failed", "err", err); return }
+	type dueRoom struct{ id, interval, minReplies int }
+	var due []dueRoom
+	for rows.Next() {
+		var d dueRoom
+		rows.Scan(&d.id, &d.interval, &d.minReplies)
+		due = append(due, d)
+	}
+	rows.Close()
+
+	for _, d := range due {
+		threads, err := activeThreads(ctx, d.id, d.interval, d.minReplies)
+		if err != nil { slog.Warn("thread digest failed", "room_id", d.id, "err", err); continue }
+		if len(threads) == 0 { continue }
+		parts := make([]string, len(threads))
+		for i, t := range threads {
+			parts[i] = fmt.Sprintf("%d replies in \"%s\"", t.Replies, t.Excerpt)
+		}
+		roomID := d.id
+		recordEvent(ctx, &roomID, RoomEvent{Kind: eventThreadDigest, Actor: systemNick(), Data: map[string]any{"threads": threads}},
+			"🧵 Thread activity: "+strings.Join(parts, ", "))
+		slog.Info("thread digest posted", "room_id", d.id, "threads", len(threads))
+	}
+}
+
+// 지난 intervalSecs초 동안 답글이 minReplies개 이상 달린 스레드 (많은 순)
+func activeThreads(ctx context.Context, roomID, intervalSecs, minReplies int) ([]threadActivity, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT p.id, p.content, COUNT(*) FROM messages r JOIN messages p ON p.id = r.parent_id
+		WHERE p.room_id = $1 AND p.type = $2 AND r.created_at > CURRENT_TIMESTAMP - $3 * INTERVAL '1 second'
+		GROUP BY p.id, p.content HAVING COUNT(*) >= $4
+		ORDER BY COUNT(*) DESC, p.id LIMIT $5`, roomID, messageTypeText, intervalSecs, minReplies, threadDigestMaxThreads)
+	if err != nil { return nil, err }
+	defer rows.Close()
+	var list []threadActivity
+	for rows.Next() {
+		var t threadActivity
+		var content string
+		rows.Scan(&t.ID, &content, &t.Replies)
+		t.Excerpt = content
+		if r := []rune(content); len(r) > threadDigestExcerpt { t.Excerpt = string(r[:threadDigestExcerpt]) + "…" }
+		list = append(list, t)
+	}
+	return list, rows.Err()
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"expvar"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// 엔드포인트별 마감/SLA 정책
+type endpointPolicy struct {
+	Timeout time.Duration // 이 시간을 넘기면 503으로 끊음
+	SLA     time.Duration // 이 시간을 넘기면 위반으로 기록 (응답은 그대로)
+}
+
+var (
+	defaultPolicy endpointPolicy
+	// 경로별 재정의 (http.endpoint_timeouts / http.endpoint_slas)
+	endpointPolicies = map[string]endpointPolicy{}
+	// 장기 연결 엔드포인트는 마감을 걸지 않음
+	longLivedPaths = map[string]bool{"/stream": true, "/ws": true, "/poll": true, "/admin/export": true, "/admin/import": true}
+
+	slaViolations = expvar.NewMap("sla_violations")
+	timeouts      = expvar.NewMap("endpoint_timeouts")
+)
+
+// 임베드 위젯 스트림(/embed/{room}/stream)도 장기 연결
+func isLongLived(path string) bool {
+	return longLivedPaths[path] || strings.HasPrefix(path, "/embed/") && strings.HasSuffix(path, "/stream")
+}
+
+func initEndpointPolicies() {
+	defaultPolicy = endpointPolicy{Timeout: time.Duration(cfg.HTTP.Timeout), SLA: time.Duration(cfg.HTTP.SLA)}
+	for path, d := range cfg.HTTP.EndpointTimeouts {
+		p := policyFor(path)
+		p.Timeout = time.Duration(d)
+		endpointPolicies[path] = p
+	}
+	for path, d := range cfg.HTTP.EndpointSLAs {
+		p := policyFor(path)
+		p.SLA = time.Duration(d)
+		endpointPolicies[path] = p
+	}
+}
+
+func policyFor(path string) endpointPolicy {
+	if p, ok := endpointPolicies[path]; ok { return p }
+	return defaultPolicy
+}
+
+// [타임아웃/SLA 미들웨어] 스트리밍 경로는 통과, 나머지는 마감 적용 후 SLA 초과 시 기록
+func timeoutMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isLongLived(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		policy := policyFor(r.URL.Path)
+		start := time.Now()
+		http.TimeoutHandler(next, policy.Timeout, `{"error":"request timed out","code":"dependency_unavailable","retryable":true}`).ServeHTTP(w, r)
+		elapsed := time.Since(start)
+
+		if elapsed >= policy.Timeout {
+			timeouts.Add(r.URL.Path, 1)
+			slog.WarnContext(r.Context(), "request timed out",
+				"path", r.URL.Path, "method", r.Method, "duration", elapsed, "timeout", policy.Timeout)
+		} else if elapsed > policy.SLA {
+			slaViolations.Add(r.URL.Path, 1)
+			slog.WarnContext(r.Context(), "request exceeded SLA",
+				"path", r.URL.Path, "method", r.Method, "duration", elapsed, "sla", policy.SLA)
+		}
+	})
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// [타이머] /timer 10m standup - 시스템 봇이 "남은 시간" 글을 올리고, 스케줄러가 그 글을 고쳐 가며
+// 남은 시간을 갱신(1분 넘게 남으면 분 단위, 그 뒤로는 10초 단위)하다가 끝나면 시작한 사람을 멘션해 알림
+// 진행 상태는 timers 테이블에 있어 파드가 재시작돼도 이어짐 (Postgres 전용)
+const (
+	timerMinDuration = 10 * time.Second
+	timerMaxDuration = 24 * time.Hour
+	timerMaxLabel    = 100
+	timerMaxActive   = 5 // 사람당 동시에 돌릴 수 있는 타이머 수
+	timerTick        = 5 * time.Second
+)
+
+var errTimerUnavailable = errors.New("timers require the postgres store")
+
+func init() {
+	registerCommand(simpleCommand{"timer", "/timer <duration> [label] - start a countdown, e.g. /timer 10m standup", startTimer})
+}
+
+func startTimer(call CommandCall) (CommandResult, error) {
+	if !fullFeatureStore() { return CommandResult{}, errTimerUnavailable }
+	arg, label, _ := strings.Cut(call.Args, " ")
+	d, err := time.ParseDuration(arg)
+	if err != nil || d < timerMinDuration || d > timerMaxDuration { return CommandResult{}, fieldError{"msg", "usage: /timer <duration 10s-24h> [label]"} }
+	label = strings.TrimSpace(label)
+	if utf8.RuneCountInString(label) > timerMaxLabel { return CommandResult{}, fieldError{"msg", "timer label too long"} }
+
+	var active int
+	db.QueryRowContext(call.Ctx, "SELECT COUNT(*) FROM timers WHERE created_by = $1 AND done_at IS NULL", call.Nick).Scan(&active)
+	if active >= timerMaxActive { return CommandResult{}, fieldError{"msg", fmt.Sprintf("at most %d timers can run at once", timerMaxActive)} }
+
+	endsAt := time.Now().Add(d)
+	msg, err := postBotMessage(call.Ctx, call.RoomID, systemNick(), timerText(label, call.Nick, d))
+	if err != nil { return CommandResult{}, err }
+	_, err = db.ExecContext(call.Ctx, "INSERT INTO timers (message_id, room_id, label, created_by, ends_at) VALUES ($1, $2, $3, $4, $5)",
+		msg.ID, call.RoomID, label, call.Nick, endsAt)
+	if err != nil { return CommandResult{}, err }
+	slog.InfoContext(call.Ctx, "timer started", "nick", call.Nick, "duration", d, "message_id", msg.ID)
+	return CommandResult{Reply: "Timer started: " + formatRemaining(d)}, nil
+}
+
+// 진행 중 표시 문구 (remaining <= 0이면 완료)
+func timerText(label, nick string, remaining time.Duration) string {
+	name := label
+	if name == "" { name = "Timer" }
+	if remaining <= 0 { return fmt.Sprintf("⏱ %s — done (started by %s)", name, nick) }
+	return fmt.Sprintf("⏱ %s — %s left (started by %s)", name, formatRemaining(remaining), nick)
+}
+
+// 1분 넘게 남았으면 분 단위로 올림, 그 아래는 10초 단위로 올림
+func formatRemaining(d time.Duration) string {
+	if d > time.Minute {
+		m := int((d + time.Minute - 1) / time.Minute)
+		if m >= 60 { return fmt.Sprintf("%dh%02dm", m/60, m%60) }
+		return fmt.Sprintf("%dm", m)
+	}
+	return fmt.Sprintf("%ds", int((d+10*time.Second-1)/(10*time.Second))*10)
+}
+
+// [타이머 갱신] 스케줄러 작업: 표시 문구가 바뀐 타이머만 고치고, 끝난 타이머는 완료 처리
+func tickTimers() {
+	ctx := context.Background()
+	rows, err := db.QueryContext(ctx, `
+		SELECT t.id, t.message_id, t.room_id, t.label, t.created_by, t.ends_at, m.content
+		FROM timers t JOIN messages m ON m.id = t.message_id
+		WHERE t.done_at IS NULL`)
+	if err != nil { slog.Warn("timer query failed", "err", err); return }
+	type timer struct {
+		id, messageID int
+		roomID        *int
+		label, nick   string
+		endsAt        time.Time
+		content       string
+	}
+	var active []timer
+	for rows.Next() {
+		var t timer
+		rows.Scan(&t.id, &t.messageID, &t.roomID, &t.label, &t.nick, &t.endsAt, &t.content)
+		active = append(active, t)
+	}
+	rows.Close()
+
+	for _, t := range active {
+		remaining := time.Until(t.endsAt)
+		if text := timerText(t.label, t.nick, remaining); text != t.content {
+			if err := editMessage(ctx, t.messageID, t.roomID, text); err != nil { slog.Warn("timer update failed", "timer_id", t.id, "err", err); continue }
+		}
+		if remaining > 0 { continue }
+
+		if _, err := db.ExecContext(ctx, "UPDATE timers SET done_at = CURRENT_TIMESTAMP WHERE id = $1", t.id); err != nil { continue }
+		name := t.label
+		if name == "" { name = "timer" }
+		msg, err := postBotMessage(ctx, t.roomID, systemNick(), fmt.Sprintf("⏰ @%s %s is up!", t.nick, name))
+		if err != nil { slog.Warn("timer completion post failed", "timer_id", t.id, "err", err); continue }
+		notifyMentions(msg)
+		slog.Info("timer finished", "timer_id", t.id, "nick", t.nick)
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"crypto/tls"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// [내장 TLS] 앞단에 TLS를 끝내 주는 인그레스가 없을 때 직접 HTTPS로 서비스
+// - tls.cert_file/key_file: 가지고 있는 인증서 사용
+// - tls.autocert_domains: Let's Encrypt에서 자동 발급/갱신 (캐시 디렉터리는 여러 파드면 공유 볼륨)
+// - tls.redirect_port: 두 번째 리스너에서 HTTP → HTTPS 리다이렉트 (autocert면 HTTP-01 챌린지도 처리)
+// 둘 다 비우면 지금처럼 평문 HTTP
+
+func tlsEnabled() bool {
+	return len(cfg.TLS.AutocertDomains) > 0 || cfg.TLS.CertFile != ""
+}
+
+// 설정에 따라 HTTP 또는 HTTPS로 서비스 (반환되면 서버가 멈춘 것)
+func serve(handler http.Handler) error {
+	srv := &http.Server{Addr: ":" + cfg.Port, Handler: handler}
+	if !tlsEnabled() {
+		slog.Info("server started", "port", cfg.Port, "registration_mode", cfg.Registration.Mode)
+		return srv.ListenAndServe()
+	}
+
+	var redirect http.Handler = http.HandlerFunc(redirectToHTTPS)
+	srv.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	if len(cfg.TLS.AutocertDomains) > 0 {
+		m := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(cfg.TLS.AutocertDomains...),
+			Cache:      autocert.DirCache(cfg.TLS.AutocertCacheDir),
+			Email:      cfg.TLS.AutocertEmail,
+		}
+		srv.TLSConfig = m.TLSConfig()
+		srv.TLSConfig.MinVersion = tls.VersionTLS12
+		redirect = m.HTTPHandler(redirect)
+	}
+
+	if cfg.TLS.RedirectPort != "" {
+		go func() {
+			rs := &http.Server{Addr: ":" + cfg.TLS.RedirectPort, Handler: redirect, ReadHeaderTimeout: 10 * time.Second}
+			if err := rs.ListenAndServe(); err != nil { fatal("http redirect server stopped", err) }
+		}()
+	}
+	slog.Info("server started", "port", cfg.Port, "tls", true, "autocert_domains", cfg.TLS.AutocertDomains,
+		"redirect_port", cfg.TLS.RedirectPort, "registration_mode", cfg.Registration.Mode)
+	// autocert면 인증서는 TLSConfig.GetCertificate가 공급
+	return srv.ListenAndServeTLS(cfg.TLS.CertFile, cfg.TLS.KeyFile)
+}
+
+// 같은 호스트의 HTTPS 포트로 영구 이동 (443이면 포트 생략)
+func redirectToHTTPS(w http.ResponseWriter, r *http.Request) {
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil { host = h }
+	if cfg.Port != "443" { host = net.JoinHostPort(host, cfg.Port) }
+	http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusMovedPermanently)
+}
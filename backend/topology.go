@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+)
+
+// [클러스터 토폴로지] 각 노드가 cluster.heartbeat_interval마다 멤버십 주제로 자기 상태를 방송하고
+// 모든 노드가 받은 하트비트를 모아 두어 GET /admin/cluster가 어느 파드로 들어오든 같은 목록을 보여 줌
+// node_ttl 동안 하트비트가 없으면 healthy=false, clusterForgetAfter가 지나면 목록에서 뺌
+
+// 멤버십 하트비트 주제
+const subjectClusterHeartbeat = "cluster.heartbeat"
+
+// 멈춘 노드를 목록에 unhealthy로 남겨 두는 시간 (스케일 인 뒤 사라지도록)
+const clusterForgetAfter = 10 * time.Minute
+
+// 빌드 때 -ldflags "-X main.version=..."로 넣음, 없으면 VCS 리비전
+var version = "dev"
+
+var (
+	clusterMu    sync.Mutex
+	clusterNodes = map[string]ClusterNode{} // 노드 ID → 마지막 하트비트
+)
+
+type ClusterNode struct {
+	NodeID          string    `json:"node_id"`
+	Instance        string    `json:"instance"`
+	Version         string    `json:"version"`
+	Protocol        string    `json:"protocol"`
+	StartedAt       time.Time `json:"started_at"`
+	Uptime          string    `json:"uptime"`
+	Connections     int       `json:"connections"`
+	Broker          string    `json:"broker"` // broker.backend
+	BrokerConnected bool      `json:"broker_connected"`
+	BrokerStatus    string    `json:"broker_status"` // NATS면 연결 상태 (CONNECTED, RECONNECTING 등)
+	JetStream       bool      `json:"jetstream"`
+	LastSeen        time.Time `json:"last_seen"`
+	Healthy         bool      `json:"healthy"`
+}
+
+func init() {
+	if version != "dev" { return }
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range info.Settings {
+			if s.Key == "vcs.revision" && len(s.Value) >= 12 { version = s.Value[:12] }
+		}
+	}
+}
+
+func localClusterNode() ClusterNode {
+	n := ClusterNode{
+		NodeID: nodeID, Instance: instanceID, Version: version, Protocol: protocolVersion, StartedAt: startedAt,
+		Uptime: time.Since(startedAt).Round(time.Second).String(), Connections: len(localConns()),
+		Broker: cfg.Broker.Backend, BrokerConnected: broker.Connected(), LastSeen: time.Now(),
+	}
+	n.BrokerStatus = "disconnected"
+	if n.BrokerConnected { n.BrokerStatus = "connected" }
+	if nb, ok := broker.(*natsBroker); ok {
+		n.BrokerStatus = nb.nc.Status().String()
+		n.JetStream = nb.js != nil
+	}
+	return n
+}
+
+// 멤버십 레지스트리 갱신과 같은 주기로 호출 (cluster.go)
+func publishClusterHeartbeat() {
+	n := localClusterNode()
+	recordClusterNode(n) // 브로커가 끊겨도 자기 자신은 보이도록
+	data, _ := json.Marshal(n)
+	if err := broker.Publish(context.Background(), subjectClusterHeartbeat, data); err != nil {
+		slog.Warn("cluster heartbeat publish failed", "err", err)
+	}
+}
+
+func handleClusterHeartbeat(m BrokerMsg) {
+	var n ClusterNode
+	if json.Unmarshal(m.Data, &n) != nil || n.NodeID == "" { return }
+	n.LastSeen = time.Now() // 노드 간 시계 차이 대신 받은 시각 기준
+	recordClusterNode(n)
+}
+
+func recordClusterNode(n ClusterNode) {
+	clusterMu.Lock()
+	defer clusterMu.Unlock()
+	if prev, ok := clusterNodes[n.NodeID]; ok && prev.Instance != n.Instance && prev.StartedAt.After(n.StartedAt) {
+		return // 같은 노드 ID의 이전 프로세스가 늦게 보낸 하트비트
+	}
+	clusterNodes[n.NodeID] = n
+}
+
+func clusterTopology() []ClusterNode {
+	clusterMu.Lock()
+	defer clusterMu.Unlock()
+	ttl := time.Duration(cfg.Cluster.NodeTTL)
+	list := []ClusterNode{}
+	for id, n := range clusterNodes {
+		age := time.Since(n.LastSeen)
+		if age > clusterForgetAfter { delete(clusterNodes, id); continue }
+		n.Healthy = age < ttl && n.BrokerConnected
+		list = append(list, n)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].NodeID < list[j].NodeID })
+	return list
+}
+
+// [클러스터] GET /admin/cluster - 노드별 버전/업타임/연결 수/브로커 상태와 요약
+func adminClusterHandler(w http.ResponseWriter, r *http.Request) {
+	nodes := clusterTopology()
+	versions := map[string]int{}
+	protocols := map[string]int{}
+	healthy := 0
+	for _, n := range nodes {
+		versions[n.Version]++
+		protocols[n.Protocol]++
+		if n.Healthy { healthy++ }
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"node_id":        nodeID, // 응답한 노드
+		"nodes":          nodes,
+		"healthy":        healthy,
+		"total":          len(nodes),
+		"versions":       versions,
+		"protocols":      protocols,
+		"all_healthy":    healthy == len(nodes),
+		"single_version": len(versions) <= 1,
+	})
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// [트레이싱] send → DB insert → 브로커 publish → broadcaster → SSE write 구간을 스팬으로 연결
+// 파드 간 전파는 브로커 메시지 헤더(W3C traceparent)로 함
+var tracer = otel.Tracer("github.com/colaH16/gotalk")
+
+// 브로드캐스터/클라이언트 채널로 흐르는 메시지 (트레이스 문맥 포함)
+type outbound struct {
+	Data        string
+	Ctx         context.Context
+	Disconnect  bool      // 전달 후 스트림 종료
+	LowPriority bool      // 타이핑/접속 표시처럼 연결이 느리면 버려도 되는 이벤트
+	LiteData    string    // 경량 모드 연결용 페이로드 (비어 있으면 Data 사용)
+	Event       string    // SSE 이벤트 이름 (비어 있으면 기본 message)
+	ID          int       // SSE id (이어받기 기준 메시지 ID, 0이면 생략)
+	Sender      string    // 보낸 사람 (차단한 연결에는 전달하지 않음, blocks.go)
+	Probe       bool      // 합성 프로브 카나리 (프로브 연결에만 전달, probe.go)
+	Received    time.Time // handleMessages가 받은 시각 (프로브 지연 측정)
+	Room        string    // 지표용 방 라벨 (roomtier.go)
+	RoomID      int       // 방 ID, 로비/DM이면 0 (비공개 방 멤버만 전달, privaterooms.go)
+}
+
+func newOutbound(ctx context.Context, data string) outbound {
+	if ctx == nil { ctx = context.Background() }
+	return outbound{Data: data, Ctx: ctx}
+}
+
+// OTLP 엔드포인트(tracing.otlp_endpoint)가 없으면 no-op 트레이서 그대로 사용
+// 반환된 함수는 종료 시 남은 스팬을 내보냄
+func initTracing() func(context.Context) error {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	if cfg.Tracing.OTLPEndpoint == "" {
+		return func(context.Context) error { return nil }
+	}
+
+	opts := []otlptracehttp.Option{otlptracehttp.WithEndpointURL(cfg.Tracing.OTLPEndpoint)}
+	exporter, err := otlptracehttp.New(context.Background(), opts...)
+	if err != nil { fatal("tracing exporter init failed", err) }
+
+	res, _ := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceName("gotalk"),
+		semconv.ServiceInstanceID(nodeID),
+	))
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.Tracing.SampleRatio))),
+	)
+	otel.SetTracerProvider(tp)
+	slog.Info("tracing enabled", "endpoint", cfg.Tracing.OTLPEndpoint, "sample_ratio", cfg.Tracing.SampleRatio)
+	return tp.Shutdown
+}
+
+// [HTTP 스팬] 들어온 traceparent를 이어받고, 라우트 패턴으로 스팬 이름을 정함
+func tracingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracer.Start(ctx, r.Method+" "+r.URL.Path, trace.WithSpanKind(trace.SpanKindServer))
+		defer span.End()
+		span.SetAttributes(
+			semconv.HTTPRequestMethodKey.String(r.Method),
+			semconv.URLPath(r.URL.Path),
+			attribute.String("request_id", requestIDFrom(ctx)),
+		)
+
+		r = r.WithContext(ctx)
+		next.ServeHTTP(w, r)
+		if r.Pattern != "" {
+			span.SetName(r.Pattern)
+			span.SetAttributes(semconv.HTTPRoute(r.Pattern))
+		}
+	})
+}
+
+// DB 작업 스팬 (호출 측에서 End)
+func startDBSpan(ctx context.Context, op string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, "db."+op, trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemPostgreSQL, attribute.String("db.operation.name", op)))
+}
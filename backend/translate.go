@@ -0,0 +1,232 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// [방 언어/번역 중계] 방장이 방의 기본 언어를 정하고 번역 중계를 켜면, 새 글을 멤버들의 프로필 언어로 번역해
+// 원문 방송 뒤에 `type: translated` (id, room_id, translations)로 따로 방송함 (클라이언트는 접어 두는 보조 본문으로 표시)
+// 번역은 (본문 해시, 원문 언어, 대상 언어)로 파드 메모리 → translations 테이블 순으로 캐시해 같은 글은 API를 다시 부르지 않고,
+// 메시지 하나당 대상 언어 수(translation.max_targets)와 길이(translation.max_length)로 비용 상한을 둠
+// /history는 캐시에 있는 번역만 붙임 (기록을 읽는다고 번역 API를 부르지 않음)
+const (
+	translationProviderNone  = "none"
+	translationProviderLibre = "libretranslate"
+
+	eventLanguage = "language"
+
+	translationMemoryMax = 10000 // 넘으면 메모리 캐시를 통째로 비움
+)
+
+// 번역 공급자 (원문/대상 언어는 ISO 639 코드)
+type Translator interface {
+	Translate(ctx context.Context, text, source, target string) (string, error)
+}
+
+var (
+	translator Translator // 꺼져 있으면 nil
+
+	translationMu    sync.Mutex
+	translationCache = map[string]string{}
+
+	languagePattern = regexp.MustCompile(`^[a-z]{2,3}$`)
+
+	translationLookups = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "gotalk_translations_total",
+		Help: "Translation lookups by result (memory, db, api, error).",
+	}, []string{"result"})
+)
+
+func initTranslation() {
+	if cfg.Translation.Provider == translationProviderLibre {
+		translator = &libreTranslator{client: &http.Client{Timeout: 10 * time.Second}}
+		slog.Info("translation relay ready", "provider", cfg.Translation.Provider)
+	}
+}
+
+// LibreTranslate 호환 API: POST /translate {q, source, target, format, api_key} → {translatedText}
+type libreTranslator struct {
+	client *http.Client
+}
+
+func (t *libreTranslator) Translate(ctx context.Context, text, source, target string) (string, error) {
+	body, _ := json.Marshal(map[string]string{"q": text, "source": source, "target": target, "format": "text", "api_key": cfg.Translation.APIKey})
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(cfg.Translation.URL, "/")+"/translate", strings.NewReader(string(body)))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := t.client.Do(req)
+	if err != nil { return "", err }
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK { return "", fmt.Errorf("translate: %s", resp.Status) }
+	var out struct {
+		TranslatedText string `json:"translatedText"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil { return "", err }
+	return out.TranslatedText, nil
+}
+
+// 언어 코드 정규화 ("ko-KR" → "ko"), 알 수 없으면 ""
+func normalizeLanguage(tag string) string {
+	lang, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+	if !languagePattern.MatchString(lang) { return "" }
+	return lang
+}
+
+// 요청의 첫 Accept-Language (프로필 언어가 비어 있을 때 기본값으로 씀)
+func requestLanguage(r *http.Request) string {
+	first, _, _ := strings.Cut(r.Header.Get("Accept-Language"), ",")
+	tag, _, _ := strings.Cut(first, ";")
+	return normalizeLanguage(tag)
+}
+
+// 캐시를 거쳐 번역 (메모리 → DB → 공급자 호출 후 DB에 저장)
+func translateCached(ctx context.Context, content, source, target string) (string, error) {
+	hash := contentHash(content)
+	key := hash + "|" + source + "|" + target
+	translationMu.Lock()
+	out, ok := translationCache[key]
+	translationMu.Unlock()
+	if ok { translationLookups.WithLabelValues("memory").Inc(); return out, nil }
+
+	err := db.QueryRowContext(ctx, "SELECT content FROM translations WHERE content_hash = $1 AND source = $2 AND target = $3", hash, source, target).Scan(&out)
+	if err == nil {
+		translationLookups.WithLabelValues("db").Inc()
+		rememberTranslation(key, out)
+		return out, nil
+	}
+	if err != sql.ErrNoRows { return "", err }
+
+	out, err = translator.Translate(ctx, content, source, target)
+	if err != nil { translationLookups.WithLabelValues("error").Inc(); return "", err }
+	translationLookups.WithLabelValues("api").Inc()
+	_, err = db.ExecContext(ctx, `INSERT INTO translations (content_hash, source, target, content) VALUES ($1, $2, $3, $4)
+		ON CONFLICT DO NOTHING`, hash, source, target, out)
+	if err != nil { slog.WarnContext(ctx, "translation cache write failed", "err", err) }
+	rememberTranslation(key, out)
+	return out, nil
+}
+
+func rememberTranslation(key, out string) {
+	translationMu.Lock()
+	if len(translationCache) >= translationMemoryMax { translationCache = map[string]string{} }
+	translationCache[key] = out
+	translationMu.Unlock()
+}
+
+// 번역 중계가 켜진 방이면 그 방의 기본 언어
+func roomTranslationSource(ctx context.Context, roomID int) (string, bool) {
+	var lang sql.NullString
+	var enabled bool
+	db.QueryRowContext(ctx, "SELECT language, translate FROM rooms WHERE id = $1", roomID).Scan(&lang, &enabled)
+	return lang.String, enabled && lang.Valid
+}
+
+// 새 방 메시지를 멤버 언어로 번역해 방송 (sendHandler가 방송 후 고루틴으로 부름)
+func relayTranslations(msg Message) {
+	if translator == nil || msg.RoomID == nil || utf8.RuneCountInString(msg.Content) > cfg.Translation.MaxLength { return }
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	source, ok := roomTranslationSource(ctx, *msg.RoomID)
+	if !ok { return }
+
+	// 멤버가 많은 언어부터 max_targets개까지
+	rows, err := db.QueryContext(ctx, `SELECT u.locale FROM room_members m JOIN users u ON u.nickname = m.nickname
+		WHERE m.room_id = $1 AND u.locale IS NOT NULL AND u.locale <> $2
+		GROUP BY u.locale ORDER BY COUNT(*) DESC, u.locale LIMIT $3`, *msg.RoomID, source, cfg.Translation.MaxTargets)
+	if err != nil { slog.Warn("translation relay failed", "message_id", msg.ID, "err", err); return }
+	var targets []string
+	for rows.Next() {
+		var t string
+		rows.Scan(&t)
+		targets = append(targets, t)
+	}
+	rows.Close()
+
+	translations := map[string]string{}
+	for _, target := range targets {
+		out, err := translateCached(ctx, msg.Content, source, target)
+		if err != nil { slog.Warn("translation failed", "message_id", msg.ID, "target", target, "err", err); continue }
+		translations[target] = out
+	}
+	if len(translations) == 0 { return }
+	data, _ := json.Marshal(Message{ID: msg.ID, Type: messageTypeTranslated, RoomID: msg.RoomID, Translations: translations})
+	if err := publishChat(ctx, data); err != nil { slog.Warn("translation relay failed", "message_id", msg.ID, "err", err) }
+}
+
+// 기록 페이지에 보는 사람 언어의 캐시된 번역을 붙임 (msgs는 복사본이어야 함, 캐시된 페이지를 고치지 않게)
+func attachTranslations(ctx context.Context, roomID *int, nick string, msgs []Message) {
+	if translator == nil || roomID == nil || nick == "" || len(msgs) == 0 { return }
+	source, ok := roomTranslationSource(ctx, *roomID)
+	if !ok { return }
+	var target sql.NullString
+	db.QueryRowContext(ctx, "SELECT locale FROM users WHERE nickname = $1", nick).Scan(&target)
+	if !target.Valid || target.String == source { return }
+
+	var hashes []string
+	for _, m := range msgs {
+		if m.Type == messageTypeText { hashes = append(hashes, contentHash(m.Content)) }
+	}
+	rows, err := db.QueryContext(ctx, "SELECT content_hash, content FROM translations WHERE content_hash = ANY($1) AND source = $2 AND target = $3",
+		pq.Array(hashes), source, target.String)
+	if err != nil { slog.WarnContext(ctx, "translation lookup failed", "err", err); return }
+	defer rows.Close()
+	found := map[string]string{}
+	for rows.Next() {
+		var hash, content string
+		rows.Scan(&hash, &content)
+		found[hash] = content
+	}
+	for i, m := range msgs {
+		if m.Type != messageTypeText { continue }
+		if out, ok := found[contentHash(m.Content)]; ok { msgs[i].Translations = map[string]string{target.String: out} }
+	}
+}
+
+// [방 언어] POST /rooms/{id}/language (nick, language=ko, translate=true|false) - 방장/운영진, language를 비우면 해제
+func setRoomLanguageHandler(w http.ResponseWriter, r *http.Request) {
+	roomID, ok := roomIDFrom(r)
+	nick := r.FormValue("nick")
+	if !ok || nick == "" { respondError(w, r, http.StatusBadRequest, errors.New("room id and nick required")); return }
+	if role := roomRole(roomID, nick); role != roomRoleOwner && role != roomRoleModerator {
+		respondError(w, r, http.StatusForbidden, errors.New("only owners and moderators can change the room language"))
+		return
+	}
+	var lang *string
+	if v := r.FormValue("language"); v != "" {
+		l := normalizeLanguage(v)
+		if l == "" { respondError(w, r, http.StatusBadRequest, fieldError{"language", "must be an ISO 639 code like en or ko"}); return }
+		lang = &l
+	}
+	enable := r.FormValue("translate") == "true"
+	if enable && lang == nil { respondError(w, r, http.StatusBadRequest, fieldError{"translate", "requires a room language"}); return }
+	if enable && translator == nil { respondError(w, r, http.StatusNotImplemented, errors.New("translation provider not configured")); return }
+
+	var old sql.NullString
+	err := db.QueryRow(`UPDATE rooms r SET language = $2, translate = $3 FROM rooms prev
+		WHERE r.id = $1 AND prev.id = r.id RETURNING prev.language`, roomID, lang, enable).Scan(&old)
+	if err == sql.ErrNoRows { respondError(w, r, http.StatusNotFound, errors.New("room not found")); return }
+	if err != nil { respondError(w, r, 500, err); return }
+
+	newLang := ""
+	if lang != nil { newLang = *lang }
+	if old.String != newLang {
+		content := nick + " cleared the room language"
+		if newLang != "" { content = nick + " set the room language to " + newLang }
+		recordEvent(r.Context(), &roomID, RoomEvent{Kind: eventLanguage, Actor: nick, Data: map[string]any{"old": old.String, "new": newLang}}, content)
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"language": newLang, "translate": enable})
+}
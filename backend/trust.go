@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// [신뢰 등급] 계정 나이와 활동(보낸 메시지 수)으로 new → basic → regular 자동 승급, 등급마다 풀리는 기능이 다름
+// 링크(trust.links), 아바타 업로드(trust.uploads), @all/@here(trust.mention_all)에 필요한 최소 등급을 설정으로 정함
+// 등급은 users.trust_level에 두고 매일 도는 스케줄러 작업이 다시 계산 (내려가지는 않음)
+// 사이트 운영진/관리자(permissions.go)는 등급과 상관없이 모두 쓸 수 있고, 가입 기록이 없는 닉네임은 new
+const (
+	trustNew     = "new"
+	trustBasic   = "basic"
+	trustRegular = "regular"
+)
+
+var trustRank = map[string]int{trustNew: 0, trustBasic: 1, trustRegular: 2}
+
+// 등급으로 막는 기능
+const (
+	trustCapLinks      = "links"
+	trustCapUploads    = "uploads"
+	trustCapMentionAll = "mention_all"
+)
+
+// 오류 문구용 기능 이름
+var trustCapLabels = map[string]string{trustCapLinks: "Links", trustCapUploads: "Uploads", trustCapMentionAll: "@all/@here mentions"}
+
+func validTrustLevel(level string) bool {
+	_, ok := trustRank[level]
+	return ok
+}
+
+// 기능에 필요한 최소 등급
+func trustRequired(capability string) string {
+	switch capability {
+	case trustCapLinks:
+		return cfg.Trust.Links
+	case trustCapUploads:
+		return cfg.Trust.Uploads
+	case trustCapMentionAll:
+		return cfg.Trust.MentionAll
+	}
+	return trustNew
+}
+
+func trustLevel(ctx context.Context, nick string) string {
+	level := trustNew
+	db.QueryRowContext(ctx, "SELECT trust_level FROM users WHERE nickname = $1", nick).Scan(&level)
+	return level
+}
+
+// 등급이 모자라 기능을 쓸 수 없으면 fieldError (끄거나 Postgres가 아니면 항상 nil)
+func checkTrust(ctx context.Context, nick, capability, field string) error {
+	if !cfg.Trust.Enabled || !fullFeatureStore() { return nil }
+	need := trustRequired(capability)
+	if need == trustNew { return nil }
+	if role := userRole(ctx, nick); role == userRoleAdmin || role == userRoleModerator { return nil }
+	if trustRank[trustLevel(ctx, nick)] >= trustRank[need] { return nil }
+	return fieldError{field, trustCapLabels[capability] + " unlock at trust level " + need}
+}
+
+// 본문이 막힌 기능(링크, @all/@here)을 쓰는지 확인
+func checkMessageTrust(ctx context.Context, nick, content string) error {
+	if mdURL.MatchString(content) {
+		if err := checkTrust(ctx, nick, trustCapLinks, "msg"); err != nil { return err }
+	}
+	if mentionsEveryone(parseMentions(content)) { return checkTrust(ctx, nick, trustCapMentionAll, "msg") }
+	return nil
+}
+
+// 조건을 채운 계정을 올림 (regular를 먼저 올려 new에서 바로 regular로 갈 수도 있음)
+func recalcTrustLevels() {
+	if !cfg.Trust.Enabled { return }
+	ctx := context.Background()
+	steps := []struct {
+		level    string
+		from     []string
+		ageSecs  float64
+		messages int
+	}{
+		{trustRegular, []string{trustNew, trustBasic}, time.Duration(cfg.Trust.RegularAge).Seconds(), cfg.Trust.RegularMessages},
+		{trustBasic, []string{trustNew}, time.Duration(cfg.Trust.BasicAge).Seconds(), cfg.Trust.BasicMessages},
+	}
+	for _, s := range steps {
+		res, err := db.ExecContext(ctx, `
+			UPDATE users u SET trust_level = $1
+			WHERE u.trust_level = ANY($2) AND u.deleted_at IS NULL
+			  AND u.created_at <= CURRENT_TIMESTAMP - make_interval(secs => $3)
+			  AND (SELECT COUNT(*) FROM messages m WHERE m.sender_nick = u.nickname AND m.type = $5) >= $4`,
+			s.level, pq.Array(s.from), s.ageSecs, s.messages, messageTypeText)
+		if err != nil { slog.Warn("trust level update failed", "level", s.level, "err", err); return }
+		if n, _ := res.RowsAffected(); n > 0 { slog.Info("trust levels raised", "level", s.level, "users", n) }
+	}
+}
+
+// 요청 핸들러용: 등급이 모자라면 403을 쓰고 false
+func trustOK(w http.ResponseWriter, r *http.Request, nick, capability, field string) bool {
+	if err := checkTrust(r.Context(), nick, capability, field); err != nil { respondError(w, r, http.StatusForbidden, err); return false }
+	return true
+}
+
+// @all/@here (방에서만 그 방 멤버 전원에게 알림, mention.go)
+func mentionsEveryone(nicks []string) bool {
+	return slices.ContainsFunc(nicks, func(n string) bool { return n == mentionAll || n == mentionHere })
+}
+
+// [신뢰 등급 지정] PUT /admin/users/{nick}/trust (level) - 자동 계산은 올리기만 하므로 내릴 때는 여기서 (조건을 채우면 다음 계산에서 다시 오름)
+func adminSetTrustHandler(w http.ResponseWriter, r *http.Request) {
+	nick, level := r.PathValue("nick"), r.FormValue("level")
+	if !validTrustLevel(level) { respondError(w, r, http.StatusBadRequest, fieldError{"level", "must be new, basic or regular"}); return }
+	res, err := db.ExecContext(r.Context(), "UPDATE users SET trust_level = $2 WHERE nickname = $1", nick, level)
+	if err != nil { respondError(w, r, 500, err); return }
+	if n, _ := res.RowsAffected(); n == 0 { respondError(w, r, http.StatusNotFound, errors.New("user not found")); return }
+	slog.InfoContext(r.Context(), "trust level set", "nick", nick, "level", level)
+	writeJSON(w, http.StatusOK, map[string]string{"nickname": nick, "trust_level": level})
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+	"unicode/utf8"
+
+	"github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"golang.org/x/net/html"
+)
+
+// [링크 미리보기] 새 글에 http(s) 주소가 있으면 방송 뒤 고루틴으로 그 페이지의 OpenGraph(og:title 등)를 읽어
+// link_previews에 주소별로 캐시하고 `type: unfurled` (id, room_id, previews)로 따로 방송함 (번역 중계와 같은 방식)
+// /history는 저장된 미리보기를 붙여 줌
+// 서버가 대신 요청을 보내므로 SSRF를 막음: http(s)와 80/443만, 접속 직전 IP가 사설/루프백/링크로컬 등이면 거부 (리디렉트도 같음),
+// 시간 제한(unfurl.timeout)과 읽는 크기 상한(unfurl.max_bytes), 메시지당 주소 수 상한(unfurl.max_links)
+const (
+	unfurlMaxTitle       = 200
+	unfurlMaxDescription = 300
+	unfurlMaxRedirects   = 3
+	unfurlCacheTTL       = 24 * time.Hour // 이 기간 안에 읽은 주소는 다시 가져오지 않음
+)
+
+type LinkPreview struct {
+	URL         string `json:"url"`
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	ImageURL    string `json:"image_url,omitempty"`
+	SiteName    string `json:"site_name,omitempty"`
+}
+
+var (
+	errUnfurlBlocked = errors.New("address not allowed")
+
+	unfurlClient  *http.Client
+	unfurlFetches = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "gotalk_unfurl_fetches_total",
+		Help: "Link preview lookups by result (cache, ok, error, blocked).",
+	}, []string{"result"})
+)
+
+func initUnfurl() {
+	dialer := &net.Dialer{Timeout: time.Duration(cfg.Unfurl.Timeout), Control: unfurlDialControl}
+	unfurlClient = &http.Client{
+		Timeout:   time.Duration(cfg.Unfurl.Timeout),
+		Transport: &http.Transport{DialContext: dialer.DialContext, Proxy: nil, MaxIdleConns: 10, IdleConnTimeout: 30 * time.Second},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= unfurlMaxRedirects { return errors.New("too many redirects") }
+			return checkUnfurlURL(req.URL)
+		},
+	}
+}
+
+// 이름 풀이가 끝난 실제 접속 주소로 확인 (DNS를 바꿔치기해도 막힘)
+func unfurlDialControl(network, address string, _ syscall.RawConn) error {
+	ap, err := netip.ParseAddrPort(address)
+	if err != nil { return err }
+	ip := ap.Addr().Unmap()
+	if !ip.IsGlobalUnicast() || ip.IsPrivate() || ip.IsLoopback() || ip.IsLinkLocalUnicast() || cgnatPrefix.Contains(ip) { return errUnfurlBlocked }
+	if p := ap.Port(); p != 80 && p != 443 { return errUnfurlBlocked }
+	return nil
+}
+
+var cgnatPrefix = netip.MustParsePrefix("100.64.0.0/10")
+
+func checkUnfurlURL(u *url.URL) error {
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.User != nil { return errUnfurlBlocked }
+	return nil
+}
+
+// 본문의 주소 (중복 제거, 앞에서부터 max_links개)
+func messageLinks(content string) []string {
+	var links []string
+	for _, l := range mdURL.FindAllString(content, -1) {
+		if !slices.Contains(links, l) { links = append(links, l) }
+		if len(links) == cfg.Unfurl.MaxLinks { break }
+	}
+	return links
+}
+
+// 새 메시지의 링크 미리보기를 저장하고 방송 (sendHandler가 방송 후 고루틴으로 부름)
+func unfurlMessage(msg Message) {
+	links := messageLinks(msg.Content)
+	if len(links) == 0 { return }
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.Unfurl.Timeout)*time.Duration(len(links))+5*time.Second)
+	defer cancel()
+
+	var previews []LinkPreview
+	for _, link := range links {
+		p, ok := linkPreview(ctx, link)
+		if !ok { continue }
+		_, err := db.ExecContext(ctx, "INSERT INTO message_link_previews (message_id, url, position, room_id) VALUES ($1, $2, $3, $4) ON CONFLICT DO NOTHING",
+			msg.ID, link, len(previews), msg.RoomID)
+		if err != nil { slog.Warn("link preview store failed", "message_id", msg.ID, "err", err); return }
+		previews = append(previews, p)
+	}
+	if len(previews) == 0 { return }
+	data, _ := json.Marshal(Message{ID: msg.ID, Type: messageTypeUnfurled, RoomID: msg.RoomID, RecipientNick: msg.RecipientNick, SenderNick: msg.SenderNick, Previews: previews})
+	if err := publishChat(ctx, data); err != nil { slog.Warn("link preview broadcast failed", "message_id", msg.ID, "err", err) }
+}
+
+// 캐시된 미리보기, 없거나 오래됐으면 가져와 저장 (보여 줄 것이 없으면 false)
+func linkPreview(ctx context.Context, link string) (LinkPreview, bool) {
+	p := LinkPreview{URL: link}
+	var ok bool
+	var fetchedAt time.Time
+	err := db.QueryRowContext(ctx, "SELECT ok, title, description, image_url, site_name, fetched_at FROM link_previews WHERE url = $1", link).
+		Scan(&ok, &p.Title, &p.Description, &p.ImageURL, &p.SiteName, &fetchedAt)
+	if err == nil && time.Since(fetchedAt) < unfurlCacheTTL {
+		unfurlFetches.WithLabelValues("cache").Inc()
+		return p, ok
+	}
+	if err != nil && err != sql.ErrNoRows { slog.Warn("link preview lookup failed", "err", err); return p, false }
+
+	p, err = fetchPreview(ctx, link)
+	ok = err == nil && p.Title != ""
+	switch {
+	case errors.Is(err, errUnfurlBlocked):
+		unfurlFetches.WithLabelValues("blocked").Inc()
+	case err != nil:
+		unfurlFetches.WithLabelValues("error").Inc()
+		slog.Debug("link preview fetch failed", "url", link, "err", err)
+	default:
+		unfurlFetches.WithLabelValues("ok").Inc()
+	}
+	_, err = db.ExecContext(ctx, `INSERT INTO link_previews (url, ok, title, description, image_url, site_name) VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (url) DO UPDATE SET ok = EXCLUDED.ok, title = EXCLUDED.title, description = EXCLUDED.description,
+			image_url = EXCLUDED.image_url, site_name = EXCLUDED.site_name, fetched_at = CURRENT_TIMESTAMP`,
+		link, ok, p.Title, p.Description, p.ImageURL, p.SiteName)
+	if err != nil { slog.Warn("link preview store failed", "err", err) }
+	return p, ok
+}
+
+func fetchPreview(ctx context.Context, link string) (LinkPreview, error) {
+	p := LinkPreview{URL: link}
+	u, err := url.Parse(link)
+	if err != nil { return p, err }
+	if err := checkUnfurlURL(u); err != nil { return p, err }
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link, nil)
+	if err != nil { return p, err }
+	req.Header.Set("User-Agent", "gotalk-unfurl")
+	req.Header.Set("Accept", "text/html")
+	resp, err := unfurlClient.Do(req)
+	if err != nil { return p, err }
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK { return p, fmt.Errorf("unfurl returned %s", resp.Status) }
+	if mt, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mt != "text/html" && mt != "application/xhtml+xml" { return p, errors.New("not an html page") }
+
+	parseOpenGraph(io.LimitReader(resp.Body, int64(cfg.Unfurl.MaxBytes)), &p)
+	// og:image는 상대 주소일 수 있고, http(s)가 아니면 버림 (클라이언트가 그대로 <img>에 넣음)
+	if p.ImageURL != "" {
+		img, err := resp.Request.URL.Parse(p.ImageURL)
+		p.ImageURL = ""
+		if err == nil && checkUnfurlURL(img) == nil { p.ImageURL = img.String() }
+	}
+	p.Title, p.Description = clipRunes(p.Title, unfurlMaxTitle), clipRunes(p.Description, unfurlMaxDescription)
+	return p, nil
+}
+
+// <head>의 og:* 메타와 <title>(og:title이 없을 때), description을 읽음 (<body>가 나오면 멈춤)
+func parseOpenGraph(r io.Reader, p *LinkPreview) {
+	z := html.NewTokenizer(r)
+	var title, description string
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			if p.Title == "" { p.Title = title }
+			if p.Description == "" { p.Description = description }
+			return
+		case html.StartTagToken, html.SelfClosingTagToken:
+			tok := z.Token()
+			switch tok.Data {
+			case "body":
+				if p.Title == "" { p.Title = title }
+				if p.Description == "" { p.Description = description }
+				return
+			case "title":
+				if z.Next() == html.TextToken { title = strings.TrimSpace(string(z.Text())) }
+			case "meta":
+				var key, content string
+				for _, a := range tok.Attr {
+					switch a.Key {
+					case "property", "name":
+						key = strings.ToLower(a.Val)
+					case "content":
+						content = strings.TrimSpace(a.Val)
+					}
+				}
+				switch key {
+				case "og:title":
+					p.Title = content
+				case "og:description":
+					p.Description = content
+				case "og:image":
+					p.ImageURL = content
+				case "og:site_name":
+					p.SiteName = content
+				case "description":
+					description = content
+				}
+			}
+		}
+	}
+}
+
+func clipRunes(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n { return s }
+	return string([]rune(s)[:n]) + "…"
+}
+
+// 기록 페이지에 저장된 미리보기를 붙임 (msgs는 복사본이어야 함, 캐시된 페이지를 고치지 않게)
+func attachPreviews(ctx context.Context, msgs []Message) {
+	ids := make([]int64, 0, len(msgs))
+	for _, m := range msgs {
+		if m.Type == messageTypeText && mdURL.MatchString(m.Content) { ids = append(ids, int64(m.ID)) }
+	}
+	if len(ids) == 0 { return }
+	rows, err := db.QueryContext(ctx, `SELECT m.message_id, p.url, p.title, p.description, p.image_url, p.site_name
+		FROM message_link_previews m JOIN link_previews p ON p.url = m.url
+		WHERE m.message_id = ANY($1) AND p.ok ORDER BY m.message_id, m.position`, pq.Array(ids))
+	if err != nil { slog.WarnContext(ctx, "link preview lookup failed", "err", err); return }
+	defer rows.Close()
+	found := map[int][]LinkPreview{}
+	for rows.Next() {
+		var id int
+		var p LinkPreview
+		rows.Scan(&id, &p.URL, &p.Title, &p.Description, &p.ImageURL, &p.SiteName)
+		found[id] = append(found[id], p)
+	}
+	for i, m := range msgs {
+		if list, ok := found[m.ID]; ok { msgs[i].Previews = list }
+	}
+}
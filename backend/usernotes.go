@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// [사용자 메모] 로그인한 사용자가 다른 사람에 대해 남기는 개인 메모 (운영/커뮤니티 관리용 CRM 메모)
+// 쓴 사람에게만 보이고, GET /users/{nick} 프로필에 함께 실림 (다른 사람은 메모 존재 여부도 모름)
+const userNoteMaxLength = 2000
+
+type UserNote struct {
+	Body      string    `json:"body"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type UserProfile struct {
+	Nickname  string     `json:"nickname"`
+	ColorCode string     `json:"color_code"`
+	Display   string     `json:"display_name,omitempty"` // 표시 이름 (profile_import.go)
+	AvatarURL string     `json:"avatar_url,omitempty"`
+	Role      string     `json:"role"`
+	Trust     string     `json:"trust_level"` // 신뢰 등급 (trust.go)
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+	Note      *UserNote  `json:"note,omitempty"` // 요청한 사람이 남긴 메모
+}
+
+// 내가 그 사람에게 남긴 메모 (없으면 nil)
+func userNoteFor(ctx context.Context, author, subject string) *UserNote {
+	var n UserNote
+	err := db.QueryRowContext(ctx, "SELECT body, updated_at FROM user_notes WHERE author = $1 AND subject = $2", author, subject).Scan(&n.Body, &n.UpdatedAt)
+	if err != nil { return nil }
+	return &n
+}
+
+// [프로필] GET /users/{nick} - 로그인했으면 내 메모도 함께
+func userProfileHandler(w http.ResponseWriter, r *http.Request) {
+	if !fullFeatureStore() { respondError(w, r, http.StatusNotImplemented, errors.New("profiles require the postgres store")); return }
+	p := UserProfile{Nickname: r.PathValue("nick")}
+	err := db.QueryRowContext(r.Context(), "SELECT COALESCE(color_code, '#ffffff'), COALESCE(display_name, ''), role, trust_level, created_at FROM users WHERE nickname = $1 AND deleted_at IS NULL", p.Nickname).
+		Scan(&p.ColorCode, &p.Display, &p.Role, &p.Trust, &p.CreatedAt)
+	if err == sql.ErrNoRows { respondError(w, r, http.StatusNotFound, errors.New("user not found")); return }
+	if err != nil { respondError(w, r, 500, err); return }
+	p.AvatarURL = avatarURLFor(p.Nickname)
+	if viewer, ok := identityFrom(r.Context()); ok && !isGuest(r.Context()) { p.Note = userNoteFor(r.Context(), viewer, p.Nickname) }
+	writeJSON(w, http.StatusOK, p)
+}
+
+// [메모 저장] PUT /users/{nick}/note (body) - 빈 본문이면 지움
+func putUserNoteHandler(w http.ResponseWriter, r *http.Request) {
+	nick, ok := requireAccount(w, r)
+	if !ok { return }
+	subject, body := r.PathValue("nick"), r.FormValue("body")
+	if subject == nick { respondError(w, r, http.StatusBadRequest, fieldError{"nick", "cannot write a note about yourself"}); return }
+	if err := validateText("body", body, userNoteMaxLength); err != nil { respondError(w, r, http.StatusBadRequest, err); return }
+	if body == "" {
+		db.ExecContext(r.Context(), "DELETE FROM user_notes WHERE author = $1 AND subject = $2", nick, subject)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if !userExists(subject) || isUserDeleted(subject) { respondError(w, r, http.StatusNotFound, errors.New("user not found")); return }
+
+	n := UserNote{Body: body}
+	err := db.QueryRowContext(r.Context(), `
+		INSERT INTO user_notes (author, subject, body) VALUES ($1, $2, $3)
+		ON CONFLICT (author, subject) DO UPDATE SET body = EXCLUDED.body, updated_at = CURRENT_TIMESTAMP
+		RETURNING updated_at`, nick, subject, body).Scan(&n.UpdatedAt)
+	if err != nil { respondError(w, r, 500, err); return }
+	writeJSON(w, http.StatusOK, n)
+}
+
+// [메모 삭제] DELETE /users/{nick}/note
+func deleteUserNoteHandler(w http.ResponseWriter, r *http.Request) {
+	nick, ok := requireAccount(w, r)
+	if !ok { return }
+	res, err := db.ExecContext(r.Context(), "DELETE FROM user_notes WHERE author = $1 AND subject = $2", nick, r.PathValue("nick"))
+	if err != nil { respondError(w, r, 500, err); return }
+	if n, _ := res.RowsAffected(); n == 0 { respondError(w, r, http.StatusNotFound, errors.New("no note for this user")); return }
+	w.WriteHeader(http.StatusNoContent)
+}
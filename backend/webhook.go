@@ -0,0 +1,192 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// [웹훅] 관리자가 등록한 URL로 새 메시지(로비/방, DM 제외)를 POST
+// - room_id: 그 방 메시지만, pattern: 본문이 정규식에 맞는 것만 (둘 다 비우면 전체)
+// - 서명: X-Gotalk-Signature: sha256=hex(HMAC-SHA256(secret, 타임스탬프 + "." + 본문))
+//   수신 측은 X-Gotalk-Timestamp와 함께 검증하고 오래된 타임스탬프는 거부하면 재전송 공격을 막을 수 있음
+// - 실패(네트워크/2xx 아님)는 dead_letter 설정대로 재시도, 끝내 실패하면 DLQ(kind=webhook)
+// 메시지를 받은 파드(/send 처리 파드)만 보내므로 파드 수만큼 중복되지 않음
+
+type Webhook struct {
+	ID              int        `json:"id"`
+	URL             string     `json:"url"`
+	Secret          string     `json:"secret,omitempty"` // 만들 때 한 번만 보여 줌
+	RoomID          *int       `json:"room_id,omitempty"`
+	Pattern         string     `json:"pattern,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	LastDeliveredAt *time.Time `json:"last_delivered_at,omitempty"`
+	LastError       string     `json:"last_error,omitempty"`
+}
+
+// 웹훅 본문
+type WebhookEvent struct {
+	Event   string  `json:"event"` // message.created
+	Message Message `json:"message"`
+}
+
+type webhookDeadLetter struct {
+	WebhookID int             `json:"webhook_id"`
+	Body      json.RawMessage `json:"body"`
+}
+
+const deadLetterWebhook = "webhook"
+
+var (
+	webhookClient     = &http.Client{Timeout: 10 * time.Second}
+	webhookDeliveries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "gotalk_webhook_deliveries_total",
+		Help: "Outgoing webhook delivery attempts by result (ok or error).",
+	}, []string{"result"})
+)
+
+func init() {
+	deadLetterRetriers[deadLetterWebhook] = retryWebhookDeadLetter
+}
+
+// 새 메시지에 맞는 웹훅마다 비동기로 전송
+func dispatchWebhooks(msg Message) {
+	if !fullFeatureStore() || msg.RecipientNick != "" { return }
+	// 방이 지정된 웹훅은 로비 메시지를 받지 않음 (room_id = NULL은 거짓)
+	rows, err := db.Query("SELECT id, url, secret, COALESCE(pattern, '') FROM webhooks WHERE room_id IS NULL OR room_id = $1", msg.RoomID)
+	if err != nil { slog.Warn("webhook lookup failed", "err", err); return }
+	defer rows.Close()
+
+	var body []byte
+	for rows.Next() {
+		var h Webhook
+		rows.Scan(&h.ID, &h.URL, &h.Secret, &h.Pattern)
+		if h.Pattern != "" {
+			re, err := regexp.Compile(h.Pattern)
+			if err != nil || !re.MatchString(msg.Content) { continue }
+		}
+		if body == nil { body, _ = json.Marshal(WebhookEvent{Event: "message.created", Message: msg}) }
+		go func(h Webhook) {
+			dl := webhookDeadLetter{WebhookID: h.ID, Body: body}
+			withRetries(context.Background(), deadLetterWebhook, dl, func() error { return deliverWebhook(h, body) })
+		}(h)
+	}
+}
+
+// 서명한 요청 (본문은 아직 읽지 않음)
+func signedWebhookRequest(h Webhook, event string, body []byte) (*http.Request, error) {
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(h.Secret))
+	mac.Write([]byte(ts + "."))
+	mac.Write(body)
+
+	req, err := http.NewRequest(http.MethodPost, h.URL, bytes.NewReader(body))
+	if err != nil { return nil, err }
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "gotalk-webhook")
+	req.Header.Set("X-Gotalk-Event", event)
+	req.Header.Set("X-Gotalk-Timestamp", ts)
+	req.Header.Set("X-Gotalk-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	return req, nil
+}
+
+// 한 번 전송하고 결과를 웹훅 행에 남김
+func deliverWebhook(h Webhook, body []byte) error {
+	req, err := signedWebhookRequest(h, "message.created", body)
+	if err != nil { return err }
+	resp, err := webhookClient.Do(req)
+	if err == nil {
+		resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode > 299 { err = fmt.Errorf("webhook returned %s", resp.Status) }
+	}
+	if err != nil {
+		webhookDeliveries.WithLabelValues("error").Inc()
+		db.Exec("UPDATE webhooks SET last_error = $2 WHERE id = $1", h.ID, err.Error())
+		return err
+	}
+	webhookDeliveries.WithLabelValues("ok").Inc()
+	db.Exec("UPDATE webhooks SET last_delivered_at = CURRENT_TIMESTAMP, last_error = NULL WHERE id = $1", h.ID)
+	return nil
+}
+
+// DLQ 재시도: 그 사이 비밀 키가 바뀌었을 수 있어 웹훅을 다시 읽음
+func retryWebhookDeadLetter(ctx context.Context, payload json.RawMessage) error {
+	var dl webhookDeadLetter
+	if err := json.Unmarshal(payload, &dl); err != nil || dl.WebhookID == 0 { return errDeadLetterPayload }
+	var h Webhook
+	err := db.QueryRowContext(ctx, "SELECT id, url, secret FROM webhooks WHERE id = $1", dl.WebhookID).Scan(&h.ID, &h.URL, &h.Secret)
+	if err == sql.ErrNoRows { return errors.New("webhook was deleted") }
+	if err != nil { return err }
+	return deliverWebhook(h, dl.Body)
+}
+
+// [웹훅 등록] POST /admin/webhooks (url, room_id?, pattern?, secret?) - secret을 비우면 만들어서 한 번만 돌려줌
+func adminCreateWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	h := Webhook{URL: r.FormValue("url"), Secret: r.FormValue("secret"), Pattern: r.FormValue("pattern")}
+	if u, err := url.Parse(h.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		respondError(w, r, http.StatusBadRequest, errors.New("url must be an absolute http(s) URL"))
+		return
+	}
+	if h.Pattern != "" {
+		if _, err := regexp.Compile(h.Pattern); err != nil { respondError(w, r, http.StatusBadRequest, errors.New("invalid pattern: "+err.Error())); return }
+	}
+	if v := r.FormValue("room_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil { respondError(w, r, http.StatusBadRequest, errors.New("invalid room_id")); return }
+		h.RoomID = &id
+	}
+	if h.Secret == "" {
+		b := make([]byte, 24)
+		rand.Read(b)
+		h.Secret = hex.EncodeToString(b)
+	}
+
+	err := db.QueryRow("INSERT INTO webhooks (url, secret, room_id, pattern) VALUES ($1, $2, $3, NULLIF($4, '')) RETURNING id, created_at",
+		h.URL, h.Secret, h.RoomID, h.Pattern).Scan(&h.ID, &h.CreatedAt)
+	if err != nil { respondError(w, r, 500, err); return }
+	slog.InfoContext(r.Context(), "webhook registered", "webhook_id", h.ID, "url", h.URL)
+	writeJSON(w, http.StatusCreated, h)
+}
+
+// [웹훅 목록] GET /admin/webhooks - 비밀 키는 빼고
+func adminWebhooksHandler(w http.ResponseWriter, r *http.Request) {
+	rows, err := db.Query("SELECT id, url, room_id, COALESCE(pattern, ''), created_at, last_delivered_at, COALESCE(last_error, '') FROM webhooks ORDER BY id")
+	if err != nil { respondError(w, r, 500, err); return }
+	defer rows.Close()
+	list := []Webhook{}
+	for rows.Next() {
+		var h Webhook
+		var roomID sql.NullInt64
+		rows.Scan(&h.ID, &h.URL, &roomID, &h.Pattern, &h.CreatedAt, &h.LastDeliveredAt, &h.LastError)
+		if roomID.Valid {
+			id := int(roomID.Int64)
+			h.RoomID = &id
+		}
+		list = append(list, h)
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+// [웹훅 삭제] DELETE /admin/webhooks/{id}
+func adminDeleteWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	res, err := db.Exec("DELETE FROM webhooks WHERE id = $1", r.PathValue("id"))
+	if err != nil { respondError(w, r, 500, err); return }
+	if n, _ := res.RowsAffected(); n == 0 { respondError(w, r, http.StatusNotFound, errors.New("webhook not found")); return }
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Web Push 전송 (RFC 8291 aes128gcm 암호화 + RFC 8292 VAPID 서명)
+// 외부 라이브러리 없이 표준 라이브러리만으로 구현
+
+var pushClient = &http.Client{Timeout: 10 * time.Second}
+
+var b64 = base64.RawURLEncoding
+
+// 브라우저 키는 패딩 유무가 섞여 들어오므로 둘 다 허용
+func decodeB64(s string) ([]byte, error) {
+	return b64.DecodeString(strings.TrimRight(s, "="))
+}
+
+// VAPID JWT 생성 (ES256, aud는 푸시 서비스 origin)
+func vapidToken(endpoint string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil { return "", err }
+	d, err := decodeB64(cfg.Push.VAPIDPrivateKey)
+	if err != nil { return "", fmt.Errorf("vapid private key: %w", err) }
+	key, err := ecdsa.ParseRawPrivateKey(elliptic.P256(), d)
+	if err != nil { return "", fmt.Errorf("vapid private key: %w", err) }
+
+	header := b64.EncodeToString([]byte(`{"typ":"JWT","alg":"ES256"}`))
+	claims, _ := json.Marshal(map[string]any{
+		"aud": u.Scheme + "://" + u.Host,
+		"exp": time.Now().Add(12 * time.Hour).Unix(),
+		"sub": cfg.Push.VAPIDSubject,
+	})
+	unsigned := header + "." + b64.EncodeToString(claims)
+
+	digest := sha256.Sum256([]byte(unsigned))
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	if err != nil { return "", err }
+	sig := make([]byte, 64)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:])
+	return unsigned + "." + b64.EncodeToString(sig), nil
+}
+
+// 구독 키로 페이로드 암호화 (단일 레코드)
+func encryptPushPayload(p256dh, auth string, payload []byte) ([]byte, error) {
+	uaPublicBytes, err := decodeB64(p256dh)
+	if err != nil { return nil, fmt.Errorf("p256dh: %w", err) }
+	authSecret, err := decodeB64(auth)
+	if err != nil { return nil, fmt.Errorf("auth: %w", err) }
+
+	curve := ecdh.P256()
+	uaPublic, err := curve.NewPublicKey(uaPublicBytes)
+	if err != nil { return nil, fmt.Errorf("p256dh: %w", err) }
+	asPrivate, err := curve.GenerateKey(rand.Reader)
+	if err != nil { return nil, err }
+	asPublic := asPrivate.PublicKey().Bytes()
+	ecdhSecret, err := asPrivate.ECDH(uaPublic)
+	if err != nil { return nil, err }
+
+	keyInfo := "WebPush: info\x00" + string(uaPublicBytes) + string(asPublic)
+	ikm, err := hkdf.Key(sha256.New, ecdhSecret, authSecret, keyInfo, 32)
+	if err != nil { return nil, err }
+
+	salt := make([]byte, 16)
+	rand.Read(salt)
+	prk, err := hkdf.Extract(sha256.New, ikm, salt)
+	if err != nil { return nil, err }
+	cek, err := hkdf.Expand(sha256.New, prk, "Content-Encoding: aes128gcm\x00", 16)
+	if err != nil { return nil, err }
+	nonce, err := hkdf.Expand(sha256.New, prk, "Content-Encoding: nonce\x00", 12)
+	if err != nil { return nil, err }
+
+	block, err := aes.NewCipher(cek)
+	if err != nil { return nil, err }
+	gcm, err := cipher.NewGCM(block)
+	if err != nil { return nil, err }
+	// 0x02: 마지막 레코드 구분자
+	ciphertext := gcm.Seal(nil, nonce, append(payload, 0x02), nil)
+
+	// 헤더: salt(16) | rs(4) | idlen(1) | keyid(as_public)
+	var body bytes.Buffer
+	body.Write(salt)
+	binary.Write(&body, binary.BigEndian, uint32(4096))
+	body.WriteByte(byte(len(asPublic)))
+	body.Write(asPublic)
+	body.Write(ciphertext)
+	return body.Bytes(), nil
+}
+
+// 푸시 서비스로 전송하고 HTTP 상태 코드를 반환
+func sendWebPush(sub PushSubscription, payload []byte, ttl int) (int, error) {
+	body, err := encryptPushPayload(sub.Keys.P256dh, sub.Keys.Auth, payload)
+	if err != nil { return 0, err }
+	token, err := vapidToken(sub.Endpoint)
+	if err != nil { return 0, err }
+
+	req, err := http.NewRequest(http.MethodPost, sub.Endpoint, bytes.NewReader(body))
+	if err != nil { return 0, err }
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("TTL", fmt.Sprint(ttl))
+	req.Header.Set("Authorization", "vapid t="+token+", k="+strings.TrimRight(cfg.Push.VAPIDPublicKey, "="))
+
+	resp, err := pushClient.Do(req)
+	if err != nil { return 0, err }
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
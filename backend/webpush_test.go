@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"testing"
+)
+
+// 브라우저 쪽 복호화 (RFC 8291) - 암호화 결과를 구독 키로 되풀 수 있는지 확인
+func decryptPushPayload(t *testing.T, ua *ecdh.PrivateKey, authSecret, body []byte) []byte {
+	t.Helper()
+	if len(body) < 21 { t.Fatalf("body too short: %d", len(body)) }
+	salt, rs, idlen := body[:16], binary.BigEndian.Uint32(body[16:20]), int(body[20])
+	if rs != 4096 || idlen != 65 { t.Fatalf("rs = %d, idlen = %d", rs, idlen) }
+	asPublicBytes, ciphertext := body[21:21+idlen], body[21+idlen:]
+
+	asPublic, err := ecdh.P256().NewPublicKey(asPublicBytes)
+	if err != nil { t.Fatal(err) }
+	ecdhSecret, err := ua.ECDH(asPublic)
+	if err != nil { t.Fatal(err) }
+	keyInfo := "WebPush: info\x00" + string(ua.PublicKey().Bytes()) + string(asPublicBytes)
+	ikm, _ := hkdf.Key(sha256.New, ecdhSecret, authSecret, keyInfo, 32)
+	prk, _ := hkdf.Extract(sha256.New, ikm, salt)
+	cek, _ := hkdf.Expand(sha256.New, prk, "Content-Encoding: aes128gcm\x00", 16)
+	nonce, _ := hkdf.Expand(sha256.New, prk, "Content-Encoding: nonce\x00", 12)
+	block, _ := aes.NewCipher(cek)
+	gcm, _ := cipher.NewGCM(block)
+	plain, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil { t.Fatalf("decrypt: %v", err) }
+	if plain[len(plain)-1] != 0x02 { t.Fatalf("missing last-record delimiter") }
+	return plain[:len(plain)-1]
+}
+
+func TestEncryptPushPayload(t *testing.T) {
+	ua, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil { t.Fatal(err) }
+	authSecret := make([]byte, 16)
+	rand.Read(authSecret)
+	p256dh, auth := b64.EncodeToString(ua.PublicKey().Bytes()), b64.EncodeToString(authSecret)
+
+	tests := []struct {
+		name    string
+		p256dh  string
+		auth    string
+		payload []byte
+		wantErr bool
+	}{
+		{"round trip", p256dh, auth, []byte(`{"title":"hi","body":"안녕"}`), false},
+		{"empty payload", p256dh, auth, []byte{}, false},
+		{"padded keys", p256dh + "=", auth + "==", []byte("x"), false},
+		{"bad p256dh encoding", "!!!", auth, []byte("x"), true},
+		{"p256dh not on curve", b64.EncodeToString(make([]byte, 65)), auth, []byte("x"), true},
+		{"bad auth encoding", p256dh, "!!!", []byte("x"), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := encryptPushPayload(tt.p256dh, tt.auth, tt.payload)
+			if (err != nil) != tt.wantErr { t.Fatalf("encryptPushPayload() err = %v, wantErr %v", err, tt.wantErr) }
+			if tt.wantErr { return }
+			if got := decryptPushPayload(t, ua, authSecret, body); !bytes.Equal(got, tt.payload) { t.Errorf("decrypted = %q, want %q", got, tt.payload) }
+		})
+	}
+}
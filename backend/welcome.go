@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"text/template"
+)
+
+type WelcomeLink struct {
+	Title string `yaml:"title" json:"title"`
+	URL   string `yaml:"url" json:"url"`
+}
+
+type welcomeData struct {
+	Nick  string
+	Links []WelcomeLink
+	Rooms []string
+}
+
+// 시스템 계정 닉네임은 일반 사용자가 쓸 수 없음
+func isReservedNick(nick string) bool {
+	return cfg.Welcome.SystemNick != "" && strings.EqualFold(nick, cfg.Welcome.SystemNick)
+}
+
+// 시스템 계정 준비 (welcomed_at을 채워 자기 자신에게 환영 DM을 보내지 않게 함)
+func ensureSystemUser() {
+	_, err := db.Exec(`
+		INSERT INTO users (nickname, color_code, welcomed_at) VALUES ($1, '#f59e0b', CURRENT_TIMESTAMP)
+		ON CONFLICT (nickname) DO NOTHING`, cfg.Welcome.SystemNick)
+	if err != nil { slog.Warn("system user setup failed", "nick", cfg.Welcome.SystemNick, "err", err) }
+}
+
+// 멤버가 많은 방 상위 N개 (환영 메시지용 추천)
+func popularRoomNames(n int) []string {
+	rows, err := db.Query(`
+		SELECT r.name FROM rooms r
+		LEFT JOIN room_members rm ON rm.room_id = r.id
+		GROUP BY r.id, r.name
+		ORDER BY COUNT(rm.nickname) DESC, r.id ASC LIMIT $1`, n)
+	if err != nil { return nil }
+	defer rows.Close()
+	var names []string
+	for rows.Next() {
+		var name string
+		rows.Scan(&name)
+		names = append(names, name)
+	}
+	return names
+}
+
+// 설정의 로케일별 템플릿이 있으면 우선, 없으면 i18n 카탈로그
+func renderWelcome(locale string, data welcomeData) (string, error) {
+	src, ok := cfg.Welcome.Templates[locale]
+	if !ok { src = translate(locale, "welcome.dm") }
+	tmpl, err := template.New("welcome").Funcs(template.FuncMap{"join": strings.Join}).Parse(src)
+	if err != nil { return "", err }
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil { return "", err }
+	return buf.String(), nil
+}
+
+// [환영 DM] 첫 로그인 때 한 번만 (welcomed_at을 원자적으로 채운 요청만 전송), 처음 보는 닉네임의 users 행은 /login이 만듦
+// 테넌트 구분이 없는 단일 배포 구조라 문구/링크는 배포마다 설정 파일(welcome.*)로 정함
+func maybeSendWelcome(nick, locale string) {
+	if !cfg.Welcome.Enabled || isReservedNick(nick) { return }
+	res, err := db.Exec("UPDATE users SET welcomed_at = CURRENT_TIMESTAMP WHERE nickname = $1 AND welcomed_at IS NULL", nick)
+	if err != nil { slog.Warn("welcome DM failed", "nick", nick, "err", err); return }
+	if n, _ := res.RowsAffected(); n == 0 { return }
+
+	content, err := renderWelcome(locale, welcomeData{Nick: nick, Links: cfg.Welcome.Links, Rooms: popularRoomNames(3)})
+	if err != nil { slog.Warn("welcome template failed", "nick", nick, "err", err); return }
+	if _, err := sendDirectMessage(cfg.Welcome.SystemNick, nick, content); err != nil {
+		slog.Warn("welcome DM failed", "nick", nick, "err", err)
+		return
+	}
+	slog.Info("welcome DM sent", "nick", nick, "locale", locale)
+}